@@ -261,6 +261,8 @@ func determineServiceFromPath(path string) string {
 		return "inventory"
 	case len(path) >= 13 && path[:13] == "/api/payments":
 		return "payment"
+	case len(path) >= 11 && path[:11] == "/api/orders":
+		return "payment"
 	case len(path) >= 5 && path[:5] == "/auth":
 		return "user"
 	default:
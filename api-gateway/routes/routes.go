@@ -75,6 +75,15 @@ var Routes = []RouteDefinition{
 		RequireAuth:  true,
 		RequireAdmin: false,
 	},
+
+	// Orders are served by the payment service
+	{
+		Prefix:       "/api/orders",
+		ServiceName:  "payment",
+		Description:  "Multi-line orders",
+		RequireAuth:  true,
+		RequireAdmin: false,
+	},
 }
 
 // SetupRoutes configures all routes in the gateway
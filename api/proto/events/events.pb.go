@@ -0,0 +1,203 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v3.21.12
+// source: api/proto/events/events.proto
+
+package eventspb
+
+import (
+	money "github.com/tair/full-observability/api/proto/money"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ProductPurchased is published by the payment service when a payment for a
+// product is recorded (event type "product.purchased")
+type ProductPurchased struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId uint32                 `protobuf:"varint,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	ProductId uint32                 `protobuf:"varint,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity  int32                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	UserId    uint32                 `protobuf:"varint,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Deprecated: major units kept for consumers of version 1; use amount_money
+	Amount        float64 `protobuf:"fixed64,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency      string  `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
+	PaymentMethod string  `protobuf:"bytes,7,opt,name=payment_method,json=paymentMethod,proto3" json:"payment_method,omitempty"`
+	// Set when stock was already taken by a reservation
+	ReservationId string       `protobuf:"bytes,8,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	AmountMoney   *money.Money `protobuf:"bytes,9,opt,name=amount_money,json=amountMoney,proto3" json:"amount_money,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductPurchased) Reset() {
+	*x = ProductPurchased{}
+	mi := &file_api_proto_events_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductPurchased) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductPurchased) ProtoMessage() {}
+
+func (x *ProductPurchased) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_events_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductPurchased.ProtoReflect.Descriptor instead.
+func (*ProductPurchased) Descriptor() ([]byte, []int) {
+	return file_api_proto_events_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ProductPurchased) GetPaymentId() uint32 {
+	if x != nil {
+		return x.PaymentId
+	}
+	return 0
+}
+
+func (x *ProductPurchased) GetProductId() uint32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *ProductPurchased) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *ProductPurchased) GetUserId() uint32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ProductPurchased) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ProductPurchased) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *ProductPurchased) GetPaymentMethod() string {
+	if x != nil {
+		return x.PaymentMethod
+	}
+	return ""
+}
+
+func (x *ProductPurchased) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+func (x *ProductPurchased) GetAmountMoney() *money.Money {
+	if x != nil {
+		return x.AmountMoney
+	}
+	return nil
+}
+
+var File_api_proto_events_events_proto protoreflect.FileDescriptor
+
+const file_api_proto_events_events_proto_rawDesc = "" +
+	"\n" +
+	"\x1dapi/proto/events/events.proto\x12\tevents.v1\x1a\x1bapi/proto/money/money.proto\"\xbb\x02\n" +
+	"\x10ProductPurchased\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\rR\tpaymentId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\rR\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x05R\bquantity\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\rR\x06userId\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\x12%\n" +
+	"\x0epayment_method\x18\a \x01(\tR\rpaymentMethod\x12%\n" +
+	"\x0ereservation_id\x18\b \x01(\tR\rreservationId\x122\n" +
+	"\famount_money\x18\t \x01(\v2\x0f.money.v1.MoneyR\vamountMoneyB>Z<github.com/tair/full-observability/api/proto/events;eventspbb\x06proto3"
+
+var (
+	file_api_proto_events_events_proto_rawDescOnce sync.Once
+	file_api_proto_events_events_proto_rawDescData []byte
+)
+
+func file_api_proto_events_events_proto_rawDescGZIP() []byte {
+	file_api_proto_events_events_proto_rawDescOnce.Do(func() {
+		file_api_proto_events_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_proto_events_events_proto_rawDesc), len(file_api_proto_events_events_proto_rawDesc)))
+	})
+	return file_api_proto_events_events_proto_rawDescData
+}
+
+var file_api_proto_events_events_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_api_proto_events_events_proto_goTypes = []any{
+	(*ProductPurchased)(nil), // 0: events.v1.ProductPurchased
+	(*money.Money)(nil),      // 1: money.v1.Money
+}
+var file_api_proto_events_events_proto_depIdxs = []int32{
+	1, // 0: events.v1.ProductPurchased.amount_money:type_name -> money.v1.Money
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_api_proto_events_events_proto_init() }
+func file_api_proto_events_events_proto_init() {
+	if File_api_proto_events_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_events_events_proto_rawDesc), len(file_api_proto_events_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_api_proto_events_events_proto_goTypes,
+		DependencyIndexes: file_api_proto_events_events_proto_depIdxs,
+		MessageInfos:      file_api_proto_events_events_proto_msgTypes,
+	}.Build()
+	File_api_proto_events_events_proto = out.File
+	file_api_proto_events_events_proto_goTypes = nil
+	file_api_proto_events_events_proto_depIdxs = nil
+}
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.10
+// 	protoc-gen-go v1.36.11
 // 	protoc        v3.21.12
 // source: api/proto/inventory/inventory.proto
 
@@ -107,6 +107,152 @@ func (x *Inventory) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+// LocationStock is the stock of a product at one location
+type LocationStock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InventoryId   uint32                 `protobuf:"varint,1,opt,name=inventory_id,json=inventoryId,proto3" json:"inventory_id,omitempty"`
+	Location      string                 `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Region        string                 `protobuf:"bytes,3,opt,name=region,proto3" json:"region,omitempty"`
+	Priority      int32                  `protobuf:"varint,4,opt,name=priority,proto3" json:"priority,omitempty"` // lower ships first
+	Active        bool                   `protobuf:"varint,5,opt,name=active,proto3" json:"active,omitempty"`     // stock at inactive locations is not allocated
+	Quantity      int32                  `protobuf:"varint,6,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LocationStock) Reset() {
+	*x = LocationStock{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LocationStock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocationStock) ProtoMessage() {}
+
+func (x *LocationStock) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocationStock.ProtoReflect.Descriptor instead.
+func (*LocationStock) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LocationStock) GetInventoryId() uint32 {
+	if x != nil {
+		return x.InventoryId
+	}
+	return 0
+}
+
+func (x *LocationStock) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *LocationStock) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *LocationStock) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *LocationStock) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *LocationStock) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+// Allocation is the part of a request taken from one location
+type Allocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InventoryId   uint32                 `protobuf:"varint,1,opt,name=inventory_id,json=inventoryId,proto3" json:"inventory_id,omitempty"`
+	Location      string                 `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Quantity      int32                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Allocation) Reset() {
+	*x = Allocation{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Allocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Allocation) ProtoMessage() {}
+
+func (x *Allocation) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Allocation.ProtoReflect.Descriptor instead.
+func (*Allocation) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Allocation) GetInventoryId() uint32 {
+	if x != nil {
+		return x.InventoryId
+	}
+	return 0
+}
+
+func (x *Allocation) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *Allocation) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
 // CreateInventoryRequest
 type CreateInventoryRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -119,7 +265,7 @@ type CreateInventoryRequest struct {
 
 func (x *CreateInventoryRequest) Reset() {
 	*x = CreateInventoryRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[1]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -131,7 +277,7 @@ func (x *CreateInventoryRequest) String() string {
 func (*CreateInventoryRequest) ProtoMessage() {}
 
 func (x *CreateInventoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[1]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -144,7 +290,7 @@ func (x *CreateInventoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateInventoryRequest.ProtoReflect.Descriptor instead.
 func (*CreateInventoryRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{1}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *CreateInventoryRequest) GetProductId() uint32 {
@@ -178,7 +324,7 @@ type GetInventoryRequest struct {
 
 func (x *GetInventoryRequest) Reset() {
 	*x = GetInventoryRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[2]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -190,7 +336,7 @@ func (x *GetInventoryRequest) String() string {
 func (*GetInventoryRequest) ProtoMessage() {}
 
 func (x *GetInventoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[2]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -203,7 +349,7 @@ func (x *GetInventoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetInventoryRequest.ProtoReflect.Descriptor instead.
 func (*GetInventoryRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{2}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetInventoryRequest) GetId() uint32 {
@@ -224,7 +370,7 @@ type UpdateQuantityRequest struct {
 
 func (x *UpdateQuantityRequest) Reset() {
 	*x = UpdateQuantityRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[3]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -236,7 +382,7 @@ func (x *UpdateQuantityRequest) String() string {
 func (*UpdateQuantityRequest) ProtoMessage() {}
 
 func (x *UpdateQuantityRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[3]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -249,7 +395,7 @@ func (x *UpdateQuantityRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateQuantityRequest.ProtoReflect.Descriptor instead.
 func (*UpdateQuantityRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{3}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *UpdateQuantityRequest) GetId() uint32 {
@@ -276,7 +422,7 @@ type DeleteInventoryRequest struct {
 
 func (x *DeleteInventoryRequest) Reset() {
 	*x = DeleteInventoryRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[4]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -288,7 +434,7 @@ func (x *DeleteInventoryRequest) String() string {
 func (*DeleteInventoryRequest) ProtoMessage() {}
 
 func (x *DeleteInventoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[4]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -301,7 +447,7 @@ func (x *DeleteInventoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteInventoryRequest.ProtoReflect.Descriptor instead.
 func (*DeleteInventoryRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{4}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *DeleteInventoryRequest) GetId() uint32 {
@@ -322,7 +468,7 @@ type ListInventoryRequest struct {
 
 func (x *ListInventoryRequest) Reset() {
 	*x = ListInventoryRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[5]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -334,7 +480,7 @@ func (x *ListInventoryRequest) String() string {
 func (*ListInventoryRequest) ProtoMessage() {}
 
 func (x *ListInventoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[5]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -347,7 +493,7 @@ func (x *ListInventoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListInventoryRequest.ProtoReflect.Descriptor instead.
 func (*ListInventoryRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{5}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ListInventoryRequest) GetLimit() int32 {
@@ -374,7 +520,7 @@ type GetByProductIDRequest struct {
 
 func (x *GetByProductIDRequest) Reset() {
 	*x = GetByProductIDRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[6]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -386,7 +532,7 @@ func (x *GetByProductIDRequest) String() string {
 func (*GetByProductIDRequest) ProtoMessage() {}
 
 func (x *GetByProductIDRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[6]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -399,7 +545,7 @@ func (x *GetByProductIDRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetByProductIDRequest.ProtoReflect.Descriptor instead.
 func (*GetByProductIDRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{6}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *GetByProductIDRequest) GetProductId() uint32 {
@@ -414,13 +560,14 @@ type CheckAvailabilityRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	ProductId        uint32                 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
 	RequiredQuantity int32                  `protobuf:"varint,2,opt,name=required_quantity,json=requiredQuantity,proto3" json:"required_quantity,omitempty"`
+	Region           string                 `protobuf:"bytes,3,opt,name=region,proto3" json:"region,omitempty"` // optional customer region, used by region-aware allocation
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
 
 func (x *CheckAvailabilityRequest) Reset() {
 	*x = CheckAvailabilityRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[7]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -432,7 +579,7 @@ func (x *CheckAvailabilityRequest) String() string {
 func (*CheckAvailabilityRequest) ProtoMessage() {}
 
 func (x *CheckAvailabilityRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[7]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -445,7 +592,7 @@ func (x *CheckAvailabilityRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckAvailabilityRequest.ProtoReflect.Descriptor instead.
 func (*CheckAvailabilityRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{7}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *CheckAvailabilityRequest) GetProductId() uint32 {
@@ -462,19 +609,28 @@ func (x *CheckAvailabilityRequest) GetRequiredQuantity() int32 {
 	return 0
 }
 
+func (x *CheckAvailabilityRequest) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
 // ReserveStockRequest
 type ReserveStockRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProductId     uint32                 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
 	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
 	ReservationId string                 `protobuf:"bytes,3,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	TtlSeconds    int32                  `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"` // optional, server default when 0
+	Region        string                 `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`                            // optional customer region, used by region-aware allocation
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReserveStockRequest) Reset() {
 	*x = ReserveStockRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[8]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -486,7 +642,7 @@ func (x *ReserveStockRequest) String() string {
 func (*ReserveStockRequest) ProtoMessage() {}
 
 func (x *ReserveStockRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[8]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -499,7 +655,7 @@ func (x *ReserveStockRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReserveStockRequest.ProtoReflect.Descriptor instead.
 func (*ReserveStockRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{8}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ReserveStockRequest) GetProductId() uint32 {
@@ -523,11 +679,25 @@ func (x *ReserveStockRequest) GetReservationId() string {
 	return ""
 }
 
+func (x *ReserveStockRequest) GetTtlSeconds() int32 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *ReserveStockRequest) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
 // ReleaseStockRequest
 type ReleaseStockRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductId     uint32                 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	ProductId     uint32                 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"` // optional, validated against the reservation
+	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`                    // ignored, the reserved quantity is released
 	ReservationId string                 `protobuf:"bytes,3,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -535,7 +705,7 @@ type ReleaseStockRequest struct {
 
 func (x *ReleaseStockRequest) Reset() {
 	*x = ReleaseStockRequest{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[9]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -547,7 +717,7 @@ func (x *ReleaseStockRequest) String() string {
 func (*ReleaseStockRequest) ProtoMessage() {}
 
 func (x *ReleaseStockRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[9]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -560,7 +730,7 @@ func (x *ReleaseStockRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReleaseStockRequest.ProtoReflect.Descriptor instead.
 func (*ReleaseStockRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{9}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ReleaseStockRequest) GetProductId() uint32 {
@@ -584,19 +754,68 @@ func (x *ReleaseStockRequest) GetReservationId() string {
 	return ""
 }
 
+// CommitStockRequest
+type CommitStockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReservationId string                 `protobuf:"bytes,1,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitStockRequest) Reset() {
+	*x = CommitStockRequest{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitStockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitStockRequest) ProtoMessage() {}
+
+func (x *CommitStockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitStockRequest.ProtoReflect.Descriptor instead.
+func (*CommitStockRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CommitStockRequest) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
 // InventoryResponse
 type InventoryResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Inventory     *Inventory             `protobuf:"bytes,3,opt,name=inventory,proto3" json:"inventory,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Success   bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message   string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Inventory *Inventory             `protobuf:"bytes,3,opt,name=inventory,proto3" json:"inventory,omitempty"`
+	// Set by GetByProductID: inventory is the highest-priority location and
+	// total_quantity the sum over the active locations
+	TotalQuantity int32            `protobuf:"varint,4,opt,name=total_quantity,json=totalQuantity,proto3" json:"total_quantity,omitempty"`
+	Locations     []*LocationStock `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *InventoryResponse) Reset() {
 	*x = InventoryResponse{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[10]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -608,7 +827,7 @@ func (x *InventoryResponse) String() string {
 func (*InventoryResponse) ProtoMessage() {}
 
 func (x *InventoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[10]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -621,7 +840,7 @@ func (x *InventoryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InventoryResponse.ProtoReflect.Descriptor instead.
 func (*InventoryResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{10}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *InventoryResponse) GetSuccess() bool {
@@ -645,6 +864,20 @@ func (x *InventoryResponse) GetInventory() *Inventory {
 	return nil
 }
 
+func (x *InventoryResponse) GetTotalQuantity() int32 {
+	if x != nil {
+		return x.TotalQuantity
+	}
+	return 0
+}
+
+func (x *InventoryResponse) GetLocations() []*LocationStock {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
 // DeleteInventoryResponse
 type DeleteInventoryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -656,7 +889,7 @@ type DeleteInventoryResponse struct {
 
 func (x *DeleteInventoryResponse) Reset() {
 	*x = DeleteInventoryResponse{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[11]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -668,7 +901,7 @@ func (x *DeleteInventoryResponse) String() string {
 func (*DeleteInventoryResponse) ProtoMessage() {}
 
 func (x *DeleteInventoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[11]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -681,7 +914,7 @@ func (x *DeleteInventoryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteInventoryResponse.ProtoReflect.Descriptor instead.
 func (*DeleteInventoryResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{11}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *DeleteInventoryResponse) GetSuccess() bool {
@@ -710,7 +943,7 @@ type ListInventoryResponse struct {
 
 func (x *ListInventoryResponse) Reset() {
 	*x = ListInventoryResponse{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[12]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -722,7 +955,7 @@ func (x *ListInventoryResponse) String() string {
 func (*ListInventoryResponse) ProtoMessage() {}
 
 func (x *ListInventoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[12]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -735,7 +968,7 @@ func (x *ListInventoryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListInventoryResponse.ProtoReflect.Descriptor instead.
 func (*ListInventoryResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{12}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ListInventoryResponse) GetSuccess() bool {
@@ -763,15 +996,17 @@ func (x *ListInventoryResponse) GetTotal() int32 {
 type CheckAvailabilityResponse struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	Available       bool                   `protobuf:"varint,1,opt,name=available,proto3" json:"available,omitempty"`
-	CurrentQuantity int32                  `protobuf:"varint,2,opt,name=current_quantity,json=currentQuantity,proto3" json:"current_quantity,omitempty"`
+	CurrentQuantity int32                  `protobuf:"varint,2,opt,name=current_quantity,json=currentQuantity,proto3" json:"current_quantity,omitempty"` // total over the active locations
 	Message         string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Locations       []*LocationStock       `protobuf:"bytes,4,rep,name=locations,proto3" json:"locations,omitempty"`
+	Allocations     []*Allocation          `protobuf:"bytes,5,rep,name=allocations,proto3" json:"allocations,omitempty"` // where a reservation would take the stock from
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
 
 func (x *CheckAvailabilityResponse) Reset() {
 	*x = CheckAvailabilityResponse{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[13]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -783,7 +1018,7 @@ func (x *CheckAvailabilityResponse) String() string {
 func (*CheckAvailabilityResponse) ProtoMessage() {}
 
 func (x *CheckAvailabilityResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[13]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -796,7 +1031,7 @@ func (x *CheckAvailabilityResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckAvailabilityResponse.ProtoReflect.Descriptor instead.
 func (*CheckAvailabilityResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{13}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *CheckAvailabilityResponse) GetAvailable() bool {
@@ -820,19 +1055,36 @@ func (x *CheckAvailabilityResponse) GetMessage() string {
 	return ""
 }
 
+func (x *CheckAvailabilityResponse) GetLocations() []*LocationStock {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+func (x *CheckAvailabilityResponse) GetAllocations() []*Allocation {
+	if x != nil {
+		return x.Allocations
+	}
+	return nil
+}
+
 // ReserveStockResponse
 type ReserveStockResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	ReservationId string                 `protobuf:"bytes,3,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	State         string                 `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Allocations   []*Allocation          `protobuf:"bytes,6,rep,name=allocations,proto3" json:"allocations,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReserveStockResponse) Reset() {
 	*x = ReserveStockResponse{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[14]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -844,7 +1096,7 @@ func (x *ReserveStockResponse) String() string {
 func (*ReserveStockResponse) ProtoMessage() {}
 
 func (x *ReserveStockResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[14]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -857,7 +1109,7 @@ func (x *ReserveStockResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReserveStockResponse.ProtoReflect.Descriptor instead.
 func (*ReserveStockResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{14}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *ReserveStockResponse) GetSuccess() bool {
@@ -881,18 +1133,40 @@ func (x *ReserveStockResponse) GetReservationId() string {
 	return ""
 }
 
+func (x *ReserveStockResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ReserveStockResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *ReserveStockResponse) GetAllocations() []*Allocation {
+	if x != nil {
+		return x.Allocations
+	}
+	return nil
+}
+
 // ReleaseStockResponse
 type ReleaseStockResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReleaseStockResponse) Reset() {
 	*x = ReleaseStockResponse{}
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[15]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -904,7 +1178,7 @@ func (x *ReleaseStockResponse) String() string {
 func (*ReleaseStockResponse) ProtoMessage() {}
 
 func (x *ReleaseStockResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_inventory_inventory_proto_msgTypes[15]
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -917,7 +1191,7 @@ func (x *ReleaseStockResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReleaseStockResponse.ProtoReflect.Descriptor instead.
 func (*ReleaseStockResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{15}
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *ReleaseStockResponse) GetSuccess() bool {
@@ -934,6 +1208,634 @@ func (x *ReleaseStockResponse) GetMessage() string {
 	return ""
 }
 
+func (x *ReleaseStockResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+// CommitStockResponse
+type CommitStockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitStockResponse) Reset() {
+	*x = CommitStockResponse{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitStockResponse) ProtoMessage() {}
+
+func (x *CommitStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitStockResponse.ProtoReflect.Descriptor instead.
+func (*CommitStockResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *CommitStockResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CommitStockResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CommitStockResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+// Transfer moves stock of a product between locations
+type Transfer struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ProductId         uint32                 `protobuf:"varint,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	FromLocation      string                 `protobuf:"bytes,3,opt,name=from_location,json=fromLocation,proto3" json:"from_location,omitempty"`
+	ToLocation        string                 `protobuf:"bytes,4,opt,name=to_location,json=toLocation,proto3" json:"to_location,omitempty"`
+	Quantity          int32                  `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	ReceivedQuantity  int32                  `protobuf:"varint,6,opt,name=received_quantity,json=receivedQuantity,proto3" json:"received_quantity,omitempty"`
+	ReturnedQuantity  int32                  `protobuf:"varint,7,opt,name=returned_quantity,json=returnedQuantity,proto3" json:"returned_quantity,omitempty"` // returned to the source by a cancellation
+	InTransitQuantity int32                  `protobuf:"varint,8,opt,name=in_transit_quantity,json=inTransitQuantity,proto3" json:"in_transit_quantity,omitempty"`
+	Status            string                 `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"` // in_transit, partially_received, received or cancelled
+	Note              string                 `protobuf:"bytes,10,opt,name=note,proto3" json:"note,omitempty"`
+	CreatedBy         uint32                 `protobuf:"varint,11,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt         *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	CompletedAt       *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Transfer) Reset() {
+	*x = Transfer{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transfer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transfer) ProtoMessage() {}
+
+func (x *Transfer) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transfer.ProtoReflect.Descriptor instead.
+func (*Transfer) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Transfer) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transfer) GetProductId() uint32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *Transfer) GetFromLocation() string {
+	if x != nil {
+		return x.FromLocation
+	}
+	return ""
+}
+
+func (x *Transfer) GetToLocation() string {
+	if x != nil {
+		return x.ToLocation
+	}
+	return ""
+}
+
+func (x *Transfer) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Transfer) GetReceivedQuantity() int32 {
+	if x != nil {
+		return x.ReceivedQuantity
+	}
+	return 0
+}
+
+func (x *Transfer) GetReturnedQuantity() int32 {
+	if x != nil {
+		return x.ReturnedQuantity
+	}
+	return 0
+}
+
+func (x *Transfer) GetInTransitQuantity() int32 {
+	if x != nil {
+		return x.InTransitQuantity
+	}
+	return 0
+}
+
+func (x *Transfer) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Transfer) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *Transfer) GetCreatedBy() uint32 {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return 0
+}
+
+func (x *Transfer) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Transfer) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+// CreateTransferRequest ships stock from one location to another
+type CreateTransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     uint32                 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	FromLocation  string                 `protobuf:"bytes,2,opt,name=from_location,json=fromLocation,proto3" json:"from_location,omitempty"`
+	ToLocation    string                 `protobuf:"bytes,3,opt,name=to_location,json=toLocation,proto3" json:"to_location,omitempty"`
+	Quantity      int32                  `protobuf:"varint,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Note          string                 `protobuf:"bytes,5,opt,name=note,proto3" json:"note,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTransferRequest) Reset() {
+	*x = CreateTransferRequest{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransferRequest) ProtoMessage() {}
+
+func (x *CreateTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransferRequest.ProtoReflect.Descriptor instead.
+func (*CreateTransferRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CreateTransferRequest) GetProductId() uint32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *CreateTransferRequest) GetFromLocation() string {
+	if x != nil {
+		return x.FromLocation
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetToLocation() string {
+	if x != nil {
+		return x.ToLocation
+	}
+	return ""
+}
+
+func (x *CreateTransferRequest) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *CreateTransferRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+// ReceiveTransferRequest
+type ReceiveTransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"` // 0 receives everything still in transit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReceiveTransferRequest) Reset() {
+	*x = ReceiveTransferRequest{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveTransferRequest) ProtoMessage() {}
+
+func (x *ReceiveTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveTransferRequest.ProtoReflect.Descriptor instead.
+func (*ReceiveTransferRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ReceiveTransferRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ReceiveTransferRequest) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+// CancelTransferRequest
+type CancelTransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTransferRequest) Reset() {
+	*x = CancelTransferRequest{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTransferRequest) ProtoMessage() {}
+
+func (x *CancelTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTransferRequest.ProtoReflect.Descriptor instead.
+func (*CancelTransferRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CancelTransferRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// GetTransferRequest
+type GetTransferRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTransferRequest) Reset() {
+	*x = GetTransferRequest{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransferRequest) ProtoMessage() {}
+
+func (x *GetTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransferRequest.ProtoReflect.Descriptor instead.
+func (*GetTransferRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetTransferRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// ListTransfersRequest
+type ListTransfersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     uint32                 `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"` // optional
+	Location      string                 `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`                     // optional, source or destination
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`                         // optional
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransfersRequest) Reset() {
+	*x = ListTransfersRequest{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransfersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransfersRequest) ProtoMessage() {}
+
+func (x *ListTransfersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransfersRequest.ProtoReflect.Descriptor instead.
+func (*ListTransfersRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListTransfersRequest) GetProductId() uint32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *ListTransfersRequest) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *ListTransfersRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListTransfersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListTransfersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// TransferResponse
+type TransferResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Transfer      *Transfer              `protobuf:"bytes,3,opt,name=transfer,proto3" json:"transfer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferResponse) Reset() {
+	*x = TransferResponse{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferResponse) ProtoMessage() {}
+
+func (x *TransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferResponse.ProtoReflect.Descriptor instead.
+func (*TransferResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *TransferResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *TransferResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TransferResponse) GetTransfer() *Transfer {
+	if x != nil {
+		return x.Transfer
+	}
+	return nil
+}
+
+// ListTransfersResponse
+type ListTransfersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Transfers     []*Transfer            `protobuf:"bytes,2,rep,name=transfers,proto3" json:"transfers,omitempty"`
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTransfersResponse) Reset() {
+	*x = ListTransfersResponse{}
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTransfersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTransfersResponse) ProtoMessage() {}
+
+func (x *ListTransfersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_inventory_inventory_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTransfersResponse.ProtoReflect.Descriptor instead.
+func (*ListTransfersResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_inventory_inventory_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListTransfersResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ListTransfersResponse) GetTransfers() []*Transfer {
+	if x != nil {
+		return x.Transfers
+	}
+	return nil
+}
+
+func (x *ListTransfersResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
 var File_api_proto_inventory_inventory_proto protoreflect.FileDescriptor
 
 const file_api_proto_inventory_inventory_proto_rawDesc = "" +
@@ -948,7 +1850,19 @@ const file_api_proto_inventory_inventory_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"o\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xb6\x01\n" +
+	"\rLocationStock\x12!\n" +
+	"\finventory_id\x18\x01 \x01(\rR\vinventoryId\x12\x1a\n" +
+	"\blocation\x18\x02 \x01(\tR\blocation\x12\x16\n" +
+	"\x06region\x18\x03 \x01(\tR\x06region\x12\x1a\n" +
+	"\bpriority\x18\x04 \x01(\x05R\bpriority\x12\x16\n" +
+	"\x06active\x18\x05 \x01(\bR\x06active\x12\x1a\n" +
+	"\bquantity\x18\x06 \x01(\x05R\bquantity\"g\n" +
+	"\n" +
+	"Allocation\x12!\n" +
+	"\finventory_id\x18\x01 \x01(\rR\vinventoryId\x12\x1a\n" +
+	"\blocation\x18\x02 \x01(\tR\blocation\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x05R\bquantity\"o\n" +
 	"\x16CreateInventoryRequest\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\rR\tproductId\x12\x1a\n" +
@@ -966,43 +1880,112 @@ const file_api_proto_inventory_inventory_proto_rawDesc = "" +
 	"\x06offset\x18\x02 \x01(\x05R\x06offset\"6\n" +
 	"\x15GetByProductIDRequest\x12\x1d\n" +
 	"\n" +
-	"product_id\x18\x01 \x01(\rR\tproductId\"f\n" +
+	"product_id\x18\x01 \x01(\rR\tproductId\"~\n" +
 	"\x18CheckAvailabilityRequest\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\rR\tproductId\x12+\n" +
-	"\x11required_quantity\x18\x02 \x01(\x05R\x10requiredQuantity\"w\n" +
+	"\x11required_quantity\x18\x02 \x01(\x05R\x10requiredQuantity\x12\x16\n" +
+	"\x06region\x18\x03 \x01(\tR\x06region\"\xb0\x01\n" +
 	"\x13ReserveStockRequest\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\rR\tproductId\x12\x1a\n" +
 	"\bquantity\x18\x02 \x01(\x05R\bquantity\x12%\n" +
-	"\x0ereservation_id\x18\x03 \x01(\tR\rreservationId\"w\n" +
+	"\x0ereservation_id\x18\x03 \x01(\tR\rreservationId\x12\x1f\n" +
+	"\vttl_seconds\x18\x04 \x01(\x05R\n" +
+	"ttlSeconds\x12\x16\n" +
+	"\x06region\x18\x05 \x01(\tR\x06region\"w\n" +
 	"\x13ReleaseStockRequest\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\rR\tproductId\x12\x1a\n" +
 	"\bquantity\x18\x02 \x01(\x05R\bquantity\x12%\n" +
-	"\x0ereservation_id\x18\x03 \x01(\tR\rreservationId\"~\n" +
+	"\x0ereservation_id\x18\x03 \x01(\tR\rreservationId\";\n" +
+	"\x12CommitStockRequest\x12%\n" +
+	"\x0ereservation_id\x18\x01 \x01(\tR\rreservationId\"\xe0\x01\n" +
 	"\x11InventoryResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x125\n" +
-	"\tinventory\x18\x03 \x01(\v2\x17.inventory.v1.InventoryR\tinventory\"M\n" +
+	"\tinventory\x18\x03 \x01(\v2\x17.inventory.v1.InventoryR\tinventory\x12%\n" +
+	"\x0etotal_quantity\x18\x04 \x01(\x05R\rtotalQuantity\x129\n" +
+	"\tlocations\x18\x05 \x03(\v2\x1b.inventory.v1.LocationStockR\tlocations\"M\n" +
 	"\x17DeleteInventoryResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\"\x82\x01\n" +
 	"\x15ListInventoryResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x129\n" +
 	"\vinventories\x18\x02 \x03(\v2\x17.inventory.v1.InventoryR\vinventories\x12\x14\n" +
-	"\x05total\x18\x03 \x01(\x05R\x05total\"~\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\"\xf5\x01\n" +
 	"\x19CheckAvailabilityResponse\x12\x1c\n" +
 	"\tavailable\x18\x01 \x01(\bR\tavailable\x12)\n" +
 	"\x10current_quantity\x18\x02 \x01(\x05R\x0fcurrentQuantity\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"q\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x129\n" +
+	"\tlocations\x18\x04 \x03(\v2\x1b.inventory.v1.LocationStockR\tlocations\x12:\n" +
+	"\vallocations\x18\x05 \x03(\v2\x18.inventory.v1.AllocationR\vallocations\"\xfe\x01\n" +
 	"\x14ReserveStockResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12%\n" +
-	"\x0ereservation_id\x18\x03 \x01(\tR\rreservationId\"J\n" +
+	"\x0ereservation_id\x18\x03 \x01(\tR\rreservationId\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12:\n" +
+	"\vallocations\x18\x06 \x03(\v2\x18.inventory.v1.AllocationR\vallocations\"`\n" +
 	"\x14ReleaseStockResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\xbe\x06\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\"_\n" +
+	"\x13CommitStockResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\"\xea\x03\n" +
+	"\bTransfer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\rR\tproductId\x12#\n" +
+	"\rfrom_location\x18\x03 \x01(\tR\ffromLocation\x12\x1f\n" +
+	"\vto_location\x18\x04 \x01(\tR\n" +
+	"toLocation\x12\x1a\n" +
+	"\bquantity\x18\x05 \x01(\x05R\bquantity\x12+\n" +
+	"\x11received_quantity\x18\x06 \x01(\x05R\x10receivedQuantity\x12+\n" +
+	"\x11returned_quantity\x18\a \x01(\x05R\x10returnedQuantity\x12.\n" +
+	"\x13in_transit_quantity\x18\b \x01(\x05R\x11inTransitQuantity\x12\x16\n" +
+	"\x06status\x18\t \x01(\tR\x06status\x12\x12\n" +
+	"\x04note\x18\n" +
+	" \x01(\tR\x04note\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\v \x01(\rR\tcreatedBy\x129\n" +
+	"\n" +
+	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12=\n" +
+	"\fcompleted_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\"\xac\x01\n" +
+	"\x15CreateTransferRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\rR\tproductId\x12#\n" +
+	"\rfrom_location\x18\x02 \x01(\tR\ffromLocation\x12\x1f\n" +
+	"\vto_location\x18\x03 \x01(\tR\n" +
+	"toLocation\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x05R\bquantity\x12\x12\n" +
+	"\x04note\x18\x05 \x01(\tR\x04note\"D\n" +
+	"\x16ReceiveTransferRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\"'\n" +
+	"\x15CancelTransferRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\"$\n" +
+	"\x12GetTransferRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\"\x97\x01\n" +
+	"\x14ListTransfersRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\rR\tproductId\x12\x1a\n" +
+	"\blocation\x18\x02 \x01(\tR\blocation\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x05R\x06offset\"z\n" +
+	"\x10TransferResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x122\n" +
+	"\btransfer\x18\x03 \x01(\v2\x16.inventory.v1.TransferR\btransfer\"}\n" +
+	"\x15ListTransfersResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x124\n" +
+	"\ttransfers\x18\x02 \x03(\v2\x16.inventory.v1.TransferR\ttransfers\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total2\xc4\n" +
+	"\n" +
 	"\x10InventoryService\x12X\n" +
 	"\x0fCreateInventory\x12$.inventory.v1.CreateInventoryRequest\x1a\x1f.inventory.v1.InventoryResponse\x12R\n" +
 	"\fGetInventory\x12!.inventory.v1.GetInventoryRequest\x1a\x1f.inventory.v1.InventoryResponse\x12V\n" +
@@ -1012,7 +1995,13 @@ const file_api_proto_inventory_inventory_proto_rawDesc = "" +
 	"\x0eGetByProductID\x12#.inventory.v1.GetByProductIDRequest\x1a\x1f.inventory.v1.InventoryResponse\x12d\n" +
 	"\x11CheckAvailability\x12&.inventory.v1.CheckAvailabilityRequest\x1a'.inventory.v1.CheckAvailabilityResponse\x12U\n" +
 	"\fReserveStock\x12!.inventory.v1.ReserveStockRequest\x1a\".inventory.v1.ReserveStockResponse\x12U\n" +
-	"\fReleaseStock\x12!.inventory.v1.ReleaseStockRequest\x1a\".inventory.v1.ReleaseStockResponseBDZBgithub.com/tair/full-observability/api/proto/inventory;inventorypbb\x06proto3"
+	"\fReleaseStock\x12!.inventory.v1.ReleaseStockRequest\x1a\".inventory.v1.ReleaseStockResponse\x12R\n" +
+	"\vCommitStock\x12 .inventory.v1.CommitStockRequest\x1a!.inventory.v1.CommitStockResponse\x12U\n" +
+	"\x0eCreateTransfer\x12#.inventory.v1.CreateTransferRequest\x1a\x1e.inventory.v1.TransferResponse\x12W\n" +
+	"\x0fReceiveTransfer\x12$.inventory.v1.ReceiveTransferRequest\x1a\x1e.inventory.v1.TransferResponse\x12U\n" +
+	"\x0eCancelTransfer\x12#.inventory.v1.CancelTransferRequest\x1a\x1e.inventory.v1.TransferResponse\x12O\n" +
+	"\vGetTransfer\x12 .inventory.v1.GetTransferRequest\x1a\x1e.inventory.v1.TransferResponse\x12X\n" +
+	"\rListTransfers\x12\".inventory.v1.ListTransfersRequest\x1a#.inventory.v1.ListTransfersResponseBDZBgithub.com/tair/full-observability/api/proto/inventory;inventorypbb\x06proto3"
 
 var (
 	file_api_proto_inventory_inventory_proto_rawDescOnce sync.Once
@@ -1026,54 +2015,87 @@ func file_api_proto_inventory_inventory_proto_rawDescGZIP() []byte {
 	return file_api_proto_inventory_inventory_proto_rawDescData
 }
 
-var file_api_proto_inventory_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_api_proto_inventory_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
 var file_api_proto_inventory_inventory_proto_goTypes = []any{
 	(*Inventory)(nil),                 // 0: inventory.v1.Inventory
-	(*CreateInventoryRequest)(nil),    // 1: inventory.v1.CreateInventoryRequest
-	(*GetInventoryRequest)(nil),       // 2: inventory.v1.GetInventoryRequest
-	(*UpdateQuantityRequest)(nil),     // 3: inventory.v1.UpdateQuantityRequest
-	(*DeleteInventoryRequest)(nil),    // 4: inventory.v1.DeleteInventoryRequest
-	(*ListInventoryRequest)(nil),      // 5: inventory.v1.ListInventoryRequest
-	(*GetByProductIDRequest)(nil),     // 6: inventory.v1.GetByProductIDRequest
-	(*CheckAvailabilityRequest)(nil),  // 7: inventory.v1.CheckAvailabilityRequest
-	(*ReserveStockRequest)(nil),       // 8: inventory.v1.ReserveStockRequest
-	(*ReleaseStockRequest)(nil),       // 9: inventory.v1.ReleaseStockRequest
-	(*InventoryResponse)(nil),         // 10: inventory.v1.InventoryResponse
-	(*DeleteInventoryResponse)(nil),   // 11: inventory.v1.DeleteInventoryResponse
-	(*ListInventoryResponse)(nil),     // 12: inventory.v1.ListInventoryResponse
-	(*CheckAvailabilityResponse)(nil), // 13: inventory.v1.CheckAvailabilityResponse
-	(*ReserveStockResponse)(nil),      // 14: inventory.v1.ReserveStockResponse
-	(*ReleaseStockResponse)(nil),      // 15: inventory.v1.ReleaseStockResponse
-	(*timestamppb.Timestamp)(nil),     // 16: google.protobuf.Timestamp
+	(*LocationStock)(nil),             // 1: inventory.v1.LocationStock
+	(*Allocation)(nil),                // 2: inventory.v1.Allocation
+	(*CreateInventoryRequest)(nil),    // 3: inventory.v1.CreateInventoryRequest
+	(*GetInventoryRequest)(nil),       // 4: inventory.v1.GetInventoryRequest
+	(*UpdateQuantityRequest)(nil),     // 5: inventory.v1.UpdateQuantityRequest
+	(*DeleteInventoryRequest)(nil),    // 6: inventory.v1.DeleteInventoryRequest
+	(*ListInventoryRequest)(nil),      // 7: inventory.v1.ListInventoryRequest
+	(*GetByProductIDRequest)(nil),     // 8: inventory.v1.GetByProductIDRequest
+	(*CheckAvailabilityRequest)(nil),  // 9: inventory.v1.CheckAvailabilityRequest
+	(*ReserveStockRequest)(nil),       // 10: inventory.v1.ReserveStockRequest
+	(*ReleaseStockRequest)(nil),       // 11: inventory.v1.ReleaseStockRequest
+	(*CommitStockRequest)(nil),        // 12: inventory.v1.CommitStockRequest
+	(*InventoryResponse)(nil),         // 13: inventory.v1.InventoryResponse
+	(*DeleteInventoryResponse)(nil),   // 14: inventory.v1.DeleteInventoryResponse
+	(*ListInventoryResponse)(nil),     // 15: inventory.v1.ListInventoryResponse
+	(*CheckAvailabilityResponse)(nil), // 16: inventory.v1.CheckAvailabilityResponse
+	(*ReserveStockResponse)(nil),      // 17: inventory.v1.ReserveStockResponse
+	(*ReleaseStockResponse)(nil),      // 18: inventory.v1.ReleaseStockResponse
+	(*CommitStockResponse)(nil),       // 19: inventory.v1.CommitStockResponse
+	(*Transfer)(nil),                  // 20: inventory.v1.Transfer
+	(*CreateTransferRequest)(nil),     // 21: inventory.v1.CreateTransferRequest
+	(*ReceiveTransferRequest)(nil),    // 22: inventory.v1.ReceiveTransferRequest
+	(*CancelTransferRequest)(nil),     // 23: inventory.v1.CancelTransferRequest
+	(*GetTransferRequest)(nil),        // 24: inventory.v1.GetTransferRequest
+	(*ListTransfersRequest)(nil),      // 25: inventory.v1.ListTransfersRequest
+	(*TransferResponse)(nil),          // 26: inventory.v1.TransferResponse
+	(*ListTransfersResponse)(nil),     // 27: inventory.v1.ListTransfersResponse
+	(*timestamppb.Timestamp)(nil),     // 28: google.protobuf.Timestamp
 }
 var file_api_proto_inventory_inventory_proto_depIdxs = []int32{
-	16, // 0: inventory.v1.Inventory.created_at:type_name -> google.protobuf.Timestamp
-	16, // 1: inventory.v1.Inventory.updated_at:type_name -> google.protobuf.Timestamp
+	28, // 0: inventory.v1.Inventory.created_at:type_name -> google.protobuf.Timestamp
+	28, // 1: inventory.v1.Inventory.updated_at:type_name -> google.protobuf.Timestamp
 	0,  // 2: inventory.v1.InventoryResponse.inventory:type_name -> inventory.v1.Inventory
-	0,  // 3: inventory.v1.ListInventoryResponse.inventories:type_name -> inventory.v1.Inventory
-	1,  // 4: inventory.v1.InventoryService.CreateInventory:input_type -> inventory.v1.CreateInventoryRequest
-	2,  // 5: inventory.v1.InventoryService.GetInventory:input_type -> inventory.v1.GetInventoryRequest
-	3,  // 6: inventory.v1.InventoryService.UpdateQuantity:input_type -> inventory.v1.UpdateQuantityRequest
-	4,  // 7: inventory.v1.InventoryService.DeleteInventory:input_type -> inventory.v1.DeleteInventoryRequest
-	5,  // 8: inventory.v1.InventoryService.ListInventory:input_type -> inventory.v1.ListInventoryRequest
-	6,  // 9: inventory.v1.InventoryService.GetByProductID:input_type -> inventory.v1.GetByProductIDRequest
-	7,  // 10: inventory.v1.InventoryService.CheckAvailability:input_type -> inventory.v1.CheckAvailabilityRequest
-	8,  // 11: inventory.v1.InventoryService.ReserveStock:input_type -> inventory.v1.ReserveStockRequest
-	9,  // 12: inventory.v1.InventoryService.ReleaseStock:input_type -> inventory.v1.ReleaseStockRequest
-	10, // 13: inventory.v1.InventoryService.CreateInventory:output_type -> inventory.v1.InventoryResponse
-	10, // 14: inventory.v1.InventoryService.GetInventory:output_type -> inventory.v1.InventoryResponse
-	10, // 15: inventory.v1.InventoryService.UpdateQuantity:output_type -> inventory.v1.InventoryResponse
-	11, // 16: inventory.v1.InventoryService.DeleteInventory:output_type -> inventory.v1.DeleteInventoryResponse
-	12, // 17: inventory.v1.InventoryService.ListInventory:output_type -> inventory.v1.ListInventoryResponse
-	10, // 18: inventory.v1.InventoryService.GetByProductID:output_type -> inventory.v1.InventoryResponse
-	13, // 19: inventory.v1.InventoryService.CheckAvailability:output_type -> inventory.v1.CheckAvailabilityResponse
-	14, // 20: inventory.v1.InventoryService.ReserveStock:output_type -> inventory.v1.ReserveStockResponse
-	15, // 21: inventory.v1.InventoryService.ReleaseStock:output_type -> inventory.v1.ReleaseStockResponse
-	13, // [13:22] is the sub-list for method output_type
-	4,  // [4:13] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	1,  // 3: inventory.v1.InventoryResponse.locations:type_name -> inventory.v1.LocationStock
+	0,  // 4: inventory.v1.ListInventoryResponse.inventories:type_name -> inventory.v1.Inventory
+	1,  // 5: inventory.v1.CheckAvailabilityResponse.locations:type_name -> inventory.v1.LocationStock
+	2,  // 6: inventory.v1.CheckAvailabilityResponse.allocations:type_name -> inventory.v1.Allocation
+	28, // 7: inventory.v1.ReserveStockResponse.expires_at:type_name -> google.protobuf.Timestamp
+	2,  // 8: inventory.v1.ReserveStockResponse.allocations:type_name -> inventory.v1.Allocation
+	28, // 9: inventory.v1.Transfer.created_at:type_name -> google.protobuf.Timestamp
+	28, // 10: inventory.v1.Transfer.completed_at:type_name -> google.protobuf.Timestamp
+	20, // 11: inventory.v1.TransferResponse.transfer:type_name -> inventory.v1.Transfer
+	20, // 12: inventory.v1.ListTransfersResponse.transfers:type_name -> inventory.v1.Transfer
+	3,  // 13: inventory.v1.InventoryService.CreateInventory:input_type -> inventory.v1.CreateInventoryRequest
+	4,  // 14: inventory.v1.InventoryService.GetInventory:input_type -> inventory.v1.GetInventoryRequest
+	5,  // 15: inventory.v1.InventoryService.UpdateQuantity:input_type -> inventory.v1.UpdateQuantityRequest
+	6,  // 16: inventory.v1.InventoryService.DeleteInventory:input_type -> inventory.v1.DeleteInventoryRequest
+	7,  // 17: inventory.v1.InventoryService.ListInventory:input_type -> inventory.v1.ListInventoryRequest
+	8,  // 18: inventory.v1.InventoryService.GetByProductID:input_type -> inventory.v1.GetByProductIDRequest
+	9,  // 19: inventory.v1.InventoryService.CheckAvailability:input_type -> inventory.v1.CheckAvailabilityRequest
+	10, // 20: inventory.v1.InventoryService.ReserveStock:input_type -> inventory.v1.ReserveStockRequest
+	11, // 21: inventory.v1.InventoryService.ReleaseStock:input_type -> inventory.v1.ReleaseStockRequest
+	12, // 22: inventory.v1.InventoryService.CommitStock:input_type -> inventory.v1.CommitStockRequest
+	21, // 23: inventory.v1.InventoryService.CreateTransfer:input_type -> inventory.v1.CreateTransferRequest
+	22, // 24: inventory.v1.InventoryService.ReceiveTransfer:input_type -> inventory.v1.ReceiveTransferRequest
+	23, // 25: inventory.v1.InventoryService.CancelTransfer:input_type -> inventory.v1.CancelTransferRequest
+	24, // 26: inventory.v1.InventoryService.GetTransfer:input_type -> inventory.v1.GetTransferRequest
+	25, // 27: inventory.v1.InventoryService.ListTransfers:input_type -> inventory.v1.ListTransfersRequest
+	13, // 28: inventory.v1.InventoryService.CreateInventory:output_type -> inventory.v1.InventoryResponse
+	13, // 29: inventory.v1.InventoryService.GetInventory:output_type -> inventory.v1.InventoryResponse
+	13, // 30: inventory.v1.InventoryService.UpdateQuantity:output_type -> inventory.v1.InventoryResponse
+	14, // 31: inventory.v1.InventoryService.DeleteInventory:output_type -> inventory.v1.DeleteInventoryResponse
+	15, // 32: inventory.v1.InventoryService.ListInventory:output_type -> inventory.v1.ListInventoryResponse
+	13, // 33: inventory.v1.InventoryService.GetByProductID:output_type -> inventory.v1.InventoryResponse
+	16, // 34: inventory.v1.InventoryService.CheckAvailability:output_type -> inventory.v1.CheckAvailabilityResponse
+	17, // 35: inventory.v1.InventoryService.ReserveStock:output_type -> inventory.v1.ReserveStockResponse
+	18, // 36: inventory.v1.InventoryService.ReleaseStock:output_type -> inventory.v1.ReleaseStockResponse
+	19, // 37: inventory.v1.InventoryService.CommitStock:output_type -> inventory.v1.CommitStockResponse
+	26, // 38: inventory.v1.InventoryService.CreateTransfer:output_type -> inventory.v1.TransferResponse
+	26, // 39: inventory.v1.InventoryService.ReceiveTransfer:output_type -> inventory.v1.TransferResponse
+	26, // 40: inventory.v1.InventoryService.CancelTransfer:output_type -> inventory.v1.TransferResponse
+	26, // 41: inventory.v1.InventoryService.GetTransfer:output_type -> inventory.v1.TransferResponse
+	27, // 42: inventory.v1.InventoryService.ListTransfers:output_type -> inventory.v1.ListTransfersResponse
+	28, // [28:43] is the sub-list for method output_type
+	13, // [13:28] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_inventory_inventory_proto_init() }
@@ -1087,7 +2109,7 @@ func file_api_proto_inventory_inventory_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_inventory_inventory_proto_rawDesc), len(file_api_proto_inventory_inventory_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   16,
+			NumMessages:   28,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
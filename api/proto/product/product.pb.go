@@ -1,12 +1,13 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.10
+// 	protoc-gen-go v1.36.11
 // 	protoc        v3.21.12
 // source: api/proto/product/product.proto
 
 package productpb
 
 import (
+	money "github.com/tair/full-observability/api/proto/money"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
@@ -24,17 +25,19 @@ const (
 
 // Product message
 type Product struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
-	Stock         int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"`
-	Category      string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
-	Sku           string                 `protobuf:"bytes,7,opt,name=sku,proto3" json:"sku,omitempty"`
-	IsActive      bool                   `protobuf:"varint,8,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price       float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	Stock       int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"`
+	Category    string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
+	Sku         string                 `protobuf:"bytes,7,opt,name=sku,proto3" json:"sku,omitempty"`
+	IsActive    bool                   `protobuf:"varint,8,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt   *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Exact price; price repeats it in major units for older clients
+	PriceMoney    *money.Money `protobuf:"bytes,11,opt,name=price_money,json=priceMoney,proto3" json:"price_money,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -139,16 +142,25 @@ func (x *Product) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Product) GetPriceMoney() *money.Money {
+	if x != nil {
+		return x.PriceMoney
+	}
+	return nil
+}
+
 // Create product request/response
 type CreateProductRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
-	Price         float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
-	Stock         int32                  `protobuf:"varint,4,opt,name=stock,proto3" json:"stock,omitempty"`
-	Category      string                 `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
-	Sku           string                 `protobuf:"bytes,6,opt,name=sku,proto3" json:"sku,omitempty"`
-	IsActive      bool                   `protobuf:"varint,7,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Price       float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	Stock       int32                  `protobuf:"varint,4,opt,name=stock,proto3" json:"stock,omitempty"`
+	Category    string                 `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
+	Sku         string                 `protobuf:"bytes,6,opt,name=sku,proto3" json:"sku,omitempty"`
+	IsActive    bool                   `protobuf:"varint,7,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	// Takes precedence over price when set
+	PriceMoney    *money.Money `protobuf:"bytes,8,opt,name=price_money,json=priceMoney,proto3" json:"price_money,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -232,6 +244,13 @@ func (x *CreateProductRequest) GetIsActive() bool {
 	return false
 }
 
+func (x *CreateProductRequest) GetPriceMoney() *money.Money {
+	if x != nil {
+		return x.PriceMoney
+	}
+	return nil
+}
+
 type ProductResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Product       *Product               `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
@@ -323,15 +342,17 @@ func (x *GetProductRequest) GetId() uint32 {
 
 // Update product request
 type UpdateProductRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
-	Stock         int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"`
-	Category      string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
-	Sku           string                 `protobuf:"bytes,7,opt,name=sku,proto3" json:"sku,omitempty"`
-	IsActive      bool                   `protobuf:"varint,8,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price       float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	Stock       int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"`
+	Category    string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
+	Sku         string                 `protobuf:"bytes,7,opt,name=sku,proto3" json:"sku,omitempty"`
+	IsActive    bool                   `protobuf:"varint,8,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	// Takes precedence over price when set
+	PriceMoney    *money.Money `protobuf:"bytes,9,opt,name=price_money,json=priceMoney,proto3" json:"price_money,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -422,6 +443,13 @@ func (x *UpdateProductRequest) GetIsActive() bool {
 	return false
 }
 
+func (x *UpdateProductRequest) GetPriceMoney() *money.Money {
+	if x != nil {
+		return x.PriceMoney
+	}
+	return nil
+}
+
 // Delete product request/response
 type DeleteProductRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -960,7 +988,7 @@ var File_api_proto_product_product_proto protoreflect.FileDescriptor
 const file_api_proto_product_product_proto_rawDesc = "" +
 	"\n" +
 	"\x1fapi/proto/product/product.proto\x12\n" +
-	"product.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbc\x02\n" +
+	"product.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bapi/proto/money/money.proto\"\xee\x02\n" +
 	"\aProduct\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\rR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
@@ -974,7 +1002,9 @@ const file_api_proto_product_product_proto_rawDesc = "" +
 	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
 	"updated_at\x18\n" +
-	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xc3\x01\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x120\n" +
+	"\vprice_money\x18\v \x01(\v2\x0f.money.v1.MoneyR\n" +
+	"priceMoney\"\xf5\x01\n" +
 	"\x14CreateProductRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
 	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x14\n" +
@@ -982,11 +1012,13 @@ const file_api_proto_product_product_proto_rawDesc = "" +
 	"\x05stock\x18\x04 \x01(\x05R\x05stock\x12\x1a\n" +
 	"\bcategory\x18\x05 \x01(\tR\bcategory\x12\x10\n" +
 	"\x03sku\x18\x06 \x01(\tR\x03sku\x12\x1b\n" +
-	"\tis_active\x18\a \x01(\bR\bisActive\"@\n" +
+	"\tis_active\x18\a \x01(\bR\bisActive\x120\n" +
+	"\vprice_money\x18\b \x01(\v2\x0f.money.v1.MoneyR\n" +
+	"priceMoney\"@\n" +
 	"\x0fProductResponse\x12-\n" +
 	"\aproduct\x18\x01 \x01(\v2\x13.product.v1.ProductR\aproduct\"#\n" +
 	"\x11GetProductRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\rR\x02id\"\xd3\x01\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\"\x85\x02\n" +
 	"\x14UpdateProductRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\rR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
@@ -995,7 +1027,9 @@ const file_api_proto_product_product_proto_rawDesc = "" +
 	"\x05stock\x18\x05 \x01(\x05R\x05stock\x12\x1a\n" +
 	"\bcategory\x18\x06 \x01(\tR\bcategory\x12\x10\n" +
 	"\x03sku\x18\a \x01(\tR\x03sku\x12\x1b\n" +
-	"\tis_active\x18\b \x01(\bR\bisActive\"&\n" +
+	"\tis_active\x18\b \x01(\bR\bisActive\x120\n" +
+	"\vprice_money\x18\t \x01(\v2\x0f.money.v1.MoneyR\n" +
+	"priceMoney\"&\n" +
 	"\x14DeleteProductRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\rR\x02id\"1\n" +
 	"\x15DeleteProductResponse\x12\x18\n" +
@@ -1075,34 +1109,38 @@ var file_api_proto_product_product_proto_goTypes = []any{
 	(*StatsResponse)(nil),             // 14: product.v1.StatsResponse
 	nil,                               // 15: product.v1.StatsResponse.ProductsByCategoryEntry
 	(*timestamppb.Timestamp)(nil),     // 16: google.protobuf.Timestamp
+	(*money.Money)(nil),               // 17: money.v1.Money
 }
 var file_api_proto_product_product_proto_depIdxs = []int32{
 	16, // 0: product.v1.Product.created_at:type_name -> google.protobuf.Timestamp
 	16, // 1: product.v1.Product.updated_at:type_name -> google.protobuf.Timestamp
-	0,  // 2: product.v1.ProductResponse.product:type_name -> product.v1.Product
-	0,  // 3: product.v1.ListProductsResponse.products:type_name -> product.v1.Product
-	15, // 4: product.v1.StatsResponse.products_by_category:type_name -> product.v1.StatsResponse.ProductsByCategoryEntry
-	1,  // 5: product.v1.ProductService.CreateProduct:input_type -> product.v1.CreateProductRequest
-	3,  // 6: product.v1.ProductService.GetProduct:input_type -> product.v1.GetProductRequest
-	4,  // 7: product.v1.ProductService.UpdateProduct:input_type -> product.v1.UpdateProductRequest
-	5,  // 8: product.v1.ProductService.DeleteProduct:input_type -> product.v1.DeleteProductRequest
-	7,  // 9: product.v1.ProductService.ListProducts:input_type -> product.v1.ListProductsRequest
-	9,  // 10: product.v1.ProductService.UpdateStock:input_type -> product.v1.UpdateStockRequest
-	11, // 11: product.v1.ProductService.CheckAvailability:input_type -> product.v1.CheckAvailabilityRequest
-	13, // 12: product.v1.ProductService.GetStats:input_type -> product.v1.GetStatsRequest
-	2,  // 13: product.v1.ProductService.CreateProduct:output_type -> product.v1.ProductResponse
-	2,  // 14: product.v1.ProductService.GetProduct:output_type -> product.v1.ProductResponse
-	2,  // 15: product.v1.ProductService.UpdateProduct:output_type -> product.v1.ProductResponse
-	6,  // 16: product.v1.ProductService.DeleteProduct:output_type -> product.v1.DeleteProductResponse
-	8,  // 17: product.v1.ProductService.ListProducts:output_type -> product.v1.ListProductsResponse
-	10, // 18: product.v1.ProductService.UpdateStock:output_type -> product.v1.UpdateStockResponse
-	12, // 19: product.v1.ProductService.CheckAvailability:output_type -> product.v1.CheckAvailabilityResponse
-	14, // 20: product.v1.ProductService.GetStats:output_type -> product.v1.StatsResponse
-	13, // [13:21] is the sub-list for method output_type
-	5,  // [5:13] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	17, // 2: product.v1.Product.price_money:type_name -> money.v1.Money
+	17, // 3: product.v1.CreateProductRequest.price_money:type_name -> money.v1.Money
+	0,  // 4: product.v1.ProductResponse.product:type_name -> product.v1.Product
+	17, // 5: product.v1.UpdateProductRequest.price_money:type_name -> money.v1.Money
+	0,  // 6: product.v1.ListProductsResponse.products:type_name -> product.v1.Product
+	15, // 7: product.v1.StatsResponse.products_by_category:type_name -> product.v1.StatsResponse.ProductsByCategoryEntry
+	1,  // 8: product.v1.ProductService.CreateProduct:input_type -> product.v1.CreateProductRequest
+	3,  // 9: product.v1.ProductService.GetProduct:input_type -> product.v1.GetProductRequest
+	4,  // 10: product.v1.ProductService.UpdateProduct:input_type -> product.v1.UpdateProductRequest
+	5,  // 11: product.v1.ProductService.DeleteProduct:input_type -> product.v1.DeleteProductRequest
+	7,  // 12: product.v1.ProductService.ListProducts:input_type -> product.v1.ListProductsRequest
+	9,  // 13: product.v1.ProductService.UpdateStock:input_type -> product.v1.UpdateStockRequest
+	11, // 14: product.v1.ProductService.CheckAvailability:input_type -> product.v1.CheckAvailabilityRequest
+	13, // 15: product.v1.ProductService.GetStats:input_type -> product.v1.GetStatsRequest
+	2,  // 16: product.v1.ProductService.CreateProduct:output_type -> product.v1.ProductResponse
+	2,  // 17: product.v1.ProductService.GetProduct:output_type -> product.v1.ProductResponse
+	2,  // 18: product.v1.ProductService.UpdateProduct:output_type -> product.v1.ProductResponse
+	6,  // 19: product.v1.ProductService.DeleteProduct:output_type -> product.v1.DeleteProductResponse
+	8,  // 20: product.v1.ProductService.ListProducts:output_type -> product.v1.ListProductsResponse
+	10, // 21: product.v1.ProductService.UpdateStock:output_type -> product.v1.UpdateStockResponse
+	12, // 22: product.v1.ProductService.CheckAvailability:output_type -> product.v1.CheckAvailabilityResponse
+	14, // 23: product.v1.ProductService.GetStats:output_type -> product.v1.StatsResponse
+	16, // [16:24] is the sub-list for method output_type
+	8,  // [8:16] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_product_product_proto_init() }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tair/full-observability/kafka"
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+const usage = `Usage: dlq-admin <command> [flags]
+
+Commands:
+  list     List messages on the dead-letter topic of a source topic
+  replay   Publish dead-lettered messages back onto their source topic
+
+Run "dlq-admin <command> -h" for the flags of a command.
+`
+
+func main() {
+	logger.Init("dlq-admin", true)
+	logger.SetLevel(getEnv("LOG_LEVEL", "warn"))
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = runList(os.Args[2:])
+	case "replay":
+		err = runReplay(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	brokers := fs.String("brokers", getEnv("KAFKA_BROKERS", "localhost:9092"), "comma-separated Kafka brokers")
+	topic := fs.String("topic", kafka.TopicProductPurchased, "source topic whose dead-letter topic is read")
+	fs.Parse(args)
+
+	letters, err := kafka.ReadDeadLetters(strings.Split(*brokers, ","), *topic)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION\tOFFSET\tEVENT_TYPE\tEVENT_ID\tATTEMPTS\tFAILED_AT\tERROR")
+	for _, letter := range letters {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d\t%s\t%s\n",
+			letter.Partition, letter.Offset, letter.EventType, letter.EventID,
+			letter.Attempts, letter.FailedAt, letter.Error)
+	}
+	return w.Flush()
+}
+
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	brokers := fs.String("brokers", getEnv("KAFKA_BROKERS", "localhost:9092"), "comma-separated Kafka brokers")
+	topic := fs.String("topic", kafka.TopicProductPurchased, "source topic whose dead-letter topic is replayed")
+	partition := fs.Int("partition", -1, "partition of the message to replay")
+	offset := fs.Int64("offset", -1, "offset of the message to replay")
+	eventID := fs.String("event-id", "", "event ID of the message to replay")
+	all := fs.Bool("all", false, "replay every message on the dead-letter topic")
+	fs.Parse(args)
+
+	if !*all && *eventID == "" && (*partition < 0 || *offset < 0) {
+		return fmt.Errorf("one of -all, -event-id or -partition with -offset is required")
+	}
+
+	brokerList := strings.Split(*brokers, ",")
+	letters, err := kafka.ReadDeadLetters(brokerList, *topic)
+	if err != nil {
+		return err
+	}
+
+	publisher, err := kafka.NewPublisher(brokerList)
+	if err != nil {
+		return err
+	}
+	defer publisher.Close()
+
+	replayed := 0
+	for _, letter := range letters {
+		switch {
+		case *all:
+		case *eventID != "" && letter.EventID == *eventID:
+		case *partition >= 0 && letter.Partition == int32(*partition) && letter.Offset == *offset:
+		default:
+			continue
+		}
+
+		if err := publisher.ReplayDeadLetter(letter); err != nil {
+			return fmt.Errorf("failed to replay %d/%d: %w", letter.Partition, letter.Offset, err)
+		}
+		replayed++
+		fmt.Printf("replayed %d/%d (event %s) to %s\n", letter.Partition, letter.Offset, letter.EventID, letter.OriginalTopic)
+	}
+
+	if replayed == 0 {
+		return fmt.Errorf("no matching dead-letter messages found")
+	}
+	fmt.Printf("%d message(s) replayed\n", replayed)
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
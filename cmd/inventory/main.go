@@ -3,22 +3,33 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 
+	eventspb "github.com/tair/full-observability/api/proto/events"
 	pb "github.com/tair/full-observability/api/proto/inventory"
 	"github.com/tair/full-observability/internal/inventory"
+	"github.com/tair/full-observability/internal/inventory/allocation"
 	grpcDelivery "github.com/tair/full-observability/internal/inventory/delivery/grpc"
 	httpDelivery "github.com/tair/full-observability/internal/inventory/delivery/http"
 	"github.com/tair/full-observability/internal/inventory/domain"
+	"github.com/tair/full-observability/internal/inventory/repository"
+	"github.com/tair/full-observability/internal/inventory/usecase/command"
 	"github.com/tair/full-observability/kafka"
 	"github.com/tair/full-observability/pkg/database"
 	"github.com/tair/full-observability/pkg/logger"
@@ -61,18 +72,44 @@ func main() {
 	}
 	defer sqlDB.Close()
 
+	// Merge stock rows duplicated before the unique product and location index
+	merged, err := repository.NewGormInventoryRepository(db).MergeDuplicateStockRows()
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Failed to merge duplicate stock rows")
+	}
+	if merged > 0 {
+		logger.Logger.Info().Int64("inventories", merged).Msg("Merged duplicate stock rows")
+	}
+
 	// Run migrations
-	if err := db.AutoMigrate(&domain.Inventory{}); err != nil {
+	if err := db.AutoMigrate(&domain.Inventory{}, &domain.Location{}, &domain.Reservation{},
+		&domain.ReservationAllocation{}, &domain.InventoryMovement{}, &domain.StockReturn{}, &domain.Transfer{}); err != nil {
 		logger.Logger.Fatal().Err(err).Msg("Failed to run migrations")
 	}
 
+	// Give stock created before the movement ledger an opening balance
+	backfilled, err := repository.NewGormMovementRepository(db).BackfillOpeningBalances()
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Failed to backfill inventory movements")
+	}
+	if backfilled > 0 {
+		logger.Logger.Info().Int64("inventories", backfilled).Msg("Recorded opening balances in the inventory movement ledger")
+	}
+
 	logger.Logger.Info().Msg("Database initialized successfully")
 
+	// Strategy choosing the locations reservations and purchases take stock from
+	strategy, err := allocation.New(getEnv("INVENTORY_ALLOCATION_STRATEGY", allocation.StrategySplit))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid allocation strategy")
+	}
+	logger.Logger.Info().Str("strategy", strategy.Name()).Msg("Stock allocation strategy configured")
+
 	// Get User Service gRPC address
 	userServiceAddr := getEnv("USER_SERVICE_GRPC_ADDR", "localhost:9090")
 
 	// Initialize handler with Wire DI (includes User Service gRPC client)
-	handler, err := inventory.InitializeHTTPHandler(db, userServiceAddr)
+	handler, err := inventory.InitializeHTTPHandler(db, userServiceAddr, strategy)
 	if err != nil {
 		logger.Logger.Fatal().Err(err).Msg("Failed to initialize handler")
 	}
@@ -82,7 +119,7 @@ func main() {
 		Msg("Inventory handler initialized with User Service client")
 
 	// Initialize gRPC server with Wire DI
-	grpcServer, err := inventory.InitializeGRPCServer(db)
+	grpcServer, err := inventory.InitializeGRPCServer(db, strategy)
 	if err != nil {
 		logger.Logger.Fatal().Err(err).Msg("Failed to initialize gRPC server")
 	}
@@ -92,52 +129,182 @@ func main() {
 	// Initialize Kafka consumer
 	kafkaBrokersStr := getEnv("KAFKA_BROKERS", "localhost:9092")
 	kafkaBrokers := strings.Split(kafkaBrokersStr, ",")
-	kafkaConsumer, err := kafka.NewConsumer(kafkaBrokers, "inventory-service-group", []string{kafka.TopicProductPurchased})
+	kafkaConsumer, err := kafka.NewConsumer(kafkaBrokers, "inventory-service-group", []string{kafka.TopicProductPurchased, kafka.TopicPaymentEvents})
 	if err != nil {
 		logger.Logger.Fatal().Err(err).Msg("Failed to initialize Kafka consumer")
 	}
 	defer kafkaConsumer.Close()
 
-	// Register event handler for product purchased events
+	// Deduplicate redelivered events so stock is not decremented twice
+	dedupRetention, err := time.ParseDuration(getEnv("EVENT_DEDUP_RETENTION", "168h"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid EVENT_DEDUP_RETENTION")
+	}
+	var dedupStore *kafka.PostgresProcessedEventStore
+	switch dedupBackend := getEnv("EVENT_DEDUP_STORE", "postgres"); dedupBackend {
+	case "postgres":
+		dedupStore = kafka.NewPostgresProcessedEventStore(db)
+		if err := dedupStore.AutoMigrate(); err != nil {
+			logger.Logger.Fatal().Err(err).Msg("Failed to migrate processed events table")
+		}
+		kafkaConsumer.UseProcessedEventStore(dedupStore)
+	case "redis":
+		redisClient := redis.NewClient(&redis.Options{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       0,
+		})
+		defer redisClient.Close()
+		kafkaConsumer.UseProcessedEventStore(kafka.NewRedisProcessedEventStore(redisClient, dedupRetention))
+		logger.Logger.Warn().Msg("Redis event deduplication claims events before handling them, events interrupted by a crash are not redelivered")
+	case "none":
+		logger.Logger.Warn().Msg("Event deduplication disabled")
+	default:
+		logger.Logger.Fatal().Str("store", dedupBackend).Msg("Unknown EVENT_DEDUP_STORE")
+	}
+
+	// Retry failed handlers, then park them on retry topics and the dead-letter topic
+	retryPolicy, err := loadRetryPolicy()
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid Kafka retry configuration")
+	}
+	kafkaPublisher, err := kafka.NewPublisher(kafkaBrokers)
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Failed to initialize Kafka publisher")
+	}
+	kafkaPublisher.SetSource(serviceName)
+	defer kafkaPublisher.Close()
+	kafkaConsumer.UseRetryPolicy(retryPolicy, kafkaPublisher)
+
+	// Register event handler for product purchased events; the protobuf type
+	// decodes both JSON and protobuf payloads
 	repo := grpcServer.GetRepository()
-	kafkaConsumer.RegisterHandler(kafka.EventTypeProductPurchased, func(ctx context.Context, event kafka.ProductPurchasedEvent) error {
+	kafka.Register(kafkaConsumer, kafka.EventTypeProductPurchased, func(ctx context.Context, event *eventspb.ProductPurchased) error {
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.Int64("product.id", int64(event.GetProductId())),
+			attribute.Int("product.quantity", int(event.GetQuantity())),
+			attribute.Int64("payment.id", int64(event.GetPaymentId())),
+		)
+
 		logger.Logger.Info().
-			Uint("product_id", event.ProductID).
-			Int32("quantity", event.Quantity).
-			Uint("payment_id", event.PaymentID).
+			Uint("product_id", uint(event.GetProductId())).
+			Int32("quantity", event.GetQuantity()).
+			Uint("payment_id", uint(event.GetPaymentId())).
 			Msg("Processing product purchased event")
 
-		// Get current inventory
-		inv, err := repo.FindByProductID(event.ProductID)
+		// Stock for saga checkouts was already taken when the reservation was made
+		if event.GetReservationId() != "" {
+			logger.Logger.Info().
+				Uint("product_id", uint(event.GetProductId())).
+				Str("reservation_id", event.GetReservationId()).
+				Msg("Purchase backed by reservation, stock already deducted")
+			return nil
+		}
+
+		// Decrease stock atomically; fails instead of clamping when stock is short
+		change := command.NewStockChange(ctx, domain.MovementPurchase,
+			domain.ReferencePayment, strconv.FormatUint(uint64(event.GetPaymentId()), 10))
+		allocations, err := repo.DecrementQuantity(uint(event.GetProductId()), int(event.GetQuantity()), strategy, change)
 		if err != nil {
+			if errors.Is(err, domain.ErrInsufficientStock) {
+				logger.Logger.Error().
+					Err(err).
+					Uint("product_id", uint(event.GetProductId())).
+					Int32("purchased", event.GetQuantity()).
+					Uint("payment_id", uint(event.GetPaymentId())).
+					Msg("Insufficient stock for purchase")
+				return err
+			}
 			logger.Logger.Error().
 				Err(err).
-				Uint("product_id", event.ProductID).
-				Msg("Failed to find inventory")
+				Uint("product_id", uint(event.GetProductId())).
+				Msg("Failed to decrease inventory quantity")
 			return err
 		}
 
-		// Decrease stock
-		newQuantity := inv.Quantity - int(event.Quantity)
-		if newQuantity < 0 {
-			newQuantity = 0
+		for _, taken := range allocations {
+			logger.Logger.Info().
+				Uint("product_id", uint(event.GetProductId())).
+				Str("location", taken.Location).
+				Int("taken", taken.Quantity).
+				Int32("purchased", event.GetQuantity()).
+				Msg("Inventory updated successfully")
+		}
+
+		return nil
+	})
+
+	// Return stock when a payment fails or items are refunded
+	restockHandler := inventory.InitializeRestockHandler(db)
+	restockOnPayment := func(ctx context.Context, event kafka.PaymentEvent) error {
+		if event.ProductID == 0 {
+			return nil
 		}
 
-		if err := repo.UpdateQuantity(event.ProductID, newQuantity); err != nil {
+		restocked, err := restockHandler.Handle(ctx, command.RestockCommand{
+			ProductID:     event.ProductID,
+			Quantity:      int(event.Quantity),
+			ReservationID: event.ReservationID,
+			PaymentID:     event.PaymentID,
+		})
+		if err != nil {
 			logger.Logger.Error().
 				Err(err).
+				Uint("payment_id", event.PaymentID).
 				Uint("product_id", event.ProductID).
-				Int("new_quantity", newQuantity).
-				Msg("Failed to update inventory quantity")
+				Msg("Failed to restock payment")
 			return err
 		}
 
 		logger.Logger.Info().
+			Uint("payment_id", event.PaymentID).
+			Str("status", event.Status).
 			Uint("product_id", event.ProductID).
-			Int("old_quantity", inv.Quantity).
-			Int("new_quantity", newQuantity).
-			Int32("purchased", event.Quantity).
-			Msg("Inventory updated successfully")
+			Int("restocked", restocked).
+			Msg("Stock returned for payment")
+
+		return nil
+	}
+	kafka.Register(kafkaConsumer, kafka.EventTypePaymentFailed, restockOnPayment)
+
+	// Refunds return exactly the items they list, to the locations they were
+	// taken from, so payment.refunded is not restocked again. Order refunds
+	// list their items by order line, each taken by its own reservation.
+	kafka.Register(kafkaConsumer, kafka.EventTypeRefundIssued, func(ctx context.Context, event kafka.RefundEvent) error {
+		lines := event.Lines
+		if len(lines) == 0 && event.ProductID != 0 {
+			lines = []kafka.RefundLine{{ProductID: event.ProductID, ReservationID: event.ReservationID, Quantity: event.Quantity}}
+		}
+
+		for _, line := range lines {
+			if line.Quantity == 0 {
+				continue
+			}
+
+			restocked, err := restockHandler.Handle(ctx, command.RestockCommand{
+				ProductID:     line.ProductID,
+				Quantity:      int(line.Quantity),
+				ReservationID: line.ReservationID,
+				PaymentID:     event.PaymentID,
+				RefundID:      event.RefundID,
+			})
+			if err != nil {
+				logger.Logger.Error().
+					Err(err).
+					Uint("payment_id", event.PaymentID).
+					Uint("refund_id", event.RefundID).
+					Uint("product_id", line.ProductID).
+					Msg("Failed to restock refund")
+				return err
+			}
+
+			logger.Logger.Info().
+				Uint("payment_id", event.PaymentID).
+				Uint("refund_id", event.RefundID).
+				Uint("product_id", line.ProductID).
+				Int("restocked", restocked).
+				Msg("Stock returned for refund")
+		}
 
 		return nil
 	})
@@ -152,9 +319,21 @@ func main() {
 
 	logger.Logger.Info().
 		Strs("kafka_brokers", kafkaBrokers).
-		Str("topic", kafka.TopicProductPurchased).
+		Strs("topics", []string{kafka.TopicProductPurchased, kafka.TopicPaymentEvents}).
 		Msg("Kafka consumer started")
 
+	// Start reservation reaper (expires abandoned stock holds)
+	reaperInterval, err := time.ParseDuration(getEnv("RESERVATION_REAPER_INTERVAL", "30s"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid RESERVATION_REAPER_INTERVAL")
+	}
+	go startReservationReaper(ctx, inventory.InitializeReservationReaper(db), reaperInterval)
+
+	// Drop processed event IDs older than the retention window
+	if dedupStore != nil {
+		go startProcessedEventsPurger(ctx, dedupStore, dedupRetention)
+	}
+
 	// Start gRPC server in goroutine
 	grpcPort := getEnv("GRPC_PORT", "9092")
 	go startGRPCServer(grpcServer, grpcPort)
@@ -172,6 +351,85 @@ func main() {
 	cancel() // Stop Kafka consumer
 }
 
+func startReservationReaper(ctx context.Context, expireHandler *command.ExpireReservationsHandler, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Logger.Info().
+		Dur("interval", interval).
+		Msg("Reservation reaper started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Logger.Info().Msg("Reservation reaper stopped")
+			return
+		case <-ticker.C:
+			expired, err := expireHandler.Handle(command.ExpireReservationsCommand{})
+			if err != nil {
+				logger.Logger.Error().Err(err).Msg("Failed to expire reservations")
+				continue
+			}
+			if expired > 0 {
+				logger.Logger.Info().
+					Int("expired", expired).
+					Msg("Expired stale stock reservations")
+			}
+		}
+	}
+}
+
+func loadRetryPolicy() (kafka.RetryPolicy, error) {
+	attempts, err := strconv.Atoi(getEnv("KAFKA_RETRY_ATTEMPTS", "3"))
+	if err != nil {
+		return kafka.RetryPolicy{}, fmt.Errorf("invalid KAFKA_RETRY_ATTEMPTS: %w", err)
+	}
+	backoff, err := time.ParseDuration(getEnv("KAFKA_RETRY_BACKOFF", "200ms"))
+	if err != nil {
+		return kafka.RetryPolicy{}, fmt.Errorf("invalid KAFKA_RETRY_BACKOFF: %w", err)
+	}
+	retryTopics, err := strconv.Atoi(getEnv("KAFKA_RETRY_TOPICS", "2"))
+	if err != nil {
+		return kafka.RetryPolicy{}, fmt.Errorf("invalid KAFKA_RETRY_TOPICS: %w", err)
+	}
+	retryDelay, err := time.ParseDuration(getEnv("KAFKA_RETRY_TOPIC_DELAY", "30s"))
+	if err != nil {
+		return kafka.RetryPolicy{}, fmt.Errorf("invalid KAFKA_RETRY_TOPIC_DELAY: %w", err)
+	}
+
+	return kafka.RetryPolicy{
+		Attempts:    attempts,
+		Backoff:     backoff,
+		RetryTopics: retryTopics,
+		RetryDelay:  retryDelay,
+		DeadLetter:  getEnv("KAFKA_DLQ_ENABLED", "true") == "true",
+		Drop:        getEnv("KAFKA_DROP_EXHAUSTED", "false") == "true",
+	}, nil
+}
+
+func startProcessedEventsPurger(ctx context.Context, store *kafka.PostgresProcessedEventStore, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := store.PurgeBefore(ctx, time.Now().Add(-retention))
+			if err != nil {
+				logger.Logger.Error().Err(err).Msg("Failed to purge processed events")
+				continue
+			}
+			if purged > 0 {
+				logger.Logger.Info().
+					Int64("purged", purged).
+					Msg("Purged processed events")
+			}
+		}
+	}
+}
+
 func startHTTPServer(handler *httpDelivery.InventoryHandler, db *sql.DB, port string) {
 	// Setup router
 	router := mux.NewRouter()
@@ -3,21 +3,36 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"gorm.io/gorm"
 
+	pb "github.com/tair/full-observability/api/proto/payment"
 	_ "github.com/tair/full-observability/cmd/payment/docs"
 	"github.com/tair/full-observability/internal/payment"
+	grpcDelivery "github.com/tair/full-observability/internal/payment/delivery/grpc"
 	"github.com/tair/full-observability/internal/payment/domain"
 	"github.com/tair/full-observability/internal/payment/handler"
+	"github.com/tair/full-observability/internal/payment/pricing"
+	"github.com/tair/full-observability/internal/payment/provider"
+	"github.com/tair/full-observability/internal/payment/risk"
+	"github.com/tair/full-observability/internal/payment/usecase/command"
+	"github.com/tair/full-observability/kafka"
 	"github.com/tair/full-observability/pkg/database"
 	"github.com/tair/full-observability/pkg/logger"
 	"github.com/tair/full-observability/pkg/tracing"
@@ -46,6 +61,9 @@ import (
 // @tag.name Payments
 // @tag.description Payment management endpoints
 
+// @tag.name Orders
+// @tag.description Multi-line order endpoints
+
 // @tag.name Health
 // @tag.description Health check endpoints
 
@@ -101,9 +119,12 @@ func main() {
 	defer sqlDB.Close()
 
 	// Run migrations
-	if err := db.AutoMigrate(&domain.Payment{}); err != nil {
+	if err := db.AutoMigrate(&domain.Payment{}, &domain.PaymentStatusHistory{}, &domain.CheckoutSaga{}, &domain.CheckoutSagaStep{}, &domain.OutboxEvent{}, &domain.WebhookEvent{}, &domain.Refund{}, &domain.RefundLine{}, &domain.Order{}, &domain.OrderLine{}, &domain.IdempotencyKey{}); err != nil {
 		logger.Logger.Fatal().Err(err).Msg("Failed to run migrations")
 	}
+	if err := migrateAmountsToMinorUnits(db); err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Failed to migrate amounts to minor units")
+	}
 
 	logger.Logger.Info().Msg("Database initialized successfully")
 
@@ -117,29 +138,409 @@ func main() {
 	// Get Kafka brokers
 	kafkaBrokers := []string{getEnv("KAFKA_BROKERS", "localhost:9092")}
 
-	// Initialize handler with Wire DI (includes gRPC clients & Kafka)
-	paymentHandler, err := payment.InitializeHandler(db, serviceAddrs, kafkaBrokers)
+	// Protobuf event payloads are checked against the schema registry at startup
+	var schemaRegistry kafka.SchemaRegistry
+	eventEncoding := getEnv("EVENT_ENCODING", "json")
+	switch eventEncoding {
+	case "json":
+	case "protobuf":
+		switch backend := getEnv("SCHEMA_REGISTRY", "postgres"); backend {
+		case "postgres":
+			postgresRegistry := kafka.NewPostgresSchemaRegistry(db)
+			if err := postgresRegistry.AutoMigrate(); err != nil {
+				logger.Logger.Fatal().Err(err).Msg("Failed to migrate schema registry")
+			}
+			schemaRegistry = postgresRegistry
+		case "file":
+			schemaRegistry = kafka.NewFileSchemaRegistry(getEnv("SCHEMA_REGISTRY_PATH", "event-schemas.json"))
+		default:
+			logger.Logger.Fatal().Str("registry", backend).Msg("Unknown SCHEMA_REGISTRY")
+		}
+	default:
+		logger.Logger.Fatal().Str("encoding", eventEncoding).Msg("Unknown EVENT_ENCODING")
+	}
+
+	// Payment providers, selected per payment method
+	gateway, err := loadPaymentGateway()
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid payment provider configuration")
+	}
+
+	webhookVerifier, err := loadWebhookVerifier()
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid webhook configuration")
+	}
+
+	// Charges are priced from the product catalog through the pricing pipeline
+	pricer, err := loadPricingPipeline()
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid pricing configuration")
+	}
+
+	// Payments are risk-checked before they are charged. Redis backs the
+	// velocity rules and is only needed when they are enabled.
+	var redisClient *redis.Client
+	if redisAddr := getEnv("REDIS_ADDR", ""); redisAddr != "" {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     redisAddr,
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       0,
+		})
+		defer redisClient.Close()
+	}
+	riskEngine, err := loadRiskEngine(redisClient)
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid risk configuration")
+	}
+
+	// Only these proxies, such as the API gateway, may report the client IP
+	// in X-Forwarded-For; other callers are identified by their address
+	trustedProxies, err := risk.ParseTrustedProxies(getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
+
+	// Responses to requests with an Idempotency-Key are remembered for this window
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_KEY_TTL", "24h"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid IDEMPOTENCY_KEY_TTL")
+	}
+
+	// Initialize HTTP handler and gRPC server with Wire DI (includes gRPC clients & Kafka)
+	servers, err := payment.InitializeServers(db, serviceAddrs, kafkaBrokers, schemaRegistry, gateway, webhookVerifier, pricer, riskEngine, idempotencyTTL, trustedProxies)
 	if err != nil {
 		logger.Logger.Fatal().Err(err).Msg("Failed to initialize handler")
 	}
+	paymentHandler := servers.HTTP
 
 	logger.Logger.Info().
 		Str("user_service_grpc", serviceAddrs.UserServiceAddr).
 		Str("product_service_grpc", serviceAddrs.ProductServiceAddr).
 		Str("inventory_service_grpc", serviceAddrs.InventoryServiceAddr).
 		Strs("kafka_brokers", kafkaBrokers).
+		Str("event_encoding", eventEncoding).
+		Dur("idempotency_key_ttl", idempotencyTTL).
 		Msg("Payment handler initialized with gRPC clients & Kafka publisher")
 
+	// Start outbox relay (publishes payment events written with the payment row)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	relayInterval, err := time.ParseDuration(getEnv("OUTBOX_RELAY_INTERVAL", "1s"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid OUTBOX_RELAY_INTERVAL")
+	}
+	go startOutboxRelay(ctx, paymentHandler.GetOutboxRelay(), relayInterval)
+
+	// Start outbox purge (sent events are only kept for inspection)
+	outboxRetention, err := time.ParseDuration(getEnv("OUTBOX_RETENTION", "168h"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid OUTBOX_RETENTION")
+	}
+	go startOutboxPurge(ctx, paymentHandler.GetOutboxRelay(), outboxRetention)
+
+	// Start idempotency key purge (expired keys are also reclaimed on reuse)
+	purgeInterval, err := time.ParseDuration(getEnv("IDEMPOTENCY_PURGE_INTERVAL", "1h"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid IDEMPOTENCY_PURGE_INTERVAL")
+	}
+	go startIdempotencyKeyPurge(ctx, paymentHandler.GetIdempotencyKeys(), purgeInterval)
+
+	// Start checkout resumer (rolls forward or compensates checkouts interrupted
+	// by a crash or a failed commit). Paid checkouts must be committed before
+	// the inventory service expires their reservations and returns the stock.
+	resumeInterval, err := time.ParseDuration(getEnv("SAGA_RESUME_INTERVAL", "1m"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid SAGA_RESUME_INTERVAL")
+	}
+	resumeIdle, err := time.ParseDuration(getEnv("SAGA_RESUME_IDLE", "30s"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid SAGA_RESUME_IDLE")
+	}
+	reservationTTL, err := time.ParseDuration(getEnv("RESERVATION_TTL", "15m"))
+	if err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Invalid RESERVATION_TTL")
+	}
+	if resumeInterval <= 0 || resumeInterval+resumeIdle >= reservationTTL {
+		logger.Logger.Fatal().
+			Dur("interval", resumeInterval).
+			Dur("idle", resumeIdle).
+			Dur("reservation_ttl", reservationTTL).
+			Msg("SAGA_RESUME_INTERVAL plus SAGA_RESUME_IDLE must be shorter than the inventory RESERVATION_TTL")
+	}
+	go startCheckoutResumer(ctx, resumeInterval, resumeIdle, paymentHandler.GetCheckoutHandler(), paymentHandler.GetPlaceOrderHandler())
+
 	// Start HTTP server
 	httpPort := getEnv("HTTP_PORT", "8083")
-	startHTTPServer(paymentHandler, sqlDB, httpPort)
+	go startHTTPServer(paymentHandler, sqlDB, httpPort)
+
+	// Start gRPC server
+	grpcPort := getEnv("GRPC_PORT", "9094")
+	go startGRPCServer(servers.GRPC, grpcPort)
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Logger.Info().Msg("Shutting down server...")
+	logger.Logger.Info().Msg("Shutting down servers...")
+	cancel() // Stop outbox relay
+}
+
+// loadPaymentGateway builds the payment gateway. Only the in-process fake
+// provider exists so far; it serves every payment method.
+func loadPaymentGateway() (*provider.Gateway, error) {
+	timeout, err := time.ParseDuration(getEnv("PAYMENT_PROVIDER_TIMEOUT", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAYMENT_PROVIDER_TIMEOUT: %w", err)
+	}
+
+	switch name := getEnv("PAYMENT_PROVIDER", "fake"); name {
+	case "fake":
+		config := provider.DefaultFakeConfig()
+		if config.Latency, err = time.ParseDuration(getEnv("FAKE_PROVIDER_LATENCY", "0s")); err != nil {
+			return nil, fmt.Errorf("invalid FAKE_PROVIDER_LATENCY: %w", err)
+		}
+		if config.DeclineAbove, err = strconv.ParseFloat(getEnv("FAKE_PROVIDER_DECLINE_ABOVE", "0"), 64); err != nil {
+			return nil, fmt.Errorf("invalid FAKE_PROVIDER_DECLINE_ABOVE: %w", err)
+		}
+
+		logger.Logger.Info().
+			Dur("latency", config.Latency).
+			Float64("decline_above", config.DeclineAbove).
+			Dur("timeout", timeout).
+			Msg("Using fake payment provider")
+		return provider.NewGateway(provider.NewFakeProvider(config), timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_PROVIDER %q", name)
+	}
+}
+
+// loadPricingPipeline builds the pricing pipeline: an optional percentage
+// discount (PRICING_DISCOUNT_PERCENT) followed by an optional tax rate
+// (PRICING_TAX_RATE) applied to the discounted total. Prices are converted
+// into other currencies with the rate table in FX_RATES_FILE, when set.
+func loadPricingPipeline() (*pricing.Pipeline, error) {
+	discountPercent, err := strconv.ParseFloat(getEnv("PRICING_DISCOUNT_PERCENT", "0"), 64)
+	if err != nil || discountPercent < 0 || discountPercent > 100 {
+		return nil, fmt.Errorf("invalid PRICING_DISCOUNT_PERCENT %q", getEnv("PRICING_DISCOUNT_PERCENT", "0"))
+	}
+	taxRate, err := strconv.ParseFloat(getEnv("PRICING_TAX_RATE", "0"), 64)
+	if err != nil || taxRate < 0 {
+		return nil, fmt.Errorf("invalid PRICING_TAX_RATE %q", getEnv("PRICING_TAX_RATE", "0"))
+	}
+
+	pipeline := pricing.NewPipeline()
+	if discountPercent > 0 {
+		pipeline.Use(pricing.PercentageDiscount{Name: getEnv("PRICING_DISCOUNT_NAME", "discount"), Percent: discountPercent})
+	}
+	if taxRate > 0 {
+		pipeline.Use(pricing.TaxRate{Name: getEnv("PRICING_TAX_NAME", "tax"), Rate: taxRate})
+	}
+
+	fxRatesFile := getEnv("FX_RATES_FILE", "")
+	if fxRatesFile != "" {
+		rates, err := pricing.LoadStaticRates(fxRatesFile)
+		if err != nil {
+			return nil, err
+		}
+		pipeline.UseFXRates(rates)
+	}
+
+	logger.Logger.Info().
+		Float64("discount_percent", discountPercent).
+		Float64("tax_rate", taxRate).
+		Str("fx_rates_file", fxRatesFile).
+		Msg("Pricing pipeline configured")
+	return pipeline, nil
+}
+
+// loadRiskEngine builds the risk engine from its optional rules: attempt
+// velocity per user and per client IP over RISK_VELOCITY_WINDOW, amount
+// thresholds per currency (RISK_AMOUNT_REVIEW, RISK_AMOUNT_DENY, e.g.
+// "USD=1000,EUR=900") and a limit for accounts younger than
+// RISK_NEW_ACCOUNT_AGE (RISK_NEW_ACCOUNT_LIMIT).
+func loadRiskEngine(redisClient *redis.Client) (*risk.Engine, error) {
+	engine := risk.NewEngine()
+
+	window, err := time.ParseDuration(getEnv("RISK_VELOCITY_WINDOW", "1h"))
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid RISK_VELOCITY_WINDOW %q", getEnv("RISK_VELOCITY_WINDOW", "1h"))
+	}
+	for _, subject := range []string{risk.VelocityByUser, risk.VelocityByIP} {
+		prefix := "RISK_VELOCITY_" + strings.ToUpper(subject)
+		review, err := strconv.ParseInt(getEnv(prefix+"_REVIEW", "0"), 10, 64)
+		if err != nil || review < 0 {
+			return nil, fmt.Errorf("invalid %s_REVIEW", prefix)
+		}
+		deny, err := strconv.ParseInt(getEnv(prefix+"_DENY", "0"), 10, 64)
+		if err != nil || deny < 0 {
+			return nil, fmt.Errorf("invalid %s_DENY", prefix)
+		}
+		if review == 0 && deny == 0 {
+			continue
+		}
+		if redisClient == nil {
+			return nil, fmt.Errorf("%s_* requires REDIS_ADDR", prefix)
+		}
+		engine.Use(risk.NewVelocity(redisClient, subject, window, review, deny))
+	}
+
+	reviewAbove, err := risk.ParseLimits(getEnv("RISK_AMOUNT_REVIEW", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RISK_AMOUNT_REVIEW: %w", err)
+	}
+	denyAbove, err := risk.ParseLimits(getEnv("RISK_AMOUNT_DENY", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RISK_AMOUNT_DENY: %w", err)
+	}
+	if len(reviewAbove) > 0 || len(denyAbove) > 0 {
+		engine.Use(risk.AmountThreshold{Review: reviewAbove, Deny: denyAbove})
+	}
+
+	newAccountAge, err := time.ParseDuration(getEnv("RISK_NEW_ACCOUNT_AGE", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RISK_NEW_ACCOUNT_AGE: %w", err)
+	}
+	if newAccountAge > 0 {
+		limits, err := risk.ParseLimits(getEnv("RISK_NEW_ACCOUNT_LIMIT", ""))
+		if err != nil || len(limits) == 0 {
+			return nil, fmt.Errorf("RISK_NEW_ACCOUNT_AGE requires a valid RISK_NEW_ACCOUNT_LIMIT")
+		}
+		decision := getEnv("RISK_NEW_ACCOUNT_DECISION", domain.RiskDecisionDeny)
+		if decision != domain.RiskDecisionReview && decision != domain.RiskDecisionDeny {
+			return nil, fmt.Errorf("invalid RISK_NEW_ACCOUNT_DECISION %q", decision)
+		}
+		engine.Use(risk.NewAccountLimit{MinAge: newAccountAge, Limits: limits, Decision: decision})
+	}
+
+	logger.Logger.Info().
+		Strs("rules", engine.Rules()).
+		Dur("velocity_window", window).
+		Msg("Risk engine configured")
+	return engine, nil
+}
+
+// loadWebhookVerifier reads the provider webhook signing secrets from
+// PAYMENT_WEBHOOK_SECRETS ("provider=secret,..."). Webhooks from providers
+// without a secret are rejected.
+func loadWebhookVerifier() (*provider.WebhookVerifier, error) {
+	tolerance, err := time.ParseDuration(getEnv("PAYMENT_WEBHOOK_TOLERANCE", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAYMENT_WEBHOOK_TOLERANCE: %w", err)
+	}
+
+	secrets := make(map[string]string)
+	for _, entry := range strings.Split(getEnv("PAYMENT_WEBHOOK_SECRETS", ""), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, secret, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" || secret == "" {
+			return nil, fmt.Errorf("invalid PAYMENT_WEBHOOK_SECRETS entry %q", entry)
+		}
+		secrets[strings.TrimSpace(name)] = secret
+	}
+	if len(secrets) == 0 {
+		logger.Logger.Warn().Msg("No PAYMENT_WEBHOOK_SECRETS configured, provider webhooks will be rejected")
+	}
+
+	return provider.NewWebhookVerifier(secrets, tolerance), nil
+}
+
+func startOutboxRelay(ctx context.Context, relay *command.RelayOutboxHandler, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Logger.Info().
+		Dur("interval", interval).
+		Msg("Outbox relay started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Logger.Info().Msg("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			if _, err := relay.Handle(ctx); err != nil {
+				logger.Logger.Error().Err(err).Msg("Outbox relay iteration failed")
+			}
+		}
+	}
+}
+
+// checkoutResumer drives unfinished checkouts to a terminal state
+type checkoutResumer interface {
+	Resume(ctx context.Context, idle time.Duration) error
+}
+
+// startCheckoutResumer resumes the checkouts idle for longer than idle once at
+// startup and then on every tick
+func startCheckoutResumer(ctx context.Context, interval, idle time.Duration, resumers ...checkoutResumer) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Logger.Info().
+		Dur("interval", interval).
+		Dur("idle", idle).
+		Msg("Checkout resumer started")
+
+	for {
+		for _, resumer := range resumers {
+			if err := resumer.Resume(ctx, idle); err != nil && ctx.Err() == nil {
+				logger.Logger.Error().Err(err).Msg("Failed to resume checkouts")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Logger.Info().Msg("Checkout resumer stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func startOutboxPurge(ctx context.Context, relay *command.RelayOutboxHandler, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := relay.PurgeSent(retention)
+			if err != nil {
+				logger.Logger.Error().Err(err).Msg("Failed to purge sent outbox events")
+				continue
+			}
+			if purged > 0 {
+				logger.Logger.Info().Int64("purged", purged).Msg("Purged sent outbox events")
+			}
+		}
+	}
+}
+
+func startIdempotencyKeyPurge(ctx context.Context, keys domain.IdempotencyKeyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := keys.PurgeExpired(time.Now())
+			if err != nil {
+				logger.Logger.Error().Err(err).Msg("Failed to purge expired idempotency keys")
+				continue
+			}
+			if purged > 0 {
+				logger.Logger.Info().Int64("purged", purged).Msg("Purged expired idempotency keys")
+			}
+		}
+	}
 }
 
 func startHTTPServer(paymentHandler *handler.PaymentHandler, db *sql.DB, port string) {
@@ -182,9 +583,108 @@ func startHTTPServer(paymentHandler *handler.PaymentHandler, db *sql.DB, port st
 	}
 }
 
+func startGRPCServer(paymentServer *grpcDelivery.PaymentServer, port string) {
+	// Create gRPC server with interceptors
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpcDelivery.TracingInterceptor, // Add tracing first
+			grpcDelivery.MetricsInterceptor, // Collect metrics
+			grpcDelivery.LoggingInterceptor, // Log requests
+			grpcDelivery.AuthInterceptor,    // Verify auth
+		),
+	)
+
+	// Register payment service
+	pb.RegisterPaymentServiceServer(grpcServer, paymentServer)
+
+	// Register reflection service (for grpcurl and grpc tools)
+	reflection.Register(grpcServer)
+
+	// Listen on TCP port
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		logger.Logger.Fatal().
+			Str("port", port).
+			Err(err).
+			Msg("Failed to listen on gRPC port")
+	}
+
+	logger.Logger.Info().
+		Str("port", port).
+		Bool("reflection_enabled", true).
+		Bool("tracing_enabled", true).
+		Bool("metrics_enabled", true).
+		Msg("gRPC server started")
+
+	if err := grpcServer.Serve(lis); err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Failed to start gRPC server")
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// migrateAmountsToMinorUnits moves amounts stored as decimals before amounts
+// used minor units. Refunds and order lines take their currency from the
+// parent row, so they are migrated before it drops its currency column.
+func migrateAmountsToMinorUnits(db *gorm.DB) error {
+	steps := []struct {
+		table   string
+		columns []database.MinorUnitsColumn
+		drop    []string
+	}{
+		{
+			table: "refunds",
+			columns: []database.MinorUnitsColumn{
+				{From: "amount", To: "amount_", Currency: "(SELECT currency FROM payments WHERE payments.id = refunds.payment_id)"},
+			},
+		},
+		{
+			table: "order_lines",
+			columns: []database.MinorUnitsColumn{
+				{From: "unit_price", To: "unit_price_", Currency: "(SELECT currency FROM orders WHERE orders.id = order_lines.order_id)"},
+				{From: "line_total", To: "line_total_", Currency: "(SELECT currency FROM orders WHERE orders.id = order_lines.order_id)"},
+			},
+		},
+		{
+			table: "orders",
+			columns: []database.MinorUnitsColumn{
+				{From: "subtotal", To: "subtotal_", Currency: "currency"},
+				{From: "tax_amount", To: "tax_amount_", Currency: "currency"},
+				{From: "discount_amount", To: "discount_amount_", Currency: "currency"},
+				{From: "total", To: "total_", Currency: "currency"},
+			},
+			drop: []string{"currency"},
+		},
+		{
+			table: "checkout_sagas",
+			columns: []database.MinorUnitsColumn{
+				{From: "amount", To: "amount_", Currency: "currency"},
+			},
+			drop: []string{"currency"},
+		},
+		{
+			table: "payments",
+			columns: []database.MinorUnitsColumn{
+				{From: "amount", To: "amount_", Currency: "currency"},
+				{From: "unit_price", To: "unit_price_", Currency: "currency"},
+				{From: "subtotal", To: "subtotal_", Currency: "currency"},
+				{From: "tax_amount", To: "tax_amount_", Currency: "currency"},
+				{From: "discount_amount", To: "discount_amount_", Currency: "currency"},
+				{From: "refunded_amount", To: "refunded_amount_", Currency: "currency"},
+			},
+			drop: []string{"currency"},
+		},
+	}
+
+	for _, step := range steps {
+		if err := database.MigrateToMinorUnits(db, step.table, step.columns, step.drop...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
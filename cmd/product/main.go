@@ -88,6 +88,13 @@ func main() {
 		logger.Logger.Fatal().Err(err).Msg("Failed to run migrations")
 	}
 
+	// Move prices stored as decimals before amounts used minor units
+	if err := database.MigrateToMinorUnits(db, "products", []database.MinorUnitsColumn{
+		{From: "price", To: "price_"},
+	}); err != nil {
+		logger.Logger.Fatal().Err(err).Msg("Failed to migrate product prices")
+	}
+
 	logger.Logger.Info().Msg("Database initialized successfully")
 
 	// Register database pool metrics
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/tair/full-observability/internal/reconciliation"
+	"github.com/tair/full-observability/pkg/database"
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+const usage = `Usage: reconcile [flags]
+
+Compares completed payments with the payment outbox and the inventory
+reservations over a time range and reports every mismatch.
+
+The payment database is configured with PAYMENT_DB_HOST, PAYMENT_DB_PORT,
+PAYMENT_DB_USER, PAYMENT_DB_PASSWORD, PAYMENT_DB_NAME and PAYMENT_DB_SSLMODE,
+the inventory database with the same variables prefixed INVENTORY_DB_.
+
+Flags:
+`
+
+func main() {
+	logger.Init("reconcile", true)
+	logger.SetLevel(getEnv("LOG_LEVEL", "warn"))
+
+	err := run()
+	var mismatches errMismatches
+	switch {
+	case errors.As(err, &mismatches):
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(3)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// errMismatches fails a run with -fail-on-mismatch that found mismatches
+type errMismatches int
+
+func (e errMismatches) Error() string {
+	return fmt.Sprintf("%d mismatch(es) found", int(e))
+}
+
+func run() error {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	since := fs.Duration("since", 24*time.Hour, "reconcile the period ending at -to of this length, when -from is not set")
+	fromFlag := fs.String("from", "", "start of the period, RFC 3339")
+	toFlag := fs.String("to", "", "end of the period, RFC 3339 (default now)")
+	format := fs.String("format", reconciliation.FormatJSON, "report format: json or csv")
+	output := fs.String("output", "", "file to write the report to (default stdout)")
+	pushgateway := fs.String("pushgateway", getEnv("PUSHGATEWAY_URL", ""), "Prometheus Pushgateway URL to export the mismatch gauges to")
+	failOnMismatch := fs.Bool("fail-on-mismatch", false, "exit with status 3 when mismatches are found")
+	fs.Parse(os.Args[1:])
+
+	to := time.Now().UTC()
+	if *toFlag != "" {
+		parsed, err := time.Parse(time.RFC3339, *toFlag)
+		if err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+		to = parsed
+	}
+	from := to.Add(-*since)
+	if *fromFlag != "" {
+		parsed, err := time.Parse(time.RFC3339, *fromFlag)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("-from must be before -to")
+	}
+	if *format != reconciliation.FormatJSON && *format != reconciliation.FormatCSV {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	paymentDB, err := connect("PAYMENT_DB_", "paymentdb")
+	if err != nil {
+		return fmt.Errorf("payment database: %w", err)
+	}
+	inventoryDB, err := connect("INVENTORY_DB_", "inventorydb")
+	if err != nil {
+		return fmt.Errorf("inventory database: %w", err)
+	}
+
+	report, err := reconciliation.NewReconciler(paymentDB, inventoryDB).Run(context.Background(), from, to)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := report.Write(w, *format); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if *pushgateway != "" {
+		if err := reconciliation.PushMetrics(*pushgateway, report); err != nil {
+			return err
+		}
+	}
+
+	logger.Logger.Info().
+		Time("from", from).
+		Time("to", to).
+		Int("payments_checked", report.PaymentsChecked).
+		Int("reservations_checked", report.ReservationsChecked).
+		Int("mismatches", len(report.Mismatches)).
+		Msg("Reconciliation finished")
+
+	if *failOnMismatch && len(report.Mismatches) > 0 {
+		return errMismatches(len(report.Mismatches))
+	}
+	return nil
+}
+
+// connect opens the database configured by the environment variables with
+// prefix. SQL logging is silenced so that it does not mix with a report
+// written to stdout.
+func connect(prefix, defaultName string) (*gorm.DB, error) {
+	db, err := database.NewGormConnection(database.Config{
+		Host:     getEnv(prefix+"HOST", "localhost"),
+		Port:     getEnv(prefix+"PORT", "5432"),
+		User:     getEnv(prefix+"USER", "postgres"),
+		Password: getEnv(prefix+"PASSWORD", "postgres"),
+		DBName:   getEnv(prefix+"NAME", defaultName),
+		SSLMode:  getEnv(prefix+"SSLMODE", "disable"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.Session(&gorm.Session{Logger: gormlogger.Default.LogMode(gormlogger.Silent)}), nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,126 @@
+package allocation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// Strategy names
+const (
+	StrategyPriority = "priority"
+	StrategyNearest  = "nearest"
+	StrategySplit    = "split"
+)
+
+// New returns the strategy called name
+func New(name string) (domain.AllocationStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case StrategyPriority:
+		return Priority{}, nil
+	case StrategyNearest:
+		return Nearest{}, nil
+	case StrategySplit:
+		return Split{}, nil
+	}
+	return nil, fmt.Errorf("unknown allocation strategy %q", name)
+}
+
+// Priority ships a request from the single location with the lowest
+// priority number that can fill it
+type Priority struct{}
+
+// Name implements domain.AllocationStrategy
+func (Priority) Name() string {
+	return StrategyPriority
+}
+
+// Allocate implements domain.AllocationStrategy
+func (Priority) Allocate(request domain.AllocationRequest, stock []domain.StockLevel) ([]domain.Allocation, error) {
+	return single(request, rank(stock, ""))
+}
+
+// Nearest ships a request from a single location, preferring the customer's
+// region and then priority. Without a region it behaves like Priority.
+type Nearest struct{}
+
+// Name implements domain.AllocationStrategy
+func (Nearest) Name() string {
+	return StrategyNearest
+}
+
+// Allocate implements domain.AllocationStrategy
+func (Nearest) Allocate(request domain.AllocationRequest, stock []domain.StockLevel) ([]domain.Allocation, error) {
+	return single(request, rank(stock, request.Region))
+}
+
+// Split fills a request from as many locations as needed, taking everything
+// a location has before moving on, in the order of Nearest. Any quantity up
+// to the product's total stock can be allocated.
+type Split struct{}
+
+// Name implements domain.AllocationStrategy
+func (Split) Name() string {
+	return StrategySplit
+}
+
+// Allocate implements domain.AllocationStrategy
+func (Split) Allocate(request domain.AllocationRequest, stock []domain.StockLevel) ([]domain.Allocation, error) {
+	var allocations []domain.Allocation
+	remaining := request.Quantity
+	for _, level := range rank(stock, request.Region) {
+		take := min(level.Quantity, remaining)
+		allocations = append(allocations, allocation(level, take))
+		remaining -= take
+		if remaining == 0 {
+			return allocations, nil
+		}
+	}
+	return nil, domain.ErrInsufficientStock
+}
+
+// single allocates the whole request from the first ranked location that
+// can fill it
+func single(request domain.AllocationRequest, ranked []domain.StockLevel) ([]domain.Allocation, error) {
+	for _, level := range ranked {
+		if level.Quantity >= request.Quantity {
+			return []domain.Allocation{allocation(level, request.Quantity)}, nil
+		}
+	}
+	return nil, domain.ErrInsufficientStock
+}
+
+// rank orders the active locations with stock: those in region first, then
+// by priority and stock row
+func rank(stock []domain.StockLevel, region string) []domain.StockLevel {
+	ranked := make([]domain.StockLevel, 0, len(stock))
+	for _, level := range stock {
+		if level.Active && level.Quantity > 0 {
+			ranked = append(ranked, level)
+		}
+	}
+
+	local := func(level domain.StockLevel) bool {
+		return region != "" && strings.EqualFold(level.Region, region)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if local(ranked[i]) != local(ranked[j]) {
+			return local(ranked[i])
+		}
+		if ranked[i].Priority != ranked[j].Priority {
+			return ranked[i].Priority < ranked[j].Priority
+		}
+		return ranked[i].InventoryID < ranked[j].InventoryID
+	})
+	return ranked
+}
+
+func allocation(level domain.StockLevel, quantity int) domain.Allocation {
+	return domain.Allocation{
+		InventoryID: level.InventoryID,
+		Location:    level.Location,
+		Quantity:    quantity,
+	}
+}
@@ -0,0 +1,149 @@
+package allocation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+var testStock = []domain.StockLevel{
+	{InventoryID: 1, Location: "berlin", Region: "eu", Priority: 2, Active: true, Quantity: 5},
+	{InventoryID: 2, Location: "dallas", Region: "us", Priority: 1, Active: true, Quantity: 3},
+	{InventoryID: 3, Location: "paris", Region: "eu", Priority: 1, Active: false, Quantity: 50},
+	{InventoryID: 4, Location: "reno", Region: "us", Priority: 1, Active: true, Quantity: 8},
+	{InventoryID: 5, Location: "madrid", Region: "eu", Priority: 0, Active: true, Quantity: 0},
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"priority", StrategyPriority, false},
+		{" Nearest ", StrategyNearest, false},
+		{"SPLIT", StrategySplit, false},
+		{"", "", true},
+		{"cheapest", "", true},
+	}
+	for _, tt := range tests {
+		strategy, err := New(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("New(%q) = %v, want an error", tt.name, strategy.Name())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("New(%q) error = %v", tt.name, err)
+			continue
+		}
+		if strategy.Name() != tt.want {
+			t.Errorf("New(%q).Name() = %q, want %q", tt.name, strategy.Name(), tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy domain.AllocationStrategy
+		request  domain.AllocationRequest
+		want     []domain.Allocation
+		wantErr  error
+	}{
+		{
+			name:     "priority ties break by stock row",
+			strategy: Priority{},
+			request:  domain.AllocationRequest{Quantity: 2},
+			want:     []domain.Allocation{{InventoryID: 2, Location: "dallas", Quantity: 2}},
+		},
+		{
+			name:     "priority skips locations that cannot fill the request",
+			strategy: Priority{},
+			request:  domain.AllocationRequest{Quantity: 4},
+			want:     []domain.Allocation{{InventoryID: 4, Location: "reno", Quantity: 4}},
+		},
+		{
+			name:     "priority ignores the region",
+			strategy: Priority{},
+			request:  domain.AllocationRequest{Quantity: 2, Region: "eu"},
+			want:     []domain.Allocation{{InventoryID: 2, Location: "dallas", Quantity: 2}},
+		},
+		{
+			name:     "priority skips inactive locations",
+			strategy: Priority{},
+			request:  domain.AllocationRequest{Quantity: 9},
+			wantErr:  domain.ErrInsufficientStock,
+		},
+		{
+			name:     "nearest prefers the region",
+			strategy: Nearest{},
+			request:  domain.AllocationRequest{Quantity: 2, Region: "EU"},
+			want:     []domain.Allocation{{InventoryID: 1, Location: "berlin", Quantity: 2}},
+		},
+		{
+			name:     "nearest falls back outside the region",
+			strategy: Nearest{},
+			request:  domain.AllocationRequest{Quantity: 6, Region: "eu"},
+			want:     []domain.Allocation{{InventoryID: 4, Location: "reno", Quantity: 6}},
+		},
+		{
+			name:     "nearest without a region",
+			strategy: Nearest{},
+			request:  domain.AllocationRequest{Quantity: 2},
+			want:     []domain.Allocation{{InventoryID: 2, Location: "dallas", Quantity: 2}},
+		},
+		{
+			name:     "split within one location",
+			strategy: Split{},
+			request:  domain.AllocationRequest{Quantity: 3, Region: "eu"},
+			want:     []domain.Allocation{{InventoryID: 1, Location: "berlin", Quantity: 3}},
+		},
+		{
+			name:     "split across locations",
+			strategy: Split{},
+			request:  domain.AllocationRequest{Quantity: 10, Region: "eu"},
+			want: []domain.Allocation{
+				{InventoryID: 1, Location: "berlin", Quantity: 5},
+				{InventoryID: 2, Location: "dallas", Quantity: 3},
+				{InventoryID: 4, Location: "reno", Quantity: 2},
+			},
+		},
+		{
+			name:     "split of all the stock",
+			strategy: Split{},
+			request:  domain.AllocationRequest{Quantity: 16},
+			want: []domain.Allocation{
+				{InventoryID: 2, Location: "dallas", Quantity: 3},
+				{InventoryID: 4, Location: "reno", Quantity: 8},
+				{InventoryID: 1, Location: "berlin", Quantity: 5},
+			},
+		},
+		{
+			name:     "split beyond the stock",
+			strategy: Split{},
+			request:  domain.AllocationRequest{Quantity: 17},
+			wantErr:  domain.ErrInsufficientStock,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.strategy.Allocate(tt.request, testStock)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Allocate() = %v, %v, want %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
 
 	pb "github.com/tair/full-observability/api/proto/inventory"
 	"github.com/tair/full-observability/internal/inventory/domain"
@@ -23,10 +26,20 @@ type InventoryGRPCServer struct {
 	createHandler         *command.CreateInventoryHandler
 	updateQuantityHandler *command.UpdateQuantityHandler
 	deleteHandler         *command.DeleteInventoryHandler
+	reserveHandler        *command.ReserveStockHandler
+	releaseHandler        *command.ReleaseStockHandler
+	commitHandler         *command.CommitStockHandler
+	transferHandler       *command.TransferStockHandler
+	receiveHandler        *command.ReceiveTransferHandler
+	cancelHandler         *command.CancelTransferHandler
 
 	// Query handlers
-	getHandler  *query.GetInventoryHandler
-	listHandler *query.ListInventoryHandler
+	getHandler           *query.GetInventoryHandler
+	listHandler          *query.ListInventoryHandler
+	stockHandler         *query.GetStockHandler
+	availabilityHandler  *query.CheckAvailabilityHandler
+	getTransferHandler   *query.GetTransferHandler
+	listTransfersHandler *query.ListTransfersHandler
 
 	repo domain.InventoryRepository
 }
@@ -36,16 +49,36 @@ func NewInventoryGRPCServer(
 	createHandler *command.CreateInventoryHandler,
 	updateQuantityHandler *command.UpdateQuantityHandler,
 	deleteHandler *command.DeleteInventoryHandler,
+	reserveHandler *command.ReserveStockHandler,
+	releaseHandler *command.ReleaseStockHandler,
+	commitHandler *command.CommitStockHandler,
+	transferHandler *command.TransferStockHandler,
+	receiveHandler *command.ReceiveTransferHandler,
+	cancelHandler *command.CancelTransferHandler,
 	getHandler *query.GetInventoryHandler,
 	listHandler *query.ListInventoryHandler,
+	stockHandler *query.GetStockHandler,
+	availabilityHandler *query.CheckAvailabilityHandler,
+	getTransferHandler *query.GetTransferHandler,
+	listTransfersHandler *query.ListTransfersHandler,
 	repo domain.InventoryRepository,
 ) *InventoryGRPCServer {
 	return &InventoryGRPCServer{
 		createHandler:         createHandler,
 		updateQuantityHandler: updateQuantityHandler,
 		deleteHandler:         deleteHandler,
+		reserveHandler:        reserveHandler,
+		releaseHandler:        releaseHandler,
+		commitHandler:         commitHandler,
+		transferHandler:       transferHandler,
+		receiveHandler:        receiveHandler,
+		cancelHandler:         cancelHandler,
 		getHandler:            getHandler,
 		listHandler:           listHandler,
+		stockHandler:          stockHandler,
+		availabilityHandler:   availabilityHandler,
+		getTransferHandler:    getTransferHandler,
+		listTransfersHandler:  listTransfersHandler,
 		repo:                  repo,
 	}
 }
@@ -69,8 +102,11 @@ func (s *InventoryGRPCServer) CreateInventory(ctx context.Context, req *pb.Creat
 		Location:  req.Location,
 	}
 
-	inventory, err := s.createHandler.Handle(cmd)
+	inventory, err := s.createHandler.Handle(ctx, cmd)
 	if err != nil {
+		if errors.Is(err, domain.ErrInventoryExists) {
+			return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+		}
 		logger.Logger.Error().Err(err).Msg("gRPC: Failed to create inventory")
 		return nil, status.Errorf(codes.Internal, "failed to create inventory: %v", err)
 	}
@@ -121,10 +157,11 @@ func (s *InventoryGRPCServer) UpdateQuantity(ctx context.Context, req *pb.Update
 
 	cmd := command.UpdateQuantityCommand{
 		ProductID: inventory.ProductID,
+		Location:  inventory.Location,
 		Quantity:  int(req.Quantity),
 	}
 
-	if err := s.updateQuantityHandler.Handle(cmd); err != nil {
+	if err := s.updateQuantityHandler.Handle(ctx, cmd); err != nil {
 		logger.Logger.Error().Err(err).Msg("gRPC: Failed to update quantity")
 		return nil, status.Errorf(codes.Internal, "failed to update quantity: %v", err)
 	}
@@ -200,22 +237,30 @@ func (s *InventoryGRPCServer) ListInventory(ctx context.Context, req *pb.ListInv
 	}, nil
 }
 
-// GetByProductID retrieves inventory by product ID
+// GetByProductID retrieves the stock of a product across its locations
 func (s *InventoryGRPCServer) GetByProductID(ctx context.Context, req *pb.GetByProductIDRequest) (*pb.InventoryResponse, error) {
 	logger.Logger.Info().
 		Uint32("product_id", req.ProductId).
 		Msg("gRPC: GetByProductID called")
 
-	inventory, err := s.repo.FindByProductID(uint(req.ProductId))
+	stock, err := s.stockHandler.Handle(query.GetStockQuery{ProductID: uint(req.ProductId)})
 	if err != nil {
 		logger.Logger.Error().Err(err).Msg("gRPC: Failed to get inventory by product ID")
 		return nil, status.Errorf(codes.NotFound, "inventory not found for product: %v", err)
 	}
 
+	inventory, err := s.repo.FindByID(stock.Locations[0].InventoryID)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("gRPC: Failed to get inventory by product ID")
+		return nil, status.Errorf(codes.Internal, "failed to get inventory: %v", err)
+	}
+
 	return &pb.InventoryResponse{
-		Success:   true,
-		Message:   "Inventory retrieved successfully",
-		Inventory: domainToProto(inventory),
+		Success:       true,
+		Message:       "Inventory retrieved successfully",
+		Inventory:     domainToProto(inventory),
+		TotalQuantity: int32(stock.Quantity),
+		Locations:     stockLevelsToProto(stock.Locations),
 	}, nil
 }
 
@@ -224,9 +269,14 @@ func (s *InventoryGRPCServer) CheckAvailability(ctx context.Context, req *pb.Che
 	logger.Logger.Info().
 		Uint32("product_id", req.ProductId).
 		Int32("required_quantity", req.RequiredQuantity).
+		Str("region", req.Region).
 		Msg("gRPC: CheckAvailability called")
 
-	inventory, err := s.repo.FindByProductID(uint(req.ProductId))
+	availability, err := s.availabilityHandler.Handle(query.CheckAvailabilityQuery{
+		ProductID: uint(req.ProductId),
+		Quantity:  int(req.RequiredQuantity),
+		Region:    req.Region,
+	})
 	if err != nil {
 		logger.Logger.Error().Err(err).Msg("gRPC: Failed to check availability")
 		return &pb.CheckAvailabilityResponse{
@@ -236,78 +286,279 @@ func (s *InventoryGRPCServer) CheckAvailability(ctx context.Context, req *pb.Che
 		}, nil
 	}
 
-	available := inventory.Quantity >= int(req.RequiredQuantity)
 	message := "Available"
-	if !available {
-		message = fmt.Sprintf("Insufficient stock. Available: %d, Required: %d", inventory.Quantity, req.RequiredQuantity)
+	if !availability.Available {
+		message = availability.Message
 	}
 
 	return &pb.CheckAvailabilityResponse{
-		Available:       available,
-		CurrentQuantity: int32(inventory.Quantity),
+		Available:       availability.Available,
+		CurrentQuantity: int32(availability.Quantity),
 		Message:         message,
+		Locations:       stockLevelsToProto(availability.Locations),
+		Allocations:     allocationsToProto(availability.Allocations),
 	}, nil
 }
 
-// ReserveStock reserves stock for a product
+// ReserveStock holds stock under the caller's reservation ID until it is committed, released or expires
 func (s *InventoryGRPCServer) ReserveStock(ctx context.Context, req *pb.ReserveStockRequest) (*pb.ReserveStockResponse, error) {
 	logger.Logger.Info().
 		Uint32("product_id", req.ProductId).
 		Int32("quantity", req.Quantity).
 		Str("reservation_id", req.ReservationId).
+		Int32("ttl_seconds", req.TtlSeconds).
+		Str("region", req.Region).
 		Msg("gRPC: ReserveStock called")
 
-	inventory, err := s.repo.FindByProductID(uint(req.ProductId))
-	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "product not found: %v", err)
+	cmd := command.ReserveStockCommand{
+		ReservationID: req.ReservationId,
+		ProductID:     uint(req.ProductId),
+		Quantity:      int(req.Quantity),
+		TTL:           time.Duration(req.TtlSeconds) * time.Second,
+		Region:        req.Region,
 	}
 
-	if inventory.Quantity < int(req.Quantity) {
-		return &pb.ReserveStockResponse{
-			Success:       false,
-			Message:       "Insufficient stock",
-			ReservationId: "",
-		}, nil
-	}
-
-	// Update quantity (reduce)
-	newQuantity := inventory.Quantity - int(req.Quantity)
-	if err := s.repo.UpdateQuantity(uint(req.ProductId), newQuantity); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to reserve stock: %v", err)
+	reservation, err := s.reserveHandler.Handle(ctx, cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInsufficientStock):
+			return &pb.ReserveStockResponse{
+				Success:       false,
+				Message:       "Insufficient stock",
+				ReservationId: "",
+			}, nil
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, status.Errorf(codes.NotFound, "product not found: %v", err)
+		case errors.Is(err, domain.ErrReservationConflict):
+			return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+		case errors.Is(err, domain.ErrReservationNotHeld):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		logger.Logger.Error().Err(err).Msg("gRPC: Failed to reserve stock")
+		return nil, status.Errorf(codes.Internal, "%v", err)
 	}
 
 	return &pb.ReserveStockResponse{
 		Success:       true,
 		Message:       "Stock reserved successfully",
-		ReservationId: req.ReservationId,
+		ReservationId: reservation.ID,
+		State:         reservation.State,
+		ExpiresAt:     timestamppb.New(reservation.ExpiresAt),
+		Allocations:   holdingsToProto(reservation.Holdings()),
 	}, nil
 }
 
-// ReleaseStock releases reserved stock
+// ReleaseStock returns the stock held by a reservation
 func (s *InventoryGRPCServer) ReleaseStock(ctx context.Context, req *pb.ReleaseStockRequest) (*pb.ReleaseStockResponse, error) {
 	logger.Logger.Info().
 		Uint32("product_id", req.ProductId).
-		Int32("quantity", req.Quantity).
 		Str("reservation_id", req.ReservationId).
 		Msg("gRPC: ReleaseStock called")
 
-	inventory, err := s.repo.FindByProductID(uint(req.ProductId))
-	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "product not found: %v", err)
+	cmd := command.ReleaseStockCommand{
+		ReservationID: req.ReservationId,
+		ProductID:     uint(req.ProductId),
 	}
 
-	// Update quantity (increase)
-	newQuantity := inventory.Quantity + int(req.Quantity)
-	if err := s.repo.UpdateQuantity(uint(req.ProductId), newQuantity); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to release stock: %v", err)
+	reservation, err := s.releaseHandler.Handle(ctx, cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrReservationNotFound):
+			// Nothing was reserved, so there is nothing to give back
+			return &pb.ReleaseStockResponse{
+				Success: true,
+				Message: "Reservation not found, nothing to release",
+			}, nil
+		case errors.Is(err, domain.ErrReservationCommitted):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		case errors.Is(err, domain.ErrReservationConflict):
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		logger.Logger.Error().Err(err).Msg("gRPC: Failed to release stock")
+		return nil, status.Errorf(codes.Internal, "%v", err)
 	}
 
 	return &pb.ReleaseStockResponse{
 		Success: true,
 		Message: "Stock released successfully",
+		State:   reservation.State,
+	}, nil
+}
+
+// CommitStock makes a reservation's deduction permanent
+func (s *InventoryGRPCServer) CommitStock(ctx context.Context, req *pb.CommitStockRequest) (*pb.CommitStockResponse, error) {
+	logger.Logger.Info().
+		Str("reservation_id", req.ReservationId).
+		Msg("gRPC: CommitStock called")
+
+	reservation, err := s.commitHandler.Handle(command.CommitStockCommand{ReservationID: req.ReservationId})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrReservationNotFound):
+			return nil, status.Errorf(codes.NotFound, "%v", err)
+		case errors.Is(err, domain.ErrReservationNotHeld):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		logger.Logger.Error().Err(err).Msg("gRPC: Failed to commit stock")
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	return &pb.CommitStockResponse{
+		Success: true,
+		Message: "Stock committed successfully",
+		State:   reservation.State,
+	}, nil
+}
+
+// CreateTransfer ships stock from one location to another
+func (s *InventoryGRPCServer) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.TransferResponse, error) {
+	logger.Logger.Info().
+		Uint32("product_id", req.ProductId).
+		Str("from_location", req.FromLocation).
+		Str("to_location", req.ToLocation).
+		Int32("quantity", req.Quantity).
+		Msg("gRPC: CreateTransfer called")
+
+	cmd := command.TransferStockCommand{
+		ProductID:    uint(req.ProductId),
+		FromLocation: req.FromLocation,
+		ToLocation:   req.ToLocation,
+		Quantity:     int(req.Quantity),
+		Note:         req.Note,
+	}
+
+	transfer, err := s.transferHandler.Handle(ctx, cmd)
+	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return &pb.TransferResponse{
+				Success: false,
+				Message: "Insufficient stock at the source location",
+			}, nil
+		}
+		logger.Logger.Error().Err(err).Msg("gRPC: Failed to create transfer")
+		return nil, transferStatusError(err)
+	}
+
+	return &pb.TransferResponse{
+		Success:  true,
+		Message:  "Transfer shipped successfully",
+		Transfer: transferToProto(transfer),
+	}, nil
+}
+
+// ReceiveTransfer receives stock of a transfer at its destination
+func (s *InventoryGRPCServer) ReceiveTransfer(ctx context.Context, req *pb.ReceiveTransferRequest) (*pb.TransferResponse, error) {
+	logger.Logger.Info().
+		Uint32("id", req.Id).
+		Int32("quantity", req.Quantity).
+		Msg("gRPC: ReceiveTransfer called")
+
+	cmd := command.ReceiveTransferCommand{
+		TransferID: uint(req.Id),
+		Quantity:   int(req.Quantity),
+	}
+
+	transfer, err := s.receiveHandler.Handle(ctx, cmd)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("gRPC: Failed to receive transfer")
+		return nil, transferStatusError(err)
+	}
+
+	return &pb.TransferResponse{
+		Success:  true,
+		Message:  "Transfer received successfully",
+		Transfer: transferToProto(transfer),
+	}, nil
+}
+
+// CancelTransfer returns the stock still in transit to the source
+func (s *InventoryGRPCServer) CancelTransfer(ctx context.Context, req *pb.CancelTransferRequest) (*pb.TransferResponse, error) {
+	logger.Logger.Info().
+		Uint32("id", req.Id).
+		Msg("gRPC: CancelTransfer called")
+
+	transfer, err := s.cancelHandler.Handle(ctx, command.CancelTransferCommand{TransferID: uint(req.Id)})
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("gRPC: Failed to cancel transfer")
+		return nil, transferStatusError(err)
+	}
+
+	return &pb.TransferResponse{
+		Success:  true,
+		Message:  "Transfer cancelled successfully",
+		Transfer: transferToProto(transfer),
 	}, nil
 }
 
+// GetTransfer retrieves a transfer by ID
+func (s *InventoryGRPCServer) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.TransferResponse, error) {
+	logger.Logger.Info().
+		Uint32("id", req.Id).
+		Msg("gRPC: GetTransfer called")
+
+	transfer, err := s.getTransferHandler.Handle(query.GetTransferQuery{ID: uint(req.Id)})
+	if err != nil {
+		return nil, transferStatusError(err)
+	}
+
+	return &pb.TransferResponse{
+		Success:  true,
+		Message:  "Transfer retrieved successfully",
+		Transfer: transferToProto(transfer),
+	}, nil
+}
+
+// ListTransfers retrieves a page of transfers, newest first
+func (s *InventoryGRPCServer) ListTransfers(ctx context.Context, req *pb.ListTransfersRequest) (*pb.ListTransfersResponse, error) {
+	logger.Logger.Info().
+		Uint32("product_id", req.ProductId).
+		Str("location", req.Location).
+		Str("status", req.Status).
+		Msg("gRPC: ListTransfers called")
+
+	q := query.ListTransfersQuery{
+		ProductID: uint(req.ProductId),
+		Location:  req.Location,
+		Status:    req.Status,
+		Limit:     int(req.Limit),
+		Offset:    int(req.Offset),
+	}
+
+	if q.Status != "" && !domain.IsValidTransferStatus(q.Status) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid status %q", q.Status)
+	}
+
+	page, err := s.listTransfersHandler.Handle(q)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("gRPC: Failed to list transfers")
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	pbTransfers := make([]*pb.Transfer, 0, len(page.Transfers))
+	for i := range page.Transfers {
+		pbTransfers = append(pbTransfers, transferToProto(&page.Transfers[i]))
+	}
+
+	return &pb.ListTransfersResponse{
+		Success:   true,
+		Transfers: pbTransfers,
+		Total:     int32(page.Total),
+	}, nil
+}
+
+// transferStatusError maps a transfer error to its gRPC status
+func transferStatusError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrTransferNotFound), errors.Is(err, domain.ErrInventoryNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, domain.ErrTransferClosed), errors.Is(err, domain.ErrInsufficientStock):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	case errors.Is(err, domain.ErrTransferOverReceipt), errors.Is(err, domain.ErrTransferSameLocation):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return status.Errorf(codes.Internal, "%v", err)
+}
+
 // domainToProto converts domain Inventory to proto Inventory
 func domainToProto(inv *domain.Inventory) *pb.Inventory {
 	if inv == nil {
@@ -323,3 +574,71 @@ func domainToProto(inv *domain.Inventory) *pb.Inventory {
 		UpdatedAt: timestamppb.New(inv.UpdatedAt),
 	}
 }
+
+// stockLevelsToProto converts domain stock levels to proto location stock
+func stockLevelsToProto(levels []domain.StockLevel) []*pb.LocationStock {
+	pbLevels := make([]*pb.LocationStock, 0, len(levels))
+	for _, level := range levels {
+		pbLevels = append(pbLevels, &pb.LocationStock{
+			InventoryId: uint32(level.InventoryID),
+			Location:    level.Location,
+			Region:      level.Region,
+			Priority:    int32(level.Priority),
+			Active:      level.Active,
+			Quantity:    int32(level.Quantity),
+		})
+	}
+	return pbLevels
+}
+
+// allocationsToProto converts domain allocations to proto allocations
+func allocationsToProto(allocations []domain.Allocation) []*pb.Allocation {
+	pbAllocations := make([]*pb.Allocation, 0, len(allocations))
+	for _, allocation := range allocations {
+		pbAllocations = append(pbAllocations, &pb.Allocation{
+			InventoryId: uint32(allocation.InventoryID),
+			Location:    allocation.Location,
+			Quantity:    int32(allocation.Quantity),
+		})
+	}
+	return pbAllocations
+}
+
+// holdingsToProto converts the stock held by a reservation to proto allocations
+func holdingsToProto(holdings []domain.ReservationAllocation) []*pb.Allocation {
+	pbAllocations := make([]*pb.Allocation, 0, len(holdings))
+	for _, holding := range holdings {
+		pbAllocations = append(pbAllocations, &pb.Allocation{
+			InventoryId: uint32(holding.InventoryID),
+			Location:    holding.Location,
+			Quantity:    int32(holding.Quantity),
+		})
+	}
+	return pbAllocations
+}
+
+// transferToProto converts a domain Transfer to a proto Transfer
+func transferToProto(transfer *domain.Transfer) *pb.Transfer {
+	if transfer == nil {
+		return nil
+	}
+
+	pbTransfer := &pb.Transfer{
+		Id:                uint32(transfer.ID),
+		ProductId:         uint32(transfer.ProductID),
+		FromLocation:      transfer.FromLocation,
+		ToLocation:        transfer.ToLocation,
+		Quantity:          int32(transfer.Quantity),
+		ReceivedQuantity:  int32(transfer.ReceivedQuantity),
+		ReturnedQuantity:  int32(transfer.ReturnedQuantity),
+		InTransitQuantity: int32(transfer.InTransit()),
+		Status:            transfer.Status,
+		Note:              transfer.Note,
+		CreatedBy:         uint32(transfer.CreatedBy),
+		CreatedAt:         timestamppb.New(transfer.CreatedAt),
+	}
+	if transfer.CompletedAt != nil {
+		pbTransfer.CompletedAt = timestamppb.New(*transfer.CompletedAt)
+	}
+	return pbTransfer
+}
@@ -3,7 +3,7 @@ package http
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -18,28 +18,57 @@ import (
 // InventoryHandler handles HTTP requests for inventory using CQRS pattern
 type InventoryHandler struct {
 	// Command handlers
-	createHandler         *command.CreateInventoryHandler
-	updateQuantityHandler *command.UpdateQuantityHandler
-	deleteHandler         *command.DeleteInventoryHandler
+	createHandler          *command.CreateInventoryHandler
+	updateQuantityHandler  *command.UpdateQuantityHandler
+	deleteHandler          *command.DeleteInventoryHandler
+	rebuildQuantityHandler *command.RebuildQuantityHandler
+	saveLocationHandler    *command.SaveLocationHandler
+	transferHandler        *command.TransferStockHandler
+	receiveTransferHandler *command.ReceiveTransferHandler
+	cancelTransferHandler  *command.CancelTransferHandler
 
 	// Query handlers
-	getHandler  *query.GetInventoryHandler
-	listHandler *query.ListInventoryHandler
+	getHandler           *query.GetInventoryHandler
+	listHandler          *query.ListInventoryHandler
+	listMovementsHandler *query.ListMovementsHandler
+	stockHandler         *query.GetStockHandler
+	availabilityHandler  *query.CheckAvailabilityHandler
+	listLocationsHandler *query.ListLocationsHandler
+	getTransferHandler   *query.GetTransferHandler
+	listTransfersHandler *query.ListTransfersHandler
 
 	repo       domain.InventoryRepository
 	userClient *client.UserServiceClient
 }
 
 // NewInventoryHandler creates a new inventory handler (manual DI)
-func NewInventoryHandler(repo domain.InventoryRepository, userClient *client.UserServiceClient) *InventoryHandler {
+func NewInventoryHandler(
+	repo domain.InventoryRepository,
+	movements domain.MovementRepository,
+	locations domain.LocationRepository,
+	transfers domain.TransferRepository,
+	strategy domain.AllocationStrategy,
+	userClient *client.UserServiceClient,
+) *InventoryHandler {
 	return &InventoryHandler{
-		createHandler:         command.NewCreateInventoryHandler(repo),
-		updateQuantityHandler: command.NewUpdateQuantityHandler(repo),
-		deleteHandler:         command.NewDeleteInventoryHandler(repo),
-		getHandler:            query.NewGetInventoryHandler(repo),
-		listHandler:           query.NewListInventoryHandler(repo),
-		repo:                  repo,
-		userClient:            userClient,
+		createHandler:          command.NewCreateInventoryHandler(repo),
+		updateQuantityHandler:  command.NewUpdateQuantityHandler(repo),
+		deleteHandler:          command.NewDeleteInventoryHandler(repo),
+		rebuildQuantityHandler: command.NewRebuildQuantityHandler(movements),
+		saveLocationHandler:    command.NewSaveLocationHandler(locations),
+		transferHandler:        command.NewTransferStockHandler(transfers),
+		receiveTransferHandler: command.NewReceiveTransferHandler(transfers),
+		cancelTransferHandler:  command.NewCancelTransferHandler(transfers),
+		getHandler:             query.NewGetInventoryHandler(repo),
+		listHandler:            query.NewListInventoryHandler(repo),
+		listMovementsHandler:   query.NewListMovementsHandler(movements),
+		stockHandler:           query.NewGetStockHandler(repo),
+		availabilityHandler:    query.NewCheckAvailabilityHandler(repo, strategy),
+		listLocationsHandler:   query.NewListLocationsHandler(locations),
+		getTransferHandler:     query.NewGetTransferHandler(transfers),
+		listTransfersHandler:   query.NewListTransfersHandler(transfers),
+		repo:                   repo,
+		userClient:             userClient,
 	}
 }
 
@@ -48,19 +77,41 @@ func NewInventoryHandlerWithDI(
 	createHandler *command.CreateInventoryHandler,
 	updateQuantityHandler *command.UpdateQuantityHandler,
 	deleteHandler *command.DeleteInventoryHandler,
+	rebuildQuantityHandler *command.RebuildQuantityHandler,
+	saveLocationHandler *command.SaveLocationHandler,
+	transferHandler *command.TransferStockHandler,
+	receiveTransferHandler *command.ReceiveTransferHandler,
+	cancelTransferHandler *command.CancelTransferHandler,
 	getHandler *query.GetInventoryHandler,
 	listHandler *query.ListInventoryHandler,
+	listMovementsHandler *query.ListMovementsHandler,
+	stockHandler *query.GetStockHandler,
+	availabilityHandler *query.CheckAvailabilityHandler,
+	listLocationsHandler *query.ListLocationsHandler,
+	getTransferHandler *query.GetTransferHandler,
+	listTransfersHandler *query.ListTransfersHandler,
 	repo domain.InventoryRepository,
 	userClient *client.UserServiceClient,
 ) *InventoryHandler {
 	return &InventoryHandler{
-		createHandler:         createHandler,
-		updateQuantityHandler: updateQuantityHandler,
-		deleteHandler:         deleteHandler,
-		getHandler:            getHandler,
-		listHandler:           listHandler,
-		repo:                  repo,
-		userClient:            userClient,
+		createHandler:          createHandler,
+		updateQuantityHandler:  updateQuantityHandler,
+		deleteHandler:          deleteHandler,
+		rebuildQuantityHandler: rebuildQuantityHandler,
+		saveLocationHandler:    saveLocationHandler,
+		transferHandler:        transferHandler,
+		receiveTransferHandler: receiveTransferHandler,
+		cancelTransferHandler:  cancelTransferHandler,
+		getHandler:             getHandler,
+		listHandler:            listHandler,
+		listMovementsHandler:   listMovementsHandler,
+		stockHandler:           stockHandler,
+		availabilityHandler:    availabilityHandler,
+		listLocationsHandler:   listLocationsHandler,
+		getTransferHandler:     getTransferHandler,
+		listTransfersHandler:   listTransfersHandler,
+		repo:                   repo,
+		userClient:             userClient,
 	}
 }
 
@@ -87,13 +138,22 @@ func (h *InventoryHandler) CreateInventory(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	actorUserID, _ := r.Context().Value(UserIDKey).(uint)
 	cmd := command.CreateInventoryCommand{
-		ProductID: req.ProductID,
-		Quantity:  req.Quantity,
-		Location:  req.Location,
+		ProductID:   req.ProductID,
+		Quantity:    req.Quantity,
+		Location:    req.Location,
+		ActorUserID: actorUserID,
 	}
 
-	inventory, err := h.createHandler.Handle(cmd)
+	inventory, err := h.createHandler.Handle(r.Context(), cmd)
+	if errors.Is(err, domain.ErrInventoryExists) {
+		respondJSON(w, http.StatusConflict, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		logger.Logger.Error().Err(err).Msg("Failed to create inventory")
 		respondJSON(w, http.StatusBadRequest, Response{
@@ -177,7 +237,8 @@ func (h *InventoryHandler) UpdateQuantity(w http.ResponseWriter, r *http.Request
 	}
 
 	var req struct {
-		Quantity int `json:"quantity"`
+		Quantity int    `json:"quantity"`
+		Location string `json:"location"` // optional when the product is stocked at a single location
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -188,12 +249,15 @@ func (h *InventoryHandler) UpdateQuantity(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	actorUserID, _ := r.Context().Value(UserIDKey).(uint)
 	cmd := command.UpdateQuantityCommand{
-		ProductID: uint(productID),
-		Quantity:  req.Quantity,
+		ProductID:   uint(productID),
+		Location:    req.Location,
+		Quantity:    req.Quantity,
+		ActorUserID: actorUserID,
 	}
 
-	if err := h.updateQuantityHandler.Handle(cmd); err != nil {
+	if err := h.updateQuantityHandler.Handle(r.Context(), cmd); err != nil {
 		logger.Logger.Error().Err(err).Msg("Failed to update quantity")
 		respondJSON(w, http.StatusBadRequest, Response{
 			Success: false,
@@ -208,7 +272,8 @@ func (h *InventoryHandler) UpdateQuantity(w http.ResponseWriter, r *http.Request
 	})
 }
 
-// GetByProductID handles GET /api/inventory/product/{product_id} (authenticated user)
+// GetByProductID handles GET /api/inventory/product/{product_id} (authenticated user).
+// It returns the product's total stock and the stock at each location.
 func (h *InventoryHandler) GetByProductID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	productID, err := strconv.ParseUint(vars["product_id"], 10, 32)
@@ -220,7 +285,7 @@ func (h *InventoryHandler) GetByProductID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	inventory, err := h.repo.FindByProductID(uint(productID))
+	stock, err := h.stockHandler.Handle(query.GetStockQuery{ProductID: uint(productID)})
 	if err != nil {
 		respondJSON(w, http.StatusNotFound, Response{
 			Success: false,
@@ -231,11 +296,12 @@ func (h *InventoryHandler) GetByProductID(w http.ResponseWriter, r *http.Request
 
 	respondJSON(w, http.StatusOK, Response{
 		Success: true,
-		Data:    inventory,
+		Data:    stock,
 	})
 }
 
-// CheckAvailability handles GET /api/inventory/check/{product_id} (authenticated user)
+// CheckAvailability handles GET /api/inventory/check/{product_id} (authenticated user).
+// The optional region query parameter is the customer's region.
 func (h *InventoryHandler) CheckAvailability(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	productID, err := strconv.ParseUint(vars["product_id"], 10, 32)
@@ -249,11 +315,12 @@ func (h *InventoryHandler) CheckAvailability(w http.ResponseWriter, r *http.Requ
 
 	// Get requested quantity from query param (default: 1)
 	requestedQty, _ := strconv.Atoi(r.URL.Query().Get("quantity"))
-	if requestedQty <= 0 {
-		requestedQty = 1
-	}
 
-	inventory, err := h.repo.FindByProductID(uint(productID))
+	availability, err := h.availabilityHandler.Handle(query.CheckAvailabilityQuery{
+		ProductID: uint(productID),
+		Quantity:  requestedQty,
+		Region:    r.URL.Query().Get("region"),
+	})
 	if err != nil {
 		respondJSON(w, http.StatusNotFound, Response{
 			Success: false,
@@ -262,27 +329,368 @@ func (h *InventoryHandler) CheckAvailability(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	available := inventory.Quantity >= requestedQty
-	message := "Product is available"
-	if !available {
-		message = fmt.Sprintf("Insufficient quantity. Available: %d, Requested: %d", inventory.Quantity, requestedQty)
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    availability,
+	})
+}
+
+// ListLocations handles GET /api/inventory/locations (authenticated user)
+func (h *InventoryHandler) ListLocations(w http.ResponseWriter, r *http.Request) {
+	locations, err := h.listLocationsHandler.Handle()
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("Failed to list locations")
+		respondJSON(w, http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   "Failed to list locations",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    locations,
+	})
+}
+
+// SaveLocation handles PUT /api/inventory/locations/{code} (admin)
+func (h *InventoryHandler) SaveLocation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name     string `json:"name"`
+		Region   string `json:"region"`
+		Priority *int   `json:"priority"` // default: domain.DefaultLocationPriority
+		Active   *bool  `json:"active"`   // default: true
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+		return
+	}
+
+	cmd := command.SaveLocationCommand{
+		Code:     mux.Vars(r)["code"],
+		Name:     req.Name,
+		Region:   req.Region,
+		Priority: domain.DefaultLocationPriority,
+		Active:   true,
+	}
+	if req.Priority != nil {
+		cmd.Priority = *req.Priority
+	}
+	if req.Active != nil {
+		cmd.Active = *req.Active
+	}
+
+	location, err := h.saveLocationHandler.Handle(cmd)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("Failed to save location")
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
 	}
 
 	respondJSON(w, http.StatusOK, Response{
 		Success: true,
-		Data: map[string]interface{}{
-			"product_id": productID,
-			"available":  available,
-			"quantity":   inventory.Quantity,
-			"requested":  requestedQty,
-			"location":   inventory.Location,
-			"message":    message,
-		},
+		Message: "Location saved successfully",
+		Data:    location,
+	})
+}
+
+// ListMovements handles GET /api/inventory/product/{product_id}/movements (admin)
+func (h *InventoryHandler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.ParseUint(vars["product_id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid product ID",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	page, err := h.listMovementsHandler.Handle(query.ListMovementsQuery{
+		ProductID: uint(productID),
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		logger.Logger.Error().Err(err).Uint64("product_id", productID).Msg("Failed to list movements")
+		respondJSON(w, http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   "Failed to list movements",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    page,
+	})
+}
+
+// RebuildQuantity handles POST /api/inventory/product/{product_id}/movements/rebuild (admin).
+// It reports the drift between the stored quantity and the ledger, and with
+// ?apply=true sets the quantity to the ledger sum.
+func (h *InventoryHandler) RebuildQuantity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.ParseUint(vars["product_id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid product ID",
+		})
+		return
+	}
+
+	apply, _ := strconv.ParseBool(r.URL.Query().Get("apply"))
+
+	balances, err := h.rebuildQuantityHandler.Handle(command.RebuildQuantityCommand{
+		ProductID: uint(productID),
+		Apply:     apply,
+	})
+	if errors.Is(err, domain.ErrInventoryNotFound) {
+		respondJSON(w, http.StatusNotFound, Response{
+			Success: false,
+			Error:   "Inventory not found for this product",
+		})
+		return
+	}
+	if err != nil {
+		logger.Logger.Error().Err(err).Uint64("product_id", productID).Msg("Failed to rebuild quantity")
+		respondJSON(w, http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   "Failed to rebuild quantity",
+		})
+		return
+	}
+
+	message := "Quantity matches the ledger"
+	for _, balance := range balances {
+		if balance.Drift == 0 {
+			continue
+		}
+		message = "Quantity drifted from the ledger"
+		if balance.Applied {
+			message = "Quantity rebuilt from the ledger"
+		}
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: message,
+		Data:    balances,
+	})
+}
+
+// CreateTransfer handles POST /api/inventory/transfers (admin).
+// The quantity leaves the source location at once and stays in transit until
+// it is received.
+func (h *InventoryHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ProductID    uint   `json:"product_id"`
+		FromLocation string `json:"from_location"`
+		ToLocation   string `json:"to_location"`
+		Quantity     int    `json:"quantity"`
+		Note         string `json:"note"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+		return
+	}
+
+	actorUserID, _ := r.Context().Value(UserIDKey).(uint)
+	cmd := command.TransferStockCommand{
+		ProductID:    req.ProductID,
+		FromLocation: req.FromLocation,
+		ToLocation:   req.ToLocation,
+		Quantity:     req.Quantity,
+		Note:         req.Note,
+		ActorUserID:  actorUserID,
+	}
+
+	transfer, err := h.transferHandler.Handle(r.Context(), cmd)
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("Failed to create transfer")
+		respondTransferError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Message: "Transfer shipped successfully",
+		Data:    transfer,
+	})
+}
+
+// ListTransfers handles GET /api/inventory/transfers (admin).
+// It can be filtered by product_id, location (source or destination) and status.
+func (h *InventoryHandler) ListTransfers(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	productID, _ := strconv.ParseUint(params.Get("product_id"), 10, 32)
+	limit, _ := strconv.Atoi(params.Get("limit"))
+	offset, _ := strconv.Atoi(params.Get("offset"))
+
+	status := params.Get("status")
+	if status != "" && !domain.IsValidTransferStatus(status) {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid transfer status",
+		})
+		return
+	}
+
+	page, err := h.listTransfersHandler.Handle(query.ListTransfersQuery{
+		ProductID: uint(productID),
+		Location:  params.Get("location"),
+		Status:    status,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("Failed to list transfers")
+		respondJSON(w, http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   "Failed to list transfers",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    page,
+	})
+}
+
+// GetTransfer handles GET /api/inventory/transfers/{id} (admin)
+func (h *InventoryHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid transfer ID",
+		})
+		return
+	}
+
+	transfer, err := h.getTransferHandler.Handle(query.GetTransferQuery{ID: uint(id)})
+	if err != nil {
+		respondTransferError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    transfer,
+	})
+}
+
+// ReceiveTransfer handles POST /api/inventory/transfers/{id}/receive (admin).
+// Without a quantity, everything still in transit is received.
+func (h *InventoryHandler) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid transfer ID",
+		})
+		return
+	}
+
+	var req struct {
+		Quantity int `json:"quantity"`
+	}
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondJSON(w, http.StatusBadRequest, Response{
+				Success: false,
+				Error:   "Invalid request body",
+			})
+			return
+		}
+	}
+
+	transfer, err := h.receiveTransferHandler.Handle(r.Context(), command.ReceiveTransferCommand{
+		TransferID: uint(id),
+		Quantity:   req.Quantity,
+	})
+	if err != nil {
+		logger.Logger.Error().Err(err).Uint64("transfer_id", id).Msg("Failed to receive transfer")
+		respondTransferError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Transfer received successfully",
+		Data:    transfer,
+	})
+}
+
+// CancelTransfer handles POST /api/inventory/transfers/{id}/cancel (admin).
+// The quantity still in transit is returned to the source location.
+func (h *InventoryHandler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid transfer ID",
+		})
+		return
+	}
+
+	transfer, err := h.cancelTransferHandler.Handle(r.Context(), command.CancelTransferCommand{TransferID: uint(id)})
+	if err != nil {
+		logger.Logger.Error().Err(err).Uint64("transfer_id", id).Msg("Failed to cancel transfer")
+		respondTransferError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Transfer cancelled successfully",
+		Data:    transfer,
+	})
+}
+
+// respondTransferError maps a transfer error to its HTTP status
+func respondTransferError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, domain.ErrTransferNotFound), errors.Is(err, domain.ErrInventoryNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, domain.ErrTransferClosed), errors.Is(err, domain.ErrInsufficientStock):
+		status = http.StatusConflict
+	}
+	respondJSON(w, status, Response{
+		Success: false,
+		Error:   err.Error(),
 	})
 }
 
 // RegisterRoutes registers all inventory routes
 func (h *InventoryHandler) RegisterRoutes(router *mux.Router) {
+	// Registered before /api/inventory/{id}, which would otherwise match them
+	router.HandleFunc("/api/inventory/locations", AuthMiddleware(h.userClient)(h.ListLocations)).Methods("GET")
+	router.HandleFunc("/api/inventory/locations/{code}", AdminMiddleware(h.userClient)(h.SaveLocation)).Methods("PUT")
+	router.HandleFunc("/api/inventory/transfers", AdminMiddleware(h.userClient)(h.CreateTransfer)).Methods("POST")
+	router.HandleFunc("/api/inventory/transfers", AdminMiddleware(h.userClient)(h.ListTransfers)).Methods("GET")
+	router.HandleFunc("/api/inventory/transfers/{id}", AdminMiddleware(h.userClient)(h.GetTransfer)).Methods("GET")
+	router.HandleFunc("/api/inventory/transfers/{id}/receive", AdminMiddleware(h.userClient)(h.ReceiveTransfer)).Methods("POST")
+	router.HandleFunc("/api/inventory/transfers/{id}/cancel", AdminMiddleware(h.userClient)(h.CancelTransfer)).Methods("POST")
+
 	// Public routes (no auth)
 	router.HandleFunc("/api/inventory", h.ListInventory).Methods("GET")
 	router.HandleFunc("/api/inventory/{id}", h.GetInventory).Methods("GET")
@@ -294,6 +702,8 @@ func (h *InventoryHandler) RegisterRoutes(router *mux.Router) {
 	// Admin routes (require admin role)
 	router.HandleFunc("/api/inventory", AdminMiddleware(h.userClient)(h.CreateInventory)).Methods("POST")
 	router.HandleFunc("/api/inventory/{product_id}/quantity", AdminMiddleware(h.userClient)(h.UpdateQuantity)).Methods("PATCH")
+	router.HandleFunc("/api/inventory/product/{product_id}/movements", AdminMiddleware(h.userClient)(h.ListMovements)).Methods("GET")
+	router.HandleFunc("/api/inventory/product/{product_id}/movements/rebuild", AdminMiddleware(h.userClient)(h.RebuildQuantity)).Methods("POST")
 }
 
 // GetUserClient returns the user service client
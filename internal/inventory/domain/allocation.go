@@ -0,0 +1,53 @@
+package domain
+
+// StockLevel is the stock of a product at one location, with the location
+// settings allocation strategies decide on
+type StockLevel struct {
+	InventoryID uint   `json:"inventory_id"`
+	Location    string `json:"location"`
+	Region      string `json:"region,omitempty"`
+	Priority    int    `json:"priority"`
+	Active      bool   `json:"active"`
+	Quantity    int    `json:"quantity"`
+}
+
+// ProductStock is the stock of a product across its locations, ordered by
+// priority
+type ProductStock struct {
+	ProductID uint         `json:"product_id"`
+	Quantity  int          `json:"quantity"` // sum over the active locations
+	Locations []StockLevel `json:"locations"`
+}
+
+// NewProductStock sums the stock levels of a product
+func NewProductStock(productID uint, levels []StockLevel) *ProductStock {
+	stock := &ProductStock{ProductID: productID, Locations: levels}
+	for _, level := range levels {
+		if level.Active {
+			stock.Quantity += level.Quantity
+		}
+	}
+	return stock
+}
+
+// AllocationRequest asks for a quantity of a product, shipped to Region when
+// the customer's region is known
+type AllocationRequest struct {
+	ProductID uint
+	Quantity  int
+	Region    string
+}
+
+// Allocation is the part of a request taken from one stock row
+type Allocation struct {
+	InventoryID uint   `json:"inventory_id"`
+	Location    string `json:"location"`
+	Quantity    int    `json:"quantity"`
+}
+
+// AllocationStrategy decides which locations fill a request. Allocate fails
+// with ErrInsufficientStock when the stock levels cannot fill it.
+type AllocationStrategy interface {
+	Name() string
+	Allocate(request AllocationRequest, stock []StockLevel) ([]Allocation, error)
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,9 +10,9 @@ import (
 // Inventory represents the inventory entity
 type Inventory struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	ProductID uint           `json:"product_id" gorm:"not null;index"`
+	ProductID uint           `json:"product_id" gorm:"not null;index;uniqueIndex:idx_inventory_product_location,priority:1,where:deleted_at IS NULL"`
 	Quantity  int            `json:"quantity" gorm:"not null;default:0"`
-	Location  string         `json:"location" gorm:"default:'warehouse'"`
+	Location  string         `json:"location" gorm:"default:'warehouse';uniqueIndex:idx_inventory_product_location,priority:2"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -22,13 +23,41 @@ func (Inventory) TableName() string {
 	return "inventories"
 }
 
-// InventoryRepository defines the contract for inventory data access
+// ErrInsufficientStock is returned when a conditional decrement would make the quantity negative
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrInventoryNotFound is returned when a product has no stock rows
+var ErrInventoryNotFound = errors.New("inventory not found")
+
+// InventoryRepository defines the contract for inventory data access. A
+// product has one stock row per location. Every quantity change is recorded
+// in the movement ledger with the StockChange that explains it.
 type InventoryRepository interface {
-	Create(inventory *Inventory) error
+	// Create fails with ErrInventoryExists when the product already has a
+	// stock row at the location
+	Create(inventory *Inventory, change StockChange) error
 	FindByID(id uint) (*Inventory, error)
-	FindByProductID(productID uint) (*Inventory, error)
+	FindByProductAndLocation(productID uint, location string) (*Inventory, error)
+	// FindStock returns the stock of the product at every location
+	FindStock(productID uint) (*ProductStock, error)
 	FindAll(limit, offset int) ([]Inventory, error)
+	// Update saves everything but the quantity, which only changes through
+	// the ledgered methods
 	Update(inventory *Inventory) error
 	Delete(id uint) error
-	UpdateQuantity(productID uint, quantity int) error
+	// UpdateQuantity sets the quantity of a stock row
+	UpdateQuantity(inventoryID uint, quantity int, change StockChange) (*Inventory, error)
+
+	// Atomic stock mutations. DecrementQuantity takes the amount from the
+	// locations chosen by the strategy and fails with ErrInsufficientStock
+	// instead of letting a quantity go below zero. IncrementQuantity returns
+	// stock to the product's highest-priority location.
+	DecrementQuantity(productID uint, amount int, strategy AllocationStrategy, change StockChange) ([]Allocation, error)
+	IncrementQuantity(productID uint, amount int, change StockChange) (*Inventory, error)
+	// ReturnTaken gives refunded stock back to the rows the reservation or
+	// purchase took it from, in the order they were taken, and returns the
+	// rows and quantities given back. A row never gets back more than it
+	// gave, and repeating a refund is a no-op. Purchases that predate the
+	// ledger return their stock to the highest-priority location.
+	ReturnTaken(request StockReturnRequest, change StockChange) ([]Allocation, error)
 }
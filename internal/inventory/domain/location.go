@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Location is a warehouse or store holding stock. Inventory rows refer to it
+// by Code; rows at a code without a Location are treated as an active
+// location of DefaultLocationPriority outside any region.
+type Location struct {
+	Code      string    `json:"code" gorm:"primaryKey;size:64"`
+	Name      string    `json:"name"`
+	Region    string    `json:"region,omitempty" gorm:"size:32;index"` // matched against the customer's region
+	Priority  int       `json:"priority" gorm:"not null"`              // lower ships first
+	Active    bool      `json:"active" gorm:"not null"`                // stock at inactive locations is not allocated
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Location) TableName() string {
+	return "locations"
+}
+
+// DefaultLocation is the location of stock created without one
+const DefaultLocation = "warehouse"
+
+// DefaultLocationPriority is the priority of locations that were not configured
+const DefaultLocationPriority = 100
+
+// Location errors
+var (
+	ErrLocationRequired = errors.New("location is required when the product is stocked at several locations")
+	ErrInventoryExists  = errors.New("inventory already exists at this location")
+)
+
+// LocationRepository defines the contract for location data access
+type LocationRepository interface {
+	// Save creates the location or updates the one with the same code
+	Save(location *Location) error
+	FindAll() ([]Location, error)
+}
@@ -0,0 +1,112 @@
+package domain
+
+import "time"
+
+// InventoryMovement is an append-only ledger entry recording one change of a
+// stock row's quantity. Summing the deltas of a row gives its quantity.
+type InventoryMovement struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	InventoryID    uint      `json:"inventory_id" gorm:"not null;index"`
+	ProductID      uint      `json:"product_id" gorm:"not null;index:idx_inventory_movements_product_created,priority:1"`
+	Delta          int       `json:"delta" gorm:"not null"`
+	QuantityBefore int       `json:"quantity_before" gorm:"not null"`
+	QuantityAfter  int       `json:"quantity_after" gorm:"not null"`
+	Reason         string    `json:"reason" gorm:"size:32;not null;index"`
+	ReferenceType  string    `json:"reference_type,omitempty" gorm:"size:32"` // payment, reservation, refund, user, transfer or inventory
+	ReferenceID    string    `json:"reference_id,omitempty" gorm:"size:64;index"`
+	TraceID        string    `json:"trace_id,omitempty" gorm:"size:32"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index:idx_inventory_movements_product_created,priority:2"`
+}
+
+// TableName specifies the table name
+func (InventoryMovement) TableName() string {
+	return "inventory_movements"
+}
+
+// Movement reasons
+const (
+	MovementInitial     = "initial"     // stock row created, or its quantity when the ledger was introduced
+	MovementPurchase    = "purchase"    // bought without a reservation
+	MovementReservation = "reservation" // held for a checkout
+	MovementRelease     = "release"     // hold released before it was committed
+	MovementExpiry      = "expiry"      // hold expired before it was committed
+	MovementAdjust      = "adjust"      // quantity set by an admin
+	MovementRestock     = "restock"     // returned after the payment failed
+	MovementReturn      = "return"      // returned by a refund
+	MovementMerge       = "merge"       // moved between duplicate rows of a product and location when they were merged
+	MovementRecompute   = "recompute"   // quantity reset to the ledger by a rebuild; no delta, the ledger already sums to it
+
+	MovementTransferOut    = "transfer_out"    // shipped to another location
+	MovementTransferIn     = "transfer_in"     // received from another location
+	MovementTransferReturn = "transfer_return" // returned to the source by a cancelled transfer
+)
+
+// Movement reference types
+const (
+	ReferencePayment     = "payment"
+	ReferenceReservation = "reservation"
+	ReferenceRefund      = "refund"
+	ReferenceUser        = "user"
+	ReferenceTransfer    = "transfer"
+	ReferenceInventory   = "inventory"
+)
+
+// StockChange explains a quantity change: why it happened, what caused it and
+// the trace of the request that made it. Repositories record it as a
+// movement in the same transaction as the change.
+type StockChange struct {
+	Reason        string
+	ReferenceType string
+	ReferenceID   string
+	TraceID       string
+}
+
+// StockReturn records the stock a refund gave back to a row that a
+// reservation or a purchase took it from, so that a row never gets back more
+// than it gave and a refund is returned once
+type StockReturn struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RefundID    uint      `json:"refund_id" gorm:"not null;uniqueIndex:idx_stock_returns_refund_source,priority:1"`
+	SourceType  string    `json:"source_type" gorm:"size:32;not null;uniqueIndex:idx_stock_returns_refund_source,priority:2;index:idx_stock_returns_source,priority:1"` // reservation or payment
+	SourceID    string    `json:"source_id" gorm:"size:64;not null;uniqueIndex:idx_stock_returns_refund_source,priority:3;index:idx_stock_returns_source,priority:2"`
+	InventoryID uint      `json:"inventory_id" gorm:"not null;uniqueIndex:idx_stock_returns_refund_source,priority:4"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (StockReturn) TableName() string {
+	return "stock_returns"
+}
+
+// StockReturnRequest asks to give back part of the stock taken by a
+// reservation or, for purchases made without one, by a payment
+type StockReturnRequest struct {
+	ProductID     uint
+	Quantity      int
+	ReservationID string
+	PaymentID     uint
+	RefundID      uint
+}
+
+// LedgerBalance compares the stored quantity of a stock row with the sum of
+// its movements
+type LedgerBalance struct {
+	InventoryID    uint `json:"inventory_id"`
+	ProductID      uint `json:"product_id"`
+	Quantity       int  `json:"quantity"` // stored quantity before the rebuild
+	LedgerQuantity int  `json:"ledger_quantity"`
+	Drift          int  `json:"drift"` // Quantity - LedgerQuantity
+	Applied        bool `json:"applied"`
+}
+
+// MovementRepository defines the contract for inventory ledger access
+type MovementRepository interface {
+	// FindByProductID returns a page of the movements of a product, newest
+	// first, and the total number of its movements
+	FindByProductID(productID uint, limit, offset int) ([]InventoryMovement, int64, error)
+	// Rebuild sums the ledger of every stock row of the product. With apply,
+	// rows whose quantity drifted from the ledger are set to the ledger sum and
+	// the reset is recorded as a recompute movement.
+	Rebuild(productID uint, apply bool) ([]LedgerBalance, error)
+}
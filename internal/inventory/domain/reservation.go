@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Reservation represents a stock hold keyed by the caller's reservation ID.
+// The held quantity may be split across locations, see Allocations.
+type Reservation struct {
+	ID          string                  `json:"id" gorm:"primaryKey;size:64"`
+	ProductID   uint                    `json:"product_id" gorm:"not null;index"`
+	InventoryID uint                    `json:"inventory_id" gorm:"not null;index"` // stock row of the first allocation
+	Quantity    int                     `json:"quantity" gorm:"not null"`
+	Region      string                  `json:"region,omitempty" gorm:"size:32"` // customer's region the stock was allocated for
+	State       string                  `json:"state" gorm:"not null;index"`
+	ExpiresAt   time.Time               `json:"expires_at" gorm:"not null;index"`
+	Allocations []ReservationAllocation `json:"allocations,omitempty" gorm:"foreignKey:ReservationID"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Reservation) TableName() string {
+	return "reservations"
+}
+
+// ReservationAllocation is the stock a reservation holds at one location
+type ReservationAllocation struct {
+	ID            uint   `json:"-" gorm:"primaryKey"`
+	ReservationID string `json:"-" gorm:"size:64;not null;index"`
+	InventoryID   uint   `json:"inventory_id" gorm:"not null"`
+	Location      string `json:"location"`
+	Quantity      int    `json:"quantity" gorm:"not null"`
+}
+
+// TableName specifies the table name
+func (ReservationAllocation) TableName() string {
+	return "reservation_allocations"
+}
+
+// Holdings returns the stock rows the reservation holds. Reservations made
+// before allocations were recorded hold their whole quantity at InventoryID.
+func (r *Reservation) Holdings() []ReservationAllocation {
+	if len(r.Allocations) > 0 {
+		return r.Allocations
+	}
+	return []ReservationAllocation{{ReservationID: r.ID, InventoryID: r.InventoryID, Quantity: r.Quantity}}
+}
+
+// Reservation states
+const (
+	ReservationHeld      = "held"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+	ReservationRestocked = "restocked" // committed, then returned after the payment failed or was refunded
+)
+
+// DefaultReservationTTL is used when the caller does not request a TTL
+const DefaultReservationTTL = 15 * time.Minute
+
+// Reservation errors
+var (
+	ErrReservationNotFound     = errors.New("reservation not found")
+	ErrReservationNotHeld      = errors.New("reservation is no longer held")
+	ErrReservationCommitted    = errors.New("reservation is already committed")
+	ErrReservationNotCommitted = errors.New("reservation is not committed yet")
+	ErrReservationConflict     = errors.New("reservation id reused with different product or quantity")
+)
+
+// ReservationRepository defines the contract for reservation data access.
+// Every mutating method is idempotent on the reservation ID. Stock taken or
+// returned is recorded in the movement ledger, referencing the reservation
+// and the trace that made the change.
+type ReservationRepository interface {
+	// Reserve holds stock for a new reservation at the locations chosen by
+	// the strategy, or returns the existing one while it is held or committed
+	Reserve(reservation *Reservation, strategy AllocationStrategy, traceID string) (*Reservation, error)
+	// Release returns held stock; released/expired reservations are returned unchanged
+	Release(id, traceID string) (*Reservation, error)
+	// Commit turns a held reservation into a permanent deduction
+	Commit(id string) (*Reservation, error)
+	// Restock returns the stock of a reservation whose payment failed or was
+	// refunded: held reservations are released, committed ones restocked, and
+	// finished ones returned unchanged
+	Restock(id, traceID string) (*Reservation, error)
+	// ExpireStale expires held reservations past their TTL and returns their stock
+	ExpireStale(now time.Time, limit int) (int, error)
+	FindByID(id string) (*Reservation, error)
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Transfer moves stock of a product from one location to another. The
+// quantity leaves the source when the transfer is shipped and arrives at the
+// destination as it is received, possibly over several receipts; cancelling
+// returns whatever is still in transit to the source.
+type Transfer struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	ProductID        uint       `json:"product_id" gorm:"not null;index"`
+	FromLocation     string     `json:"from_location" gorm:"size:64;not null"`
+	ToLocation       string     `json:"to_location" gorm:"size:64;not null"`
+	FromInventoryID  uint       `json:"from_inventory_id" gorm:"not null"`
+	ToInventoryID    uint       `json:"to_inventory_id" gorm:"not null"`
+	Quantity         int        `json:"quantity" gorm:"not null"`
+	ReceivedQuantity int        `json:"received_quantity" gorm:"not null;default:0"`
+	ReturnedQuantity int        `json:"returned_quantity" gorm:"not null;default:0"` // returned to the source by a cancellation
+	Status           string     `json:"status" gorm:"size:32;not null;index"`
+	Note             string     `json:"note,omitempty"`
+	CreatedBy        uint       `json:"created_by,omitempty"` // admin who shipped the transfer
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Transfer) TableName() string {
+	return "inventory_transfers"
+}
+
+// Transfer statuses
+const (
+	TransferInTransit         = "in_transit"
+	TransferPartiallyReceived = "partially_received"
+	TransferReceived          = "received"
+	TransferCancelled         = "cancelled"
+)
+
+// Transfer errors
+var (
+	ErrTransferNotFound     = errors.New("transfer not found")
+	ErrTransferClosed       = errors.New("transfer is already received or cancelled")
+	ErrTransferOverReceipt  = errors.New("received quantity exceeds the quantity in transit")
+	ErrTransferSameLocation = errors.New("transfer source and destination must differ")
+)
+
+// InTransit returns the quantity shipped but neither received nor returned
+func (t *Transfer) InTransit() int {
+	return t.Quantity - t.ReceivedQuantity - t.ReturnedQuantity
+}
+
+// IsOpen reports whether stock of the transfer is still in transit
+func (t *Transfer) IsOpen() bool {
+	return t.Status == TransferInTransit || t.Status == TransferPartiallyReceived
+}
+
+// IsValidTransferStatus reports whether status is a known transfer status
+func IsValidTransferStatus(status string) bool {
+	switch status {
+	case TransferInTransit, TransferPartiallyReceived, TransferReceived, TransferCancelled:
+		return true
+	}
+	return false
+}
+
+// TransferFilter narrows a transfer listing; zero fields do not filter
+type TransferFilter struct {
+	ProductID uint
+	Location  string // source or destination
+	Status    string
+	Limit     int
+	Offset    int
+}
+
+// TransferRepository defines the contract for transfer data access. Stock
+// leaving and arriving is recorded in the movement ledger of both locations,
+// referencing the transfer and the trace that made the change.
+type TransferRepository interface {
+	// Ship takes the quantity from the source location and creates the
+	// transfer in transit. The destination stock row is created when the
+	// product has none at that location.
+	Ship(transfer *Transfer, traceID string) error
+	// Receive adds quantity to the destination; the transfer is received once
+	// nothing is left in transit
+	Receive(id uint, quantity int, traceID string) (*Transfer, error)
+	// Cancel returns the quantity still in transit to the source
+	Cancel(id uint, traceID string) (*Transfer, error)
+	FindByID(id uint) (*Transfer, error)
+	FindAll(filter TransferFilter) ([]Transfer, int64, error)
+}
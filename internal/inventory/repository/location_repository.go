@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"github.com/tair/full-observability/internal/inventory/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormLocationRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLocationRepository(db *gorm.DB) *GormLocationRepository {
+	return &GormLocationRepository{db: db}
+}
+
+func (r *GormLocationRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.Location{})
+}
+
+func (r *GormLocationRepository) Save(location *domain.Location) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "region", "priority", "active", "updated_at"}),
+	}, clause.Returning{}).Create(location).Error
+}
+
+func (r *GormLocationRepository) FindAll() ([]domain.Location, error) {
+	var locations []domain.Location
+	err := r.db.Order("priority, code").Find(&locations).Error
+	return locations, err
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"github.com/tair/full-observability/internal/inventory/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormMovementRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMovementRepository(db *gorm.DB) *GormMovementRepository {
+	return &GormMovementRepository{db: db}
+}
+
+func (r *GormMovementRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.InventoryMovement{})
+}
+
+// BackfillOpeningBalances records the quantity of every stock row without
+// movements as an initial movement, so that stock created before the ledger
+// existed rebuilds to its current quantity. It is safe to run on every start.
+func (r *GormMovementRepository) BackfillOpeningBalances() (int64, error) {
+	result := r.db.Exec(`
+		INSERT INTO inventory_movements
+			(inventory_id, product_id, delta, quantity_before, quantity_after, reason, created_at)
+		SELECT i.id, i.product_id, i.quantity, 0, i.quantity, ?, NOW()
+		FROM inventories i
+		WHERE i.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM inventory_movements m WHERE m.inventory_id = i.id)`,
+		domain.MovementInitial)
+	return result.RowsAffected, result.Error
+}
+
+func (r *GormMovementRepository) FindByProductID(productID uint, limit, offset int) ([]domain.InventoryMovement, int64, error) {
+	var total int64
+	if err := r.db.Model(&domain.InventoryMovement{}).
+		Where("product_id = ?", productID).
+		Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var movements []domain.InventoryMovement
+	err := r.db.Where("product_id = ?", productID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&movements).Error
+	return movements, total, err
+}
+
+func (r *GormMovementRepository) Rebuild(productID uint, apply bool) ([]domain.LedgerBalance, error) {
+	var balances []domain.LedgerBalance
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the stock rows so that no movement lands between the sum and the update
+		var inventories []domain.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ?", productID).
+			Order("id").
+			Find(&inventories).Error; err != nil {
+			return err
+		}
+		if len(inventories) == 0 {
+			return domain.ErrInventoryNotFound
+		}
+
+		for _, inventory := range inventories {
+			var ledgerQuantity int
+			if err := tx.Model(&domain.InventoryMovement{}).
+				Select("COALESCE(SUM(delta), 0)").
+				Where("inventory_id = ?", inventory.ID).
+				Scan(&ledgerQuantity).Error; err != nil {
+				return err
+			}
+
+			balance := domain.LedgerBalance{
+				InventoryID:    inventory.ID,
+				ProductID:      inventory.ProductID,
+				Quantity:       inventory.Quantity,
+				LedgerQuantity: ledgerQuantity,
+				Drift:          inventory.Quantity - ledgerQuantity,
+			}
+			if apply && balance.Drift != 0 {
+				if err := tx.Model(&inventory).Update("quantity", ledgerQuantity).Error; err != nil {
+					return err
+				}
+				// The ledger already sums to the new quantity, so the reset is
+				// recorded with its before and after quantities but no delta
+				if err := tx.Create(&domain.InventoryMovement{
+					InventoryID:    inventory.ID,
+					ProductID:      inventory.ProductID,
+					QuantityBefore: balance.Quantity,
+					QuantityAfter:  ledgerQuantity,
+					Reason:         domain.MovementRecompute,
+				}).Error; err != nil {
+					return err
+				}
+				balance.Applied = true
+			}
+			balances = append(balances, balance)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
@@ -1,8 +1,14 @@
 package repository
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
 	"github.com/tair/full-observability/internal/inventory/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormInventoryRepository struct {
@@ -17,8 +23,107 @@ func (r *GormInventoryRepository) AutoMigrate() error {
 	return r.db.AutoMigrate(&domain.Inventory{})
 }
 
-func (r *GormInventoryRepository) Create(inventory *domain.Inventory) error {
-	return r.db.Create(inventory).Error
+// MergeDuplicateStockRows folds live stock rows sharing a product and
+// location into the oldest of them, so that the unique product and location
+// index can be built. The quantity of every other row is moved to the kept
+// row and recorded in the ledger on both sides before the row is
+// soft-deleted. It returns the number of merged rows and is safe to run on
+// every start, before the index is migrated.
+func (r *GormInventoryRepository) MergeDuplicateStockRows() (int64, error) {
+	if !r.db.Migrator().HasTable(&domain.Inventory{}) {
+		return 0, nil
+	}
+
+	// The moved quantities are recorded against the rows' opening balances
+	if err := r.db.AutoMigrate(&domain.InventoryMovement{}); err != nil {
+		return 0, err
+	}
+	if _, err := NewGormMovementRepository(r.db).BackfillOpeningBalances(); err != nil {
+		return 0, err
+	}
+
+	var duplicates []struct {
+		ProductID uint
+		Location  string
+	}
+	if err := r.db.Model(&domain.Inventory{}).
+		Select("product_id, location").
+		Where("location IS NOT NULL").
+		Group("product_id, location").
+		Having("COUNT(*) > 1").
+		Scan(&duplicates).Error; err != nil {
+		return 0, err
+	}
+
+	var merged int64
+	for _, duplicate := range duplicates {
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			var rows []domain.Inventory
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("product_id = ? AND location = ?", duplicate.ProductID, duplicate.Location).
+				Order("id").
+				Find(&rows).Error; err != nil {
+				return err
+			}
+			if len(rows) < 2 {
+				return nil
+			}
+
+			kept := &rows[0]
+			for i := range rows[1:] {
+				row := &rows[i+1]
+				if quantity := row.Quantity; quantity != 0 {
+					row.Quantity = 0
+					if err := tx.Model(row).Update("quantity", 0).Error; err != nil {
+						return err
+					}
+					if err := recordMovement(tx, row, -quantity, mergeChange(kept.ID)); err != nil {
+						return err
+					}
+
+					kept.Quantity += quantity
+					if err := tx.Model(kept).Update("quantity", kept.Quantity).Error; err != nil {
+						return err
+					}
+					if err := recordMovement(tx, kept, quantity, mergeChange(row.ID)); err != nil {
+						return err
+					}
+				}
+				if err := tx.Delete(row).Error; err != nil {
+					return err
+				}
+			}
+			merged += int64(len(rows) - 1)
+			return nil
+		})
+		if err != nil {
+			return merged, fmt.Errorf("failed to merge stock rows of product %d at %s: %w", duplicate.ProductID, duplicate.Location, err)
+		}
+	}
+	return merged, nil
+}
+
+// mergeChange explains a quantity moved between merged rows, referencing the
+// row on the other side
+func mergeChange(inventoryID uint) domain.StockChange {
+	return domain.StockChange{
+		Reason:        domain.MovementMerge,
+		ReferenceType: domain.ReferenceInventory,
+		ReferenceID:   strconv.FormatUint(uint64(inventoryID), 10),
+	}
+}
+
+func (r *GormInventoryRepository) Create(inventory *domain.Inventory, change domain.StockChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(stockRowConflict()).Create(inventory)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInventoryExists
+		}
+		return recordMovement(tx, inventory, inventory.Quantity, change)
+	})
 }
 
 func (r *GormInventoryRepository) FindByID(id uint) (*domain.Inventory, error) {
@@ -30,15 +135,26 @@ func (r *GormInventoryRepository) FindByID(id uint) (*domain.Inventory, error) {
 	return &inventory, nil
 }
 
-func (r *GormInventoryRepository) FindByProductID(productID uint) (*domain.Inventory, error) {
+func (r *GormInventoryRepository) FindByProductAndLocation(productID uint, location string) (*domain.Inventory, error) {
 	var inventory domain.Inventory
-	err := r.db.Where("product_id = ?", productID).First(&inventory).Error
+	err := r.db.Where("product_id = ? AND location = ?", productID, location).First(&inventory).Error
 	if err != nil {
 		return nil, err
 	}
 	return &inventory, nil
 }
 
+func (r *GormInventoryRepository) FindStock(productID uint) (*domain.ProductStock, error) {
+	levels, err := stockLevels(r.db, productID, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return domain.NewProductStock(productID, levels), nil
+}
+
 func (r *GormInventoryRepository) FindAll(limit, offset int) ([]domain.Inventory, error) {
 	var inventories []domain.Inventory
 	err := r.db.Limit(limit).Offset(offset).Find(&inventories).Error
@@ -46,15 +162,396 @@ func (r *GormInventoryRepository) FindAll(limit, offset int) ([]domain.Inventory
 }
 
 func (r *GormInventoryRepository) Update(inventory *domain.Inventory) error {
-	return r.db.Save(inventory).Error
+	return r.db.Omit("quantity").Save(inventory).Error
 }
 
 func (r *GormInventoryRepository) Delete(id uint) error {
 	return r.db.Delete(&domain.Inventory{}, id).Error
 }
 
-func (r *GormInventoryRepository) UpdateQuantity(productID uint, quantity int) error {
-	return r.db.Model(&domain.Inventory{}).
-		Where("product_id = ?", productID).
-		Update("quantity", quantity).Error
+func (r *GormInventoryRepository) UpdateQuantity(inventoryID uint, quantity int, change domain.StockChange) (*domain.Inventory, error) {
+	var inventory domain.Inventory
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the row so that the recorded delta matches the overwritten quantity
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&inventory, inventoryID).Error; err != nil {
+			return err
+		}
+
+		delta := quantity - inventory.Quantity
+		if delta == 0 {
+			return nil
+		}
+		if err := tx.Model(&inventory).Update("quantity", quantity).Error; err != nil {
+			return err
+		}
+		return recordMovement(tx, &inventory, delta, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+func (r *GormInventoryRepository) DecrementQuantity(productID uint, amount int, strategy domain.AllocationStrategy, change domain.StockChange) ([]domain.Allocation, error) {
+	var allocations []domain.Allocation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		allocations, err = allocateStock(tx, domain.AllocationRequest{ProductID: productID, Quantity: amount}, strategy)
+		if err != nil {
+			return err
+		}
+		return takeStock(tx, allocations, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+func (r *GormInventoryRepository) IncrementQuantity(productID uint, amount int, change domain.StockChange) (*domain.Inventory, error) {
+	var inventory domain.Inventory
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&inventory).
+			Clauses(clause.Returning{}).
+			Where("id = (?)", primaryStockRowID(tx, productID)).
+			Update("quantity", gorm.Expr("quantity + ?", amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordMovement(tx, &inventory, amount, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+func (r *GormInventoryRepository) ReturnTaken(request domain.StockReturnRequest, change domain.StockChange) ([]domain.Allocation, error) {
+	var returned []domain.Allocation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		sourceType := domain.ReferencePayment
+		sourceID := strconv.FormatUint(uint64(request.PaymentID), 10)
+		var reservation *domain.Reservation
+		if request.ReservationID != "" {
+			sourceType, sourceID = domain.ReferenceReservation, request.ReservationID
+
+			// The reservation lock serializes the refunds of its stock
+			var err error
+			if reservation, err = findReservationForUpdate(tx, request.ReservationID); err != nil {
+				return err
+			}
+			switch reservation.State {
+			case domain.ReservationCommitted:
+			case domain.ReservationHeld:
+				// The checkout that took it has not committed it yet
+				return domain.ErrReservationNotCommitted
+			default:
+				// Released, expired or restocked: none of its stock is taken anymore
+				return nil
+			}
+		}
+
+		var done int64
+		if err := tx.Model(&domain.StockReturn{}).
+			Where("refund_id = ? AND source_type = ? AND source_id = ?", request.RefundID, sourceType, sourceID).
+			Count(&done).Error; err != nil {
+			return err
+		}
+		if done > 0 {
+			return nil
+		}
+
+		var taken []domain.Allocation
+		if reservation != nil {
+			for _, holding := range reservation.Holdings() {
+				taken = append(taken, domain.Allocation{InventoryID: holding.InventoryID, Location: holding.Location, Quantity: holding.Quantity})
+			}
+		} else {
+			var err error
+			if taken, err = purchasedStock(tx, request); err != nil {
+				return err
+			}
+
+			// Without a reservation, the row locks serialize the refunds of the purchase
+			ids := make([]uint, 0, len(taken))
+			for _, row := range taken {
+				ids = append(ids, row.InventoryID)
+			}
+			var rows []domain.Inventory
+			if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id IN ?", ids).Order("id").Find(&rows).Error; err != nil {
+				return err
+			}
+		}
+
+		// Stock already given back to each row for the source
+		var previous []struct {
+			InventoryID uint
+			Quantity    int
+		}
+		if err := tx.Model(&domain.StockReturn{}).
+			Select("inventory_id, SUM(quantity) AS quantity").
+			Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+			Group("inventory_id").
+			Scan(&previous).Error; err != nil {
+			return err
+		}
+		given := make(map[uint]int, len(previous))
+		for _, row := range previous {
+			given[row.InventoryID] = row.Quantity
+		}
+
+		remaining := request.Quantity
+		for _, row := range taken {
+			quantity := min(row.Quantity-given[row.InventoryID], remaining)
+			if quantity <= 0 {
+				continue
+			}
+
+			inventory, err := incrementStockByID(tx, row.InventoryID, quantity)
+			if err != nil {
+				return err
+			}
+			if err := recordMovement(tx, inventory, quantity, change); err != nil {
+				return err
+			}
+			if err := tx.Create(&domain.StockReturn{
+				RefundID:    request.RefundID,
+				SourceType:  sourceType,
+				SourceID:    sourceID,
+				InventoryID: row.InventoryID,
+				Quantity:    quantity,
+			}).Error; err != nil {
+				return err
+			}
+
+			given[row.InventoryID] += quantity
+			remaining -= quantity
+			returned = append(returned, domain.Allocation{InventoryID: inventory.ID, Location: inventory.Location, Quantity: quantity})
+			if remaining == 0 {
+				break
+			}
+		}
+
+		if reservation != nil {
+			for _, row := range taken {
+				if given[row.InventoryID] < row.Quantity {
+					return nil
+				}
+			}
+			reservation.State = domain.ReservationRestocked
+			return tx.Omit("Allocations").Save(reservation).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return returned, nil
+}
+
+// purchasedStock returns the rows a purchase made without a reservation took
+// the product's stock from, in the order they were taken. Purchases that
+// predate the ledger are returned to the highest-priority location.
+func purchasedStock(tx *gorm.DB, request domain.StockReturnRequest) ([]domain.Allocation, error) {
+	var taken []domain.Allocation
+	err := tx.Model(&domain.InventoryMovement{}).
+		Select("inventory_id, -SUM(delta) AS quantity").
+		Where("reason = ? AND reference_type = ? AND reference_id = ? AND product_id = ?",
+			domain.MovementPurchase, domain.ReferencePayment, strconv.FormatUint(uint64(request.PaymentID), 10), request.ProductID).
+		Group("inventory_id").
+		Order("MIN(id)").
+		Scan(&taken).Error
+	if err != nil || len(taken) > 0 {
+		return taken, err
+	}
+
+	var ids []uint
+	if err := primaryStockRowID(tx, request.ProductID).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	// How much the purchase took is unknown, so the row takes the whole return
+	return []domain.Allocation{{InventoryID: ids[0], Quantity: math.MaxInt32}}, nil
+}
+
+// allocateStock locks the product's stock rows and lets the strategy choose
+// the rows that fill the request. The rows stay locked until the transaction
+// ends, so the allocation cannot be invalidated by a concurrent one.
+func allocateStock(tx *gorm.DB, request domain.AllocationRequest, strategy domain.AllocationStrategy) ([]domain.Allocation, error) {
+	levels, err := stockLevels(tx, request.ProductID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	allocations, err := strategy.Allocate(request, levels)
+	if err != nil {
+		return nil, err
+	}
+
+	allocated := 0
+	for _, allocation := range allocations {
+		allocated += allocation.Quantity
+	}
+	if allocated != request.Quantity {
+		return nil, fmt.Errorf("allocation strategy %s allocated %d of %d", strategy.Name(), allocated, request.Quantity)
+	}
+	return allocations, nil
+}
+
+// takeStock subtracts every allocation from its stock row and records the
+// movements
+func takeStock(tx *gorm.DB, allocations []domain.Allocation, change domain.StockChange) error {
+	for _, allocation := range allocations {
+		inventory, err := decrementStockByID(tx, allocation.InventoryID, allocation.Quantity)
+		if err != nil {
+			return err
+		}
+		if err := recordMovement(tx, inventory, -allocation.Quantity, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decrementStockByID subtracts amount from a stock row in a single
+// conditional UPDATE, so concurrent callers can never drive it below zero
+func decrementStockByID(db *gorm.DB, inventoryID uint, amount int) (*domain.Inventory, error) {
+	var inventory domain.Inventory
+	result := db.Model(&inventory).
+		Clauses(clause.Returning{}).
+		Where("id = ?", inventoryID).
+		Where("quantity >= ?", amount).
+		Update("quantity", gorm.Expr("quantity - ?", amount))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, domain.ErrInsufficientStock
+	}
+	return &inventory, nil
+}
+
+// incrementStockByID adds amount back to a specific stock row. Stock owed to
+// a row that was deleted since is added to the live row of its product and
+// location, which is created when there is none. The returned row is the one
+// that received the stock.
+func incrementStockByID(db *gorm.DB, inventoryID uint, amount int) (*domain.Inventory, error) {
+	var inventory domain.Inventory
+	result := db.Model(&inventory).
+		Clauses(clause.Returning{}).
+		Where("id = ?", inventoryID).
+		Update("quantity", gorm.Expr("quantity + ?", amount))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return &inventory, nil
+	}
+
+	var deleted domain.Inventory
+	if err := db.Unscoped().First(&deleted, inventoryID).Error; err != nil {
+		return nil, err
+	}
+	live, err := findOrCreateStockRow(db, deleted.ProductID, deleted.Location)
+	if err != nil {
+		return nil, err
+	}
+	return incrementStockByID(db, live.ID, amount)
+}
+
+// recordMovement appends the ledger entry of a change of delta that left the
+// stock row at inventory.Quantity
+func recordMovement(tx *gorm.DB, inventory *domain.Inventory, delta int, change domain.StockChange) error {
+	return tx.Create(&domain.InventoryMovement{
+		InventoryID:    inventory.ID,
+		ProductID:      inventory.ProductID,
+		Delta:          delta,
+		QuantityBefore: inventory.Quantity - delta,
+		QuantityAfter:  inventory.Quantity,
+		Reason:         change.Reason,
+		ReferenceType:  change.ReferenceType,
+		ReferenceID:    change.ReferenceID,
+		TraceID:        change.TraceID,
+	}).Error
+}
+
+// stockLevels returns the stock of a product at each of its locations,
+// ordered by priority. With lock the stock rows are locked for update.
+func stockLevels(db *gorm.DB, productID uint, lock bool) ([]domain.StockLevel, error) {
+	query := db.Where("product_id = ?", productID).Order("id")
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var inventories []domain.Inventory
+	if err := query.Find(&inventories).Error; err != nil {
+		return nil, err
+	}
+	if len(inventories) == 0 {
+		return nil, nil
+	}
+
+	codes := make([]string, 0, len(inventories))
+	for _, inventory := range inventories {
+		codes = append(codes, inventory.Location)
+	}
+	var locations []domain.Location
+	if err := db.Where("code IN ?", codes).Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]domain.Location, len(locations))
+	for _, location := range locations {
+		byCode[location.Code] = location
+	}
+
+	levels := make([]domain.StockLevel, 0, len(inventories))
+	for _, inventory := range inventories {
+		level := domain.StockLevel{
+			InventoryID: inventory.ID,
+			Location:    inventory.Location,
+			Priority:    domain.DefaultLocationPriority,
+			Active:      true,
+			Quantity:    inventory.Quantity,
+		}
+		if location, ok := byCode[inventory.Location]; ok {
+			level.Region = location.Region
+			level.Priority = location.Priority
+			level.Active = location.Active
+		}
+		levels = append(levels, level)
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		return levels[i].Priority < levels[j].Priority
+	})
+	return levels, nil
+}
+
+// stockRowConflict skips the insert of a stock row when the product already
+// has a live one at the location, as decided by idx_inventory_product_location
+func stockRowConflict() clause.OnConflict {
+	return clause.OnConflict{
+		Columns:     []clause.Column{{Name: "product_id"}, {Name: "location"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+		DoNothing:   true,
+	}
+}
+
+// primaryStockRowID selects the stock row of the product's highest-priority
+// location
+func primaryStockRowID(db *gorm.DB, productID uint) *gorm.DB {
+	return db.Model(&domain.Inventory{}).
+		Select("inventories.id").
+		Joins("LEFT JOIN locations ON locations.code = inventories.location").
+		Where("inventories.product_id = ?", productID).
+		Order(fmt.Sprintf("COALESCE(locations.priority, %d), inventories.id", domain.DefaultLocationPriority)).
+		Limit(1)
 }
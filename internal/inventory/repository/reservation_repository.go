@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormReservationRepository struct {
+	db *gorm.DB
+}
+
+func NewGormReservationRepository(db *gorm.DB) *GormReservationRepository {
+	return &GormReservationRepository{db: db}
+}
+
+func (r *GormReservationRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.Reservation{}, &domain.ReservationAllocation{})
+}
+
+func (r *GormReservationRepository) Reserve(reservation *domain.Reservation, strategy domain.AllocationStrategy, traceID string) (*domain.Reservation, error) {
+	var result *domain.Reservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := findReservationForUpdate(tx, reservation.ID)
+		if err == nil {
+			if existing.ProductID != reservation.ProductID || existing.Quantity != reservation.Quantity {
+				return domain.ErrReservationConflict
+			}
+			if existing.State != domain.ReservationHeld && existing.State != domain.ReservationCommitted {
+				// Its stock is back on the shelf, so it no longer reserves anything
+				return domain.ErrReservationNotHeld
+			}
+			result = existing
+			return nil
+		}
+		if !errors.Is(err, domain.ErrReservationNotFound) {
+			return err
+		}
+
+		allocations, err := allocateStock(tx, domain.AllocationRequest{
+			ProductID: reservation.ProductID,
+			Quantity:  reservation.Quantity,
+			Region:    reservation.Region,
+		}, strategy)
+		if err != nil {
+			return err
+		}
+		if err := takeStock(tx, allocations, reservationChange(domain.MovementReservation, reservation.ID, traceID)); err != nil {
+			return err
+		}
+
+		reservation.InventoryID = allocations[0].InventoryID
+		reservation.Allocations = nil
+		for _, allocation := range allocations {
+			reservation.Allocations = append(reservation.Allocations, domain.ReservationAllocation{
+				InventoryID: allocation.InventoryID,
+				Location:    allocation.Location,
+				Quantity:    allocation.Quantity,
+			})
+		}
+		reservation.State = domain.ReservationHeld
+		if err := tx.Create(reservation).Error; err != nil {
+			return err
+		}
+
+		result = reservation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *GormReservationRepository) Release(id, traceID string) (*domain.Reservation, error) {
+	return r.finish(id, domain.ReservationReleased, traceID)
+}
+
+func (r *GormReservationRepository) Commit(id string) (*domain.Reservation, error) {
+	var result *domain.Reservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		reservation, err := findReservationForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+
+		switch reservation.State {
+		case domain.ReservationCommitted:
+			result = reservation
+			return nil
+		case domain.ReservationHeld:
+		default:
+			return domain.ErrReservationNotHeld
+		}
+
+		reservation.State = domain.ReservationCommitted
+		if err := tx.Omit("Allocations").Save(reservation).Error; err != nil {
+			return err
+		}
+
+		result = reservation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *GormReservationRepository) Restock(id, traceID string) (*domain.Reservation, error) {
+	var result *domain.Reservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		reservation, err := findReservationForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+
+		reason := domain.MovementRelease
+		switch reservation.State {
+		case domain.ReservationHeld:
+			reservation.State = domain.ReservationReleased
+		case domain.ReservationCommitted:
+			reservation.State = domain.ReservationRestocked
+			reason = domain.MovementRestock
+		default:
+			result = reservation
+			return nil
+		}
+
+		if err := returnStock(tx, reservation, reservationChange(reason, reservation.ID, traceID)); err != nil {
+			return err
+		}
+		if err := tx.Omit("Allocations").Save(reservation).Error; err != nil {
+			return err
+		}
+
+		result = reservation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *GormReservationRepository) ExpireStale(now time.Time, limit int) (int, error) {
+	var ids []string
+	err := r.db.Model(&domain.Reservation{}).
+		Where("state = ? AND expires_at < ?", domain.ReservationHeld, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		reservation, err := r.finish(id, domain.ReservationExpired, "")
+		if err != nil {
+			return expired, err
+		}
+		if reservation.State == domain.ReservationExpired {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (r *GormReservationRepository) FindByID(id string) (*domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := r.db.Preload("Allocations").Where("id = ?", id).First(&reservation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// finish moves a held reservation to a final state (released or expired) and
+// returns its quantity to the inventory row it was taken from
+func (r *GormReservationRepository) finish(id, state, traceID string) (*domain.Reservation, error) {
+	var result *domain.Reservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		reservation, err := findReservationForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+
+		switch reservation.State {
+		case domain.ReservationReleased, domain.ReservationExpired:
+			result = reservation
+			return nil
+		case domain.ReservationCommitted:
+			return domain.ErrReservationCommitted
+		}
+
+		reason := domain.MovementRelease
+		if state == domain.ReservationExpired {
+			reason = domain.MovementExpiry
+		}
+		if err := returnStock(tx, reservation, reservationChange(reason, reservation.ID, traceID)); err != nil {
+			return err
+		}
+
+		reservation.State = state
+		if err := tx.Omit("Allocations").Save(reservation).Error; err != nil {
+			return err
+		}
+
+		result = reservation
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// returnStock puts the stock held by a reservation back into the rows it was
+// taken from
+func returnStock(tx *gorm.DB, reservation *domain.Reservation, change domain.StockChange) error {
+	for _, holding := range reservation.Holdings() {
+		inventory, err := incrementStockByID(tx, holding.InventoryID, holding.Quantity)
+		if err != nil {
+			return err
+		}
+		if err := recordMovement(tx, inventory, holding.Quantity, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reservationChange explains a stock change made for a reservation
+func reservationChange(reason, reservationID, traceID string) domain.StockChange {
+	return domain.StockChange{
+		Reason:        reason,
+		ReferenceType: domain.ReferenceReservation,
+		ReferenceID:   reservationID,
+		TraceID:       traceID,
+	}
+}
+
+func findReservationForUpdate(tx *gorm.DB, id string) (*domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&reservation).Error
+	if err == nil {
+		err = tx.Where("reservation_id = ?", id).Order("id").Find(&reservation.Allocations).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tair/full-observability/internal/inventory/allocation"
+	"github.com/tair/full-observability/internal/inventory/domain"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns an in-memory database with the inventory schema and the
+// given stock rows of product 1
+func newTestDB(t *testing.T, stock map[string]int) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&domain.Inventory{}, &domain.InventoryMovement{}, &domain.Location{},
+		&domain.Reservation{}, &domain.ReservationAllocation{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	priority := 1
+	for _, code := range []string{"berlin", "reno"} {
+		quantity, ok := stock[code]
+		if !ok {
+			continue
+		}
+		if err := db.Create(&domain.Location{Code: code, Priority: priority, Active: true}).Error; err != nil {
+			t.Fatalf("create location: %v", err)
+		}
+		if err := db.Create(&domain.Inventory{ProductID: 1, Location: code, Quantity: quantity}).Error; err != nil {
+			t.Fatalf("create stock: %v", err)
+		}
+		priority++
+	}
+	return db
+}
+
+func newTestReservation(id string, quantity int) *domain.Reservation {
+	return &domain.Reservation{ID: id, ProductID: 1, Quantity: quantity, ExpiresAt: time.Now().Add(domain.DefaultReservationTTL)}
+}
+
+// assertStock checks the quantity of every stock row of product 1
+func assertStock(t *testing.T, db *gorm.DB, want map[string]int) {
+	t.Helper()
+	var rows []domain.Inventory
+	if err := db.Where("product_id = ?", 1).Find(&rows).Error; err != nil {
+		t.Fatalf("load stock: %v", err)
+	}
+	for _, row := range rows {
+		if row.Quantity != want[row.Location] {
+			t.Errorf("stock at %s = %d, want %d", row.Location, row.Quantity, want[row.Location])
+		}
+	}
+}
+
+// assertMovements checks the number of ledger entries of a reservation
+func assertMovements(t *testing.T, db *gorm.DB, reservationID string, want int64) {
+	t.Helper()
+	var count int64
+	if err := db.Model(&domain.InventoryMovement{}).
+		Where("reference_type = ? AND reference_id = ?", domain.ReferenceReservation, reservationID).
+		Count(&count).Error; err != nil {
+		t.Fatalf("count movements: %v", err)
+	}
+	if count != want {
+		t.Errorf("movements of %s = %d, want %d", reservationID, count, want)
+	}
+}
+
+func TestReserveIsIdempotent(t *testing.T) {
+	db := newTestDB(t, map[string]int{"berlin": 10})
+	repo := NewGormReservationRepository(db)
+
+	first, err := repo.Reserve(newTestReservation("res-1", 4), allocation.Priority{}, "")
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	again, err := repo.Reserve(newTestReservation("res-1", 4), allocation.Priority{}, "")
+	if err != nil {
+		t.Fatalf("repeated Reserve() error = %v", err)
+	}
+	if again.ID != first.ID || again.State != domain.ReservationHeld || len(again.Allocations) != 1 {
+		t.Errorf("repeated Reserve() = %+v, want the held reservation", again)
+	}
+	assertStock(t, db, map[string]int{"berlin": 6})
+	assertMovements(t, db, "res-1", 1)
+
+	if _, err := repo.Reserve(newTestReservation("res-1", 5), allocation.Priority{}, ""); !errors.Is(err, domain.ErrReservationConflict) {
+		t.Errorf("Reserve() with another quantity error = %v, want ErrReservationConflict", err)
+	}
+
+	if _, err := repo.Commit("res-1"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	committed, err := repo.Reserve(newTestReservation("res-1", 4), allocation.Priority{}, "")
+	if err != nil || committed.State != domain.ReservationCommitted {
+		t.Errorf("Reserve() of a committed reservation = %v, %v, want it unchanged", committed, err)
+	}
+	assertStock(t, db, map[string]int{"berlin": 6})
+}
+
+func TestReserveAfterReleaseIsRejected(t *testing.T) {
+	db := newTestDB(t, map[string]int{"berlin": 10})
+	repo := NewGormReservationRepository(db)
+
+	if _, err := repo.Reserve(newTestReservation("res-1", 4), allocation.Priority{}, ""); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if _, err := repo.Release("res-1", ""); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := repo.Reserve(newTestReservation("res-1", 4), allocation.Priority{}, ""); !errors.Is(err, domain.ErrReservationNotHeld) {
+		t.Errorf("Reserve() after Release() error = %v, want ErrReservationNotHeld", err)
+	}
+	assertStock(t, db, map[string]int{"berlin": 10})
+}
+
+func TestCommitIsIdempotent(t *testing.T) {
+	db := newTestDB(t, map[string]int{"berlin": 10})
+	repo := NewGormReservationRepository(db)
+
+	if _, err := repo.Reserve(newTestReservation("res-1", 4), allocation.Priority{}, ""); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		reservation, err := repo.Commit("res-1")
+		if err != nil {
+			t.Fatalf("Commit() #%d error = %v", i+1, err)
+		}
+		if reservation.State != domain.ReservationCommitted {
+			t.Errorf("Commit() #%d state = %s, want %s", i+1, reservation.State, domain.ReservationCommitted)
+		}
+	}
+	assertStock(t, db, map[string]int{"berlin": 6})
+	assertMovements(t, db, "res-1", 1)
+
+	if _, err := repo.Release("res-1", ""); !errors.Is(err, domain.ErrReservationCommitted) {
+		t.Errorf("Release() of a committed reservation error = %v, want ErrReservationCommitted", err)
+	}
+	if _, err := repo.Commit("res-2"); !errors.Is(err, domain.ErrReservationNotFound) {
+		t.Errorf("Commit() of an unknown reservation error = %v, want ErrReservationNotFound", err)
+	}
+}
+
+func TestReleaseIsIdempotent(t *testing.T) {
+	db := newTestDB(t, map[string]int{"berlin": 3, "reno": 5})
+	repo := NewGormReservationRepository(db)
+
+	if _, err := repo.Reserve(newTestReservation("res-1", 6), allocation.Split{}, ""); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	assertStock(t, db, map[string]int{"berlin": 0, "reno": 2})
+
+	for i := 0; i < 2; i++ {
+		reservation, err := repo.Release("res-1", "")
+		if err != nil {
+			t.Fatalf("Release() #%d error = %v", i+1, err)
+		}
+		if reservation.State != domain.ReservationReleased {
+			t.Errorf("Release() #%d state = %s, want %s", i+1, reservation.State, domain.ReservationReleased)
+		}
+	}
+	assertStock(t, db, map[string]int{"berlin": 3, "reno": 5})
+	assertMovements(t, db, "res-1", 4)
+
+	if _, err := repo.Commit("res-1"); !errors.Is(err, domain.ErrReservationNotHeld) {
+		t.Errorf("Commit() of a released reservation error = %v, want ErrReservationNotHeld", err)
+	}
+}
+
+func TestRestockIsIdempotent(t *testing.T) {
+	db := newTestDB(t, map[string]int{"berlin": 10})
+	repo := NewGormReservationRepository(db)
+
+	if _, err := repo.Reserve(newTestReservation("res-1", 4), allocation.Priority{}, ""); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if _, err := repo.Commit("res-1"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		reservation, err := repo.Restock("res-1", "")
+		if err != nil {
+			t.Fatalf("Restock() #%d error = %v", i+1, err)
+		}
+		if reservation.State != domain.ReservationRestocked {
+			t.Errorf("Restock() #%d state = %s, want %s", i+1, reservation.State, domain.ReservationRestocked)
+		}
+	}
+	assertStock(t, db, map[string]int{"berlin": 10})
+	assertMovements(t, db, "res-1", 2)
+}
+
+func TestReleaseReturnsStockOfReservationsWithoutAllocations(t *testing.T) {
+	db := newTestDB(t, map[string]int{"berlin": 6})
+	repo := NewGormReservationRepository(db)
+
+	var row domain.Inventory
+	if err := db.First(&row).Error; err != nil {
+		t.Fatalf("load stock: %v", err)
+	}
+	legacy := newTestReservation("res-1", 4)
+	legacy.InventoryID = row.ID
+	legacy.State = domain.ReservationHeld
+	if err := db.Create(legacy).Error; err != nil {
+		t.Fatalf("create reservation: %v", err)
+	}
+
+	if _, err := repo.Release("res-1", ""); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	assertStock(t, db, map[string]int{"berlin": 10})
+}
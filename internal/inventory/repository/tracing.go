@@ -28,7 +28,7 @@ func NewGormInventoryRepositoryWithTracing(db *gorm.DB) *GormInventoryRepository
 }
 
 // Create with tracing
-func (r *GormInventoryRepositoryWithTracing) CreateWithContext(ctx context.Context, inventory *domain.Inventory) error {
+func (r *GormInventoryRepositoryWithTracing) CreateWithContext(ctx context.Context, inventory *domain.Inventory, change domain.StockChange) error {
 	_, span := tracer.Start(ctx, "repository.Create",
 		trace.WithAttributes(
 			attribute.Int("inventory.product_id", int(inventory.ProductID)),
@@ -38,7 +38,7 @@ func (r *GormInventoryRepositoryWithTracing) CreateWithContext(ctx context.Conte
 	)
 	defer span.End()
 
-	err := r.GormInventoryRepository.Create(inventory)
+	err := r.GormInventoryRepository.Create(inventory, change)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -73,16 +73,16 @@ func (r *GormInventoryRepositoryWithTracing) FindByIDWithContext(ctx context.Con
 	return inventory, nil
 }
 
-// FindByProductID with tracing
-func (r *GormInventoryRepositoryWithTracing) FindByProductIDWithContext(ctx context.Context, productID uint) (*domain.Inventory, error) {
-	_, span := tracer.Start(ctx, "repository.FindByProductID",
+// FindStock with tracing
+func (r *GormInventoryRepositoryWithTracing) FindStockWithContext(ctx context.Context, productID uint) (*domain.ProductStock, error) {
+	_, span := tracer.Start(ctx, "repository.FindStock",
 		trace.WithAttributes(
 			attribute.Int("inventory.product_id", int(productID)),
 		),
 	)
 	defer span.End()
 
-	inventory, err := r.GormInventoryRepository.FindByProductID(productID)
+	stock, err := r.GormInventoryRepository.FindStock(productID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -90,11 +90,10 @@ func (r *GormInventoryRepositoryWithTracing) FindByProductIDWithContext(ctx cont
 	}
 
 	span.SetAttributes(
-		attribute.Int("inventory.id", int(inventory.ID)),
-		attribute.Int("inventory.quantity", inventory.Quantity),
-		attribute.String("inventory.location", inventory.Location),
+		attribute.Int("inventory.quantity", stock.Quantity),
+		attribute.Int("inventory.locations", len(stock.Locations)),
 	)
-	return inventory, nil
+	return stock, nil
 }
 
 // FindAll with tracing
@@ -160,23 +159,66 @@ func (r *GormInventoryRepositoryWithTracing) DeleteWithContext(ctx context.Conte
 }
 
 // UpdateQuantity with tracing
-func (r *GormInventoryRepositoryWithTracing) UpdateQuantityWithContext(ctx context.Context, productID uint, quantity int) error {
+func (r *GormInventoryRepositoryWithTracing) UpdateQuantityWithContext(ctx context.Context, inventoryID uint, quantity int, change domain.StockChange) (*domain.Inventory, error) {
 	_, span := tracer.Start(ctx, "repository.UpdateQuantity",
 		trace.WithAttributes(
-			attribute.Int("inventory.product_id", int(productID)),
+			attribute.Int("inventory.id", int(inventoryID)),
 			attribute.Int("quantity.new_value", quantity),
 		),
 	)
 	defer span.End()
 
-	err := r.GormInventoryRepository.UpdateQuantity(productID, quantity)
+	inventory, err := r.GormInventoryRepository.UpdateQuantity(inventoryID, quantity, change)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		return err
+		return nil, err
 	}
 
-	return nil
+	return inventory, nil
+}
+
+// DecrementQuantity with tracing
+func (r *GormInventoryRepositoryWithTracing) DecrementQuantityWithContext(ctx context.Context, productID uint, amount int, strategy domain.AllocationStrategy, change domain.StockChange) ([]domain.Allocation, error) {
+	_, span := tracer.Start(ctx, "repository.DecrementQuantity",
+		trace.WithAttributes(
+			attribute.Int("inventory.product_id", int(productID)),
+			attribute.Int("quantity.delta", -amount),
+			attribute.String("allocation.strategy", strategy.Name()),
+		),
+	)
+	defer span.End()
+
+	allocations, err := r.GormInventoryRepository.DecrementQuantity(productID, amount, strategy, change)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("allocation.locations", len(allocations)))
+	return allocations, nil
+}
+
+// IncrementQuantity with tracing
+func (r *GormInventoryRepositoryWithTracing) IncrementQuantityWithContext(ctx context.Context, productID uint, amount int, change domain.StockChange) (*domain.Inventory, error) {
+	_, span := tracer.Start(ctx, "repository.IncrementQuantity",
+		trace.WithAttributes(
+			attribute.Int("inventory.product_id", int(productID)),
+			attribute.Int("quantity.delta", amount),
+		),
+	)
+	defer span.End()
+
+	inventory, err := r.GormInventoryRepository.IncrementQuantity(productID, amount, change)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("quantity.new_value", inventory.Quantity))
+	return inventory, nil
 }
 
 // Helper function to add database error details to span
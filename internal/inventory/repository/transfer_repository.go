@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTransferRepository(db *gorm.DB) *GormTransferRepository {
+	return &GormTransferRepository{db: db}
+}
+
+func (r *GormTransferRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.Transfer{})
+}
+
+func (r *GormTransferRepository) Ship(transfer *domain.Transfer, traceID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var source domain.Inventory
+		err := tx.Where("product_id = ? AND location = ?", transfer.ProductID, transfer.FromLocation).First(&source).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInventoryNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		destination, err := findOrCreateStockRow(tx, transfer.ProductID, transfer.ToLocation)
+		if err != nil {
+			return err
+		}
+
+		transfer.FromInventoryID = source.ID
+		transfer.ToInventoryID = destination.ID
+		transfer.Status = domain.TransferInTransit
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+
+		inventory, err := decrementStockByID(tx, source.ID, transfer.Quantity)
+		if err != nil {
+			return err
+		}
+		return recordMovement(tx, inventory, -transfer.Quantity, transferChange(domain.MovementTransferOut, transfer.ID, traceID))
+	})
+}
+
+func (r *GormTransferRepository) Receive(id uint, quantity int, traceID string) (*domain.Transfer, error) {
+	var result *domain.Transfer
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		transfer, err := findTransferForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+		if !transfer.IsOpen() {
+			return domain.ErrTransferClosed
+		}
+		if quantity > transfer.InTransit() {
+			return domain.ErrTransferOverReceipt
+		}
+
+		inventory, err := incrementStockByID(tx, transfer.ToInventoryID, quantity)
+		if err != nil {
+			return err
+		}
+		if err := recordMovement(tx, inventory, quantity, transferChange(domain.MovementTransferIn, transfer.ID, traceID)); err != nil {
+			return err
+		}
+
+		transfer.ReceivedQuantity += quantity
+		transfer.Status = domain.TransferPartiallyReceived
+		if transfer.InTransit() == 0 {
+			now := time.Now()
+			transfer.Status = domain.TransferReceived
+			transfer.CompletedAt = &now
+		}
+		if err := tx.Save(transfer).Error; err != nil {
+			return err
+		}
+
+		result = transfer
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *GormTransferRepository) Cancel(id uint, traceID string) (*domain.Transfer, error) {
+	var result *domain.Transfer
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		transfer, err := findTransferForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+		if transfer.Status == domain.TransferCancelled {
+			result = transfer
+			return nil
+		}
+		if !transfer.IsOpen() {
+			return domain.ErrTransferClosed
+		}
+
+		returned := transfer.InTransit()
+		inventory, err := incrementStockByID(tx, transfer.FromInventoryID, returned)
+		if err != nil {
+			return err
+		}
+		if err := recordMovement(tx, inventory, returned, transferChange(domain.MovementTransferReturn, transfer.ID, traceID)); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transfer.ReturnedQuantity = returned
+		transfer.Status = domain.TransferCancelled
+		transfer.CompletedAt = &now
+		if err := tx.Save(transfer).Error; err != nil {
+			return err
+		}
+
+		result = transfer
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *GormTransferRepository) FindByID(id uint) (*domain.Transfer, error) {
+	var transfer domain.Transfer
+	err := r.db.First(&transfer, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *GormTransferRepository) FindAll(filter domain.TransferFilter) ([]domain.Transfer, int64, error) {
+	var total int64
+	if err := r.filter(filter).Model(&domain.Transfer{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var transfers []domain.Transfer
+	err := r.filter(filter).
+		Order("created_at DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&transfers).Error
+	return transfers, total, err
+}
+
+// filter builds the WHERE clause of a transfer listing
+func (r *GormTransferRepository) filter(filter domain.TransferFilter) *gorm.DB {
+	query := r.db
+	if filter.ProductID != 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.Location != "" {
+		query = query.Where("(from_location = ? OR to_location = ?)", filter.Location, filter.Location)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	return query
+}
+
+// findOrCreateStockRow returns the product's stock row at location, creating
+// an empty one when there is none. A row created concurrently wins the
+// insert, and is the one returned.
+func findOrCreateStockRow(tx *gorm.DB, productID uint, location string) (*domain.Inventory, error) {
+	var inventory domain.Inventory
+	err := tx.Where("product_id = ? AND location = ?", productID, location).First(&inventory).Error
+	if err == nil {
+		return &inventory, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	inventory = domain.Inventory{ProductID: productID, Location: location}
+	result := tx.Clauses(stockRowConflict()).Create(&inventory)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		inventory = domain.Inventory{}
+		if err := tx.Where("product_id = ? AND location = ?", productID, location).First(&inventory).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &inventory, nil
+}
+
+// transferChange explains a stock change made for a transfer
+func transferChange(reason string, transferID uint, traceID string) domain.StockChange {
+	return domain.StockChange{
+		Reason:        reason,
+		ReferenceType: domain.ReferenceTransfer,
+		ReferenceID:   strconv.FormatUint(uint64(transferID), 10),
+		TraceID:       traceID,
+	}
+}
+
+func findTransferForUpdate(tx *gorm.DB, id uint) (*domain.Transfer, error) {
+	var transfer domain.Transfer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// CancelTransferCommand represents the command to cancel a transfer
+type CancelTransferCommand struct {
+	TransferID uint
+}
+
+// CancelTransferHandler handles cancel transfer command
+type CancelTransferHandler struct {
+	repo domain.TransferRepository
+}
+
+// NewCancelTransferHandler creates a new cancel transfer handler
+func NewCancelTransferHandler(repo domain.TransferRepository) *CancelTransferHandler {
+	return &CancelTransferHandler{repo: repo}
+}
+
+// Handle executes the cancel transfer command. Stock still in transit goes
+// back to the source location; what was already received stays at the
+// destination. Cancelling twice is a no-op.
+func (h *CancelTransferHandler) Handle(ctx context.Context, cmd CancelTransferCommand) (*domain.Transfer, error) {
+	if cmd.TransferID == 0 {
+		return nil, fmt.Errorf("transfer_id is required")
+	}
+
+	transfer, err := h.repo.Cancel(cmd.TransferID, traceID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel transfer: %w", err)
+	}
+
+	return transfer, nil
+}
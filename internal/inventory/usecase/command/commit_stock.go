@@ -0,0 +1,36 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// CommitStockCommand represents the command to commit a reservation
+type CommitStockCommand struct {
+	ReservationID string
+}
+
+// CommitStockHandler handles commit stock command
+type CommitStockHandler struct {
+	repo domain.ReservationRepository
+}
+
+// NewCommitStockHandler creates a new commit stock handler
+func NewCommitStockHandler(repo domain.ReservationRepository) *CommitStockHandler {
+	return &CommitStockHandler{repo: repo}
+}
+
+// Handle executes the commit stock command. Committing twice is a no-op.
+func (h *CommitStockHandler) Handle(cmd CommitStockCommand) (*domain.Reservation, error) {
+	if cmd.ReservationID == "" {
+		return nil, fmt.Errorf("reservation_id is required")
+	}
+
+	reservation, err := h.repo.Commit(cmd.ReservationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit stock: %w", err)
+	}
+
+	return reservation, nil
+}
@@ -1,7 +1,9 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/tair/full-observability/internal/inventory/domain"
 )
@@ -11,6 +13,8 @@ type CreateInventoryCommand struct {
 	ProductID uint
 	Quantity  int
 	Location  string
+	// ActorUserID is the admin creating the stock row, recorded in the ledger
+	ActorUserID uint
 }
 
 // CreateInventoryHandler handles create inventory command
@@ -23,8 +27,9 @@ func NewCreateInventoryHandler(repo domain.InventoryRepository) *CreateInventory
 	return &CreateInventoryHandler{repo: repo}
 }
 
-// Handle executes the create inventory command
-func (h *CreateInventoryHandler) Handle(cmd CreateInventoryCommand) (*domain.Inventory, error) {
+// Handle executes the create inventory command. The initial quantity is the
+// first movement of the row's ledger.
+func (h *CreateInventoryHandler) Handle(ctx context.Context, cmd CreateInventoryCommand) (*domain.Inventory, error) {
 	if cmd.ProductID == 0 {
 		return nil, fmt.Errorf("product_id is required")
 	}
@@ -34,7 +39,7 @@ func (h *CreateInventoryHandler) Handle(cmd CreateInventoryCommand) (*domain.Inv
 	}
 
 	if cmd.Location == "" {
-		cmd.Location = "warehouse"
+		cmd.Location = domain.DefaultLocation
 	}
 
 	inventory := &domain.Inventory{
@@ -43,7 +48,13 @@ func (h *CreateInventoryHandler) Handle(cmd CreateInventoryCommand) (*domain.Inv
 		Location:  cmd.Location,
 	}
 
-	if err := h.repo.Create(inventory); err != nil {
+	change := NewStockChange(ctx, domain.MovementInitial, "", "")
+	if cmd.ActorUserID != 0 {
+		change.ReferenceType = domain.ReferenceUser
+		change.ReferenceID = strconv.FormatUint(uint64(cmd.ActorUserID), 10)
+	}
+
+	if err := h.repo.Create(inventory, change); err != nil {
 		return nil, fmt.Errorf("failed to create inventory: %w", err)
 	}
 
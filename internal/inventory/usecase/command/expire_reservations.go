@@ -0,0 +1,42 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// ExpireReservationsCommand represents the command to expire stale holds
+type ExpireReservationsCommand struct {
+	Now       time.Time
+	BatchSize int
+}
+
+// ExpireReservationsHandler handles expire reservations command
+type ExpireReservationsHandler struct {
+	repo domain.ReservationRepository
+}
+
+// NewExpireReservationsHandler creates a new expire reservations handler
+func NewExpireReservationsHandler(repo domain.ReservationRepository) *ExpireReservationsHandler {
+	return &ExpireReservationsHandler{repo: repo}
+}
+
+// Handle expires held reservations past their TTL and returns how many were expired
+func (h *ExpireReservationsHandler) Handle(cmd ExpireReservationsCommand) (int, error) {
+	if cmd.Now.IsZero() {
+		cmd.Now = time.Now()
+	}
+
+	if cmd.BatchSize == 0 {
+		cmd.BatchSize = 100
+	}
+
+	expired, err := h.repo.ExpireStale(cmd.Now, cmd.BatchSize)
+	if err != nil {
+		return expired, fmt.Errorf("failed to expire reservations: %w", err)
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+// RebuildQuantityCommand represents the command to rebuild the quantity of a
+// product from its ledger
+type RebuildQuantityCommand struct {
+	ProductID uint
+	// Apply sets drifted quantities to the ledger sum; without it the drift
+	// is only reported
+	Apply bool
+}
+
+// RebuildQuantityHandler handles rebuild quantity command
+type RebuildQuantityHandler struct {
+	repo domain.MovementRepository
+}
+
+// NewRebuildQuantityHandler creates a new rebuild quantity handler
+func NewRebuildQuantityHandler(repo domain.MovementRepository) *RebuildQuantityHandler {
+	return &RebuildQuantityHandler{repo: repo}
+}
+
+// Handle executes the rebuild quantity command
+func (h *RebuildQuantityHandler) Handle(cmd RebuildQuantityCommand) ([]domain.LedgerBalance, error) {
+	if cmd.ProductID == 0 {
+		return nil, fmt.Errorf("product_id is required")
+	}
+
+	balances, err := h.repo.Rebuild(cmd.ProductID, cmd.Apply)
+	if errors.Is(err, domain.ErrInventoryNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild quantity: %w", err)
+	}
+
+	for _, balance := range balances {
+		if balance.Drift != 0 {
+			logger.Logger.Warn().
+				Uint("inventory_id", balance.InventoryID).
+				Uint("product_id", balance.ProductID).
+				Int("quantity", balance.Quantity).
+				Int("ledger_quantity", balance.LedgerQuantity).
+				Bool("applied", balance.Applied).
+				Msg("Inventory quantity drifted from ledger")
+		}
+	}
+
+	return balances, nil
+}
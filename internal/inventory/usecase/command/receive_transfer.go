@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// ReceiveTransferCommand represents the command to receive stock of a transfer
+// at its destination
+type ReceiveTransferCommand struct {
+	TransferID uint
+	Quantity   int // 0 receives everything still in transit
+}
+
+// ReceiveTransferHandler handles receive transfer command
+type ReceiveTransferHandler struct {
+	repo domain.TransferRepository
+}
+
+// NewReceiveTransferHandler creates a new receive transfer handler
+func NewReceiveTransferHandler(repo domain.TransferRepository) *ReceiveTransferHandler {
+	return &ReceiveTransferHandler{repo: repo}
+}
+
+// Handle executes the receive transfer command. A transfer can be received
+// in several parts; it is received once nothing is left in transit.
+func (h *ReceiveTransferHandler) Handle(ctx context.Context, cmd ReceiveTransferCommand) (*domain.Transfer, error) {
+	if cmd.TransferID == 0 {
+		return nil, fmt.Errorf("transfer_id is required")
+	}
+
+	if cmd.Quantity < 0 {
+		return nil, fmt.Errorf("quantity cannot be negative")
+	}
+
+	if cmd.Quantity == 0 {
+		transfer, err := h.repo.FindByID(cmd.TransferID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive transfer: %w", err)
+		}
+		if !transfer.IsOpen() {
+			return nil, fmt.Errorf("failed to receive transfer: %w", domain.ErrTransferClosed)
+		}
+		cmd.Quantity = transfer.InTransit()
+	}
+
+	transfer, err := h.repo.Receive(cmd.TransferID, cmd.Quantity, traceID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive transfer: %w", err)
+	}
+
+	return transfer, nil
+}
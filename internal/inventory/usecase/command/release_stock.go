@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// ReleaseStockCommand represents the command to release a reservation
+type ReleaseStockCommand struct {
+	ReservationID string
+	ProductID     uint // optional, validated against the reservation
+}
+
+// ReleaseStockHandler handles release stock command
+type ReleaseStockHandler struct {
+	repo domain.ReservationRepository
+}
+
+// NewReleaseStockHandler creates a new release stock handler
+func NewReleaseStockHandler(repo domain.ReservationRepository) *ReleaseStockHandler {
+	return &ReleaseStockHandler{repo: repo}
+}
+
+// Handle executes the release stock command. Only the reserved quantity is
+// returned, and releasing an already released or expired reservation is a no-op.
+func (h *ReleaseStockHandler) Handle(ctx context.Context, cmd ReleaseStockCommand) (*domain.Reservation, error) {
+	if cmd.ReservationID == "" {
+		return nil, fmt.Errorf("reservation_id is required")
+	}
+
+	if cmd.ProductID != 0 {
+		existing, err := h.repo.FindByID(cmd.ReservationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to release stock: %w", err)
+		}
+		if existing.ProductID != cmd.ProductID {
+			return nil, fmt.Errorf("failed to release stock: %w", domain.ErrReservationConflict)
+		}
+	}
+
+	reservation, err := h.repo.Release(cmd.ReservationID, traceID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to release stock: %w", err)
+	}
+
+	return reservation, nil
+}
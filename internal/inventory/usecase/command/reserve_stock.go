@@ -0,0 +1,65 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// ReserveStockCommand represents the command to hold stock for a reservation
+type ReserveStockCommand struct {
+	ReservationID string
+	ProductID     uint
+	Quantity      int
+	TTL           time.Duration
+	Region        string // optional customer region, used by region-aware strategies
+}
+
+// ReserveStockHandler handles reserve stock command
+type ReserveStockHandler struct {
+	repo     domain.ReservationRepository
+	strategy domain.AllocationStrategy
+}
+
+// NewReserveStockHandler creates a new reserve stock handler
+func NewReserveStockHandler(repo domain.ReservationRepository, strategy domain.AllocationStrategy) *ReserveStockHandler {
+	return &ReserveStockHandler{repo: repo, strategy: strategy}
+}
+
+// Handle executes the reserve stock command. The locations the stock is
+// taken from are chosen by the allocation strategy. Repeating it with the
+// same reservation ID returns the original reservation without reserving twice.
+func (h *ReserveStockHandler) Handle(ctx context.Context, cmd ReserveStockCommand) (*domain.Reservation, error) {
+	if cmd.ReservationID == "" {
+		return nil, fmt.Errorf("reservation_id is required")
+	}
+
+	if cmd.ProductID == 0 {
+		return nil, fmt.Errorf("product_id is required")
+	}
+
+	if cmd.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than 0")
+	}
+
+	if cmd.TTL <= 0 {
+		cmd.TTL = domain.DefaultReservationTTL
+	}
+
+	reservation := &domain.Reservation{
+		ID:        cmd.ReservationID,
+		ProductID: cmd.ProductID,
+		Quantity:  cmd.Quantity,
+		Region:    cmd.Region,
+		ExpiresAt: time.Now().Add(cmd.TTL),
+	}
+
+	result, err := h.repo.Reserve(reservation, h.strategy, traceID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// RestockCommand represents the command to return the stock of a failed
+// payment or of refunded items
+type RestockCommand struct {
+	ProductID     uint
+	Quantity      int
+	ReservationID string // set when the stock was taken by a reservation
+	PaymentID     uint   // the failed or refunded payment, recorded in the ledger
+	RefundID      uint   // set when the stock is returned by a refund
+}
+
+// RestockHandler handles restock command
+type RestockHandler struct {
+	repo         domain.InventoryRepository
+	reservations domain.ReservationRepository
+}
+
+// NewRestockHandler creates a new restock handler
+func NewRestockHandler(repo domain.InventoryRepository, reservations domain.ReservationRepository) *RestockHandler {
+	return &RestockHandler{repo: repo, reservations: reservations}
+}
+
+// Handle returns the purchased quantity to stock and reports how much was
+// returned. Reservation-backed purchases are restocked through the
+// reservation, so repeating the command for them is a no-op. Refunded items
+// go back to the rows they were taken from, once per refund.
+func (h *RestockHandler) Handle(ctx context.Context, cmd RestockCommand) (int, error) {
+	if cmd.RefundID != 0 {
+		return h.returnRefunded(ctx, cmd)
+	}
+
+	if cmd.ReservationID != "" {
+		before, err := h.reservations.FindByID(cmd.ReservationID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to restock: %w", err)
+		}
+
+		after, err := h.reservations.Restock(cmd.ReservationID, traceID(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to restock: %w", err)
+		}
+		if after.State == before.State {
+			return 0, nil
+		}
+		return after.Quantity, nil
+	}
+
+	if cmd.ProductID == 0 {
+		return 0, fmt.Errorf("product_id is required")
+	}
+
+	if cmd.Quantity <= 0 {
+		return 0, fmt.Errorf("quantity must be greater than 0")
+	}
+
+	change := NewStockChange(ctx, domain.MovementRestock, "", "")
+	if cmd.PaymentID != 0 {
+		change.ReferenceType = domain.ReferencePayment
+		change.ReferenceID = strconv.FormatUint(uint64(cmd.PaymentID), 10)
+	}
+
+	if _, err := h.repo.IncrementQuantity(cmd.ProductID, cmd.Quantity, change); err != nil {
+		return 0, fmt.Errorf("failed to restock: %w", err)
+	}
+
+	return cmd.Quantity, nil
+}
+
+// returnRefunded gives the refunded items back to the stock rows the
+// reservation or purchase took them from
+func (h *RestockHandler) returnRefunded(ctx context.Context, cmd RestockCommand) (int, error) {
+	if cmd.Quantity <= 0 {
+		return 0, fmt.Errorf("quantity must be greater than 0")
+	}
+	if cmd.ReservationID == "" && cmd.ProductID == 0 {
+		return 0, fmt.Errorf("product_id is required")
+	}
+
+	change := NewStockChange(ctx, domain.MovementReturn, domain.ReferenceRefund, strconv.FormatUint(uint64(cmd.RefundID), 10))
+	returned, err := h.repo.ReturnTaken(domain.StockReturnRequest{
+		ProductID:     cmd.ProductID,
+		Quantity:      cmd.Quantity,
+		ReservationID: cmd.ReservationID,
+		PaymentID:     cmd.PaymentID,
+		RefundID:      cmd.RefundID,
+	}, change)
+	if err != nil {
+		return 0, fmt.Errorf("failed to return refunded stock: %w", err)
+	}
+
+	total := 0
+	for _, allocation := range returned {
+		total += allocation.Quantity
+	}
+	return total, nil
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// SaveLocationCommand represents the command to create or update a location
+type SaveLocationCommand struct {
+	Code     string
+	Name     string
+	Region   string
+	Priority int
+	Active   bool
+}
+
+// SaveLocationHandler handles save location command
+type SaveLocationHandler struct {
+	repo domain.LocationRepository
+}
+
+// NewSaveLocationHandler creates a new save location handler
+func NewSaveLocationHandler(repo domain.LocationRepository) *SaveLocationHandler {
+	return &SaveLocationHandler{repo: repo}
+}
+
+// Handle executes the save location command
+func (h *SaveLocationHandler) Handle(cmd SaveLocationCommand) (*domain.Location, error) {
+	cmd.Code = strings.TrimSpace(cmd.Code)
+	if cmd.Code == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+
+	if cmd.Priority < 0 {
+		return nil, fmt.Errorf("priority cannot be negative")
+	}
+
+	if cmd.Name == "" {
+		cmd.Name = cmd.Code
+	}
+
+	location := &domain.Location{
+		Code:     cmd.Code,
+		Name:     cmd.Name,
+		Region:   strings.ToLower(strings.TrimSpace(cmd.Region)),
+		Priority: cmd.Priority,
+		Active:   cmd.Active,
+	}
+
+	if err := h.repo.Save(location); err != nil {
+		return nil, fmt.Errorf("failed to save location: %w", err)
+	}
+
+	return location, nil
+}
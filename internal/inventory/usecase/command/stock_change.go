@@ -0,0 +1,28 @@
+package command
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// NewStockChange explains a stock change for the movement ledger, tagged with
+// the trace of ctx
+func NewStockChange(ctx context.Context, reason, referenceType, referenceID string) domain.StockChange {
+	return domain.StockChange{
+		Reason:        reason,
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+		TraceID:       traceID(ctx),
+	}
+}
+
+// traceID returns the trace ID of ctx, or an empty string outside a trace
+func traceID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// TransferStockCommand represents the command to ship stock from one location
+// to another
+type TransferStockCommand struct {
+	ProductID    uint
+	FromLocation string
+	ToLocation   string
+	Quantity     int
+	Note         string
+	// ActorUserID is the admin shipping the transfer
+	ActorUserID uint
+}
+
+// TransferStockHandler handles transfer stock command
+type TransferStockHandler struct {
+	repo domain.TransferRepository
+}
+
+// NewTransferStockHandler creates a new transfer stock handler
+func NewTransferStockHandler(repo domain.TransferRepository) *TransferStockHandler {
+	return &TransferStockHandler{repo: repo}
+}
+
+// Handle executes the transfer stock command. The quantity leaves the source
+// location at once and stays in transit until it is received or the
+// transfer is cancelled.
+func (h *TransferStockHandler) Handle(ctx context.Context, cmd TransferStockCommand) (*domain.Transfer, error) {
+	if cmd.ProductID == 0 {
+		return nil, fmt.Errorf("product_id is required")
+	}
+
+	cmd.FromLocation = strings.TrimSpace(cmd.FromLocation)
+	cmd.ToLocation = strings.TrimSpace(cmd.ToLocation)
+	if cmd.FromLocation == "" || cmd.ToLocation == "" {
+		return nil, fmt.Errorf("from_location and to_location are required")
+	}
+
+	if cmd.FromLocation == cmd.ToLocation {
+		return nil, domain.ErrTransferSameLocation
+	}
+
+	if cmd.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than 0")
+	}
+
+	transfer := &domain.Transfer{
+		ProductID:    cmd.ProductID,
+		FromLocation: cmd.FromLocation,
+		ToLocation:   cmd.ToLocation,
+		Quantity:     cmd.Quantity,
+		Note:         cmd.Note,
+		CreatedBy:    cmd.ActorUserID,
+	}
+
+	if err := h.repo.Ship(transfer, traceID(ctx)); err != nil {
+		return nil, fmt.Errorf("failed to transfer stock: %w", err)
+	}
+
+	return transfer, nil
+}
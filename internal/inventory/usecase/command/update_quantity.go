@@ -1,7 +1,9 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/tair/full-observability/internal/inventory/domain"
 )
@@ -9,7 +11,10 @@ import (
 // UpdateQuantityCommand represents the command to update inventory quantity
 type UpdateQuantityCommand struct {
 	ProductID uint
+	Location  string // optional when the product is stocked at a single location
 	Quantity  int
+	// ActorUserID is the admin setting the quantity, recorded in the ledger
+	ActorUserID uint
 }
 
 // UpdateQuantityHandler handles update quantity command
@@ -22,8 +27,10 @@ func NewUpdateQuantityHandler(repo domain.InventoryRepository) *UpdateQuantityHa
 	return &UpdateQuantityHandler{repo: repo}
 }
 
-// Handle executes the update quantity command
-func (h *UpdateQuantityHandler) Handle(cmd UpdateQuantityCommand) error {
+// Handle executes the update quantity command for the product's stock at one
+// location. The difference to the current quantity is recorded as a manual
+// adjustment.
+func (h *UpdateQuantityHandler) Handle(ctx context.Context, cmd UpdateQuantityCommand) error {
 	if cmd.ProductID == 0 {
 		return fmt.Errorf("product_id is required")
 	}
@@ -32,9 +39,41 @@ func (h *UpdateQuantityHandler) Handle(cmd UpdateQuantityCommand) error {
 		return fmt.Errorf("quantity cannot be negative")
 	}
 
-	if err := h.repo.UpdateQuantity(cmd.ProductID, cmd.Quantity); err != nil {
+	change := NewStockChange(ctx, domain.MovementAdjust, "", "")
+	if cmd.ActorUserID != 0 {
+		change.ReferenceType = domain.ReferenceUser
+		change.ReferenceID = strconv.FormatUint(uint64(cmd.ActorUserID), 10)
+	}
+
+	inventoryID, err := h.stockRowID(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to update quantity: %w", err)
+	}
+
+	if _, err := h.repo.UpdateQuantity(inventoryID, cmd.Quantity, change); err != nil {
 		return fmt.Errorf("failed to update quantity: %w", err)
 	}
 
 	return nil
 }
+
+// stockRowID finds the stock row of the command's location, or the only stock
+// row of the product when no location is given
+func (h *UpdateQuantityHandler) stockRowID(cmd UpdateQuantityCommand) (uint, error) {
+	if cmd.Location != "" {
+		inventory, err := h.repo.FindByProductAndLocation(cmd.ProductID, cmd.Location)
+		if err != nil {
+			return 0, err
+		}
+		return inventory.ID, nil
+	}
+
+	stock, err := h.repo.FindStock(cmd.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	if len(stock.Locations) > 1 {
+		return 0, domain.ErrLocationRequired
+	}
+	return stock.Locations[0].InventoryID, nil
+}
@@ -0,0 +1,86 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// CheckAvailabilityQuery represents the query to check whether a quantity of
+// a product can be reserved
+type CheckAvailabilityQuery struct {
+	ProductID uint
+	Quantity  int
+	Region    string // optional customer region, used by region-aware strategies
+}
+
+// Availability is the answer to an availability check
+type Availability struct {
+	ProductID   uint                `json:"product_id"`
+	Requested   int                 `json:"requested"`
+	Available   bool                `json:"available"`
+	Quantity    int                 `json:"quantity"` // total over the active locations
+	Strategy    string              `json:"strategy"`
+	Allocations []domain.Allocation `json:"allocations,omitempty"` // where the stock would be taken from
+	Locations   []domain.StockLevel `json:"locations"`
+	Message     string              `json:"message"`
+}
+
+// CheckAvailabilityHandler handles check availability query
+type CheckAvailabilityHandler struct {
+	repo     domain.InventoryRepository
+	strategy domain.AllocationStrategy
+}
+
+// NewCheckAvailabilityHandler creates a new check availability handler
+func NewCheckAvailabilityHandler(repo domain.InventoryRepository, strategy domain.AllocationStrategy) *CheckAvailabilityHandler {
+	return &CheckAvailabilityHandler{repo: repo, strategy: strategy}
+}
+
+// Handle executes the check availability query. The quantity is available
+// when the allocation strategy reservations use could allocate it now, which
+// for single-location strategies needs more than enough total stock.
+func (h *CheckAvailabilityHandler) Handle(query CheckAvailabilityQuery) (*Availability, error) {
+	if query.ProductID == 0 {
+		return nil, fmt.Errorf("product_id is required")
+	}
+
+	if query.Quantity <= 0 {
+		query.Quantity = 1
+	}
+
+	stock, err := h.repo.FindStock(query.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check availability: %w", err)
+	}
+
+	availability := &Availability{
+		ProductID: query.ProductID,
+		Requested: query.Quantity,
+		Quantity:  stock.Quantity,
+		Strategy:  h.strategy.Name(),
+		Locations: stock.Locations,
+		Message:   "Product is available",
+	}
+
+	allocations, err := h.strategy.Allocate(domain.AllocationRequest{
+		ProductID: query.ProductID,
+		Quantity:  query.Quantity,
+		Region:    query.Region,
+	}, stock.Locations)
+	switch {
+	case err == nil:
+		availability.Available = true
+		availability.Allocations = allocations
+	case errors.Is(err, domain.ErrInsufficientStock) && stock.Quantity >= query.Quantity:
+		availability.Message = fmt.Sprintf("No single location can ship %d. Available: %d across %d locations",
+			query.Quantity, stock.Quantity, len(stock.Locations))
+	case errors.Is(err, domain.ErrInsufficientStock):
+		availability.Message = fmt.Sprintf("Insufficient quantity. Available: %d, Requested: %d", stock.Quantity, query.Quantity)
+	default:
+		return nil, fmt.Errorf("failed to check availability: %w", err)
+	}
+
+	return availability, nil
+}
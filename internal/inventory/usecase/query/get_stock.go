@@ -0,0 +1,36 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// GetStockQuery represents the query to get the stock of a product across locations
+type GetStockQuery struct {
+	ProductID uint
+}
+
+// GetStockHandler handles get stock query
+type GetStockHandler struct {
+	repo domain.InventoryRepository
+}
+
+// NewGetStockHandler creates a new get stock handler
+func NewGetStockHandler(repo domain.InventoryRepository) *GetStockHandler {
+	return &GetStockHandler{repo: repo}
+}
+
+// Handle executes the get stock query
+func (h *GetStockHandler) Handle(query GetStockQuery) (*domain.ProductStock, error) {
+	if query.ProductID == 0 {
+		return nil, fmt.Errorf("product_id is required")
+	}
+
+	stock, err := h.repo.FindStock(query.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock: %w", err)
+	}
+
+	return stock, nil
+}
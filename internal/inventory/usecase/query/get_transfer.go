@@ -0,0 +1,36 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// GetTransferQuery represents the query to get a transfer
+type GetTransferQuery struct {
+	ID uint
+}
+
+// GetTransferHandler handles get transfer query
+type GetTransferHandler struct {
+	repo domain.TransferRepository
+}
+
+// NewGetTransferHandler creates a new get transfer handler
+func NewGetTransferHandler(repo domain.TransferRepository) *GetTransferHandler {
+	return &GetTransferHandler{repo: repo}
+}
+
+// Handle executes the get transfer query
+func (h *GetTransferHandler) Handle(query GetTransferQuery) (*domain.Transfer, error) {
+	if query.ID == 0 {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	transfer, err := h.repo.FindByID(query.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer: %w", err)
+	}
+
+	return transfer, nil
+}
@@ -0,0 +1,27 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// ListLocationsHandler handles list locations query
+type ListLocationsHandler struct {
+	repo domain.LocationRepository
+}
+
+// NewListLocationsHandler creates a new list locations handler
+func NewListLocationsHandler(repo domain.LocationRepository) *ListLocationsHandler {
+	return &ListLocationsHandler{repo: repo}
+}
+
+// Handle executes the list locations query
+func (h *ListLocationsHandler) Handle() ([]domain.Location, error) {
+	locations, err := h.repo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locations: %w", err)
+	}
+
+	return locations, nil
+}
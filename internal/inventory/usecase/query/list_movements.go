@@ -0,0 +1,63 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// ListMovementsQuery represents the query to list the ledger of a product
+type ListMovementsQuery struct {
+	ProductID uint
+	Limit     int
+	Offset    int
+}
+
+// MovementPage is a page of movements, newest first
+type MovementPage struct {
+	Movements []domain.InventoryMovement `json:"movements"`
+	Total     int64                      `json:"total"`
+	Limit     int                        `json:"limit"`
+	Offset    int                        `json:"offset"`
+}
+
+// ListMovementsHandler handles list movements query
+type ListMovementsHandler struct {
+	repo domain.MovementRepository
+}
+
+// NewListMovementsHandler creates a new list movements handler
+func NewListMovementsHandler(repo domain.MovementRepository) *ListMovementsHandler {
+	return &ListMovementsHandler{repo: repo}
+}
+
+// Handle executes the list movements query
+func (h *ListMovementsHandler) Handle(query ListMovementsQuery) (*MovementPage, error) {
+	if query.ProductID == 0 {
+		return nil, fmt.Errorf("product_id is required")
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 50
+	}
+
+	if query.Limit > 500 {
+		query.Limit = 500
+	}
+
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	movements, total, err := h.repo.FindByProductID(query.ProductID, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list movements: %w", err)
+	}
+
+	return &MovementPage{
+		Movements: movements,
+		Total:     total,
+		Limit:     query.Limit,
+		Offset:    query.Offset,
+	}, nil
+}
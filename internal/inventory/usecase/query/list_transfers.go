@@ -0,0 +1,71 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/inventory/domain"
+)
+
+// ListTransfersQuery represents the query to list transfers
+type ListTransfersQuery struct {
+	ProductID uint
+	Location  string // source or destination
+	Status    string
+	Limit     int
+	Offset    int
+}
+
+// TransferPage is a page of transfers, newest first
+type TransferPage struct {
+	Transfers []domain.Transfer `json:"transfers"`
+	Total     int64             `json:"total"`
+	Limit     int               `json:"limit"`
+	Offset    int               `json:"offset"`
+}
+
+// ListTransfersHandler handles list transfers query
+type ListTransfersHandler struct {
+	repo domain.TransferRepository
+}
+
+// NewListTransfersHandler creates a new list transfers handler
+func NewListTransfersHandler(repo domain.TransferRepository) *ListTransfersHandler {
+	return &ListTransfersHandler{repo: repo}
+}
+
+// Handle executes the list transfers query
+func (h *ListTransfersHandler) Handle(query ListTransfersQuery) (*TransferPage, error) {
+	if query.Status != "" && !domain.IsValidTransferStatus(query.Status) {
+		return nil, fmt.Errorf("invalid status %q", query.Status)
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 20
+	}
+
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	transfers, total, err := h.repo.FindAll(domain.TransferFilter{
+		ProductID: query.ProductID,
+		Location:  query.Location,
+		Status:    query.Status,
+		Limit:     query.Limit,
+		Offset:    query.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+
+	return &TransferPage{
+		Transfers: transfers,
+		Total:     total,
+		Limit:     query.Limit,
+		Offset:    query.Offset,
+	}, nil
+}
@@ -21,6 +21,26 @@ func ProvideInventoryRepository(db *gorm.DB) domain.InventoryRepository {
 	return repository.NewGormInventoryRepository(db)
 }
 
+// ProvideReservationRepository provides the reservation repository
+func ProvideReservationRepository(db *gorm.DB) domain.ReservationRepository {
+	return repository.NewGormReservationRepository(db)
+}
+
+// ProvideMovementRepository provides the inventory movement ledger repository
+func ProvideMovementRepository(db *gorm.DB) domain.MovementRepository {
+	return repository.NewGormMovementRepository(db)
+}
+
+// ProvideLocationRepository provides the location repository
+func ProvideLocationRepository(db *gorm.DB) domain.LocationRepository {
+	return repository.NewGormLocationRepository(db)
+}
+
+// ProvideTransferRepository provides the stock transfer repository
+func ProvideTransferRepository(db *gorm.DB) domain.TransferRepository {
+	return repository.NewGormTransferRepository(db)
+}
+
 // Command Handlers Providers
 func ProvideCreateInventoryHandler(repo domain.InventoryRepository) *command.CreateInventoryHandler {
 	return command.NewCreateInventoryHandler(repo)
@@ -34,6 +54,46 @@ func ProvideDeleteInventoryHandler(repo domain.InventoryRepository) *command.Del
 	return command.NewDeleteInventoryHandler(repo)
 }
 
+func ProvideRebuildQuantityHandler(repo domain.MovementRepository) *command.RebuildQuantityHandler {
+	return command.NewRebuildQuantityHandler(repo)
+}
+
+func ProvideSaveLocationHandler(repo domain.LocationRepository) *command.SaveLocationHandler {
+	return command.NewSaveLocationHandler(repo)
+}
+
+func ProvideReserveStockHandler(repo domain.ReservationRepository, strategy domain.AllocationStrategy) *command.ReserveStockHandler {
+	return command.NewReserveStockHandler(repo, strategy)
+}
+
+func ProvideReleaseStockHandler(repo domain.ReservationRepository) *command.ReleaseStockHandler {
+	return command.NewReleaseStockHandler(repo)
+}
+
+func ProvideCommitStockHandler(repo domain.ReservationRepository) *command.CommitStockHandler {
+	return command.NewCommitStockHandler(repo)
+}
+
+func ProvideTransferStockHandler(repo domain.TransferRepository) *command.TransferStockHandler {
+	return command.NewTransferStockHandler(repo)
+}
+
+func ProvideReceiveTransferHandler(repo domain.TransferRepository) *command.ReceiveTransferHandler {
+	return command.NewReceiveTransferHandler(repo)
+}
+
+func ProvideCancelTransferHandler(repo domain.TransferRepository) *command.CancelTransferHandler {
+	return command.NewCancelTransferHandler(repo)
+}
+
+func ProvideExpireReservationsHandler(repo domain.ReservationRepository) *command.ExpireReservationsHandler {
+	return command.NewExpireReservationsHandler(repo)
+}
+
+func ProvideRestockHandler(repo domain.InventoryRepository, reservations domain.ReservationRepository) *command.RestockHandler {
+	return command.NewRestockHandler(repo, reservations)
+}
+
 // Query Handlers Providers
 func ProvideGetInventoryHandler(repo domain.InventoryRepository) *query.GetInventoryHandler {
 	return query.NewGetInventoryHandler(repo)
@@ -43,6 +103,30 @@ func ProvideListInventoryHandler(repo domain.InventoryRepository) *query.ListInv
 	return query.NewListInventoryHandler(repo)
 }
 
+func ProvideListMovementsHandler(repo domain.MovementRepository) *query.ListMovementsHandler {
+	return query.NewListMovementsHandler(repo)
+}
+
+func ProvideGetStockHandler(repo domain.InventoryRepository) *query.GetStockHandler {
+	return query.NewGetStockHandler(repo)
+}
+
+func ProvideCheckAvailabilityHandler(repo domain.InventoryRepository, strategy domain.AllocationStrategy) *query.CheckAvailabilityHandler {
+	return query.NewCheckAvailabilityHandler(repo, strategy)
+}
+
+func ProvideListLocationsHandler(repo domain.LocationRepository) *query.ListLocationsHandler {
+	return query.NewListLocationsHandler(repo)
+}
+
+func ProvideGetTransferHandler(repo domain.TransferRepository) *query.GetTransferHandler {
+	return query.NewGetTransferHandler(repo)
+}
+
+func ProvideListTransfersHandler(repo domain.TransferRepository) *query.ListTransfersHandler {
+	return query.NewListTransfersHandler(repo)
+}
+
 // ProvideUserServiceClient provides the user service gRPC client
 func ProvideUserServiceClient(userServiceAddr string) (*client.UserServiceClient, error) {
 	return client.NewUserServiceClient(userServiceAddr)
@@ -51,17 +135,35 @@ func ProvideUserServiceClient(userServiceAddr string) (*client.UserServiceClient
 // Wire sets
 var RepositorySet = wire.NewSet(
 	ProvideInventoryRepository,
+	ProvideReservationRepository,
+	ProvideMovementRepository,
+	ProvideLocationRepository,
+	ProvideTransferRepository,
 )
 
 var CommandHandlerSet = wire.NewSet(
 	ProvideCreateInventoryHandler,
 	ProvideUpdateQuantityHandler,
 	ProvideDeleteInventoryHandler,
+	ProvideRebuildQuantityHandler,
+	ProvideSaveLocationHandler,
+	ProvideReserveStockHandler,
+	ProvideReleaseStockHandler,
+	ProvideCommitStockHandler,
+	ProvideTransferStockHandler,
+	ProvideReceiveTransferHandler,
+	ProvideCancelTransferHandler,
 )
 
 var QueryHandlerSet = wire.NewSet(
 	ProvideGetInventoryHandler,
 	ProvideListInventoryHandler,
+	ProvideListMovementsHandler,
+	ProvideGetStockHandler,
+	ProvideCheckAvailabilityHandler,
+	ProvideListLocationsHandler,
+	ProvideGetTransferHandler,
+	ProvideListTransfersHandler,
 )
 
 var AllHandlersSet = wire.NewSet(
@@ -71,7 +173,7 @@ var AllHandlersSet = wire.NewSet(
 )
 
 // InitializeHTTPHandler initializes HTTP handler with all dependencies
-func InitializeHTTPHandler(db *gorm.DB, userServiceAddr string) (*http.InventoryHandler, error) {
+func InitializeHTTPHandler(db *gorm.DB, userServiceAddr string, strategy domain.AllocationStrategy) (*http.InventoryHandler, error) {
 	wire.Build(
 		AllHandlersSet,
 		ProvideUserServiceClient,
@@ -80,8 +182,26 @@ func InitializeHTTPHandler(db *gorm.DB, userServiceAddr string) (*http.Inventory
 	return nil, nil
 }
 
+// InitializeReservationReaper initializes the handler used by the reservation reaper
+func InitializeReservationReaper(db *gorm.DB) *command.ExpireReservationsHandler {
+	wire.Build(
+		ProvideReservationRepository,
+		ProvideExpireReservationsHandler,
+	)
+	return nil
+}
+
+// InitializeRestockHandler initializes the handler used to restock failed and refunded payments
+func InitializeRestockHandler(db *gorm.DB) *command.RestockHandler {
+	wire.Build(
+		RepositorySet,
+		ProvideRestockHandler,
+	)
+	return nil
+}
+
 // InitializeGRPCServer initializes gRPC server with all dependencies
-func InitializeGRPCServer(db *gorm.DB) (*grpcDelivery.InventoryGRPCServer, error) {
+func InitializeGRPCServer(db *gorm.DB, strategy domain.AllocationStrategy) (*grpcDelivery.InventoryGRPCServer, error) {
 	wire.Build(
 		AllHandlersSet,
 		grpcDelivery.NewInventoryGRPCServer,
@@ -21,30 +21,71 @@ import (
 // Injectors from wire.go:
 
 // InitializeHTTPHandler initializes HTTP handler with all dependencies
-func InitializeHTTPHandler(db *gorm.DB, userServiceAddr string) (*http.InventoryHandler, error) {
+func InitializeHTTPHandler(db *gorm.DB, userServiceAddr string, strategy domain.AllocationStrategy) (*http.InventoryHandler, error) {
 	inventoryRepository := ProvideInventoryRepository(db)
 	createInventoryHandler := ProvideCreateInventoryHandler(inventoryRepository)
 	updateQuantityHandler := ProvideUpdateQuantityHandler(inventoryRepository)
 	deleteInventoryHandler := ProvideDeleteInventoryHandler(inventoryRepository)
+	movementRepository := ProvideMovementRepository(db)
+	rebuildQuantityHandler := ProvideRebuildQuantityHandler(movementRepository)
+	locationRepository := ProvideLocationRepository(db)
+	saveLocationHandler := ProvideSaveLocationHandler(locationRepository)
+	transferRepository := ProvideTransferRepository(db)
+	transferStockHandler := ProvideTransferStockHandler(transferRepository)
+	receiveTransferHandler := ProvideReceiveTransferHandler(transferRepository)
+	cancelTransferHandler := ProvideCancelTransferHandler(transferRepository)
 	getInventoryHandler := ProvideGetInventoryHandler(inventoryRepository)
 	listInventoryHandler := ProvideListInventoryHandler(inventoryRepository)
+	listMovementsHandler := ProvideListMovementsHandler(movementRepository)
+	getStockHandler := ProvideGetStockHandler(inventoryRepository)
+	checkAvailabilityHandler := ProvideCheckAvailabilityHandler(inventoryRepository, strategy)
+	listLocationsHandler := ProvideListLocationsHandler(locationRepository)
+	getTransferHandler := ProvideGetTransferHandler(transferRepository)
+	listTransfersHandler := ProvideListTransfersHandler(transferRepository)
 	userServiceClient, err := ProvideUserServiceClient(userServiceAddr)
 	if err != nil {
 		return nil, err
 	}
-	inventoryHandler := http.NewInventoryHandlerWithDI(createInventoryHandler, updateQuantityHandler, deleteInventoryHandler, getInventoryHandler, listInventoryHandler, inventoryRepository, userServiceClient)
+	inventoryHandler := http.NewInventoryHandlerWithDI(createInventoryHandler, updateQuantityHandler, deleteInventoryHandler, rebuildQuantityHandler, saveLocationHandler, transferStockHandler, receiveTransferHandler, cancelTransferHandler, getInventoryHandler, listInventoryHandler, listMovementsHandler, getStockHandler, checkAvailabilityHandler, listLocationsHandler, getTransferHandler, listTransfersHandler, inventoryRepository, userServiceClient)
 	return inventoryHandler, nil
 }
 
+// InitializeReservationReaper initializes the handler used by the reservation reaper
+func InitializeReservationReaper(db *gorm.DB) *command.ExpireReservationsHandler {
+	reservationRepository := ProvideReservationRepository(db)
+	expireReservationsHandler := ProvideExpireReservationsHandler(reservationRepository)
+	return expireReservationsHandler
+}
+
+// InitializeRestockHandler initializes the handler used to restock failed and refunded payments
+func InitializeRestockHandler(db *gorm.DB) *command.RestockHandler {
+	inventoryRepository := ProvideInventoryRepository(db)
+	reservationRepository := ProvideReservationRepository(db)
+	restockHandler := ProvideRestockHandler(inventoryRepository, reservationRepository)
+	return restockHandler
+}
+
 // InitializeGRPCServer initializes gRPC server with all dependencies
-func InitializeGRPCServer(db *gorm.DB) (*grpc.InventoryGRPCServer, error) {
+func InitializeGRPCServer(db *gorm.DB, strategy domain.AllocationStrategy) (*grpc.InventoryGRPCServer, error) {
 	inventoryRepository := ProvideInventoryRepository(db)
 	createInventoryHandler := ProvideCreateInventoryHandler(inventoryRepository)
 	updateQuantityHandler := ProvideUpdateQuantityHandler(inventoryRepository)
 	deleteInventoryHandler := ProvideDeleteInventoryHandler(inventoryRepository)
+	reservationRepository := ProvideReservationRepository(db)
+	reserveStockHandler := ProvideReserveStockHandler(reservationRepository, strategy)
+	releaseStockHandler := ProvideReleaseStockHandler(reservationRepository)
+	commitStockHandler := ProvideCommitStockHandler(reservationRepository)
+	transferRepository := ProvideTransferRepository(db)
+	transferStockHandler := ProvideTransferStockHandler(transferRepository)
+	receiveTransferHandler := ProvideReceiveTransferHandler(transferRepository)
+	cancelTransferHandler := ProvideCancelTransferHandler(transferRepository)
 	getInventoryHandler := ProvideGetInventoryHandler(inventoryRepository)
 	listInventoryHandler := ProvideListInventoryHandler(inventoryRepository)
-	inventoryGRPCServer := grpc.NewInventoryGRPCServer(createInventoryHandler, updateQuantityHandler, deleteInventoryHandler, getInventoryHandler, listInventoryHandler, inventoryRepository)
+	getStockHandler := ProvideGetStockHandler(inventoryRepository)
+	checkAvailabilityHandler := ProvideCheckAvailabilityHandler(inventoryRepository, strategy)
+	getTransferHandler := ProvideGetTransferHandler(transferRepository)
+	listTransfersHandler := ProvideListTransfersHandler(transferRepository)
+	inventoryGRPCServer := grpc.NewInventoryGRPCServer(createInventoryHandler, updateQuantityHandler, deleteInventoryHandler, reserveStockHandler, releaseStockHandler, commitStockHandler, transferStockHandler, receiveTransferHandler, cancelTransferHandler, getInventoryHandler, listInventoryHandler, getStockHandler, checkAvailabilityHandler, getTransferHandler, listTransfersHandler, inventoryRepository)
 	return inventoryGRPCServer, nil
 }
 
@@ -55,6 +96,26 @@ func ProvideInventoryRepository(db *gorm.DB) domain.InventoryRepository {
 	return repository.NewGormInventoryRepository(db)
 }
 
+// ProvideReservationRepository provides the reservation repository
+func ProvideReservationRepository(db *gorm.DB) domain.ReservationRepository {
+	return repository.NewGormReservationRepository(db)
+}
+
+// ProvideMovementRepository provides the inventory movement ledger repository
+func ProvideMovementRepository(db *gorm.DB) domain.MovementRepository {
+	return repository.NewGormMovementRepository(db)
+}
+
+// ProvideLocationRepository provides the location repository
+func ProvideLocationRepository(db *gorm.DB) domain.LocationRepository {
+	return repository.NewGormLocationRepository(db)
+}
+
+// ProvideTransferRepository provides the stock transfer repository
+func ProvideTransferRepository(db *gorm.DB) domain.TransferRepository {
+	return repository.NewGormTransferRepository(db)
+}
+
 // Command Handlers Providers
 func ProvideCreateInventoryHandler(repo domain.InventoryRepository) *command.CreateInventoryHandler {
 	return command.NewCreateInventoryHandler(repo)
@@ -68,6 +129,46 @@ func ProvideDeleteInventoryHandler(repo domain.InventoryRepository) *command.Del
 	return command.NewDeleteInventoryHandler(repo)
 }
 
+func ProvideRebuildQuantityHandler(repo domain.MovementRepository) *command.RebuildQuantityHandler {
+	return command.NewRebuildQuantityHandler(repo)
+}
+
+func ProvideSaveLocationHandler(repo domain.LocationRepository) *command.SaveLocationHandler {
+	return command.NewSaveLocationHandler(repo)
+}
+
+func ProvideReserveStockHandler(repo domain.ReservationRepository, strategy domain.AllocationStrategy) *command.ReserveStockHandler {
+	return command.NewReserveStockHandler(repo, strategy)
+}
+
+func ProvideReleaseStockHandler(repo domain.ReservationRepository) *command.ReleaseStockHandler {
+	return command.NewReleaseStockHandler(repo)
+}
+
+func ProvideCommitStockHandler(repo domain.ReservationRepository) *command.CommitStockHandler {
+	return command.NewCommitStockHandler(repo)
+}
+
+func ProvideTransferStockHandler(repo domain.TransferRepository) *command.TransferStockHandler {
+	return command.NewTransferStockHandler(repo)
+}
+
+func ProvideReceiveTransferHandler(repo domain.TransferRepository) *command.ReceiveTransferHandler {
+	return command.NewReceiveTransferHandler(repo)
+}
+
+func ProvideCancelTransferHandler(repo domain.TransferRepository) *command.CancelTransferHandler {
+	return command.NewCancelTransferHandler(repo)
+}
+
+func ProvideExpireReservationsHandler(repo domain.ReservationRepository) *command.ExpireReservationsHandler {
+	return command.NewExpireReservationsHandler(repo)
+}
+
+func ProvideRestockHandler(repo domain.InventoryRepository, reservations domain.ReservationRepository) *command.RestockHandler {
+	return command.NewRestockHandler(repo, reservations)
+}
+
 // Query Handlers Providers
 func ProvideGetInventoryHandler(repo domain.InventoryRepository) *query.GetInventoryHandler {
 	return query.NewGetInventoryHandler(repo)
@@ -77,6 +178,30 @@ func ProvideListInventoryHandler(repo domain.InventoryRepository) *query.ListInv
 	return query.NewListInventoryHandler(repo)
 }
 
+func ProvideListMovementsHandler(repo domain.MovementRepository) *query.ListMovementsHandler {
+	return query.NewListMovementsHandler(repo)
+}
+
+func ProvideGetStockHandler(repo domain.InventoryRepository) *query.GetStockHandler {
+	return query.NewGetStockHandler(repo)
+}
+
+func ProvideCheckAvailabilityHandler(repo domain.InventoryRepository, strategy domain.AllocationStrategy) *query.CheckAvailabilityHandler {
+	return query.NewCheckAvailabilityHandler(repo, strategy)
+}
+
+func ProvideListLocationsHandler(repo domain.LocationRepository) *query.ListLocationsHandler {
+	return query.NewListLocationsHandler(repo)
+}
+
+func ProvideGetTransferHandler(repo domain.TransferRepository) *query.GetTransferHandler {
+	return query.NewGetTransferHandler(repo)
+}
+
+func ProvideListTransfersHandler(repo domain.TransferRepository) *query.ListTransfersHandler {
+	return query.NewListTransfersHandler(repo)
+}
+
 // ProvideUserServiceClient provides the user service gRPC client
 func ProvideUserServiceClient(userServiceAddr string) (*client.UserServiceClient, error) {
 	return client.NewUserServiceClient(userServiceAddr)
@@ -85,17 +210,35 @@ func ProvideUserServiceClient(userServiceAddr string) (*client.UserServiceClient
 // Wire sets
 var RepositorySet = wire.NewSet(
 	ProvideInventoryRepository,
+	ProvideReservationRepository,
+	ProvideMovementRepository,
+	ProvideLocationRepository,
+	ProvideTransferRepository,
 )
 
 var CommandHandlerSet = wire.NewSet(
 	ProvideCreateInventoryHandler,
 	ProvideUpdateQuantityHandler,
 	ProvideDeleteInventoryHandler,
+	ProvideRebuildQuantityHandler,
+	ProvideSaveLocationHandler,
+	ProvideReserveStockHandler,
+	ProvideReleaseStockHandler,
+	ProvideCommitStockHandler,
+	ProvideTransferStockHandler,
+	ProvideReceiveTransferHandler,
+	ProvideCancelTransferHandler,
 )
 
 var QueryHandlerSet = wire.NewSet(
 	ProvideGetInventoryHandler,
 	ProvideListInventoryHandler,
+	ProvideListMovementsHandler,
+	ProvideGetStockHandler,
+	ProvideCheckAvailabilityHandler,
+	ProvideListLocationsHandler,
+	ProvideGetTransferHandler,
+	ProvideListTransfersHandler,
 )
 
 var AllHandlersSet = wire.NewSet(
@@ -72,14 +72,16 @@ func (c *InventoryServiceClient) GetByProductID(ctx context.Context, productID u
 	return resp.Inventory, nil
 }
 
-// CheckAvailability checks if a product is available with required quantity
-func (c *InventoryServiceClient) CheckAvailability(ctx context.Context, productID uint, requiredQuantity int32) (bool, int32, string, error) {
+// CheckAvailability checks if a product is available with required quantity,
+// shipped to region when it is not empty
+func (c *InventoryServiceClient) CheckAvailability(ctx context.Context, productID uint, requiredQuantity int32, region string) (bool, int32, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	req := &pb.CheckAvailabilityRequest{
 		ProductId:        uint32(productID),
 		RequiredQuantity: requiredQuantity,
+		Region:           region,
 	}
 
 	resp, err := c.client.CheckAvailability(ctx, req)
@@ -90,8 +92,9 @@ func (c *InventoryServiceClient) CheckAvailability(ctx context.Context, productI
 	return resp.Available, resp.CurrentQuantity, resp.Message, nil
 }
 
-// ReserveStock reserves stock for a product
-func (c *InventoryServiceClient) ReserveStock(ctx context.Context, productID uint, quantity int32, reservationID string) (bool, string, error) {
+// ReserveStock reserves stock for a product, allocated for shipping to region
+// when it is not empty
+func (c *InventoryServiceClient) ReserveStock(ctx context.Context, productID uint, quantity int32, reservationID, region string) (bool, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
@@ -99,6 +102,7 @@ func (c *InventoryServiceClient) ReserveStock(ctx context.Context, productID uin
 		ProductId:     uint32(productID),
 		Quantity:      quantity,
 		ReservationId: reservationID,
+		Region:        region,
 	}
 
 	resp, err := c.client.ReserveStock(ctx, req)
@@ -128,6 +132,23 @@ func (c *InventoryServiceClient) ReleaseStock(ctx context.Context, productID uin
 	return resp.Success, resp.Message, nil
 }
 
+// CommitStock makes a reservation permanent
+func (c *InventoryServiceClient) CommitStock(ctx context.Context, reservationID string) (bool, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	req := &pb.CommitStockRequest{
+		ReservationId: reservationID,
+	}
+
+	resp, err := c.client.CommitStock(ctx, req)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to commit stock: %w", err)
+	}
+
+	return resp.Success, resp.Message, nil
+}
+
 // GetInventory gets inventory by ID
 func (c *InventoryServiceClient) GetInventory(ctx context.Context, inventoryID uint) (*pb.Inventory, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
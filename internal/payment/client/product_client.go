@@ -2,17 +2,22 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 
 	pb "github.com/tair/full-observability/api/proto/product"
+	"github.com/tair/full-observability/internal/payment/domain"
 	"github.com/tair/full-observability/pkg/logger"
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // ProductServiceClient wraps the gRPC client for product service
@@ -74,6 +79,26 @@ func (c *ProductServiceClient) GetProduct(ctx context.Context, productID uint) (
 	return resp.Product, nil
 }
 
+// GetProductSnapshot gets the catalog data copied onto order lines. A product
+// the service does not know is reported as domain.ErrProductUnavailable.
+func (c *ProductServiceClient) GetProductSnapshot(ctx context.Context, productID uint) (*domain.ProductSnapshot, error) {
+	product, err := c.GetProduct(ctx, productID)
+	if err != nil {
+		if status.Code(errors.Unwrap(err)) == codes.NotFound {
+			return nil, fmt.Errorf("product %d: %w", productID, domain.ErrProductUnavailable)
+		}
+		return nil, err
+	}
+
+	return &domain.ProductSnapshot{
+		ProductID: uint(product.GetId()),
+		Name:      product.GetName(),
+		SKU:       product.GetSku(),
+		Price:     productPrice(product),
+		IsActive:  product.GetIsActive(),
+	}, nil
+}
+
 // CheckAvailability checks if a product is available with required quantity
 func (c *ProductServiceClient) CheckAvailability(ctx context.Context, productID uint, quantity int32) (bool, int32, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
@@ -132,3 +157,12 @@ func (c *ProductServiceClient) ListProducts(ctx context.Context, limit, offset i
 
 	return resp.Products, nil
 }
+
+// productPrice returns the exact price of a product, falling back to the
+// major-unit price of product services that predate minor units
+func productPrice(product *pb.Product) money.Money {
+	if price := product.GetPriceMoney(); price != nil {
+		return money.New(price.GetMinor(), price.GetCurrency())
+	}
+	return money.FromMajor(product.GetPrice(), money.DefaultCurrency)
+}
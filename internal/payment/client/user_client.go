@@ -6,8 +6,10 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 
@@ -78,6 +80,23 @@ func (c *UserServiceClient) GetUser(ctx context.Context, userID uint, token stri
 	return resp.User, nil
 }
 
+// AccountCreatedAt returns when a user's account was created, for the risk
+// check. Accounts that are not found or have no creation time are reported as
+// created now, so that they are checked as new accounts.
+func (c *UserServiceClient) AccountCreatedAt(ctx context.Context, userID uint, token string) (time.Time, error) {
+	user, err := c.GetUser(ctx, userID, token)
+	if status.Code(err) == codes.NotFound {
+		return time.Now(), nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if user.CreatedAt == nil {
+		return time.Now(), nil
+	}
+	return user.CreatedAt.AsTime(), nil
+}
+
 // ValidateUserRole validates if a user has the required role
 func (c *UserServiceClient) ValidateUserRole(ctx context.Context, userID uint, token string, requiredRole string) (bool, error) {
 	user, err := c.GetUser(ctx, userID, token)
@@ -0,0 +1,338 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+
+	moneypb "github.com/tair/full-observability/api/proto/money"
+	pb "github.com/tair/full-observability/api/proto/payment"
+	"github.com/tair/full-observability/internal/payment/client"
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/internal/payment/risk"
+	"github.com/tair/full-observability/internal/payment/usecase/command"
+	"github.com/tair/full-observability/internal/payment/usecase/query"
+	"github.com/tair/full-observability/pkg/logger"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// PaymentServer implements the gRPC PaymentService on the same command and
+// query handlers as the HTTP API
+type PaymentServer struct {
+	pb.UnimplementedPaymentServiceServer
+
+	// Command handlers
+	checkoutHandler     *command.CheckoutHandler
+	updateStatusHandler *command.UpdateStatusHandler
+	refundHandler       *command.RefundPaymentHandler
+
+	// Query handlers
+	getHandler  *query.GetPaymentHandler
+	listHandler *query.ListPaymentsHandler
+
+	trustedProxies risk.TrustedProxies
+	userClient     *client.UserServiceClient // looks up the payer's account age
+}
+
+// NewPaymentServerWithDI creates a new gRPC payment server using dependency injection
+// This is used by Wire for automatic dependency injection
+func NewPaymentServerWithDI(
+	checkoutHandler *command.CheckoutHandler,
+	updateStatusHandler *command.UpdateStatusHandler,
+	refundHandler *command.RefundPaymentHandler,
+	getHandler *query.GetPaymentHandler,
+	listHandler *query.ListPaymentsHandler,
+	trustedProxies risk.TrustedProxies,
+	userClient *client.UserServiceClient,
+) *PaymentServer {
+	return &PaymentServer{
+		checkoutHandler:     checkoutHandler,
+		updateStatusHandler: updateStatusHandler,
+		refundHandler:       refundHandler,
+		getHandler:          getHandler,
+		listHandler:         listHandler,
+		trustedProxies:      trustedProxies,
+		userClient:          userClient,
+	}
+}
+
+// CreatePayment runs the checkout saga for a product. The payment is made by
+// the caller; only admins may pay on behalf of another user.
+func (s *PaymentServer) CreatePayment(ctx context.Context, req *pb.CreatePaymentRequest) (*pb.PaymentResponse, error) {
+	if req.ProductId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "product_id is required")
+	}
+	if req.Quantity <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must be greater than 0")
+	}
+
+	userID, _ := ctx.Value(UserIDKey).(uint)
+	if role, _ := ctx.Value(RoleKey).(string); role == "admin" && req.UserId != 0 {
+		userID = uint(req.UserId)
+	}
+	if userID == 0 {
+		return nil, status.Error(codes.PermissionDenied, "caller is not identified")
+	}
+
+	cmd := command.CheckoutCommand{
+		UserID:         userID,
+		ProductID:      uint(req.ProductId),
+		Quantity:       req.Quantity,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		PaymentMethod:  req.PaymentMethod,
+		CardNumber:     req.CardNumber,
+		Origin:         s.paymentOrigin(ctx, userID),
+		ShippingRegion: req.ShippingRegion,
+	}
+
+	payment, err := s.checkoutHandler.Handle(ctx, cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInsufficientStock), errors.Is(err, domain.ErrProductUnavailable),
+			errors.Is(err, domain.ErrPaymentDeclined):
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to create payment: %v", err)
+		case errors.Is(err, domain.ErrProviderTimeout):
+			return nil, status.Errorf(codes.DeadlineExceeded, "failed to create payment: %v", err)
+		case errors.Is(err, domain.ErrPaymentRiskDenied):
+			return nil, status.Errorf(codes.PermissionDenied, "failed to create payment: %v", err)
+		case errors.Is(err, domain.ErrCaptureNotRecorded):
+			// Charged: the checkout completes in the background, so the
+			// client must not retry it
+			return &pb.PaymentResponse{Payment: domainPaymentToProto(payment)}, nil
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "failed to create payment: %v", err)
+	}
+
+	return &pb.PaymentResponse{
+		Payment: domainPaymentToProto(payment),
+	}, nil
+}
+
+// GetPayment retrieves a payment by ID
+func (s *PaymentServer) GetPayment(ctx context.Context, req *pb.GetPaymentRequest) (*pb.PaymentResponse, error) {
+	payment, err := s.getHandler.Handle(query.GetPaymentQuery{ID: uint(req.Id)})
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "payment not found: %v", err)
+	}
+
+	return &pb.PaymentResponse{
+		Payment: domainPaymentToProto(payment),
+	}, nil
+}
+
+// ListPayments searches payments. Callers without the admin role only see
+// their own payments.
+func (s *PaymentServer) ListPayments(ctx context.Context, req *pb.ListPaymentsRequest) (*pb.ListPaymentsResponse, error) {
+	q := query.ListPaymentsQuery{
+		UserID: uint(req.UserId),
+		PaymentSearchParams: query.PaymentSearchParams{
+			Statuses:       req.Statuses,
+			PaymentMethods: req.PaymentMethods,
+			RiskDecisions:  req.RiskDecisions,
+			Currency:       req.Currency,
+			MinAmount:      req.MinAmount,
+			MaxAmount:      req.MaxAmount,
+			Sort:           req.Sort,
+			Cursor:         req.Cursor,
+			Limit:          int(req.Limit),
+		},
+	}
+	if req.CreatedFrom != nil {
+		from := req.CreatedFrom.AsTime()
+		q.CreatedFrom = &from
+	}
+	if req.CreatedTo != nil {
+		to := req.CreatedTo.AsTime()
+		q.CreatedTo = &to
+	}
+	if role, _ := ctx.Value(RoleKey).(string); role != "admin" {
+		q.UserID, _ = ctx.Value(UserIDKey).(uint)
+		if q.UserID == 0 {
+			return nil, status.Error(codes.PermissionDenied, "caller is not identified")
+		}
+	}
+
+	page, err := s.listHandler.Handle(q)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSearch) {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to list payments: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to list payments: %v", err)
+	}
+
+	payments := make([]*pb.Payment, 0, len(page.Payments))
+	for i := range page.Payments {
+		payments = append(payments, domainPaymentToProto(&page.Payments[i]))
+	}
+
+	return &pb.ListPaymentsResponse{
+		Payments:   payments,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}, nil
+}
+
+// UpdatePaymentStatus moves a payment to a new status and returns it
+func (s *PaymentServer) UpdatePaymentStatus(ctx context.Context, req *pb.UpdatePaymentStatusRequest) (*pb.PaymentResponse, error) {
+	actorUserID, _ := ctx.Value(UserIDKey).(uint)
+	cmd := command.UpdateStatusCommand{
+		PaymentID:   uint(req.Id),
+		Status:      req.Status,
+		ActorUserID: actorUserID,
+		Reason:      req.Reason,
+	}
+
+	if err := s.updateStatusHandler.Handle(ctx, cmd); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, status.Errorf(codes.NotFound, "payment not found: %v", err)
+		case errors.Is(err, domain.ErrInvalidStatusTransition):
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to update payment status: %v", err)
+		case errors.Is(err, domain.ErrRefundFailed):
+			return nil, status.Errorf(providerErrorCode(err), "failed to update payment status: %v", err)
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "failed to update payment status: %v", err)
+	}
+
+	payment, err := s.getHandler.Handle(query.GetPaymentQuery{ID: uint(req.Id)})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to reload payment: %v", err)
+	}
+
+	return &pb.PaymentResponse{
+		Payment: domainPaymentToProto(payment),
+	}, nil
+}
+
+// RefundPayment refunds part or all of a completed payment
+func (s *PaymentServer) RefundPayment(ctx context.Context, req *pb.RefundPaymentRequest) (*pb.RefundResponse, error) {
+	actorUserID, _ := ctx.Value(UserIDKey).(uint)
+	cmd := command.RefundPaymentCommand{
+		PaymentID:   uint(req.PaymentId),
+		Amount:      req.Amount,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		ActorUserID: actorUserID,
+	}
+	for _, line := range req.Lines {
+		cmd.Lines = append(cmd.Lines, command.RefundLineInput{ProductID: uint(line.ProductId), Quantity: line.Quantity})
+	}
+
+	refund, err := s.refundHandler.Handle(ctx, cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, status.Errorf(codes.NotFound, "payment not found: %v", err)
+		case errors.Is(err, domain.ErrPaymentNotRefundable), errors.Is(err, domain.ErrRefundExceedsPayment):
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to refund payment: %v", err)
+		case errors.Is(err, domain.ErrRefundFailed):
+			return nil, status.Errorf(providerErrorCode(err), "failed to refund payment: %v", err)
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "failed to refund payment: %v", err)
+	}
+
+	return &pb.RefundResponse{
+		Refund: domainRefundToProto(refund),
+	}, nil
+}
+
+// paymentOrigin describes the payment for the risk check: the peer address,
+// or the x-forwarded-for client when the peer is a trusted proxy, and the age
+// of the payer's account
+func (s *PaymentServer) paymentOrigin(ctx context.Context, userID uint) domain.PaymentOrigin {
+	origin := domain.PaymentOrigin{AccountCreatedAt: s.accountCreatedAt(ctx, userID)}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return origin
+	}
+	var forwarded []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		forwarded = md.Get("x-forwarded-for")
+	}
+	origin.ClientIP = s.trustedProxies.ClientIP(p.Addr.String(), forwarded)
+	return origin
+}
+
+// accountCreatedAt looks up when the payer's account was created. When the
+// lookup fails the account is checked as new.
+func (s *PaymentServer) accountCreatedAt(ctx context.Context, userID uint) *time.Time {
+	createdAt := time.Now()
+	if s.userClient != nil {
+		token, _ := ctx.Value(TokenKey).(string)
+		found, err := s.userClient.AccountCreatedAt(ctx, userID, token)
+		if err != nil {
+			logger.Warn(ctx).
+				Err(err).
+				Uint("user_id", userID).
+				Msg("Failed to look up account age, checking the payment as a new account")
+		} else {
+			createdAt = found
+		}
+	}
+	return &createdAt
+}
+
+// providerErrorCode maps a payment provider failure to a gRPC code
+func providerErrorCode(err error) codes.Code {
+	if errors.Is(err, domain.ErrProviderTimeout) {
+		return codes.DeadlineExceeded
+	}
+	return codes.Unavailable
+}
+
+// Helper functions
+
+func domainPaymentToProto(payment *domain.Payment) *pb.Payment {
+	result := &pb.Payment{
+		Id:            uint32(payment.ID),
+		UserId:        uint32(payment.UserID),
+		OrderId:       payment.OrderID,
+		Amount:        moneyToProto(payment.Amount),
+		Status:        payment.Status,
+		PaymentMethod: payment.PaymentMethod,
+		TransactionId: payment.TransactionID,
+		Provider:      payment.Provider,
+		CardLast4:     payment.CardLast4,
+		ProductId:     uint32(payment.ProductID),
+		Quantity:      payment.Quantity,
+		ReservationId: payment.ReservationID,
+		CreatedAt:     timestamppb.New(payment.CreatedAt),
+		UpdatedAt:     timestamppb.New(payment.UpdatedAt),
+		RiskDecision:  payment.RiskDecision,
+		RiskReasons:   payment.RiskReasons,
+	}
+	if !payment.RefundedAmount.IsZero() {
+		result.RefundedAmount = moneyToProto(payment.RefundedAmount)
+	}
+	return result
+}
+
+func domainRefundToProto(refund *domain.Refund) *pb.Refund {
+	lines := make([]*pb.RefundLine, 0, len(refund.Lines))
+	for _, line := range refund.Lines {
+		lines = append(lines, &pb.RefundLine{ProductId: uint32(line.ProductID), Quantity: line.Quantity})
+	}
+	return &pb.Refund{
+		Id:                uint32(refund.ID),
+		PaymentId:         uint32(refund.PaymentID),
+		Amount:            moneyToProto(refund.Amount),
+		Quantity:          refund.Quantity,
+		Reason:            refund.Reason,
+		Status:            refund.Status,
+		ProviderReference: refund.ProviderReference,
+		CreatedAt:         timestamppb.New(refund.CreatedAt),
+		Lines:             lines,
+	}
+}
+
+func moneyToProto(amount money.Money) *moneypb.Money {
+	return &moneypb.Money{Minor: amount.Minor, Currency: amount.Currency}
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// CheckoutSaga tracks an orchestrated checkout (reserve stock -> charge -> confirm)
+// so that a crashed payment pod can resume or compensate it on restart
+type CheckoutSaga struct {
+	ID             uint               `json:"id" gorm:"primaryKey"`
+	ReservationID  string             `json:"reservation_id" gorm:"not null;uniqueIndex"`
+	OrderID        string             `json:"order_id" gorm:"not null;uniqueIndex"`
+	UserID         uint               `json:"user_id" gorm:"not null;index"`
+	ProductID      uint               `json:"product_id" gorm:"not null"`
+	Quantity       int32              `json:"quantity" gorm:"not null"`
+	Amount         money.Money        `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	PaymentMethod  string             `json:"payment_method"`
+	ShippingRegion string             `json:"shipping_region,omitempty" gorm:"size:32"`
+	PaymentID      *uint              `json:"payment_id,omitempty" gorm:"index"`
+	Step           string             `json:"step" gorm:"not null"`
+	Status         string             `json:"status" gorm:"not null;index"`
+	LastError      string             `json:"last_error,omitempty"`
+	Steps          []CheckoutSagaStep `json:"steps,omitempty" gorm:"foreignKey:SagaID"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (CheckoutSaga) TableName() string {
+	return "checkout_sagas"
+}
+
+// CheckoutSagaStep is an append-only record of a single saga step outcome
+type CheckoutSagaStep struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SagaID    uint      `json:"saga_id" gorm:"not null;index"`
+	Step      string    `json:"step" gorm:"not null"`
+	Outcome   string    `json:"outcome" gorm:"not null"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (CheckoutSagaStep) TableName() string {
+	return "checkout_saga_steps"
+}
+
+// Saga steps
+const (
+	SagaStepReserveStock = "reserve_stock"
+	SagaStepCharge       = "charge"
+	SagaStepConfirm      = "confirm"
+	SagaStepReleaseStock = "release_stock"
+)
+
+// Saga step outcomes
+const (
+	SagaOutcomeStarted   = "started"
+	SagaOutcomeSucceeded = "succeeded"
+	SagaOutcomeFailed    = "failed"
+)
+
+// Saga statuses
+const (
+	SagaStatusRunning      = "running"
+	SagaStatusCompleted    = "completed"
+	SagaStatusCompensating = "compensating"
+	SagaStatusCompensated  = "compensated"
+)
+
+// ErrInsufficientStock is returned when the inventory service refuses a reservation
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// IsTerminal reports whether the saga needs no further work
+func (s *CheckoutSaga) IsTerminal() bool {
+	return s.Status == SagaStatusCompleted || s.Status == SagaStatusCompensated
+}
+
+// CheckoutSagaRepository defines the contract for checkout saga persistence
+type CheckoutSagaRepository interface {
+	Create(saga *CheckoutSaga) error
+	Update(saga *CheckoutSaga) error
+	FindByID(id uint) (*CheckoutSaga, error)
+	// FindIncomplete returns up to limit unfinished sagas with an ID above
+	// afterID that were last updated before idleSince, ordered by ID
+	FindIncomplete(idleSince time.Time, afterID uint, limit int) ([]CheckoutSaga, error)
+	RecordStep(sagaID uint, step, outcome, errMsg string) error
+}
+
+// StockReservationService is the subset of the inventory service used by the
+// checkout saga. Stock is reserved at the locations the inventory service
+// allocates for shipping to region, which may be empty.
+type StockReservationService interface {
+	ReserveStock(ctx context.Context, productID uint, quantity int32, reservationID, region string) (bool, string, error)
+	ReleaseStock(ctx context.Context, productID uint, quantity int32, reservationID string) (bool, string, error)
+	CommitStock(ctx context.Context, reservationID string) (bool, string, error)
+}
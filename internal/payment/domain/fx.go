@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// ErrFXRateUnavailable is returned when no exchange rate is known for a
+// currency pair
+var ErrFXRateUnavailable = errors.New("exchange rate unavailable")
+
+// FXRate is the price of one major unit of From in major units of To
+type FXRate struct {
+	From   string
+	To     string
+	Rate   float64
+	Source string    // provider that quoted the rate, e.g. "static:rates.json"
+	AsOf   time.Time // when the rate was published
+}
+
+// FXRateProvider quotes exchange rates for converting catalog prices into the
+// currency a customer pays in. It fails with ErrFXRateUnavailable for unknown
+// currency pairs.
+type FXRateProvider interface {
+	Rate(ctx context.Context, from, to string) (*FXRate, error)
+}
+
+// FXConversion records how a catalog subtotal was converted into the charged
+// currency, for reconciliation against the provider's settlement
+type FXConversion struct {
+	From   money.Money `json:"from"` // subtotal in the catalog currency
+	Rate   float64     `json:"rate"`
+	Source string      `json:"source"`
+	AsOf   time.Time   `json:"as_of"`
+}
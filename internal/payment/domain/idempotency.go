@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+)
+
+// Idempotency key states
+const (
+	IdempotencyInProgress = "in_progress"
+	IdempotencyCompleted  = "completed"
+)
+
+// IdempotencyKey remembers the request made with a client-supplied
+// Idempotency-Key and the response it produced, so that retries of the same
+// request get the original response instead of repeating its effects
+type IdempotencyKey struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Scope          string    `json:"scope" gorm:"not null;uniqueIndex:idx_idempotency_keys_scope_key,priority:1"` // caller and endpoint the key belongs to
+	Key            string    `json:"key" gorm:"not null;uniqueIndex:idx_idempotency_keys_scope_key,priority:2"`
+	RequestHash    string    `json:"request_hash" gorm:"not null"`
+	Status         string    `json:"status" gorm:"not null"`
+	ResponseStatus int       `json:"response_status"`
+	ResponseBody   []byte    `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at" gorm:"not null;index"`
+}
+
+// TableName specifies the table name
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// IdempotencyKeyRepository stores idempotency keys
+type IdempotencyKeyRepository interface {
+	// Claim stores the key as in progress. When an unexpired key with the same
+	// scope already exists it is returned instead and claimed is false.
+	Claim(key *IdempotencyKey) (existing *IdempotencyKey, claimed bool, err error)
+	// Complete records the response of a claimed key
+	Complete(scope, key string, responseStatus int, responseBody []byte) error
+	// Release deletes a claimed key so that the request can be retried
+	Release(scope, key string) error
+	// PurgeExpired deletes keys that expired before now
+	PurgeExpired(now time.Time) (int64, error)
+}
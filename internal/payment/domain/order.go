@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// Order is a customer's purchase of one or more products. It is paid by a
+// single payment sharing its order number, and is checked out as a saga that
+// reserves the stock of every line before charging.
+type Order struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	OrderNumber    string      `json:"order_number" gorm:"not null;uniqueIndex"` // Payment.OrderID of the order's payment
+	UserID         uint        `json:"user_id" gorm:"not null;index"`
+	Status         string      `json:"status" gorm:"not null;index"`
+	Step           string      `json:"step" gorm:"not null"` // checkout saga step, see SagaStep*
+	Subtotal       money.Money `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+	TaxAmount      money.Money `json:"tax_amount" gorm:"embedded;embeddedPrefix:tax_amount_"`
+	DiscountAmount money.Money `json:"discount_amount" gorm:"embedded;embeddedPrefix:discount_amount_"` // positive amount taken off the subtotal
+	Total          money.Money `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	PaymentMethod  string      `json:"payment_method"`
+	ShippingRegion string      `json:"shipping_region,omitempty" gorm:"size:32"`
+	PaymentID      *uint       `json:"payment_id,omitempty" gorm:"index"`
+	LastError      string      `json:"last_error,omitempty"`
+	Lines          []OrderLine `json:"lines" gorm:"foreignKey:OrderID"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Order) TableName() string {
+	return "orders"
+}
+
+// OrderLine is a product in an order with the price it had when the order was placed
+type OrderLine struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	OrderID       uint        `json:"order_id" gorm:"not null;index"`
+	ProductID     uint        `json:"product_id" gorm:"not null;index"`
+	ProductName   string      `json:"product_name"`
+	SKU           string      `json:"sku,omitempty"`
+	UnitPrice     money.Money `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+	Quantity      int32       `json:"quantity" gorm:"not null"`
+	LineTotal     money.Money `json:"line_total" gorm:"embedded;embeddedPrefix:line_total_"`
+	ReservationID string      `json:"reservation_id" gorm:"not null;uniqueIndex"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (OrderLine) TableName() string {
+	return "order_lines"
+}
+
+// Order statuses
+const (
+	OrderStatusPending    = "pending"    // reserving stock or charging
+	OrderStatusPaid       = "paid"       // charged, committing the reserved stock
+	OrderStatusCompleted  = "completed"  // charged and stock committed
+	OrderStatusCancelling = "cancelling" // releasing the stock of every line
+	OrderStatusCancelled  = "cancelled"
+)
+
+// MaxOrderLines bounds the number of lines in a single order
+const MaxOrderLines = 50
+
+// MaxLineQuantity bounds the number of items of a product in one payment or
+// order line, keeping their price far from overflowing
+const MaxLineQuantity = 10000
+
+// Order errors
+var (
+	ErrProductUnavailable = errors.New("product is not available")
+	ErrDuplicateOrderLine = errors.New("product is listed more than once")
+)
+
+// IsTerminal reports whether the order checkout needs no further work
+func (o *Order) IsTerminal() bool {
+	return o.Status == OrderStatusCompleted || o.Status == OrderStatusCancelled
+}
+
+// Currency returns the currency the order is priced in
+func (o *Order) Currency() string {
+	return o.Total.Currency
+}
+
+// CalculateSubtotal sets the line totals and the order subtotal from the unit
+// price snapshots. Every line must be priced in currency.
+func (o *Order) CalculateSubtotal(currency string) error {
+	subtotal := money.Zero(currency)
+	for i := range o.Lines {
+		line := &o.Lines[i]
+		var err error
+		if line.LineTotal, err = line.UnitPrice.Mul(int64(line.Quantity)); err != nil {
+			return fmt.Errorf("product %d: %w", line.ProductID, err)
+		}
+		if subtotal, err = subtotal.Add(line.LineTotal); err != nil {
+			return fmt.Errorf("product %d: %w", line.ProductID, err)
+		}
+	}
+	o.Subtotal = subtotal
+	o.Total = subtotal
+	return nil
+}
+
+// ApplyQuote records the taxes, discounts and total priced for the subtotal.
+// The subtotal is replaced by its conversion when the quote is in another
+// currency than the lines.
+func (o *Order) ApplyQuote(quote *PriceQuote) {
+	o.Subtotal = quote.Subtotal
+	o.TaxAmount = quote.AdjustmentTotal(AdjustmentTax)
+	o.DiscountAmount = quote.AdjustmentTotal(AdjustmentDiscount).Neg()
+	o.Total = quote.Total
+}
+
+// ProductSnapshot is the catalog data of a product copied onto an order line
+type ProductSnapshot struct {
+	ProductID uint
+	Name      string
+	SKU       string
+	Price     money.Money
+	IsActive  bool
+}
+
+// ProductCatalog is the subset of the product service used to price orders
+type ProductCatalog interface {
+	GetProductSnapshot(ctx context.Context, productID uint) (*ProductSnapshot, error)
+}
+
+// OrderRepository defines the contract for order persistence
+type OrderRepository interface {
+	// Create inserts the order together with its lines
+	Create(order *Order) error
+	// Update saves the order's own columns; lines are immutable
+	Update(order *Order) error
+	FindByID(id uint) (*Order, error)
+	// FindByOrderNumber returns the order paid by the payment with the given
+	// order ID
+	FindByOrderNumber(orderNumber string) (*Order, error)
+	FindByUserID(userID uint, limit, offset int) ([]Order, error)
+	// FindIncomplete returns up to limit orders whose checkout saga has not
+	// finished, with an ID above afterID and last updated before idleSince,
+	// ordered by ID
+	FindIncomplete(idleSince time.Time, afterID uint, limit int) ([]Order, error)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+)
+
+// OutboxEvent is an integration event written in the same transaction as the
+// state change it describes and published to Kafka afterwards by the relay
+type OutboxEvent struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	EventID       string            `json:"event_id" gorm:"not null;uniqueIndex"`
+	EventType     string            `json:"event_type" gorm:"not null"`
+	Topic         string            `json:"topic" gorm:"not null"`
+	MessageKey    string            `json:"message_key"`
+	Payload       []byte            `json:"payload" gorm:"not null"`
+	Headers       map[string]string `json:"headers" gorm:"serializer:json"` // trace context and envelope headers captured at write time
+	Status        string            `json:"status" gorm:"not null;default:'pending';index:idx_outbox_due,priority:1"`
+	Attempts      int               `json:"attempts" gorm:"not null;default:0"`
+	LastError     string            `json:"last_error,omitempty"`
+	NextAttemptAt time.Time         `json:"next_attempt_at" gorm:"not null;index:idx_outbox_due,priority:2"`
+	SentAt        *time.Time        `json:"sent_at,omitempty" gorm:"index"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// EventSource is recorded as the source of events published by this service
+const EventSource = "payment-service"
+
+// Outbox statuses
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+)
+
+// OutboxRepository defines the contract for outbox relay data access
+type OutboxRepository interface {
+	// ClaimDue leases up to limit pending events whose next attempt is due, so
+	// that concurrent relays in other pods skip them until the lease expires.
+	// Events behind an earlier pending event with the same topic and key are
+	// left for later, keeping the events of a key in order.
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]OutboxEvent, error)
+	MarkSent(id uint, sentAt time.Time) error
+	MarkFailed(id uint, errMsg string, nextAttemptAt time.Time) error
+	// Backlog returns the number of pending events and the creation time of the oldest one
+	Backlog() (int64, *time.Time, error)
+	// PurgeSent deletes events sent before the given time
+	PurgeSent(before time.Time) (int64, error)
+}
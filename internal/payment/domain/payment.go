@@ -4,21 +4,39 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // Payment represents the payment entity
 type Payment struct {
-	ID            uint           `json:"id" gorm:"primaryKey"`
-	UserID        uint           `json:"user_id" gorm:"not null;index"`
-	OrderID       string         `json:"order_id" gorm:"not null;uniqueIndex"`
-	Amount        float64        `json:"amount" gorm:"not null"`
-	Currency      string         `json:"currency" gorm:"default:'USD'"`
-	Status        string         `json:"status" gorm:"default:'pending'"` // pending, completed, failed, refunded
-	PaymentMethod string         `json:"payment_method"`                  // credit_card, debit_card, paypal, etc.
-	TransactionID string         `json:"transaction_id"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	UserID          uint           `json:"user_id" gorm:"not null;index"`
+	OrderID         string         `json:"order_id" gorm:"not null;uniqueIndex"`
+	Amount          money.Money    `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Status          string         `json:"status" gorm:"default:'pending';index"` // pending, completed, failed, refunded
+	PaymentMethod   string         `json:"payment_method"`                        // credit_card, debit_card, paypal, etc.
+	TransactionID   string         `json:"transaction_id"`                        // provider capture reference
+	Provider        string         `json:"provider,omitempty"`
+	AuthorizationID string         `json:"authorization_id,omitempty"`
+	CardLast4       string         `json:"card_last4,omitempty"`
+	ProductID       uint           `json:"product_id,omitempty" gorm:"index"` // set for payments that bought stock
+	Quantity        int32          `json:"quantity,omitempty"`
+	ReservationID   string         `json:"reservation_id,omitempty"`
+	UnitPrice       money.Money    `json:"unit_price,omitzero" gorm:"embedded;embeddedPrefix:unit_price_"` // catalog price snapshot when the payment was priced
+	Subtotal        money.Money    `json:"subtotal,omitzero" gorm:"embedded;embeddedPrefix:subtotal_"`
+	TaxAmount       money.Money    `json:"tax_amount,omitzero" gorm:"embedded;embeddedPrefix:tax_amount_"`
+	DiscountAmount  money.Money    `json:"discount_amount,omitzero" gorm:"embedded;embeddedPrefix:discount_amount_"` // positive amount taken off the subtotal
+	RefundedAmount  money.Money    `json:"refunded_amount,omitzero" gorm:"embedded;embeddedPrefix:refunded_amount_"`
+	CatalogSubtotal money.Money    `json:"catalog_subtotal,omitzero" gorm:"embedded;embeddedPrefix:catalog_subtotal_"` // subtotal in the catalog currency when it was converted
+	FXRate          float64        `json:"fx_rate,omitempty" gorm:"type:numeric(20,10)"`                               // catalog currency -> payment currency
+	FXRateSource    string         `json:"fx_rate_source,omitempty"`
+	FXRateAsOf      *time.Time     `json:"fx_rate_as_of,omitempty"`
+	RiskDecision    string         `json:"risk_decision,omitempty" gorm:"index"` // allow, review, deny
+	RiskReasons     string         `json:"risk_reasons,omitempty"`
+	CreatedAt       time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // TableName specifies the table name
@@ -26,6 +44,28 @@ func (Payment) TableName() string {
 	return "payments"
 }
 
+// ApplyQuote records the price snapshot the payment was charged from
+func (p *Payment) ApplyQuote(quote *PriceQuote) {
+	p.Subtotal = quote.Subtotal
+	p.TaxAmount = quote.AdjustmentTotal(AdjustmentTax)
+	p.DiscountAmount = quote.AdjustmentTotal(AdjustmentDiscount).Neg()
+
+	if conversion := quote.Conversion; conversion != nil {
+		asOf := conversion.AsOf
+		p.CatalogSubtotal = conversion.From
+		p.FXRate = conversion.Rate
+		p.FXRateSource = conversion.Source
+		p.FXRateAsOf = &asOf
+	}
+}
+
+// ApplyRiskAssessment records the outcome of the risk check the payment was
+// charged after
+func (p *Payment) ApplyRiskAssessment(assessment *RiskAssessment) {
+	p.RiskDecision = assessment.Decision
+	p.RiskReasons = assessment.Reasons()
+}
+
 // Payment statuses
 const (
 	StatusPending   = "pending"
@@ -37,10 +77,24 @@ const (
 // PaymentRepository defines the contract for payment data access
 type PaymentRepository interface {
 	Create(payment *Payment) error
+	// CreateWithOutbox inserts the payment and the events built for it in one transaction
+	CreateWithOutbox(payment *Payment, eventsFor func(*Payment) ([]OutboxEvent, error)) error
 	FindByID(id uint) (*Payment, error)
 	FindByOrderID(orderID string) (*Payment, error)
-	FindByUserID(userID uint, limit, offset int) ([]Payment, error)
-	FindAll(limit, offset int) ([]Payment, error)
+	// Search returns a page of the payments matching the search and the total
+	// number of matches, ignoring the page
+	Search(search PaymentSearch) ([]Payment, int64, error)
 	Update(payment *Payment) error
-	UpdateStatus(id uint, status string) error
+	// SaveProviderReferences sets the provider authorization and transaction
+	// references of a payment without writing its other columns; empty
+	// references are left unchanged
+	SaveProviderReferences(id uint, authorizationID, transactionID string) error
+	// UpdateStatusWithOutbox applies a status change, records it in the status
+	// history and inserts the events built for it in one transaction. It fails
+	// with a *StatusTransitionError when the move is not allowed, and is a
+	// no-op when the payment already has the status. The change's
+	// TransactionID is recorded under the same lock.
+	UpdateStatusWithOutbox(change StatusChange, eventsFor func(payment *Payment, previous string) ([]OutboxEvent, error)) (*Payment, error)
+	// FindStatusHistory returns the status transitions of a payment, oldest first
+	FindStatusHistory(paymentID uint) ([]PaymentStatusHistory, error)
 }
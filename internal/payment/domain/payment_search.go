@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// ErrInvalidSearch is returned for payment searches with invalid filters,
+// sort order or cursor
+var ErrInvalidSearch = errors.New("invalid payment search")
+
+// PaymentFilter narrows a payment search. Zero fields match every payment.
+type PaymentFilter struct {
+	UserID         uint
+	Statuses       []string
+	PaymentMethods []string
+	RiskDecisions  []string
+	Currency       string
+	MinAmount      *money.Money // inclusive, in Currency
+	MaxAmount      *money.Money // inclusive, in Currency
+	CreatedFrom    *time.Time   // inclusive
+	CreatedTo      *time.Time   // exclusive
+}
+
+// Payment sort fields
+const (
+	PaymentSortCreatedAt = "created_at"
+	PaymentSortUpdatedAt = "updated_at"
+	PaymentSortAmount    = "amount"
+	PaymentSortID        = "id"
+)
+
+// paymentSortColumns maps sort fields to their column
+var paymentSortColumns = map[string]string{
+	PaymentSortCreatedAt: "created_at",
+	PaymentSortUpdatedAt: "updated_at",
+	PaymentSortAmount:    "amount_minor",
+	PaymentSortID:        "id",
+}
+
+// PaymentSort orders a payment search by a field, with the payment ID
+// breaking ties so that the order is total
+type PaymentSort struct {
+	Field      string
+	Descending bool
+}
+
+// DefaultPaymentSort lists the newest payments first
+var DefaultPaymentSort = PaymentSort{Field: PaymentSortCreatedAt, Descending: true}
+
+// ParsePaymentSort parses a sort field, prefixed with "-" for descending
+// order, e.g. "-amount". An empty string is DefaultPaymentSort.
+func ParsePaymentSort(value string) (PaymentSort, error) {
+	if value == "" {
+		return DefaultPaymentSort, nil
+	}
+	sort := PaymentSort{Field: strings.TrimPrefix(value, "-"), Descending: strings.HasPrefix(value, "-")}
+	if _, ok := paymentSortColumns[sort.Field]; !ok {
+		return PaymentSort{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidSearch, sort.Field)
+	}
+	return sort, nil
+}
+
+// String formats the sort the way ParsePaymentSort reads it
+func (s PaymentSort) String() string {
+	if s.Descending {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Column returns the column the sort orders by
+func (s PaymentSort) Column() string {
+	return paymentSortColumns[s.Field]
+}
+
+// PaymentCursor marks the last payment of a page; the next page starts after
+// it in the same sort order. Unlike an offset it does not drift when
+// payments are added.
+type PaymentCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// NewPaymentCursor creates the cursor of the page ending with payment
+func NewPaymentCursor(sort PaymentSort, payment *Payment) PaymentCursor {
+	var value string
+	switch sort.Field {
+	case PaymentSortCreatedAt:
+		value = payment.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case PaymentSortUpdatedAt:
+		value = payment.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case PaymentSortAmount:
+		value = strconv.FormatInt(payment.Amount.Minor, 10)
+	}
+	return PaymentCursor{Sort: sort.String(), Value: value, ID: payment.ID}
+}
+
+// Encode returns the cursor as an opaque URL-safe token
+func (c PaymentCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePaymentCursor reads a token written by Encode. The cursor must have
+// been issued for the same sort order.
+func DecodePaymentCursor(token string, sort PaymentSort) (*PaymentCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidSearch)
+	}
+	var cursor PaymentCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidSearch)
+	}
+	if cursor.Sort != sort.String() {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidSearch, cursor.Sort)
+	}
+	if _, err := cursor.SortValue(sort); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// SortValue returns the cursor's value of the sort field, typed for comparing
+// against its column
+func (c PaymentCursor) SortValue(sort PaymentSort) (any, error) {
+	switch sort.Field {
+	case PaymentSortCreatedAt, PaymentSortUpdatedAt:
+		value, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidSearch)
+		}
+		return value, nil
+	case PaymentSortAmount:
+		value, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidSearch)
+		}
+		return value, nil
+	default:
+		return c.ID, nil
+	}
+}
+
+// PaymentSearch is a filtered, sorted page of payments. Pages start after
+// the cursor when set; Offset is only honoured without one, for clients that
+// still page by offset.
+type PaymentSearch struct {
+	Filter PaymentFilter
+	Sort   PaymentSort
+	After  *PaymentCursor
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// ErrAmountMismatch is returned when a client-supplied amount differs from the
+// server-side price
+var ErrAmountMismatch = errors.New("amount does not match the calculated price")
+
+// Price adjustment kinds
+const (
+	AdjustmentTax      = "tax"
+	AdjustmentDiscount = "discount"
+)
+
+// PriceAdjustment is a tax or discount applied on top of the subtotal.
+// Discounts have a negative amount.
+type PriceAdjustment struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	Amount money.Money `json:"amount"`
+}
+
+// PriceQuote is the server-side price of a purchase: the catalog subtotal and
+// the adjustments applied to it by the pricing pipeline. All amounts are in
+// the charged currency; Conversion is set when the catalog subtotal was in
+// another currency.
+type PriceQuote struct {
+	Subtotal    money.Money       `json:"subtotal"`
+	Adjustments []PriceAdjustment `json:"adjustments,omitempty"`
+	Total       money.Money       `json:"total"`
+	Conversion  *FXConversion     `json:"conversion,omitempty"`
+}
+
+// Currency returns the currency the quote is priced in
+func (q *PriceQuote) Currency() string {
+	return q.Subtotal.Currency
+}
+
+// Adjust adds an adjustment and updates the total
+func (q *PriceQuote) Adjust(kind, name string, amount money.Money) error {
+	total, err := q.Total.Add(amount)
+	if err != nil {
+		return err
+	}
+	q.Adjustments = append(q.Adjustments, PriceAdjustment{Kind: kind, Name: name, Amount: amount})
+	q.Total = total
+	return nil
+}
+
+// AdjustmentTotal returns the sum of the adjustments of a kind
+func (q *PriceQuote) AdjustmentTotal(kind string) money.Money {
+	var total int64
+	for _, adjustment := range q.Adjustments {
+		if adjustment.Kind == kind {
+			total += adjustment.Amount.Minor
+		}
+	}
+	return money.New(total, q.Currency())
+}
+
+// Matches reports whether a client-supplied amount equals the quoted total,
+// in the same currency
+func (q *PriceQuote) Matches(amount money.Money) bool {
+	return amount == q.Total
+}
+
+// PriceRule is a step of the pricing pipeline, such as a tax or a discount.
+// Rules run in order and see the total left by the previous rules.
+type PriceRule interface {
+	Apply(ctx context.Context, quote *PriceQuote) error
+}
+
+// Pricer turns a catalog subtotal into the amount to charge in currency,
+// converting it when the catalog uses another currency
+type Pricer interface {
+	Price(ctx context.Context, subtotal money.Money, currency string) (*PriceQuote, error)
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// Payment provider errors
+var (
+	ErrPaymentDeclined          = errors.New("payment declined")
+	ErrProviderTimeout          = errors.New("payment provider timed out")
+	ErrUnsupportedPaymentMethod = errors.New("unsupported payment method")
+	// ErrCaptureNotRecorded means the provider took the money but the payment
+	// could not be completed; the charge must be resumed, never compensated
+	ErrCaptureNotRecorded = errors.New("payment captured but not recorded")
+)
+
+// DeclineError describes a charge refused by a provider. It matches
+// ErrPaymentDeclined with errors.Is.
+type DeclineError struct {
+	Provider string
+	Code     string // provider decline code, e.g. insufficient_funds
+	Reason   string
+}
+
+func (e *DeclineError) Error() string {
+	return fmt.Sprintf("%s by %s: %s (%s)", ErrPaymentDeclined, e.Provider, e.Reason, e.Code)
+}
+
+// Unwrap returns ErrPaymentDeclined
+func (e *DeclineError) Unwrap() error {
+	return ErrPaymentDeclined
+}
+
+// AuthorizeRequest holds the details a provider needs to place a hold on funds
+type AuthorizeRequest struct {
+	IdempotencyKey string // repeated requests with the same key return the same authorization
+	UserID         uint
+	Amount         money.Money
+	PaymentMethod  string
+	CardNumber     string // only for card payment methods; never persisted
+}
+
+// ProviderResult is the outcome of a successful provider operation
+type ProviderResult struct {
+	Reference string // authorization, capture or refund ID assigned by the provider
+	Amount    money.Money
+}
+
+// PaymentProvider talks to a payment processor. Authorize places a hold that
+// Capture settles or Void cancels; Refund returns captured funds.
+type PaymentProvider interface {
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (*ProviderResult, error)
+	Capture(ctx context.Context, authorizationID string, amount money.Money) (*ProviderResult, error)
+	Void(ctx context.Context, authorizationID string) error
+	Refund(ctx context.Context, transactionID string, amount money.Money) (*ProviderResult, error)
+}
+
+// PaymentGateway selects the provider that handles a payment
+type PaymentGateway interface {
+	// ProviderFor returns the provider for a payment method, failing with
+	// ErrUnsupportedPaymentMethod when none is configured
+	ProviderFor(paymentMethod string) (PaymentProvider, error)
+	// Provider returns a provider by name, for operations on existing payments
+	Provider(name string) (PaymentProvider, error)
+}
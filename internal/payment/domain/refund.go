@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// Refund errors
+var (
+	ErrPaymentNotRefundable = errors.New("payment cannot be refunded")
+	ErrRefundExceedsPayment = errors.New("refund exceeds the refundable balance")
+	ErrRefundFailed         = errors.New("refund failed at provider")
+)
+
+// Refund statuses. A refund is pending while the provider handles it; only
+// pending and succeeded refunds count against the refundable balance.
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+// Refund returns part or all of a completed payment
+type Refund struct {
+	ID                uint         `json:"id" gorm:"primaryKey"`
+	PaymentID         uint         `json:"payment_id" gorm:"not null;index"`
+	Amount            money.Money  `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Quantity          int32        `json:"quantity"`                                   // purchased items returned to stock
+	Lines             []RefundLine `json:"lines,omitempty" gorm:"foreignKey:RefundID"` // the returned items of an order, by order line
+	Reason            string       `json:"reason,omitempty"`
+	ActorUserID       uint         `json:"actor_user_id,omitempty"`
+	Status            string       `json:"status" gorm:"not null;default:'pending'"`
+	ProviderReference string       `json:"provider_reference,omitempty"`
+	LastError         string       `json:"last_error,omitempty"`
+	TraceID           string       `json:"trace_id,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+// RefundLine is the part of an order refund returning the items of one order
+// line. The line's reservation identifies the stock the items were taken from.
+type RefundLine struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	RefundID      uint   `json:"refund_id" gorm:"not null;index"`
+	ProductID     uint   `json:"product_id" gorm:"not null"`
+	ReservationID string `json:"reservation_id" gorm:"not null;index"`
+	Quantity      int32  `json:"quantity" gorm:"not null"`
+}
+
+// TableName specifies the table name
+func (RefundLine) TableName() string {
+	return "refund_lines"
+}
+
+// RefundTotals sums the refunds of a payment that count against its balance
+type RefundTotals struct {
+	Amount   money.Money
+	Quantity int32
+	Lines    map[string]int32 // items refunded by order line reservation
+}
+
+// ValidateRefund checks a refund against the payment and the refunds already
+// issued or in flight. The refund must be in the payment's currency. The
+// items of an order payment are refunded by line, against orderLines.
+func ValidateRefund(payment *Payment, orderLines []OrderLine, refunded RefundTotals, refund *Refund) error {
+	if payment.Status != StatusCompleted {
+		return fmt.Errorf("%w: payment %d is %s", ErrPaymentNotRefundable, payment.ID, payment.Status)
+	}
+	if !refund.Amount.IsPositive() {
+		return fmt.Errorf("refund amount must be greater than 0")
+	}
+	if refund.Quantity < 0 {
+		return fmt.Errorf("refund quantity must not be negative")
+	}
+
+	remaining, err := payment.Amount.Sub(refunded.Amount)
+	if err != nil {
+		return err
+	}
+	cmp, err := refund.Amount.Cmp(remaining)
+	if err != nil {
+		return err
+	}
+	if cmp > 0 {
+		return fmt.Errorf("%w: %s requested, %s of %s remaining",
+			ErrRefundExceedsPayment, refund.Amount, remaining, payment.Amount)
+	}
+	if len(orderLines) > 0 {
+		return validateRefundLines(orderLines, refunded, refund)
+	}
+	if len(refund.Lines) > 0 {
+		return fmt.Errorf("payment %d is not an order, refund lines are not accepted", payment.ID)
+	}
+	if remaining := payment.Quantity - refunded.Quantity; refund.Quantity > remaining {
+		return fmt.Errorf("%w: %d items requested, %d of %d remaining",
+			ErrRefundExceedsPayment, refund.Quantity, remaining, payment.Quantity)
+	}
+	return nil
+}
+
+// validateRefundLines checks the lines of an order refund against the order
+// lines and the items refunded from them so far
+func validateRefundLines(orderLines []OrderLine, refunded RefundTotals, refund *Refund) error {
+	ordered := make(map[string]OrderLine, len(orderLines))
+	for _, line := range orderLines {
+		ordered[line.ReservationID] = line
+	}
+
+	var quantity int32
+	seen := make(map[string]bool, len(refund.Lines))
+	for _, line := range refund.Lines {
+		orderLine, ok := ordered[line.ReservationID]
+		if !ok || orderLine.ProductID != line.ProductID {
+			return fmt.Errorf("product %d is not in the order", line.ProductID)
+		}
+		if seen[line.ReservationID] {
+			return fmt.Errorf("product %d: %w", line.ProductID, ErrDuplicateOrderLine)
+		}
+		seen[line.ReservationID] = true
+
+		if line.Quantity <= 0 {
+			return fmt.Errorf("refund quantity of product %d must be greater than 0", line.ProductID)
+		}
+		if remaining := orderLine.Quantity - refunded.Lines[line.ReservationID]; line.Quantity > remaining {
+			return fmt.Errorf("%w: %d items of product %d requested, %d of %d remaining",
+				ErrRefundExceedsPayment, line.Quantity, line.ProductID, remaining, orderLine.Quantity)
+		}
+		quantity += line.Quantity
+	}
+	if refund.Quantity != quantity {
+		return fmt.Errorf("refund quantity %d does not match the %d items of its lines", refund.Quantity, quantity)
+	}
+	return nil
+}
+
+// IsFullyRefunded reports whether the refunded amount covers the payment
+func (p *Payment) IsFullyRefunded() bool {
+	return p.RefundedAmount.Currency == p.Amount.Currency && p.RefundedAmount.Minor >= p.Amount.Minor
+}
+
+// RefundableAmount returns the part of the payment not refunded yet
+func (p *Payment) RefundableAmount() money.Money {
+	return money.New(p.Amount.Minor-p.refunded().Minor, p.Amount.Currency)
+}
+
+// AddRefunded adds a succeeded refund to the refunded amount
+func (p *Payment) AddRefunded(amount money.Money) error {
+	refunded, err := p.refunded().Add(amount)
+	if err != nil {
+		return err
+	}
+	p.RefundedAmount = refunded
+	return nil
+}
+
+// refunded returns the refunded amount, in the payment's currency while
+// nothing was refunded yet
+func (p *Payment) refunded() money.Money {
+	if p.RefundedAmount.IsZero() {
+		return money.Zero(p.Amount.Currency)
+	}
+	return p.RefundedAmount
+}
+
+// RefundRepository defines the contract for refund persistence
+type RefundRepository interface {
+	// CreatePending locks the payment, validates the refund with
+	// ValidateRefund and stores it as pending
+	CreatePending(refund *Refund) (*Payment, error)
+	// Complete marks a pending refund as succeeded and adds it to the
+	// payment's refunded amount. When the payment becomes fully refunded it
+	// is moved to refunded with change recorded in its status history. The
+	// events built for the refund are inserted in the same transaction.
+	Complete(refundID uint, providerReference string, change StatusChange, eventsFor func(payment *Payment, refund *Refund, previous string) ([]OutboxEvent, error)) (*Refund, *Payment, error)
+	// Fail marks a pending refund as failed, releasing its share of the balance
+	Fail(refundID uint, errMsg string) error
+	FindByPaymentID(paymentID uint) ([]Refund, error)
+}
@@ -0,0 +1,236 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+func TestValidateRefund(t *testing.T) {
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	tests := []struct {
+		name     string
+		status   string
+		refunded RefundTotals
+		refund   Refund
+		wantErr  bool
+		wantIs   error
+	}{
+		{
+			name:   "partial refund",
+			status: StatusCompleted,
+			refund: Refund{Amount: usd(400), Quantity: 1},
+		},
+		{
+			name:     "rest of the payment",
+			status:   StatusCompleted,
+			refunded: RefundTotals{Amount: usd(400), Quantity: 1},
+			refund:   Refund{Amount: usd(600), Quantity: 2},
+		},
+		{
+			name:   "amount only",
+			status: StatusCompleted,
+			refund: Refund{Amount: usd(1000)},
+		},
+		{
+			name:    "pending payment",
+			status:  StatusPending,
+			refund:  Refund{Amount: usd(100)},
+			wantErr: true,
+			wantIs:  ErrPaymentNotRefundable,
+		},
+		{
+			name:    "refunded payment",
+			status:  StatusRefunded,
+			refund:  Refund{Amount: usd(100)},
+			wantErr: true,
+			wantIs:  ErrPaymentNotRefundable,
+		},
+		{
+			name:    "zero amount",
+			status:  StatusCompleted,
+			refund:  Refund{Amount: usd(0)},
+			wantErr: true,
+		},
+		{
+			name:    "negative amount",
+			status:  StatusCompleted,
+			refund:  Refund{Amount: usd(-100)},
+			wantErr: true,
+		},
+		{
+			name:    "negative quantity",
+			status:  StatusCompleted,
+			refund:  Refund{Amount: usd(100), Quantity: -1},
+			wantErr: true,
+		},
+		{
+			name:    "more than the payment",
+			status:  StatusCompleted,
+			refund:  Refund{Amount: usd(1001)},
+			wantErr: true,
+			wantIs:  ErrRefundExceedsPayment,
+		},
+		{
+			name:     "more than the remaining amount",
+			status:   StatusCompleted,
+			refunded: RefundTotals{Amount: usd(700)},
+			refund:   Refund{Amount: usd(301)},
+			wantErr:  true,
+			wantIs:   ErrRefundExceedsPayment,
+		},
+		{
+			name:     "more than the remaining items",
+			status:   StatusCompleted,
+			refunded: RefundTotals{Amount: usd(300), Quantity: 2},
+			refund:   Refund{Amount: usd(100), Quantity: 2},
+			wantErr:  true,
+			wantIs:   ErrRefundExceedsPayment,
+		},
+		{
+			name:    "other currency",
+			status:  StatusCompleted,
+			refund:  Refund{Amount: money.New(100, "EUR")},
+			wantErr: true,
+			wantIs:  money.ErrCurrencyMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := &Payment{ID: 7, Status: tt.status, Amount: usd(1000), Quantity: 3}
+			if tt.refunded.Amount.IsZero() {
+				tt.refunded.Amount = usd(0)
+			}
+			err := ValidateRefund(payment, nil, tt.refunded, &tt.refund)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("ValidateRefund() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidateRefund() error = nil, want an error")
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Fatalf("ValidateRefund() error = %v, want %v", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestValidateRefundLines(t *testing.T) {
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	orderLines := []OrderLine{
+		{ProductID: 1, Quantity: 2, ReservationID: "res-1"},
+		{ProductID: 2, Quantity: 1, ReservationID: "res-2"},
+	}
+	tests := []struct {
+		name     string
+		refunded map[string]int32
+		lines    []RefundLine
+		quantity int32
+		wantErr  bool
+		wantIs   error
+	}{
+		{
+			name:     "one line",
+			lines:    []RefundLine{{ProductID: 1, ReservationID: "res-1", Quantity: 2}},
+			quantity: 2,
+		},
+		{
+			name:     "rest of a line",
+			refunded: map[string]int32{"res-1": 1},
+			lines: []RefundLine{
+				{ProductID: 1, ReservationID: "res-1", Quantity: 1},
+				{ProductID: 2, ReservationID: "res-2", Quantity: 1},
+			},
+			quantity: 2,
+		},
+		{
+			name:     "amount only",
+			quantity: 0,
+		},
+		{
+			name:     "product not in the order",
+			lines:    []RefundLine{{ProductID: 3, ReservationID: "res-3", Quantity: 1}},
+			quantity: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "product of another line",
+			lines:    []RefundLine{{ProductID: 2, ReservationID: "res-1", Quantity: 1}},
+			quantity: 1,
+			wantErr:  true,
+		},
+		{
+			name: "duplicate line",
+			lines: []RefundLine{
+				{ProductID: 1, ReservationID: "res-1", Quantity: 1},
+				{ProductID: 1, ReservationID: "res-1", Quantity: 1},
+			},
+			quantity: 2,
+			wantErr:  true,
+			wantIs:   ErrDuplicateOrderLine,
+		},
+		{
+			name:     "zero quantity",
+			lines:    []RefundLine{{ProductID: 1, ReservationID: "res-1", Quantity: 0}},
+			quantity: 0,
+			wantErr:  true,
+		},
+		{
+			name:     "more than the line",
+			lines:    []RefundLine{{ProductID: 2, ReservationID: "res-2", Quantity: 2}},
+			quantity: 2,
+			wantErr:  true,
+			wantIs:   ErrRefundExceedsPayment,
+		},
+		{
+			name:     "more than the rest of the line",
+			refunded: map[string]int32{"res-1": 2},
+			lines:    []RefundLine{{ProductID: 1, ReservationID: "res-1", Quantity: 1}},
+			quantity: 1,
+			wantErr:  true,
+			wantIs:   ErrRefundExceedsPayment,
+		},
+		{
+			name:     "quantity not matching the lines",
+			lines:    []RefundLine{{ProductID: 1, ReservationID: "res-1", Quantity: 1}},
+			quantity: 2,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := &Payment{ID: 7, Status: StatusCompleted, Amount: usd(1000), OrderID: "ORD-1"}
+			refunded := RefundTotals{Amount: usd(0), Lines: tt.refunded}
+			refund := &Refund{Amount: usd(100), Quantity: tt.quantity, Lines: tt.lines}
+			err := ValidateRefund(payment, orderLines, refunded, refund)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("ValidateRefund() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidateRefund() error = nil, want an error")
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Fatalf("ValidateRefund() error = %v, want %v", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestValidateRefundRejectsLinesOutsideAnOrder(t *testing.T) {
+	payment := &Payment{ID: 7, Status: StatusCompleted, Amount: money.New(1000, "USD"), ProductID: 1, Quantity: 2}
+	refund := &Refund{
+		Amount:   money.New(100, "USD"),
+		Quantity: 1,
+		Lines:    []RefundLine{{ProductID: 1, ReservationID: "res-1", Quantity: 1}},
+	}
+	if err := ValidateRefund(payment, nil, RefundTotals{Amount: money.Zero("USD")}, refund); err == nil {
+		t.Fatal("ValidateRefund() error = nil, want an error")
+	}
+}
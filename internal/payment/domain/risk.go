@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// ErrPaymentRiskDenied is returned when the risk check denies a payment
+var ErrPaymentRiskDenied = errors.New("payment denied by risk check")
+
+// Risk decisions, from least to most severe
+const (
+	RiskDecisionAllow  = "allow"
+	RiskDecisionReview = "review" // charged, but flagged for manual review
+	RiskDecisionDeny   = "deny"   // failed without charging
+)
+
+var riskDecisionSeverity = map[string]int{
+	RiskDecisionAllow:  0,
+	RiskDecisionReview: 1,
+	RiskDecisionDeny:   2,
+}
+
+// IsValidRiskDecision reports whether decision is a known risk decision
+func IsValidRiskDecision(decision string) bool {
+	_, ok := riskDecisionSeverity[decision]
+	return ok
+}
+
+// PaymentOrigin describes where a payment request came from. It is gathered
+// by the delivery layer and used by the risk check.
+type PaymentOrigin struct {
+	ClientIP         string
+	AccountCreatedAt *time.Time // nil when the account age is unknown
+}
+
+// RiskInput is a payment about to be charged
+type RiskInput struct {
+	UserID        uint
+	Amount        money.Money
+	PaymentMethod string
+	Origin        PaymentOrigin
+}
+
+// RiskVerdict is the outcome of one risk rule
+type RiskVerdict struct {
+	Rule     string `json:"rule"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// RiskAssessment combines the verdicts of the risk rules. Its decision is the
+// most severe one of the verdicts.
+type RiskAssessment struct {
+	Decision string        `json:"decision"`
+	Verdicts []RiskVerdict `json:"verdicts,omitempty"`
+}
+
+// NewRiskAssessment creates an assessment that allows the payment
+func NewRiskAssessment() *RiskAssessment {
+	return &RiskAssessment{Decision: RiskDecisionAllow}
+}
+
+// Add records a verdict, raising the decision when the verdict is more severe
+func (a *RiskAssessment) Add(verdict RiskVerdict) {
+	a.Verdicts = append(a.Verdicts, verdict)
+	if riskDecisionSeverity[verdict.Decision] > riskDecisionSeverity[a.Decision] {
+		a.Decision = verdict.Decision
+	}
+}
+
+// Reasons joins the reasons of the verdicts that did not allow the payment
+func (a *RiskAssessment) Reasons() string {
+	var reasons []string
+	for _, verdict := range a.Verdicts {
+		if verdict.Decision != RiskDecisionAllow && verdict.Reason != "" {
+			reasons = append(reasons, verdict.Rule+": "+verdict.Reason)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// RiskRule is a check of the risk evaluation, such as a velocity limit or an
+// amount threshold. A rule that has nothing to say returns an allow verdict.
+type RiskRule interface {
+	Name() string
+	Evaluate(ctx context.Context, input RiskInput) (RiskVerdict, error)
+}
+
+// RiskEvaluator decides whether a payment may be charged
+type RiskEvaluator interface {
+	Evaluate(ctx context.Context, input RiskInput) (*RiskAssessment, error)
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidStatusTransition is returned when a payment cannot move to the requested status
+var ErrInvalidStatusTransition = errors.New("invalid payment status transition")
+
+// StatusTransitionError describes a rejected status change. It matches
+// ErrInvalidStatusTransition with errors.Is.
+type StatusTransitionError struct {
+	PaymentID uint
+	From      string
+	To        string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("%s: payment %d cannot move from %s to %s", ErrInvalidStatusTransition, e.PaymentID, e.From, e.To)
+}
+
+// Unwrap returns ErrInvalidStatusTransition
+func (e *StatusTransitionError) Unwrap() error {
+	return ErrInvalidStatusTransition
+}
+
+// statusTransitions lists the statuses a payment may move to from each status.
+// Failed and refunded are terminal.
+var statusTransitions = map[string][]string{
+	StatusPending:   {StatusCompleted, StatusFailed},
+	StatusCompleted: {StatusRefunded},
+}
+
+// IsValidStatus reports whether status is a known payment status
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusCompleted, StatusFailed, StatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether a payment may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves the payment to status, returning a *StatusTransitionError
+// when the state machine does not allow it
+func (p *Payment) Transition(status string) error {
+	if !CanTransition(p.Status, status) {
+		return &StatusTransitionError{PaymentID: p.ID, From: p.Status, To: status}
+	}
+	p.Status = status
+	return nil
+}
+
+// StatusChange is a requested status transition and who asked for it
+type StatusChange struct {
+	PaymentID   uint
+	To          string
+	ActorUserID uint // 0 when the change was made by the system
+	Reason      string
+	TraceID     string
+	// TransactionID is the provider's capture reference, recorded with the
+	// change when the payment has none yet
+	TransactionID string
+}
+
+// PaymentStatusHistory records one status transition of a payment
+type PaymentStatusHistory struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PaymentID   uint      `json:"payment_id" gorm:"not null;index"`
+	FromStatus  string    `json:"from_status"`
+	ToStatus    string    `json:"to_status" gorm:"not null"`
+	ActorUserID uint      `json:"actor_user_id,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	TraceID     string    `json:"trace_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name
+func (PaymentStatusHistory) TableName() string {
+	return "payment_status_history"
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusPending, StatusCompleted, true},
+		{StatusPending, StatusFailed, true},
+		{StatusCompleted, StatusRefunded, true},
+
+		{StatusPending, StatusPending, false},
+		{StatusPending, StatusRefunded, false},
+		{StatusCompleted, StatusPending, false},
+		{StatusCompleted, StatusCompleted, false},
+		{StatusCompleted, StatusFailed, false},
+		{StatusFailed, StatusPending, false},
+		{StatusFailed, StatusCompleted, false},
+		{StatusFailed, StatusRefunded, false},
+		{StatusRefunded, StatusPending, false},
+		{StatusRefunded, StatusCompleted, false},
+		{StatusRefunded, StatusFailed, false},
+		{"", StatusCompleted, false},
+		{StatusPending, "settled", false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestPaymentTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{"capture", StatusPending, StatusCompleted, false},
+		{"decline", StatusPending, StatusFailed, false},
+		{"refund", StatusCompleted, StatusRefunded, false},
+		{"refund before capture", StatusPending, StatusRefunded, true},
+		{"fail after capture", StatusCompleted, StatusFailed, true},
+		{"revive failed", StatusFailed, StatusCompleted, true},
+		{"reopen refunded", StatusRefunded, StatusPending, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := &Payment{ID: 7, Status: tt.from}
+			err := payment.Transition(tt.to)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Transition(%q) error = %v", tt.to, err)
+				}
+				if payment.Status != tt.to {
+					t.Errorf("Status = %q, want %q", payment.Status, tt.to)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidStatusTransition) {
+				t.Fatalf("Transition(%q) error = %v, want ErrInvalidStatusTransition", tt.to, err)
+			}
+			var transitionErr *StatusTransitionError
+			if !errors.As(err, &transitionErr) {
+				t.Fatalf("Transition(%q) error = %T, want *StatusTransitionError", tt.to, err)
+			}
+			if transitionErr.PaymentID != 7 || transitionErr.From != tt.from || transitionErr.To != tt.to {
+				t.Errorf("error = %+v, want payment 7 from %q to %q", transitionErr, tt.from, tt.to)
+			}
+			if payment.Status != tt.from {
+				t.Errorf("Status = %q after a rejected transition, want %q", payment.Status, tt.from)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Webhook errors
+var (
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrWebhookExpired          = errors.New("webhook timestamp outside tolerance")
+	ErrUnknownWebhookProvider  = errors.New("unknown webhook provider")
+	ErrUnsupportedWebhookEvent = errors.New("unsupported webhook event type")
+)
+
+// Provider webhook event types
+const (
+	WebhookChargeSucceeded = "charge.succeeded"
+	WebhookChargeFailed    = "charge.failed"
+	WebhookChargeRefunded  = "charge.refunded"
+)
+
+// webhookStatuses maps provider webhook event types to the payment status they confirm
+var webhookStatuses = map[string]string{
+	WebhookChargeSucceeded: StatusCompleted,
+	WebhookChargeFailed:    StatusFailed,
+	WebhookChargeRefunded:  StatusRefunded,
+}
+
+// WebhookStatus returns the payment status confirmed by a webhook event type
+func WebhookStatus(eventType string) (string, bool) {
+	status, ok := webhookStatuses[eventType]
+	return status, ok
+}
+
+// WebhookEvent records a provider webhook that has been accepted, so that
+// redeliveries of the same provider event are ignored
+type WebhookEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Provider   string    `json:"provider" gorm:"not null;uniqueIndex:idx_webhook_events_provider_event,priority:1"`
+	EventID    string    `json:"event_id" gorm:"not null;uniqueIndex:idx_webhook_events_provider_event,priority:2"`
+	EventType  string    `json:"event_type" gorm:"not null"`
+	PaymentID  uint      `json:"payment_id" gorm:"index"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// TableName specifies the table name
+func (WebhookEvent) TableName() string {
+	return "payment_webhook_events"
+}
+
+// WebhookEventRepository deduplicates provider webhooks
+type WebhookEventRepository interface {
+	// Claim records the event, returning false when it was already recorded
+	Claim(event *WebhookEvent) (bool, error)
+	// Release forgets the event so that a redelivery is handled again
+	Release(provider, eventID string) error
+}
+
+// WebhookVerifier authenticates the signature of a provider webhook
+type WebhookVerifier interface {
+	Verify(provider, signatureHeader string, body []byte) error
+}
@@ -1,18 +1,28 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/tair/full-observability/internal/payment/client"
 	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/internal/payment/provider"
+	"github.com/tair/full-observability/internal/payment/risk"
 	"github.com/tair/full-observability/internal/payment/usecase/command"
 	"github.com/tair/full-observability/internal/payment/usecase/query"
 	"github.com/tair/full-observability/kafka"
 	"github.com/tair/full-observability/pkg/logger"
+	"github.com/tair/full-observability/pkg/money"
+	"gorm.io/gorm"
 )
 
 // PaymentHandler handles HTTP requests for payments using CQRS pattern
@@ -20,13 +30,25 @@ type PaymentHandler struct {
 	// Command handlers
 	createHandler       *command.CreatePaymentHandler
 	updateStatusHandler *command.UpdateStatusHandler
+	checkoutHandler     *command.CheckoutHandler
+	outboxRelay         *command.RelayOutboxHandler
+	webhookHandler      *command.HandleWebhookHandler
+	refundHandler       *command.RefundPaymentHandler
+	placeOrderHandler   *command.PlaceOrderHandler
 
 	// Query handlers
-	getHandler   *query.GetPaymentHandler
-	listHandler  *query.ListPaymentsHandler
-	getMyHandler *query.GetMyPaymentsHandler
+	getHandler         *query.GetPaymentHandler
+	listHandler        *query.ListPaymentsHandler
+	getMyHandler       *query.GetMyPaymentsHandler
+	historyHandler     *query.GetPaymentHistoryHandler
+	refundsHandler     *query.ListRefundsHandler
+	getOrderHandler    *query.GetOrderHandler
+	getMyOrdersHandler *query.GetMyOrdersHandler
 
 	repo            domain.PaymentRepository
+	webhookVerifier domain.WebhookVerifier
+	idempotency     IdempotencyConfig
+	trustedProxies  risk.TrustedProxies
 	userClient      *client.UserServiceClient
 	productClient   *client.ProductServiceClient
 	inventoryClient *client.InventoryServiceClient
@@ -34,14 +56,26 @@ type PaymentHandler struct {
 }
 
 // NewPaymentHandler creates a new payment handler (manual DI)
-func NewPaymentHandler(repo domain.PaymentRepository, userClient *client.UserServiceClient, productClient *client.ProductServiceClient, inventoryClient *client.InventoryServiceClient) *PaymentHandler {
+func NewPaymentHandler(repo domain.PaymentRepository, sagaRepo domain.CheckoutSagaRepository, orderRepo domain.OrderRepository, webhookRepo domain.WebhookEventRepository, refundRepo domain.RefundRepository, gateway domain.PaymentGateway, pricer domain.Pricer, webhookVerifier domain.WebhookVerifier, idempotency IdempotencyConfig, userClient *client.UserServiceClient, productClient *client.ProductServiceClient, inventoryClient *client.InventoryServiceClient) *PaymentHandler {
+	createHandler := command.NewCreatePaymentHandler(repo, gateway, nil, nil)
+	refundHandler := command.NewRefundPaymentHandler(repo, refundRepo, orderRepo, gateway, nil)
 	return &PaymentHandler{
-		createHandler:       command.NewCreatePaymentHandler(repo),
-		updateStatusHandler: command.NewUpdateStatusHandler(repo),
+		createHandler:       createHandler,
+		updateStatusHandler: command.NewUpdateStatusHandler(repo, gateway, refundHandler, nil),
+		checkoutHandler:     command.NewCheckoutHandler(sagaRepo, repo, createHandler, productClient, pricer, inventoryClient),
+		webhookHandler:      command.NewHandleWebhookHandler(repo, webhookRepo, refundHandler, nil),
+		refundHandler:       refundHandler,
+		placeOrderHandler:   command.NewPlaceOrderHandler(orderRepo, repo, createHandler, productClient, pricer, inventoryClient),
 		getHandler:          query.NewGetPaymentHandler(repo),
 		listHandler:         query.NewListPaymentsHandler(repo),
 		getMyHandler:        query.NewGetMyPaymentsHandler(repo),
+		historyHandler:      query.NewGetPaymentHistoryHandler(repo),
+		refundsHandler:      query.NewListRefundsHandler(repo, refundRepo),
+		getOrderHandler:     query.NewGetOrderHandler(orderRepo),
+		getMyOrdersHandler:  query.NewGetMyOrdersHandler(orderRepo),
 		repo:                repo,
+		webhookVerifier:     webhookVerifier,
+		idempotency:         idempotency,
 		userClient:          userClient,
 		productClient:       productClient,
 		inventoryClient:     inventoryClient,
@@ -52,10 +86,22 @@ func NewPaymentHandler(repo domain.PaymentRepository, userClient *client.UserSer
 func NewPaymentHandlerWithDI(
 	createHandler *command.CreatePaymentHandler,
 	updateStatusHandler *command.UpdateStatusHandler,
+	checkoutHandler *command.CheckoutHandler,
+	outboxRelay *command.RelayOutboxHandler,
+	webhookHandler *command.HandleWebhookHandler,
+	refundHandler *command.RefundPaymentHandler,
+	placeOrderHandler *command.PlaceOrderHandler,
 	getHandler *query.GetPaymentHandler,
 	listHandler *query.ListPaymentsHandler,
 	getMyHandler *query.GetMyPaymentsHandler,
+	historyHandler *query.GetPaymentHistoryHandler,
+	refundsHandler *query.ListRefundsHandler,
+	getOrderHandler *query.GetOrderHandler,
+	getMyOrdersHandler *query.GetMyOrdersHandler,
 	repo domain.PaymentRepository,
+	webhookVerifier domain.WebhookVerifier,
+	idempotency IdempotencyConfig,
+	trustedProxies risk.TrustedProxies,
 	userClient *client.UserServiceClient,
 	productClient *client.ProductServiceClient,
 	inventoryClient *client.InventoryServiceClient,
@@ -64,10 +110,22 @@ func NewPaymentHandlerWithDI(
 	return &PaymentHandler{
 		createHandler:       createHandler,
 		updateStatusHandler: updateStatusHandler,
+		checkoutHandler:     checkoutHandler,
+		outboxRelay:         outboxRelay,
+		webhookHandler:      webhookHandler,
+		refundHandler:       refundHandler,
+		placeOrderHandler:   placeOrderHandler,
 		getHandler:          getHandler,
 		listHandler:         listHandler,
 		getMyHandler:        getMyHandler,
+		historyHandler:      historyHandler,
+		refundsHandler:      refundsHandler,
+		getOrderHandler:     getOrderHandler,
+		getMyOrdersHandler:  getMyOrdersHandler,
 		repo:                repo,
+		webhookVerifier:     webhookVerifier,
+		idempotency:         idempotency,
+		trustedProxies:      trustedProxies,
 		userClient:          userClient,
 		productClient:       productClient,
 		inventoryClient:     inventoryClient,
@@ -82,15 +140,19 @@ type Response struct {
 	Error   string      `json:"error,omitempty"`
 }
 
-// CreatePayment handles POST /api/payments
+// CreatePayment handles POST /api/payments. The payment is made by the
+// caller; only admins may pay on behalf of another user.
 func (h *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		UserID        uint    `json:"user_id"`
-		ProductID     uint    `json:"product_id"`
-		Quantity      int32   `json:"quantity"`
-		Amount        float64 `json:"amount"`
-		Currency      string  `json:"currency"`
-		PaymentMethod string  `json:"payment_method"`
+		UserID        uint        `json:"user_id"` // admins only, defaults to the caller
+		ProductID     uint        `json:"product_id"`
+		Quantity      int32       `json:"quantity"`
+		Amount        json.Number `json:"amount"` // optional, checked against the server-side price
+		Currency      string      `json:"currency"`
+		PaymentMethod string      `json:"payment_method"`
+		CardNumber    string      `json:"card_number"`
+		// Optional customer region, used to ship from the nearest location
+		ShippingRegion string `json:"shipping_region"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -120,7 +182,7 @@ func (h *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 
 	// Check product stock availability via Inventory Service gRPC
 	ctx := r.Context()
-	available, currentStock, message, err := h.inventoryClient.CheckAvailability(ctx, req.ProductID, req.Quantity)
+	available, currentStock, message, err := h.inventoryClient.CheckAvailability(ctx, req.ProductID, req.Quantity, req.ShippingRegion)
 	if err != nil {
 		logger.Logger.Error().
 			Err(err).
@@ -158,16 +220,75 @@ func (h *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 		Int32("current_stock", currentStock).
 		Msg("Stock validation passed")
 
-	cmd := command.CreatePaymentCommand{
-		UserID:        req.UserID,
-		Amount:        req.Amount,
-		Currency:      req.Currency,
-		PaymentMethod: req.PaymentMethod,
+	// The risk check runs on the authenticated identity, never on the body
+	origin := h.paymentOrigin(r)
+	userID, _ := ctx.Value(UserIDKey).(uint)
+	if role, _ := ctx.Value(RoleKey).(string); role == "admin" && req.UserID != 0 && req.UserID != userID {
+		userID = req.UserID
+		origin.AccountCreatedAt = h.accountCreatedAt(ctx, userID) // the caller's account age says nothing about the payer
+	}
+
+	// Run checkout saga: reserve stock -> charge -> confirm (or release on failure)
+	cmd := command.CheckoutCommand{
+		UserID:         userID,
+		ProductID:      req.ProductID,
+		Quantity:       req.Quantity,
+		Amount:         req.Amount.String(),
+		Currency:       req.Currency,
+		PaymentMethod:  req.PaymentMethod,
+		CardNumber:     req.CardNumber,
+		Origin:         origin,
+		ShippingRegion: req.ShippingRegion,
 	}
 
-	payment, err := h.createHandler.Handle(cmd)
+	payment, err := h.checkoutHandler.Handle(ctx, cmd)
 	if err != nil {
 		logger.Logger.Error().Err(err).Msg("Failed to create payment")
+		switch {
+		case errors.Is(err, domain.ErrInsufficientStock):
+			respondJSON(w, http.StatusConflict, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		case errors.Is(err, domain.ErrAmountMismatch), errors.Is(err, domain.ErrProductUnavailable),
+			errors.Is(err, domain.ErrFXRateUnavailable), errors.Is(err, money.ErrInvalidCurrency):
+			respondJSON(w, http.StatusUnprocessableEntity, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		case errors.Is(err, domain.ErrPaymentDeclined):
+			respondJSON(w, http.StatusPaymentRequired, Response{
+				Success: false,
+				Error:   err.Error(),
+				Data:    payment,
+			})
+			return
+		case errors.Is(err, domain.ErrProviderTimeout):
+			respondJSON(w, http.StatusGatewayTimeout, Response{
+				Success: false,
+				Error:   err.Error(),
+				Data:    payment,
+			})
+			return
+		case errors.Is(err, domain.ErrPaymentRiskDenied):
+			respondJSON(w, http.StatusForbidden, Response{
+				Success: false,
+				Error:   err.Error(),
+				Data:    payment,
+			})
+			return
+		case errors.Is(err, domain.ErrCaptureNotRecorded):
+			// Charged: the checkout completes in the background, so the
+			// client must not retry it
+			respondJSON(w, http.StatusAccepted, Response{
+				Success: true,
+				Message: "Payment captured, the checkout will be completed shortly",
+				Data:    payment,
+			})
+			return
+		}
 		respondJSON(w, http.StatusBadRequest, Response{
 			Success: false,
 			Error:   err.Error(),
@@ -175,34 +296,6 @@ func (h *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Publish product purchased event to Kafka (with tracing)
-	if h.kafkaPublisher != nil {
-		event := kafka.ProductPurchasedEvent{
-			PaymentID:     payment.ID,
-			ProductID:     req.ProductID,
-			Quantity:      req.Quantity,
-			UserID:        req.UserID,
-			Amount:        req.Amount,
-			Currency:      req.Currency,
-			PaymentMethod: req.PaymentMethod,
-		}
-
-		if err := h.kafkaPublisher.PublishProductPurchased(ctx, event); err != nil {
-			logger.Logger.Error().
-				Err(err).
-				Uint("payment_id", payment.ID).
-				Uint("product_id", req.ProductID).
-				Msg("Failed to publish product purchased event")
-			// Don't fail the payment, just log the error
-		} else {
-			logger.Logger.Info().
-				Uint("payment_id", payment.ID).
-				Uint("product_id", req.ProductID).
-				Int32("quantity", req.Quantity).
-				Msg("Product purchased event published successfully")
-		}
-	}
-
 	respondJSON(w, http.StatusCreated, Response{
 		Success: true,
 		Message: "Payment created successfully",
@@ -247,18 +340,47 @@ func (h *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ListPayments handles GET /api/payments
+// ListPayments handles GET /api/payments. Payments can be filtered by
+// user_id, status, payment_method, currency, min_amount/max_amount and
+// created_from/created_to, sorted with sort, and paged with limit and the
+// next_cursor of the previous page.
 func (h *PaymentHandler) ListPayments(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	params, err := parsePaymentSearch(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var userID uint
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, Response{
+				Success: false,
+				Error:   "Invalid user_id",
+			})
+			return
+		}
+		userID = uint(id)
+	}
 
 	q := query.ListPaymentsQuery{
-		Limit:  limit,
-		Offset: offset,
+		UserID:              userID,
+		PaymentSearchParams: params,
 	}
 
-	payments, err := h.listHandler.Handle(q)
+	page, err := h.listHandler.Handle(q)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSearch) {
+			respondJSON(w, http.StatusBadRequest, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
 		logger.Logger.Error().Err(err).Msg("Failed to list payments")
 		respondJSON(w, http.StatusInternalServerError, Response{
 			Success: false,
@@ -269,10 +391,7 @@ func (h *PaymentHandler) ListPayments(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, Response{
 		Success: true,
-		Data: map[string]interface{}{
-			"payments": payments,
-			"total":    len(payments),
-		},
+		Data:    page,
 	})
 }
 
@@ -290,6 +409,7 @@ func (h *PaymentHandler) UpdatePaymentStatus(w http.ResponseWriter, r *http.Requ
 
 	var req struct {
 		Status string `json:"status"`
+		Reason string `json:"reason"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -300,13 +420,30 @@ func (h *PaymentHandler) UpdatePaymentStatus(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	actorUserID, _ := r.Context().Value(UserIDKey).(uint)
 	cmd := command.UpdateStatusCommand{
-		PaymentID: uint(id),
-		Status:    req.Status,
+		PaymentID:   uint(id),
+		Status:      req.Status,
+		ActorUserID: actorUserID,
+		Reason:      req.Reason,
 	}
 
-	if err := h.updateStatusHandler.Handle(cmd); err != nil {
+	if err := h.updateStatusHandler.Handle(r.Context(), cmd); err != nil {
 		logger.Logger.Error().Err(err).Msg("Failed to update payment status")
+		if errors.Is(err, domain.ErrInvalidStatusTransition) {
+			respondJSON(w, http.StatusConflict, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrRefundFailed) {
+			respondJSON(w, providerErrorStatus(err), Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
 		respondJSON(w, http.StatusBadRequest, Response{
 			Success: false,
 			Error:   err.Error(),
@@ -320,7 +457,247 @@ func (h *PaymentHandler) UpdatePaymentStatus(w http.ResponseWriter, r *http.Requ
 	})
 }
 
-// GetMyPayments handles GET /api/payments/my (authenticated user)
+// RefundPayment handles POST /api/payments/{id}/refunds
+func (h *PaymentHandler) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid payment ID",
+		})
+		return
+	}
+
+	var req struct {
+		Amount   json.Number `json:"amount"`   // in the payment's currency; omitted or 0 for the remaining balance
+		Quantity int32       `json:"quantity"` // items returned to stock
+		Lines    []struct {
+			ProductID uint  `json:"product_id"`
+			Quantity  int32 `json:"quantity"`
+		} `json:"lines"` // items returned by an order payment
+		Reason string `json:"reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+		return
+	}
+
+	actorUserID, _ := r.Context().Value(UserIDKey).(uint)
+	cmd := command.RefundPaymentCommand{
+		PaymentID:   uint(id),
+		Amount:      req.Amount.String(),
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		ActorUserID: actorUserID,
+	}
+	for _, line := range req.Lines {
+		cmd.Lines = append(cmd.Lines, command.RefundLineInput{ProductID: line.ProductID, Quantity: line.Quantity})
+	}
+
+	refund, err := h.refundHandler.Handle(r.Context(), cmd)
+	if err != nil {
+		logger.Logger.Error().Err(err).Uint64("payment_id", id).Msg("Failed to refund payment")
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondJSON(w, http.StatusNotFound, Response{
+				Success: false,
+				Error:   "Payment not found",
+			})
+		case errors.Is(err, domain.ErrPaymentNotRefundable), errors.Is(err, domain.ErrRefundExceedsPayment):
+			respondJSON(w, http.StatusConflict, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case errors.Is(err, domain.ErrRefundFailed):
+			respondJSON(w, providerErrorStatus(err), Response{
+				Success: false,
+				Error:   err.Error(),
+				Data:    refund,
+			})
+		default:
+			respondJSON(w, http.StatusBadRequest, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Message: "Refund issued successfully",
+		Data:    refund,
+	})
+}
+
+// ListRefunds handles GET /api/payments/{id}/refunds
+func (h *PaymentHandler) ListRefunds(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid payment ID",
+		})
+		return
+	}
+
+	q := query.ListRefundsQuery{PaymentID: uint(id)}
+	refunds, err := h.refundsHandler.Handle(q)
+	if err != nil {
+		respondJSON(w, http.StatusNotFound, Response{
+			Success: false,
+			Error:   "Payment not found",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"refunds": refunds,
+			"total":   len(refunds),
+		},
+	})
+}
+
+// GetPaymentHistory handles GET /api/payments/{id}/history
+func (h *PaymentHandler) GetPaymentHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid payment ID",
+		})
+		return
+	}
+
+	q := query.GetPaymentHistoryQuery{PaymentID: uint(id)}
+	history, err := h.historyHandler.Handle(q)
+	if err != nil {
+		respondJSON(w, http.StatusNotFound, Response{
+			Success: false,
+			Error:   "Payment not found",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: map[string]interface{}{
+			"history": history,
+			"total":   len(history),
+		},
+	})
+}
+
+// maxWebhookBodyBytes bounds the size of provider webhook bodies
+const maxWebhookBodyBytes = 1 << 20
+
+// HandleProviderWebhook handles POST /api/payments/webhooks/{provider}. The
+// body is authenticated with the provider's signing secret before it is parsed.
+func (h *PaymentHandler) HandleProviderWebhook(w http.ResponseWriter, r *http.Request) {
+	providerName := mux.Vars(r)["provider"]
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+		return
+	}
+
+	if err := h.webhookVerifier.Verify(providerName, r.Header.Get(provider.WebhookSignatureHeader), body); err != nil {
+		logger.Logger.Warn().
+			Err(err).
+			Str("provider", providerName).
+			Msg("Rejected provider webhook")
+		status := http.StatusUnauthorized
+		if errors.Is(err, domain.ErrUnknownWebhookProvider) {
+			status = http.StatusNotFound
+		}
+		respondJSON(w, status, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			OrderID       string `json:"order_id"`
+			TransactionID string `json:"transaction_id"`
+			Reason        string `json:"reason"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" || event.Type == "" {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid webhook payload",
+		})
+		return
+	}
+
+	cmd := command.HandleWebhookCommand{
+		Provider:      providerName,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		OrderID:       event.Data.OrderID,
+		TransactionID: event.Data.TransactionID,
+		Reason:        event.Data.Reason,
+	}
+
+	applied, err := h.webhookHandler.Handle(r.Context(), cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnsupportedWebhookEvent):
+			// Acknowledge so the provider does not retry events we do not act on
+			respondJSON(w, http.StatusOK, Response{
+				Success: true,
+				Message: "Webhook event ignored",
+			})
+		case errors.Is(err, domain.ErrInvalidStatusTransition):
+			logger.Logger.Warn().Err(err).Str("event_id", event.ID).Msg("Provider webhook rejected by payment state machine")
+			respondJSON(w, http.StatusConflict, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondJSON(w, http.StatusNotFound, Response{
+				Success: false,
+				Error:   "Payment not found",
+			})
+		default:
+			logger.Logger.Error().Err(err).Str("event_id", event.ID).Msg("Failed to handle provider webhook")
+			respondJSON(w, http.StatusInternalServerError, Response{
+				Success: false,
+				Error:   "Failed to handle webhook",
+			})
+		}
+		return
+	}
+
+	message := "Webhook processed"
+	if !applied {
+		message = "Webhook already processed"
+	}
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: message,
+	})
+}
+
+// GetMyPayments handles GET /api/payments/my (authenticated user). It takes
+// the same filters as ListPayments except user_id.
 func (h *PaymentHandler) GetMyPayments(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(UserIDKey).(uint)
 	if !ok {
@@ -331,21 +708,211 @@ func (h *PaymentHandler) GetMyPayments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, err := parsePaymentSearch(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	q := query.GetMyPaymentsQuery{
+		UserID:              userID,
+		PaymentSearchParams: params,
+	}
+
+	page, err := h.getMyHandler.Handle(q)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSearch) {
+			respondJSON(w, http.StatusBadRequest, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		logger.Logger.Error().Err(err).Msg("Failed to get user payments")
+		respondJSON(w, http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   "Failed to get payments",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    page,
+	})
+}
+
+// PlaceOrder handles POST /api/orders
+func (h *PaymentHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(uint)
+	if !ok {
+		respondJSON(w, http.StatusUnauthorized, Response{
+			Success: false,
+			Error:   "User ID not found in context",
+		})
+		return
+	}
+
+	var req struct {
+		Lines []struct {
+			ProductID uint  `json:"product_id"`
+			Quantity  int32 `json:"quantity"`
+		} `json:"lines"`
+		Currency       string `json:"currency"`
+		PaymentMethod  string `json:"payment_method"`
+		CardNumber     string `json:"card_number"`
+		ShippingRegion string `json:"shipping_region"` // optional, used to ship from the nearest location
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+		return
+	}
+
+	cmd := command.PlaceOrderCommand{
+		UserID:         userID,
+		Currency:       req.Currency,
+		PaymentMethod:  req.PaymentMethod,
+		CardNumber:     req.CardNumber,
+		Origin:         h.paymentOrigin(r),
+		ShippingRegion: req.ShippingRegion,
+	}
+	for _, line := range req.Lines {
+		cmd.Lines = append(cmd.Lines, command.OrderLineInput{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+		})
+	}
+
+	order, payment, err := h.placeOrderHandler.Handle(r.Context(), cmd)
+	if err != nil {
+		logger.Logger.Error().Err(err).Uint("user_id", userID).Msg("Failed to place order")
+		data := map[string]interface{}{
+			"order":   order,
+			"payment": payment,
+		}
+		switch {
+		case errors.Is(err, domain.ErrInsufficientStock):
+			respondJSON(w, http.StatusConflict, Response{
+				Success: false,
+				Error:   err.Error(),
+				Data:    data,
+			})
+		case errors.Is(err, domain.ErrProductUnavailable), errors.Is(err, money.ErrCurrencyMismatch),
+			errors.Is(err, domain.ErrFXRateUnavailable), errors.Is(err, money.ErrInvalidCurrency):
+			respondJSON(w, http.StatusUnprocessableEntity, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case errors.Is(err, domain.ErrPaymentDeclined):
+			respondJSON(w, http.StatusPaymentRequired, Response{
+				Success: false,
+				Error:   err.Error(),
+				Data:    data,
+			})
+		case errors.Is(err, domain.ErrProviderTimeout):
+			respondJSON(w, http.StatusGatewayTimeout, Response{
+				Success: false,
+				Error:   err.Error(),
+				Data:    data,
+			})
+		case errors.Is(err, domain.ErrPaymentRiskDenied):
+			respondJSON(w, http.StatusForbidden, Response{
+				Success: false,
+				Error:   err.Error(),
+				Data:    data,
+			})
+		case errors.Is(err, domain.ErrCaptureNotRecorded):
+			// Charged: the checkout completes in the background, so the
+			// client must not retry it
+			respondJSON(w, http.StatusAccepted, Response{
+				Success: true,
+				Message: "Order paid, the checkout will be completed shortly",
+				Data:    data,
+			})
+		default:
+			respondJSON(w, http.StatusBadRequest, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Message: "Order placed successfully",
+		Data: map[string]interface{}{
+			"order":   order,
+			"payment": payment,
+		},
+	})
+}
+
+// GetOrder handles GET /api/orders/{id} (owner or admin)
+func (h *PaymentHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "Invalid order ID",
+		})
+		return
+	}
+
+	q := query.GetOrderQuery{ID: uint(id)}
+	order, err := h.getOrderHandler.Handle(q)
+
+	// Other users' orders are reported as missing rather than forbidden
+	userID, _ := r.Context().Value(UserIDKey).(uint)
+	role, _ := r.Context().Value(RoleKey).(string)
+	if err != nil || (order.UserID != userID && role != "admin") {
+		respondJSON(w, http.StatusNotFound, Response{
+			Success: false,
+			Error:   "Order not found",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    order,
+	})
+}
+
+// GetMyOrders handles GET /api/orders/my (authenticated user)
+func (h *PaymentHandler) GetMyOrders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(uint)
+	if !ok {
+		respondJSON(w, http.StatusUnauthorized, Response{
+			Success: false,
+			Error:   "User ID not found in context",
+		})
+		return
+	}
+
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 
-	q := query.GetMyPaymentsQuery{
+	q := query.GetMyOrdersQuery{
 		UserID: userID,
 		Limit:  limit,
 		Offset: offset,
 	}
 
-	payments, err := h.getMyHandler.Handle(q)
+	orders, err := h.getMyOrdersHandler.Handle(q)
 	if err != nil {
-		logger.Logger.Error().Err(err).Msg("Failed to get user payments")
+		logger.Logger.Error().Err(err).Msg("Failed to get user orders")
 		respondJSON(w, http.StatusInternalServerError, Response{
 			Success: false,
-			Error:   "Failed to get payments",
+			Error:   "Failed to get orders",
 		})
 		return
 	}
@@ -353,15 +920,37 @@ func (h *PaymentHandler) GetMyPayments(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, Response{
 		Success: true,
 		Data: map[string]interface{}{
-			"payments": payments,
-			"total":    len(payments),
+			"orders": orders,
+			"total":  len(orders),
 		},
 	})
 }
 
+// GetCheckoutHandler returns the checkout saga handler (for startup recovery)
+func (h *PaymentHandler) GetCheckoutHandler() *command.CheckoutHandler {
+	return h.checkoutHandler
+}
+
+// GetPlaceOrderHandler returns the order checkout handler (for startup recovery)
+func (h *PaymentHandler) GetPlaceOrderHandler() *command.PlaceOrderHandler {
+	return h.placeOrderHandler
+}
+
+// GetOutboxRelay returns the outbox relay handler (for the background relay loop)
+func (h *PaymentHandler) GetOutboxRelay() *command.RelayOutboxHandler {
+	return h.outboxRelay
+}
+
+// GetIdempotencyKeys returns the idempotency key repository (for the background purge loop)
+func (h *PaymentHandler) GetIdempotencyKeys() domain.IdempotencyKeyRepository {
+	return h.idempotency.Keys
+}
+
 // GetMiddlewareConfig returns middleware configuration
 func (h *PaymentHandler) GetMiddlewareConfig() MiddlewareConfig {
-	return DefaultMiddlewareConfig(h.userClient)
+	config := DefaultMiddlewareConfig(h.userClient)
+	config.Idempotency = h.idempotency
+	return config
 }
 
 // RegisterRoutes registers all payment routes
@@ -373,12 +962,22 @@ func (h *PaymentHandler) RegisterRoutes(router *mux.Router) {
 
 	// Authenticated user routes (any logged-in user)
 	router.HandleFunc("/api/payments/my", middlewareConfig.GetAuthMiddleware()(h.GetMyPayments)).Methods("GET")
-	router.HandleFunc("/api/payments", middlewareConfig.GetAuthMiddleware()(h.CreatePayment)).Methods("POST")
+	router.HandleFunc("/api/payments", middlewareConfig.GetAuthMiddleware()(middlewareConfig.GetIdempotencyMiddleware()(h.CreatePayment))).Methods("POST")
+
+	router.HandleFunc("/api/orders/my", middlewareConfig.GetAuthMiddleware()(h.GetMyOrders)).Methods("GET")
+	router.HandleFunc("/api/orders", middlewareConfig.GetAuthMiddleware()(middlewareConfig.GetIdempotencyMiddleware()(h.PlaceOrder))).Methods("POST")
+	router.HandleFunc("/api/orders/{id}", middlewareConfig.GetAuthMiddleware()(h.GetOrder)).Methods("GET")
+
+	// Provider webhooks (authenticated by signature)
+	router.HandleFunc("/api/payments/webhooks/{provider}", h.HandleProviderWebhook).Methods("POST")
 
 	// Admin routes (require admin role)
 	router.HandleFunc("/api/payments", middlewareConfig.GetAdminMiddleware()(h.ListPayments)).Methods("GET")
 	router.HandleFunc("/api/payments/{id}", middlewareConfig.GetAdminMiddleware()(h.GetPayment)).Methods("GET")
 	router.HandleFunc("/api/payments/{id}/status", middlewareConfig.GetAdminMiddleware()(h.UpdatePaymentStatus)).Methods("PATCH")
+	router.HandleFunc("/api/payments/{id}/history", middlewareConfig.GetAdminMiddleware()(h.GetPaymentHistory)).Methods("GET")
+	router.HandleFunc("/api/payments/{id}/refunds", middlewareConfig.GetAdminMiddleware()(h.RefundPayment)).Methods("POST")
+	router.HandleFunc("/api/payments/{id}/refunds", middlewareConfig.GetAdminMiddleware()(h.ListRefunds)).Methods("GET")
 }
 
 // RegisterHealthCheck registers health check endpoint
@@ -399,6 +998,107 @@ func (h *PaymentHandler) RegisterHealthCheck(router *mux.Router, db *sql.DB) {
 	}).Methods("GET")
 }
 
+// parsePaymentSearch reads the search parameters of the payment list
+// endpoints. List parameters take comma-separated values or repeat.
+func parsePaymentSearch(r *http.Request) (query.PaymentSearchParams, error) {
+	values := r.URL.Query()
+	params := query.PaymentSearchParams{
+		Statuses:       listParam(values["status"]),
+		PaymentMethods: listParam(values["payment_method"]),
+		RiskDecisions:  listParam(values["risk_decision"]),
+		Currency:       values.Get("currency"),
+		MinAmount:      values.Get("min_amount"),
+		MaxAmount:      values.Get("max_amount"),
+		Sort:           values.Get("sort"),
+		Cursor:         values.Get("cursor"),
+	}
+
+	var err error
+	if params.Limit, err = intParam(values.Get("limit")); err != nil {
+		return params, fmt.Errorf("invalid limit")
+	}
+	if params.Offset, err = intParam(values.Get("offset")); err != nil {
+		return params, fmt.Errorf("invalid offset")
+	}
+	if params.CreatedFrom, err = timeParam(values.Get("created_from")); err != nil {
+		return params, fmt.Errorf("invalid created_from, expected RFC 3339")
+	}
+	if params.CreatedTo, err = timeParam(values.Get("created_to")); err != nil {
+		return params, fmt.Errorf("invalid created_to, expected RFC 3339")
+	}
+	return params, nil
+}
+
+func listParam(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+func intParam(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func timeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// paymentOrigin describes the caller of a payment request for the risk
+// check. X-Forwarded-For is only honoured when the request came through a
+// trusted proxy such as the API gateway.
+func (h *PaymentHandler) paymentOrigin(r *http.Request) domain.PaymentOrigin {
+	origin := domain.PaymentOrigin{
+		ClientIP: h.trustedProxies.ClientIP(r.RemoteAddr, r.Header.Values("X-Forwarded-For")),
+	}
+	if createdAt, ok := r.Context().Value(AccountCreatedAtKey).(time.Time); ok {
+		origin.AccountCreatedAt = &createdAt
+	}
+	return origin
+}
+
+// accountCreatedAt looks up when a payer's account was created for the risk
+// check. When the lookup fails the account is checked as new.
+func (h *PaymentHandler) accountCreatedAt(ctx context.Context, userID uint) *time.Time {
+	createdAt := time.Now()
+	if h.userClient != nil {
+		token, _ := ctx.Value(TokenKey).(string)
+		found, err := h.userClient.AccountCreatedAt(ctx, userID, token)
+		if err != nil {
+			logger.Logger.Warn().
+				Err(err).
+				Uint("user_id", userID).
+				Msg("Failed to look up account age, checking the payment as a new account")
+		} else {
+			createdAt = found
+		}
+	}
+	return &createdAt
+}
+
+// providerErrorStatus maps a failed provider call to a gateway status code
+func providerErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrProviderTimeout) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
 // respondJSON sends a JSON response
 func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
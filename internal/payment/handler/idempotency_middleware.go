@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client-chosen key of a retryable request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed for a retried key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBodyBytes  = 1 << 20
+)
+
+// IdempotencyConfig configures Idempotency-Key handling
+type IdempotencyConfig struct {
+	Keys domain.IdempotencyKeyRepository
+	TTL  time.Duration // how long keys are remembered; 0 disables idempotency
+}
+
+// IdempotencyMiddleware makes a request safe to retry when the client sends an
+// Idempotency-Key header. The first request with a key runs normally and its
+// response is stored; retries with the same key and body get the stored
+// response back. Reusing a key with a different body is rejected with 422,
+// and a retry that arrives while the first request is still running gets 409.
+// It must run after AuthMiddleware since keys are scoped per user.
+func IdempotencyMiddleware(config IdempotencyConfig) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if config.Keys == nil || config.TTL <= 0 {
+			return next
+		}
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				respondError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			userID, _ := r.Context().Value(UserIDKey).(uint)
+			scope := fmt.Sprintf("user:%d:%s %s", userID, r.Method, r.URL.Path)
+			hash := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(hash[:])
+
+			now := time.Now()
+			existing, claimed, err := config.Keys.Claim(&domain.IdempotencyKey{
+				Scope:       scope,
+				Key:         key,
+				RequestHash: requestHash,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(config.TTL),
+			})
+			if err != nil {
+				logger.Logger.Error().Err(err).Str("scope", scope).Msg("Failed to claim idempotency key")
+				respondError(w, http.StatusInternalServerError, "Failed to process Idempotency-Key")
+				return
+			}
+
+			if !claimed {
+				switch {
+				case existing.RequestHash != requestHash:
+					logger.Logger.Warn().Str("scope", scope).Msg("Idempotency key reused with a different request")
+					respondError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request body")
+				case existing.Status != domain.IdempotencyCompleted:
+					respondError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+				default:
+					logger.Logger.Info().Str("scope", scope).Msg("Replaying response for idempotency key")
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(existing.ResponseStatus)
+					w.Write(existing.ResponseBody)
+				}
+				return
+			}
+
+			// A panic must not leave the key stuck in progress until it expires
+			defer func() {
+				if p := recover(); p != nil {
+					config.Keys.Release(scope, key)
+					panic(p)
+				}
+			}()
+
+			recorder := &idempotencyRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// Server errors other than a provider timeout happen before a payment
+			// exists, so the key is released and the client can simply retry
+			if recorder.statusCode >= http.StatusInternalServerError && recorder.statusCode != http.StatusGatewayTimeout {
+				if err := config.Keys.Release(scope, key); err != nil {
+					logger.Logger.Error().Err(err).Str("scope", scope).Msg("Failed to release idempotency key")
+				}
+				return
+			}
+			if err := config.Keys.Complete(scope, key, recorder.statusCode, recorder.body.Bytes()); err != nil {
+				logger.Logger.Error().Err(err).Str("scope", scope).Msg("Failed to store idempotent response")
+			}
+		}
+	}
+}
+
+// idempotencyRecorder wraps http.ResponseWriter to keep a copy of the response
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(code int) {
+	rec.statusCode = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/tair/full-observability/internal/payment/client"
 	"github.com/tair/full-observability/pkg/auth"
@@ -17,6 +18,10 @@ const (
 	UserIDKey   contextKey = "user_id"
 	UsernameKey contextKey = "username"
 	RoleKey     contextKey = "role"
+	TokenKey    contextKey = "token"
+
+	// AccountCreatedAtKey holds the time.Time the caller's account was created
+	AccountCreatedAtKey contextKey = "account_created_at"
 )
 
 // AuthMiddleware validates JWT token using User Service gRPC client
@@ -76,6 +81,14 @@ func AuthMiddleware(userClient *client.UserServiceClient) func(http.HandlerFunc)
 			ctx = context.WithValue(r.Context(), UserIDKey, claims.UserID)
 			ctx = context.WithValue(ctx, UsernameKey, user.Username)
 			ctx = context.WithValue(ctx, RoleKey, user.Role)
+			ctx = context.WithValue(ctx, TokenKey, token)
+
+			// Accounts of unknown age are checked as new
+			createdAt := time.Now()
+			if user.CreatedAt != nil {
+				createdAt = user.CreatedAt.AsTime()
+			}
+			ctx = context.WithValue(ctx, AccountCreatedAtKey, createdAt)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
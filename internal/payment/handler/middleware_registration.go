@@ -12,6 +12,7 @@ type MiddlewareConfig struct {
 	EnableLogging bool
 	EnableTracing bool
 	UserClient    *client.UserServiceClient
+	Idempotency   IdempotencyConfig
 }
 
 // DefaultMiddlewareConfig returns default middleware configuration
@@ -47,3 +48,8 @@ func (config MiddlewareConfig) GetAuthMiddleware() func(http.HandlerFunc) http.H
 func (config MiddlewareConfig) GetAdminMiddleware() func(http.HandlerFunc) http.HandlerFunc {
 	return AdminMiddleware(config.UserClient)
 }
+
+// GetIdempotencyMiddleware returns the Idempotency-Key middleware
+func (config MiddlewareConfig) GetIdempotencyMiddleware() func(http.HandlerFunc) http.HandlerFunc {
+	return IdempotencyMiddleware(config.Idempotency)
+}
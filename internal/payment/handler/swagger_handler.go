@@ -19,16 +19,21 @@ func RegisterSwaggerDocs(router *mux.Router, swaggerHandler http.Handler) {
 
 // CreatePayment godoc
 // @Summary Create a new payment
-// @Description Create a new payment with product purchase (Authenticated users)
+// @Description Create a new payment with product purchase (Authenticated users). The amount is calculated from the product price, quantity and pricing pipeline; a client-supplied amount must match it.
 // @Tags Payments
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param request body object{user_id=int,product_id=int,quantity=int,amount=number,currency=string,payment_method=string} true "Payment data"
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body return the original response"
+// @Param request body object{user_id=int,product_id=int,quantity=int,amount=number,currency=string,payment_method=string,card_number=string} true "Payment data"
 // @Success 201 {object} object{success=bool,message=string,data=object}
 // @Failure 400 {object} object{success=bool,error=string}
 // @Failure 401 {object} object{success=bool,error=string}
+// @Failure 402 {object} object{success=bool,error=string,data=object}
+// @Failure 409 {object} object{success=bool,error=string}
+// @Failure 422 {object} object{success=bool,error=string}
 // @Failure 503 {object} object{success=bool,error=string}
+// @Failure 504 {object} object{success=bool,error=string,data=object}
 // @Router /api/payments [post]
 func (h *PaymentHandler) CreatePaymentDoc() {}
 
@@ -62,19 +67,84 @@ func (h *PaymentHandler) ListPaymentsDoc() {}
 
 // UpdatePaymentStatus godoc
 // @Summary Update payment status
-// @Description Update the status of a payment (Admin only)
+// @Description Update the status of a payment (Admin only). Moving a payment to refunded issues a full refund of its remaining balance.
 // @Tags Payments
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "Payment ID"
-// @Param request body object{status=string} true "Status data (pending/completed/failed/refunded)"
+// @Param request body object{status=string,reason=string} true "Status data (pending/completed/failed/refunded) and an optional reason"
 // @Success 200 {object} object{success=bool,message=string}
 // @Failure 400 {object} object{success=bool,error=string}
 // @Failure 403 {object} object{success=bool,error=string}
+// @Failure 409 {object} object{success=bool,error=string}
 // @Router /api/payments/{id}/status [patch]
 func (h *PaymentHandler) UpdatePaymentStatusDoc() {}
 
+// GetPaymentHistory godoc
+// @Summary Get payment status history
+// @Description Get the status transitions of a payment, oldest first (Admin only)
+// @Tags Payments
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Success 200 {object} object{success=bool,data=object{history=array,total=int}}
+// @Failure 400 {object} object{success=bool,error=string}
+// @Failure 403 {object} object{success=bool,error=string}
+// @Failure 404 {object} object{success=bool,error=string}
+// @Router /api/payments/{id}/history [get]
+func (h *PaymentHandler) GetPaymentHistoryDoc() {}
+
+// RefundPayment godoc
+// @Summary Refund a payment
+// @Description Refund part or all of a completed payment (Admin only). Omit amount to refund the remaining balance; quantity is the number of purchased items returned to stock.
+// @Tags Payments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Param request body object{amount=number,quantity=int,reason=string} true "Refund data"
+// @Success 201 {object} object{success=bool,message=string,data=object}
+// @Failure 400 {object} object{success=bool,error=string}
+// @Failure 403 {object} object{success=bool,error=string}
+// @Failure 404 {object} object{success=bool,error=string}
+// @Failure 409 {object} object{success=bool,error=string}
+// @Failure 502 {object} object{success=bool,error=string,data=object}
+// @Failure 504 {object} object{success=bool,error=string,data=object}
+// @Router /api/payments/{id}/refunds [post]
+func (h *PaymentHandler) RefundPaymentDoc() {}
+
+// ListRefunds godoc
+// @Summary List payment refunds
+// @Description Get the refunds of a payment, oldest first (Admin only)
+// @Tags Payments
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Success 200 {object} object{success=bool,data=object{refunds=array,total=int}}
+// @Failure 400 {object} object{success=bool,error=string}
+// @Failure 403 {object} object{success=bool,error=string}
+// @Failure 404 {object} object{success=bool,error=string}
+// @Router /api/payments/{id}/refunds [get]
+func (h *PaymentHandler) ListRefundsDoc() {}
+
+// HandleProviderWebhook godoc
+// @Summary Provider webhook
+// @Description Confirm a payment asynchronously. The body must be signed with the provider's secret in the X-Webhook-Signature header ("t=<unix>,v1=<hex HMAC-SHA256 of t.body>"); redelivered events are acknowledged without effect.
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param X-Webhook-Signature header string true "Webhook signature"
+// @Param request body object{id=string,type=string,data=object{order_id=string,transaction_id=string,reason=string}} true "Webhook event (charge.succeeded/charge.failed/charge.refunded)"
+// @Success 200 {object} object{success=bool,message=string}
+// @Failure 400 {object} object{success=bool,error=string}
+// @Failure 401 {object} object{success=bool,error=string}
+// @Failure 404 {object} object{success=bool,error=string}
+// @Failure 409 {object} object{success=bool,error=string}
+// @Router /api/payments/webhooks/{provider} [post]
+func (h *PaymentHandler) HandleProviderWebhookDoc() {}
+
 // GetMyPayments godoc
 // @Summary Get my payments
 // @Description Get payments for the authenticated user
@@ -89,6 +159,53 @@ func (h *PaymentHandler) UpdatePaymentStatusDoc() {}
 // @Router /api/payments/my [get]
 func (h *PaymentHandler) GetMyPaymentsDoc() {}
 
+// PlaceOrder godoc
+// @Summary Place an order
+// @Description Place a multi-line order (Authenticated users). Lines are priced from the product catalog, the stock of every line is reserved, and the order total is charged; if any line cannot be reserved or the charge fails, no stock is held.
+// @Tags Orders
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body return the original response"
+// @Param request body object{lines=[]object{product_id=int,quantity=int},currency=string,payment_method=string,card_number=string} true "Order data"
+// @Success 201 {object} object{success=bool,message=string,data=object{order=object,payment=object}}
+// @Failure 400 {object} object{success=bool,error=string}
+// @Failure 401 {object} object{success=bool,error=string}
+// @Failure 402 {object} object{success=bool,error=string,data=object}
+// @Failure 409 {object} object{success=bool,error=string,data=object}
+// @Failure 422 {object} object{success=bool,error=string}
+// @Failure 504 {object} object{success=bool,error=string,data=object}
+// @Router /api/orders [post]
+func (h *PaymentHandler) PlaceOrderDoc() {}
+
+// GetOrder godoc
+// @Summary Get order by ID
+// @Description Get an order with its lines (owner or admin)
+// @Tags Orders
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} object{success=bool,data=object}
+// @Failure 400 {object} object{success=bool,error=string}
+// @Failure 401 {object} object{success=bool,error=string}
+// @Failure 404 {object} object{success=bool,error=string}
+// @Router /api/orders/{id} [get]
+func (h *PaymentHandler) GetOrderDoc() {}
+
+// GetMyOrders godoc
+// @Summary Get my orders
+// @Description Get orders of the authenticated user
+// @Tags Orders
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} object{success=bool,data=object{orders=array,total=int}}
+// @Failure 401 {object} object{success=bool,error=string}
+// @Failure 500 {object} object{success=bool,error=string}
+// @Router /api/orders/my [get]
+func (h *PaymentHandler) GetMyOrdersDoc() {}
+
 // HealthCheck godoc
 // @Summary Health check
 // @Description Check service health and database connectivity
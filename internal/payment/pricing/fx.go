@@ -0,0 +1,91 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// StaticRates is a fixed table of exchange rates against a base currency,
+// for offline use and tests. Rates between two quoted currencies are crossed
+// through the base.
+type StaticRates struct {
+	Base  string             `json:"base"`
+	AsOf  time.Time          `json:"as_of"`
+	Rates map[string]float64 `json:"rates"` // major units of the currency per major unit of Base
+
+	source string
+}
+
+// LoadStaticRates reads a rate table from a JSON file such as
+//
+//	{"base": "USD", "as_of": "2026-01-02T00:00:00Z", "rates": {"EUR": 0.92, "JPY": 151.2}}
+func LoadStaticRates(path string) (*StaticRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates: %w", err)
+	}
+
+	var rates StaticRates
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates %s: %w", path, err)
+	}
+	rates.source = "static:" + path
+	if err := rates.validate(); err != nil {
+		return nil, fmt.Errorf("invalid exchange rates %s: %w", path, err)
+	}
+	return &rates, nil
+}
+
+func (s *StaticRates) validate() error {
+	s.Base = strings.ToUpper(s.Base)
+	if err := money.ValidateCurrency(s.Base); err != nil {
+		return err
+	}
+
+	rates := make(map[string]float64, len(s.Rates))
+	for currency, rate := range s.Rates {
+		if err := money.ValidateCurrency(currency); err != nil {
+			return err
+		}
+		if rate <= 0 {
+			return fmt.Errorf("rate for %s must be greater than 0", currency)
+		}
+		rates[strings.ToUpper(currency)] = rate
+	}
+	rates[s.Base] = 1
+	s.Rates = rates
+	return nil
+}
+
+// Rate implements domain.FXRateProvider
+func (s *StaticRates) Rate(ctx context.Context, from, to string) (*domain.FXRate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	fromRate, ok := s.Rates[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: no rate for %s", domain.ErrFXRateUnavailable, from)
+	}
+	toRate, ok := s.Rates[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: no rate for %s", domain.ErrFXRateUnavailable, to)
+	}
+
+	source := s.source
+	if source == "" {
+		source = "static"
+	}
+	return &domain.FXRate{
+		From:   from,
+		To:     to,
+		Rate:   toRate / fromRate,
+		Source: source,
+		AsOf:   s.AsOf,
+	}, nil
+}
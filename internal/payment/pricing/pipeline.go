@@ -0,0 +1,91 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// Pipeline prices a subtotal by converting it into the charged currency and
+// running its rules in order. A pipeline without rules charges the subtotal
+// as is.
+type Pipeline struct {
+	rules []domain.PriceRule
+	rates domain.FXRateProvider // optional; without it only the catalog currency can be charged
+}
+
+// NewPipeline creates a pricing pipeline
+func NewPipeline(rules ...domain.PriceRule) *Pipeline {
+	return &Pipeline{rules: rules}
+}
+
+// Use appends rules to the pipeline
+func (p *Pipeline) Use(rules ...domain.PriceRule) {
+	p.rules = append(p.rules, rules...)
+}
+
+// UseFXRates sets the exchange rates used to charge in a currency other than
+// the catalog's
+func (p *Pipeline) UseFXRates(rates domain.FXRateProvider) {
+	p.rates = rates
+}
+
+// Price implements domain.Pricer. An empty currency charges the catalog
+// currency. Rules run on the converted subtotal, so taxes and discounts are
+// rounded in the charged currency.
+func (p *Pipeline) Price(ctx context.Context, subtotal money.Money, currency string) (*domain.PriceQuote, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = subtotal.Currency
+	}
+	if err := money.ValidateCurrency(currency); err != nil {
+		return nil, err
+	}
+
+	quote := &domain.PriceQuote{}
+	if currency != subtotal.Currency {
+		conversion, err := p.convert(ctx, subtotal, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to price: %w", err)
+		}
+		quote.Conversion = conversion
+		if subtotal, err = subtotal.Convert(currency, conversion.Rate); err != nil {
+			return nil, fmt.Errorf("failed to price: %w", err)
+		}
+	}
+	quote.Subtotal = subtotal
+	quote.Total = subtotal
+
+	for _, rule := range p.rules {
+		if err := rule.Apply(ctx, quote); err != nil {
+			return nil, fmt.Errorf("failed to price: %w", err)
+		}
+	}
+
+	if quote.Total.IsNegative() {
+		return nil, fmt.Errorf("failed to price: total %s is negative", quote.Total)
+	}
+	return quote, nil
+}
+
+func (p *Pipeline) convert(ctx context.Context, subtotal money.Money, currency string) (*domain.FXConversion, error) {
+	if p.rates == nil {
+		return nil, fmt.Errorf("%w: no exchange rates configured for %s to %s", domain.ErrFXRateUnavailable, subtotal.Currency, currency)
+	}
+	rate, err := p.rates.Rate(ctx, subtotal.Currency, currency)
+	if err != nil {
+		return nil, err
+	}
+	if rate.Rate <= 0 {
+		return nil, fmt.Errorf("%w: invalid rate %v for %s to %s", domain.ErrFXRateUnavailable, rate.Rate, subtotal.Currency, currency)
+	}
+	return &domain.FXConversion{
+		From:   subtotal,
+		Rate:   rate.Rate,
+		Source: rate.Source,
+		AsOf:   rate.AsOf,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package pricing
+
+import (
+	"context"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+)
+
+// PercentageDiscount takes a percentage off the running total, rounded to the
+// currency's minor unit
+type PercentageDiscount struct {
+	Name    string
+	Percent float64 // 10 takes 10% off
+}
+
+// Apply implements domain.PriceRule
+func (d PercentageDiscount) Apply(ctx context.Context, quote *domain.PriceQuote) error {
+	if d.Percent <= 0 || !quote.Total.IsPositive() {
+		return nil
+	}
+	discount, err := quote.Total.MulRate(-d.Percent / 100)
+	if err != nil {
+		return err
+	}
+	return quote.Adjust(domain.AdjustmentDiscount, d.Name, discount)
+}
+
+// TaxRate adds a flat tax on the running total, rounded to the currency's
+// minor unit. Place it after discounts to tax the discounted price.
+type TaxRate struct {
+	Name string
+	Rate float64 // 0.18 adds 18%
+}
+
+// Apply implements domain.PriceRule
+func (t TaxRate) Apply(ctx context.Context, quote *domain.PriceQuote) error {
+	if t.Rate <= 0 || !quote.Total.IsPositive() {
+		return nil
+	}
+	tax, err := quote.Total.MulRate(t.Rate)
+	if err != nil {
+		return err
+	}
+	return quote.Adjust(domain.AdjustmentTax, t.Name, tax)
+}
@@ -0,0 +1,226 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// Test card numbers understood by the fake provider
+const (
+	FakeCardApproved          = "4242424242424242"
+	FakeCardDeclined          = "4000000000000002"
+	FakeCardInsufficientFunds = "4000000000009995"
+	FakeCardExpired           = "4000000000000069"
+	FakeCardTimeout           = "4000000000000119"
+)
+
+// FakeConfig configures the behaviour of the fake provider
+type FakeConfig struct {
+	Name         string
+	Latency      time.Duration     // added to every call
+	DeclineCards map[string]string // card number -> decline code
+	TimeoutCards []string          // card numbers whose authorization never answers
+	DeclineAbove float64           // amounts above this many major units are declined; 0 disables the rule
+}
+
+// DefaultFakeConfig returns a fake configuration that recognises the test cards
+func DefaultFakeConfig() FakeConfig {
+	return FakeConfig{
+		Name: "fake",
+		DeclineCards: map[string]string{
+			FakeCardDeclined:          "card_declined",
+			FakeCardInsufficientFunds: "insufficient_funds",
+			FakeCardExpired:           "expired_card",
+		},
+		TimeoutCards: []string{FakeCardTimeout},
+	}
+}
+
+// FakeProvider is a deterministic in-process payment provider for local runs
+// and end-to-end tests. Outcomes depend only on the card number and amount,
+// and authorization IDs are derived from the idempotency key.
+type FakeProvider struct {
+	config FakeConfig
+
+	mu             sync.Mutex
+	authorizations map[string]*fakeAuthorization // by authorization ID
+	transactions   map[string]*fakeAuthorization // by capture transaction ID
+}
+
+type fakeAuthorization struct {
+	id            string
+	key           string
+	amount        money.Money
+	transactionID string
+	voided        bool
+	refunded      int64 // minor units
+	refunds       int
+}
+
+// NewFakeProvider creates a fake provider
+func NewFakeProvider(config FakeConfig) *FakeProvider {
+	if config.Name == "" {
+		config.Name = "fake"
+	}
+	return &FakeProvider{
+		config:         config,
+		authorizations: make(map[string]*fakeAuthorization),
+		transactions:   make(map[string]*fakeAuthorization),
+	}
+}
+
+// Name returns the provider name
+func (p *FakeProvider) Name() string {
+	return p.config.Name
+}
+
+// Authorize places a hold unless a decline rule matches. Timeout cards block
+// until the context is done.
+func (p *FakeProvider) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.ProviderResult, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	card := strings.ReplaceAll(req.CardNumber, " ", "")
+	for _, timeoutCard := range p.config.TimeoutCards {
+		if card == timeoutCard {
+			<-ctx.Done()
+			return nil, fmt.Errorf("%s authorize: %w", p.Name(), ctx.Err())
+		}
+	}
+	if code, ok := p.config.DeclineCards[card]; ok {
+		return nil, &domain.DeclineError{Provider: p.Name(), Code: code, Reason: "card was declined"}
+	}
+	if p.config.DeclineAbove > 0 && req.Amount.Major() > p.config.DeclineAbove {
+		return nil, &domain.DeclineError{
+			Provider: p.Name(),
+			Code:     "amount_too_large",
+			Reason:   fmt.Sprintf("amount exceeds %.2f", p.config.DeclineAbove),
+		}
+	}
+	if req.IdempotencyKey == "" {
+		return nil, fmt.Errorf("%s authorize: idempotency key is required", p.Name())
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := "fake_auth_" + req.IdempotencyKey
+	if auth, ok := p.authorizations[id]; ok {
+		return &domain.ProviderResult{Reference: auth.id, Amount: auth.amount}, nil
+	}
+	p.authorizations[id] = &fakeAuthorization{id: id, key: req.IdempotencyKey, amount: req.Amount}
+	return &domain.ProviderResult{Reference: id, Amount: req.Amount}, nil
+}
+
+// Capture settles an authorization. Capturing it again returns the same transaction.
+func (p *FakeProvider) Capture(ctx context.Context, authorizationID string, amount money.Money) (*domain.ProviderResult, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	auth, ok := p.authorizations[authorizationID]
+	if !ok {
+		// State is in memory; accept authorizations issued before a restart
+		key, issued := strings.CutPrefix(authorizationID, "fake_auth_")
+		if !issued {
+			return nil, fmt.Errorf("%s capture: unknown authorization %s", p.Name(), authorizationID)
+		}
+		auth = &fakeAuthorization{id: authorizationID, key: key, amount: amount}
+		p.authorizations[authorizationID] = auth
+	}
+	if auth.voided {
+		return nil, fmt.Errorf("%s capture: authorization %s was voided", p.Name(), authorizationID)
+	}
+	cmp, err := amount.Cmp(auth.amount)
+	if err != nil {
+		return nil, fmt.Errorf("%s capture: %w", p.Name(), err)
+	}
+	if cmp > 0 {
+		return nil, fmt.Errorf("%s capture: amount %s exceeds authorized %s", p.Name(), amount, auth.amount)
+	}
+	if auth.transactionID == "" {
+		auth.transactionID = "fake_txn_" + auth.key
+		p.transactions[auth.transactionID] = auth
+	}
+	return &domain.ProviderResult{Reference: auth.transactionID, Amount: amount}, nil
+}
+
+// Void cancels an uncaptured authorization. Voiding an unknown or already
+// voided authorization succeeds, so a timed-out authorization can always be
+// cleaned up.
+func (p *FakeProvider) Void(ctx context.Context, authorizationID string) error {
+	if err := p.wait(ctx); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	auth, ok := p.authorizations[authorizationID]
+	if !ok {
+		return nil
+	}
+	if auth.transactionID != "" {
+		return fmt.Errorf("%s void: authorization %s was already captured", p.Name(), authorizationID)
+	}
+	auth.voided = true
+	return nil
+}
+
+// Refund returns captured funds, up to the captured amount in total
+func (p *FakeProvider) Refund(ctx context.Context, transactionID string, amount money.Money) (*domain.ProviderResult, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	auth, ok := p.transactions[transactionID]
+	if !ok {
+		key, issued := strings.CutPrefix(transactionID, "fake_txn_")
+		if !issued {
+			return nil, fmt.Errorf("%s refund: unknown transaction %s", p.Name(), transactionID)
+		}
+		auth = &fakeAuthorization{id: "fake_auth_" + key, key: key, amount: amount, transactionID: transactionID}
+		p.authorizations[auth.id] = auth
+		p.transactions[transactionID] = auth
+	}
+	if !amount.SameCurrency(auth.amount) {
+		return nil, fmt.Errorf("%s refund: %w: %s and %s", p.Name(), money.ErrCurrencyMismatch, amount.Currency, auth.amount.Currency)
+	}
+	if refundable := money.New(auth.amount.Minor-auth.refunded, auth.amount.Currency); amount.Minor > refundable.Minor {
+		return nil, fmt.Errorf("%s refund: amount %s exceeds refundable %s", p.Name(), amount, refundable)
+	}
+	auth.refunded += amount.Minor
+	auth.refunds++
+	return &domain.ProviderResult{
+		Reference: fmt.Sprintf("fake_rfnd_%s_%d", auth.key, auth.refunds),
+		Amount:    amount,
+	}, nil
+}
+
+// wait simulates the provider's network latency
+func (p *FakeProvider) wait(ctx context.Context) error {
+	if p.config.Latency <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(p.config.Latency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", p.Name(), ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// Gateway routes payments to providers by payment method. Every provider call
+// is bounded by the gateway timeout.
+type Gateway struct {
+	timeout         time.Duration
+	defaultProvider domain.PaymentProvider
+	byMethod        map[string]domain.PaymentProvider
+	byName          map[string]domain.PaymentProvider
+}
+
+// NewGateway creates a gateway that sends payment methods without a registered
+// provider to defaultProvider. A nil defaultProvider rejects them instead.
+func NewGateway(defaultProvider domain.PaymentProvider, timeout time.Duration) *Gateway {
+	g := &Gateway{
+		timeout:  timeout,
+		byMethod: make(map[string]domain.PaymentProvider),
+		byName:   make(map[string]domain.PaymentProvider),
+	}
+	if defaultProvider != nil {
+		g.defaultProvider = g.wrap(defaultProvider)
+		g.byName[defaultProvider.Name()] = g.defaultProvider
+	}
+	return g
+}
+
+// Register routes the given payment methods to a provider
+func (g *Gateway) Register(provider domain.PaymentProvider, methods ...string) {
+	wrapped := g.wrap(provider)
+	g.byName[provider.Name()] = wrapped
+	for _, method := range methods {
+		g.byMethod[method] = wrapped
+	}
+}
+
+// ProviderFor returns the provider for a payment method
+func (g *Gateway) ProviderFor(paymentMethod string) (domain.PaymentProvider, error) {
+	if provider, ok := g.byMethod[paymentMethod]; ok {
+		return provider, nil
+	}
+	if g.defaultProvider != nil {
+		return g.defaultProvider, nil
+	}
+	return nil, fmt.Errorf("%w: %q", domain.ErrUnsupportedPaymentMethod, paymentMethod)
+}
+
+// Provider returns a registered provider by name
+func (g *Gateway) Provider(name string) (domain.PaymentProvider, error) {
+	if provider, ok := g.byName[name]; ok {
+		return provider, nil
+	}
+	return nil, fmt.Errorf("unknown payment provider %q", name)
+}
+
+func (g *Gateway) wrap(provider domain.PaymentProvider) domain.PaymentProvider {
+	if g.timeout <= 0 {
+		return provider
+	}
+	return &timeoutProvider{PaymentProvider: provider, timeout: g.timeout}
+}
+
+// timeoutProvider bounds each call of a provider and reports an expired
+// deadline as domain.ErrProviderTimeout
+type timeoutProvider struct {
+	domain.PaymentProvider
+	timeout time.Duration
+}
+
+func (p *timeoutProvider) Authorize(ctx context.Context, req domain.AuthorizeRequest) (*domain.ProviderResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	result, err := p.PaymentProvider.Authorize(ctx, req)
+	return result, p.mapError("authorize", err)
+}
+
+func (p *timeoutProvider) Capture(ctx context.Context, authorizationID string, amount money.Money) (*domain.ProviderResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	result, err := p.PaymentProvider.Capture(ctx, authorizationID, amount)
+	return result, p.mapError("capture", err)
+}
+
+func (p *timeoutProvider) Void(ctx context.Context, authorizationID string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return p.mapError("void", p.PaymentProvider.Void(ctx, authorizationID))
+}
+
+func (p *timeoutProvider) Refund(ctx context.Context, transactionID string, amount money.Money) (*domain.ProviderResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	result, err := p.PaymentProvider.Refund(ctx, transactionID, amount)
+	return result, p.mapError("refund", err)
+}
+
+func (p *timeoutProvider) mapError(operation string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, domain.ErrProviderTimeout) {
+		return fmt.Errorf("%w: %s %s after %s", domain.ErrProviderTimeout, p.Name(), operation, p.timeout)
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+)
+
+// WebhookSignatureHeader carries the webhook signature in the form
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">"
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// WebhookVerifier authenticates provider webhooks with a shared secret per
+// provider. The signed timestamp must be within the tolerance of the current
+// time, so captured requests cannot be replayed later.
+type WebhookVerifier struct {
+	secrets   map[string]string // provider name -> secret
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// NewWebhookVerifier creates a verifier for the given provider secrets
+func NewWebhookVerifier(secrets map[string]string, tolerance time.Duration) *WebhookVerifier {
+	return &WebhookVerifier{secrets: secrets, tolerance: tolerance, now: time.Now}
+}
+
+// Verify checks the signature header of a webhook body sent by provider
+func (v *WebhookVerifier) Verify(provider, header string, body []byte) error {
+	secret, ok := v.secrets[provider]
+	if !ok || secret == "" {
+		return fmt.Errorf("%w: %q", domain.ErrUnknownWebhookProvider, provider)
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed %s header", domain.ErrInvalidWebhookSignature, WebhookSignatureHeader)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", domain.ErrInvalidWebhookSignature)
+	}
+	age := v.now().Sub(time.Unix(unix, 0))
+	if age > v.tolerance || age < -v.tolerance {
+		return fmt.Errorf("%w: signed %s ago", domain.ErrWebhookExpired, age.Round(time.Second))
+	}
+
+	// Several signatures are accepted so that secrets can be rotated
+	expected := computeSignature(secret, timestamp, body)
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return domain.ErrInvalidWebhookSignature
+}
+
+// SignWebhook returns the signature header value for a webhook body, as a
+// provider would send it
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(computeSignature(secret, t, body)))
+}
+
+func computeSignature(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+)
+
+func TestWebhookVerifierVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"id":"evt_1","type":"payment.captured"}`)
+	valid := SignWebhook("secret", now, body)
+
+	tests := []struct {
+		name     string
+		provider string
+		header   string
+		body     []byte
+		wantErr  error
+	}{
+		{"valid", "fake", valid, body, nil},
+		{"clock skew within tolerance", "fake", SignWebhook("secret", now.Add(4*time.Minute), body), body, nil},
+		{"rotated secret", "fake", valid + ",v1=" + "00ff", body, nil},
+		{"replayed after tolerance", "fake", SignWebhook("secret", now.Add(-6*time.Minute), body), body, domain.ErrWebhookExpired},
+		{"signed in the future", "fake", SignWebhook("secret", now.Add(6*time.Minute), body), body, domain.ErrWebhookExpired},
+		{"replayed with a fresh timestamp", "fake", "t=1700000000,v1=" + signatureOf(SignWebhook("secret", now.Add(-time.Hour), body)), body, domain.ErrInvalidWebhookSignature},
+		{"tampered body", "fake", valid, []byte(`{"id":"evt_1","type":"payment.refunded"}`), domain.ErrInvalidWebhookSignature},
+		{"wrong secret", "fake", SignWebhook("other", now, body), body, domain.ErrInvalidWebhookSignature},
+		{"not hex", "fake", "t=1700000000,v1=zz", body, domain.ErrInvalidWebhookSignature},
+		{"missing signature", "fake", "t=1700000000", body, domain.ErrInvalidWebhookSignature},
+		{"missing timestamp", "fake", "v1=" + signatureOf(valid), body, domain.ErrInvalidWebhookSignature},
+		{"invalid timestamp", "fake", "t=yesterday,v1=" + signatureOf(valid), body, domain.ErrInvalidWebhookSignature},
+		{"empty header", "fake", "", body, domain.ErrInvalidWebhookSignature},
+		{"unknown provider", "stripe", valid, body, domain.ErrUnknownWebhookProvider},
+		{"provider without secret", "disabled", valid, body, domain.ErrUnknownWebhookProvider},
+	}
+
+	verifier := NewWebhookVerifier(map[string]string{"fake": "secret", "disabled": ""}, 5*time.Minute)
+	verifier.now = func() time.Time { return now }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifier.Verify(tt.provider, tt.header, tt.body)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// signatureOf returns the v1 signature of a header written by SignWebhook
+func signatureOf(header string) string {
+	return header[strings.LastIndex(header, "=")+1:]
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"gorm.io/gorm"
+)
+
+type GormCheckoutSagaRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCheckoutSagaRepository(db *gorm.DB) *GormCheckoutSagaRepository {
+	return &GormCheckoutSagaRepository{db: db}
+}
+
+func (r *GormCheckoutSagaRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.CheckoutSaga{}, &domain.CheckoutSagaStep{})
+}
+
+func (r *GormCheckoutSagaRepository) Create(saga *domain.CheckoutSaga) error {
+	return r.db.Omit("Steps").Create(saga).Error
+}
+
+func (r *GormCheckoutSagaRepository) Update(saga *domain.CheckoutSaga) error {
+	return r.db.Omit("Steps").Save(saga).Error
+}
+
+func (r *GormCheckoutSagaRepository) FindByID(id uint) (*domain.CheckoutSaga, error) {
+	var saga domain.CheckoutSaga
+	err := r.db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&saga, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &saga, nil
+}
+
+func (r *GormCheckoutSagaRepository) FindIncomplete(idleSince time.Time, afterID uint, limit int) ([]domain.CheckoutSaga, error) {
+	var sagas []domain.CheckoutSaga
+	err := r.db.Where("status IN ?", []string{domain.SagaStatusRunning, domain.SagaStatusCompensating}).
+		Where("updated_at < ? AND id > ?", idleSince, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&sagas).Error
+	return sagas, err
+}
+
+func (r *GormCheckoutSagaRepository) RecordStep(sagaID uint, step, outcome, errMsg string) error {
+	return r.db.Create(&domain.CheckoutSagaStep{
+		SagaID:  sagaID,
+		Step:    step,
+		Outcome: outcome,
+		Error:   errMsg,
+	}).Error
+}
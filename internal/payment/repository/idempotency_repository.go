@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormIdempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIdempotencyKeyRepository(db *gorm.DB) *GormIdempotencyKeyRepository {
+	return &GormIdempotencyKeyRepository{db: db}
+}
+
+func (r *GormIdempotencyKeyRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.IdempotencyKey{})
+}
+
+func (r *GormIdempotencyKeyRepository) Claim(key *domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error) {
+	var existing domain.IdempotencyKey
+	claimed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// An expired key no longer protects anything and can be claimed again
+		if err := tx.Where("scope = ? AND key = ? AND expires_at <= ?", key.Scope, key.Key, time.Now()).
+			Delete(&domain.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+
+		key.Status = domain.IdempotencyInProgress
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			claimed = true
+			return nil
+		}
+
+		return tx.Where("scope = ? AND key = ?", key.Scope, key.Key).First(&existing).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if claimed {
+		return nil, true, nil
+	}
+	return &existing, false, nil
+}
+
+func (r *GormIdempotencyKeyRepository) Complete(scope, key string, responseStatus int, responseBody []byte) error {
+	return r.db.Model(&domain.IdempotencyKey{}).
+		Where("scope = ? AND key = ?", scope, key).
+		Updates(map[string]interface{}{
+			"status":          domain.IdempotencyCompleted,
+			"response_status": responseStatus,
+			"response_body":   responseBody,
+		}).Error
+}
+
+func (r *GormIdempotencyKeyRepository) Release(scope, key string) error {
+	return r.db.Where("scope = ? AND key = ?", scope, key).
+		Delete(&domain.IdempotencyKey{}).Error
+}
+
+func (r *GormIdempotencyKeyRepository) PurgeExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&domain.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"gorm.io/gorm"
+)
+
+type GormOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOrderRepository(db *gorm.DB) *GormOrderRepository {
+	return &GormOrderRepository{db: db}
+}
+
+func (r *GormOrderRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.Order{}, &domain.OrderLine{})
+}
+
+func (r *GormOrderRepository) Create(order *domain.Order) error {
+	// Lines are inserted through the association in the same transaction
+	return r.db.Create(order).Error
+}
+
+func (r *GormOrderRepository) Update(order *domain.Order) error {
+	return r.db.Omit("Lines").Save(order).Error
+}
+
+func (r *GormOrderRepository) FindByID(id uint) (*domain.Order, error) {
+	var order domain.Order
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&order, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *GormOrderRepository) FindByOrderNumber(orderNumber string) (*domain.Order, error) {
+	var order domain.Order
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("order_number = ?", orderNumber).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *GormOrderRepository) FindByUserID(userID uint, limit, offset int) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *GormOrderRepository) FindIncomplete(idleSince time.Time, afterID uint, limit int) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("status IN ?", []string{domain.OrderStatusPending, domain.OrderStatusPaid, domain.OrderStatusCancelling}).
+		Where("updated_at < ? AND id > ?", idleSince, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
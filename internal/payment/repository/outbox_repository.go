@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOutboxRepository(db *gorm.DB) *GormOutboxRepository {
+	return &GormOutboxRepository{db: db}
+}
+
+func (r *GormOutboxRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.OutboxEvent{})
+}
+
+func (r *GormOutboxRepository) ClaimDue(now time.Time, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	var events []domain.OutboxEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var candidates []domain.OutboxEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domain.OutboxStatusPending, now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&candidates).Error; err != nil {
+			return err
+		}
+
+		var err error
+		if events, err = inKeyOrder(tx, candidates); err != nil {
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+		}
+
+		return tx.Model(&domain.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return events, err
+}
+
+// inKeyOrder drops the candidates behind an earlier pending event with the
+// same topic and key that is not a candidate itself, such as one waiting out
+// a failed attempt or leased by another relay. Events without a key have no
+// order to keep.
+func inKeyOrder(tx *gorm.DB, candidates []domain.OutboxEvent) ([]domain.OutboxEvent, error) {
+	type outboxKey struct{ topic, key string }
+
+	claimed := make(map[uint]bool, len(candidates))
+	seen := make(map[outboxKey]bool)
+	var keys [][]interface{}
+	var lastID uint
+	for _, event := range candidates {
+		claimed[event.ID] = true
+		lastID = event.ID
+		key := outboxKey{event.Topic, event.MessageKey}
+		if event.MessageKey != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, []interface{}{event.Topic, event.MessageKey})
+		}
+	}
+	if len(keys) == 0 {
+		return candidates, nil
+	}
+
+	var earlier []domain.OutboxEvent
+	if err := tx.Select("id, topic, message_key").
+		Where("status = ? AND id < ?", domain.OutboxStatusPending, lastID).
+		Where("(topic, message_key) IN ?", keys).
+		Find(&earlier).Error; err != nil {
+		return nil, err
+	}
+
+	blockedAfter := make(map[outboxKey]uint)
+	for _, event := range earlier {
+		key := outboxKey{event.Topic, event.MessageKey}
+		if first, ok := blockedAfter[key]; !claimed[event.ID] && (!ok || event.ID < first) {
+			blockedAfter[key] = event.ID
+		}
+	}
+
+	events := candidates[:0]
+	for _, event := range candidates {
+		if first, ok := blockedAfter[outboxKey{event.Topic, event.MessageKey}]; ok && event.ID > first {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (r *GormOutboxRepository) MarkSent(id uint, sentAt time.Time) error {
+	return r.db.Model(&domain.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     domain.OutboxStatusSent,
+			"sent_at":    sentAt,
+			"last_error": "",
+		}).Error
+}
+
+func (r *GormOutboxRepository) MarkFailed(id uint, errMsg string, nextAttemptAt time.Time) error {
+	return r.db.Model(&domain.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      errMsg,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
+
+func (r *GormOutboxRepository) Backlog() (int64, *time.Time, error) {
+	var result struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	err := r.db.Model(&domain.OutboxEvent{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where("status = ?", domain.OutboxStatusPending).
+		Scan(&result).Error
+	if err != nil {
+		return 0, nil, err
+	}
+	return result.Count, result.Oldest, nil
+}
+
+func (r *GormOutboxRepository) PurgeSent(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND sent_at < ?", domain.OutboxStatusSent, before).
+		Delete(&domain.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormRefundRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRefundRepository(db *gorm.DB) *GormRefundRepository {
+	return &GormRefundRepository{db: db}
+}
+
+func (r *GormRefundRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.Refund{}, &domain.RefundLine{})
+}
+
+func (r *GormRefundRepository) CreatePending(refund *domain.Refund) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// The payment row lock serializes refunds of the same payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, refund.PaymentID).Error; err != nil {
+			return err
+		}
+
+		// Refunds are validated against the payment's currency, so their
+		// minor units can be summed as is
+		var sums struct {
+			AmountMinor int64
+			Quantity    int32
+		}
+		if err := tx.Model(&domain.Refund{}).
+			Select("COALESCE(SUM(amount_minor), 0) AS amount_minor, COALESCE(SUM(quantity), 0) AS quantity").
+			Where("payment_id = ? AND status IN ?", payment.ID, []string{domain.RefundStatusPending, domain.RefundStatusSucceeded}).
+			Scan(&sums).Error; err != nil {
+			return err
+		}
+
+		totals := domain.RefundTotals{
+			Amount:   money.New(sums.AmountMinor, payment.Amount.Currency),
+			Quantity: sums.Quantity,
+		}
+
+		// Order payments refund their items by order line
+		var orderLines []domain.OrderLine
+		if payment.ProductID == 0 && payment.OrderID != "" {
+			if err := tx.Joins("JOIN orders ON orders.id = order_lines.order_id").
+				Where("orders.order_number = ?", payment.OrderID).
+				Order("order_lines.id ASC").
+				Find(&orderLines).Error; err != nil {
+				return err
+			}
+		}
+		if len(orderLines) > 0 {
+			var lineSums []struct {
+				ReservationID string
+				Quantity      int32
+			}
+			if err := tx.Model(&domain.RefundLine{}).
+				Select("refund_lines.reservation_id, SUM(refund_lines.quantity) AS quantity").
+				Joins("JOIN refunds ON refunds.id = refund_lines.refund_id").
+				Where("refunds.payment_id = ? AND refunds.status IN ?", payment.ID, []string{domain.RefundStatusPending, domain.RefundStatusSucceeded}).
+				Group("refund_lines.reservation_id").
+				Scan(&lineSums).Error; err != nil {
+				return err
+			}
+			totals.Lines = make(map[string]int32, len(lineSums))
+			for _, sum := range lineSums {
+				totals.Lines[sum.ReservationID] = sum.Quantity
+			}
+		}
+
+		if err := domain.ValidateRefund(&payment, orderLines, totals, refund); err != nil {
+			return err
+		}
+
+		refund.Status = domain.RefundStatusPending
+		return tx.Create(refund).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *GormRefundRepository) Complete(refundID uint, providerReference string, change domain.StatusChange, eventsFor func(*domain.Payment, *domain.Refund, string) ([]domain.OutboxEvent, error)) (*domain.Refund, *domain.Payment, error) {
+	var refund domain.Refund
+	var payment domain.Payment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Lines").First(&refund, refundID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, refund.PaymentID).Error; err != nil {
+			return err
+		}
+		if refund.Status != domain.RefundStatusPending {
+			return fmt.Errorf("refund %d is %s", refund.ID, refund.Status)
+		}
+
+		refund.Status = domain.RefundStatusSucceeded
+		refund.ProviderReference = providerReference
+		refund.LastError = ""
+		if err := tx.Omit("Lines").Save(&refund).Error; err != nil {
+			return err
+		}
+
+		previous := payment.Status
+		if err := payment.AddRefunded(refund.Amount); err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"refunded_amount_minor":    payment.RefundedAmount.Minor,
+			"refunded_amount_currency": payment.RefundedAmount.Currency,
+		}
+
+		if payment.IsFullyRefunded() {
+			if err := payment.Transition(domain.StatusRefunded); err != nil {
+				return err
+			}
+			updates["status"] = payment.Status
+
+			history := domain.PaymentStatusHistory{
+				PaymentID:   payment.ID,
+				FromStatus:  previous,
+				ToStatus:    payment.Status,
+				ActorUserID: change.ActorUserID,
+				Reason:      change.Reason,
+				TraceID:     change.TraceID,
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&payment).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		events, err := eventsFor(&payment, &refund, previous)
+		if err != nil {
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		return tx.Create(&events).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &refund, &payment, nil
+}
+
+func (r *GormRefundRepository) Fail(refundID uint, errMsg string) error {
+	return r.db.Model(&domain.Refund{}).
+		Where("id = ? AND status = ?", refundID, domain.RefundStatusPending).
+		Updates(map[string]interface{}{
+			"status":     domain.RefundStatusFailed,
+			"last_error": errMsg,
+		}).Error
+}
+
+func (r *GormRefundRepository) FindByPaymentID(paymentID uint) ([]domain.Refund, error) {
+	var refunds []domain.Refund
+	err := r.db.Preload("Lines").Where("payment_id = ?", paymentID).
+		Order("created_at ASC, id ASC").
+		Find(&refunds).Error
+	return refunds, err
+}
@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"fmt"
+
 	"github.com/tair/full-observability/internal/payment/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormPaymentRepository struct {
@@ -14,13 +17,32 @@ func NewGormPaymentRepository(db *gorm.DB) *GormPaymentRepository {
 }
 
 func (r *GormPaymentRepository) AutoMigrate() error {
-	return r.db.AutoMigrate(&domain.Payment{})
+	return r.db.AutoMigrate(&domain.Payment{}, &domain.PaymentStatusHistory{})
 }
 
 func (r *GormPaymentRepository) Create(payment *domain.Payment) error {
 	return r.db.Create(payment).Error
 }
 
+func (r *GormPaymentRepository) CreateWithOutbox(payment *domain.Payment, eventsFor func(*domain.Payment) ([]domain.OutboxEvent, error)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+
+		events, err := eventsFor(payment)
+		if err != nil {
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		return tx.Create(&events).Error
+	})
+}
+
 func (r *GormPaymentRepository) FindByID(id uint) (*domain.Payment, error) {
 	var payment domain.Payment
 	err := r.db.First(&payment, id).Error
@@ -39,29 +61,149 @@ func (r *GormPaymentRepository) FindByOrderID(orderID string) (*domain.Payment,
 	return &payment, nil
 }
 
-func (r *GormPaymentRepository) FindByUserID(userID uint, limit, offset int) ([]domain.Payment, error) {
+func (r *GormPaymentRepository) Search(search domain.PaymentSearch) ([]domain.Payment, int64, error) {
+	var total int64
+	if err := r.filter(search.Filter).Model(&domain.Payment{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	direction, after := "ASC", ">"
+	if search.Sort.Descending {
+		direction, after = "DESC", "<"
+	}
+	column := search.Sort.Column()
+
+	query := r.filter(search.Filter)
+	switch {
+	case search.After != nil:
+		value, err := search.After.SortValue(search.Sort)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Keyset pagination: continue after the (sort value, id) of the cursor
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, after), value, search.After.ID)
+	case search.Offset > 0:
+		query = query.Offset(search.Offset)
+	}
+
 	var payments []domain.Payment
-	err := r.db.Where("user_id = ?", userID).
-		Limit(limit).Offset(offset).
-		Order("created_at DESC").
+	err := query.
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(search.Limit).
 		Find(&payments).Error
-	return payments, err
+	return payments, total, err
 }
 
-func (r *GormPaymentRepository) FindAll(limit, offset int) ([]domain.Payment, error) {
-	var payments []domain.Payment
-	err := r.db.Limit(limit).Offset(offset).
-		Order("created_at DESC").
-		Find(&payments).Error
-	return payments, err
+// filter builds a query for the payments matching filter
+func (r *GormPaymentRepository) filter(filter domain.PaymentFilter) *gorm.DB {
+	query := r.db
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.PaymentMethods) > 0 {
+		query = query.Where("payment_method IN ?", filter.PaymentMethods)
+	}
+	if len(filter.RiskDecisions) > 0 {
+		query = query.Where("risk_decision IN ?", filter.RiskDecisions)
+	}
+	if filter.Currency != "" {
+		query = query.Where("amount_currency = ?", filter.Currency)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("amount_minor >= ?", filter.MinAmount.Minor)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("amount_minor <= ?", filter.MaxAmount.Minor)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+	return query
 }
 
 func (r *GormPaymentRepository) Update(payment *domain.Payment) error {
 	return r.db.Save(payment).Error
 }
 
-func (r *GormPaymentRepository) UpdateStatus(id uint, status string) error {
-	return r.db.Model(&domain.Payment{}).
-		Where("id = ?", id).
-		Update("status", status).Error
+func (r *GormPaymentRepository) SaveProviderReferences(id uint, authorizationID, transactionID string) error {
+	references := map[string]interface{}{}
+	if authorizationID != "" {
+		references["authorization_id"] = authorizationID
+	}
+	if transactionID != "" {
+		references["transaction_id"] = transactionID
+	}
+	if len(references) == 0 {
+		return nil
+	}
+	return r.db.Model(&domain.Payment{}).Where("id = ?", id).Updates(references).Error
+}
+
+func (r *GormPaymentRepository) UpdateStatusWithOutbox(change domain.StatusChange, eventsFor func(*domain.Payment, string) ([]domain.OutboxEvent, error)) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, change.PaymentID).Error; err != nil {
+			return err
+		}
+
+		if change.TransactionID != "" && payment.TransactionID == "" {
+			payment.TransactionID = change.TransactionID
+			if err := tx.Model(&payment).Update("transaction_id", payment.TransactionID).Error; err != nil {
+				return err
+			}
+		}
+
+		previous := payment.Status
+		if previous == change.To {
+			return nil
+		}
+		if err := payment.Transition(change.To); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&payment).Update("status", payment.Status).Error; err != nil {
+			return err
+		}
+
+		history := domain.PaymentStatusHistory{
+			PaymentID:   payment.ID,
+			FromStatus:  previous,
+			ToStatus:    payment.Status,
+			ActorUserID: change.ActorUserID,
+			Reason:      change.Reason,
+			TraceID:     change.TraceID,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		events, err := eventsFor(&payment, previous)
+		if err != nil {
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		return tx.Create(&events).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *GormPaymentRepository) FindStatusHistory(paymentID uint) ([]domain.PaymentStatusHistory, error) {
+	var history []domain.PaymentStatusHistory
+	err := r.db.Where("payment_id = ?", paymentID).
+		Order("created_at ASC, id ASC").
+		Find(&history).Error
+	return history, err
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormWebhookEventRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWebhookEventRepository(db *gorm.DB) *GormWebhookEventRepository {
+	return &GormWebhookEventRepository{db: db}
+}
+
+func (r *GormWebhookEventRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&domain.WebhookEvent{})
+}
+
+func (r *GormWebhookEventRepository) Claim(event *domain.WebhookEvent) (bool, error) {
+	if event.ReceivedAt.IsZero() {
+		event.ReceivedAt = time.Now()
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *GormWebhookEventRepository) Release(provider, eventID string) error {
+	return r.db.Where("provider = ? AND event_id = ?", provider, eventID).
+		Delete(&domain.WebhookEvent{}).Error
+}
@@ -0,0 +1,103 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+// Risk evaluation Prometheus metrics
+var (
+	riskDecisionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_service_risk_decisions_total",
+			Help: "Total number of payments evaluated by the risk check, by decision",
+		},
+		[]string{"decision"},
+	)
+
+	riskRuleHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_service_risk_rule_hits_total",
+			Help: "Total number of risk rule verdicts that flagged or denied a payment",
+		},
+		[]string{"rule", "decision"},
+	)
+
+	riskRuleErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_service_risk_rule_errors_total",
+			Help: "Total number of risk rules that could not be evaluated",
+		},
+		[]string{"rule"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(riskDecisionsTotal)
+	prometheus.MustRegister(riskRuleHitsTotal)
+	prometheus.MustRegister(riskRuleErrorsTotal)
+}
+
+// Engine evaluates a payment against every rule and keeps the most severe
+// verdict. An engine without rules allows every payment.
+type Engine struct {
+	rules []domain.RiskRule
+}
+
+// NewEngine creates a risk engine
+func NewEngine(rules ...domain.RiskRule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Use appends rules to the engine
+func (e *Engine) Use(rules ...domain.RiskRule) {
+	e.rules = append(e.rules, rules...)
+}
+
+// Rules returns the names of the rules, in order
+func (e *Engine) Rules() []string {
+	names := make([]string, 0, len(e.rules))
+	for _, rule := range e.rules {
+		names = append(names, rule.Name())
+	}
+	return names
+}
+
+// Evaluate implements domain.RiskEvaluator. A rule that fails, e.g. because
+// Redis is down, flags the payment for review rather than blocking sales.
+func (e *Engine) Evaluate(ctx context.Context, input domain.RiskInput) (*domain.RiskAssessment, error) {
+	assessment := domain.NewRiskAssessment()
+
+	for _, rule := range e.rules {
+		verdict, err := rule.Evaluate(ctx, input)
+		if err != nil {
+			riskRuleErrorsTotal.WithLabelValues(rule.Name()).Inc()
+			logger.Logger.Error().
+				Err(err).
+				Str("rule", rule.Name()).
+				Uint("user_id", input.UserID).
+				Msg("Risk rule failed, flagging payment for review")
+			verdict = domain.RiskVerdict{
+				Decision: domain.RiskDecisionReview,
+				Reason:   fmt.Sprintf("rule unavailable: %v", err),
+			}
+		}
+		verdict.Rule = rule.Name()
+		if !domain.IsValidRiskDecision(verdict.Decision) {
+			return nil, fmt.Errorf("risk rule %s returned unknown decision %q", rule.Name(), verdict.Decision)
+		}
+
+		if verdict.Decision != domain.RiskDecisionAllow {
+			riskRuleHitsTotal.WithLabelValues(verdict.Rule, verdict.Decision).Inc()
+		}
+		assessment.Add(verdict)
+	}
+
+	riskDecisionsTotal.WithLabelValues(assessment.Decision).Inc()
+	return assessment, nil
+}
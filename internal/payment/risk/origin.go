@@ -0,0 +1,87 @@
+package risk
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// TrustedProxies are the networks of the proxies in front of the payment
+// service, such as the API gateway. Only they may report the client address
+// in X-Forwarded-For; everyone else is identified by the peer address.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies reads proxies written as "10.0.0.0/8,192.168.1.10"; an
+// address without a prefix length is a single host
+func ParseTrustedProxies(value string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Trusts reports whether ip belongs to a trusted proxy
+func (t TrustedProxies) Trusts(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client that connected from peer, a
+// host or host:port. X-Forwarded-For values are only read when the peer is a
+// trusted proxy, and then from the right: the client is the first entry not
+// added by a trusted proxy, since everything to its left is whatever the
+// client sent.
+func (t TrustedProxies) ClientIP(peer string, forwardedFor []string) string {
+	ip := peer
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		ip = host
+	}
+	if !t.Trusts(ip) {
+		return ip
+	}
+
+	var entries []string
+	for _, header := range forwardedFor {
+		entries = append(entries, strings.Split(header, ",")...)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(entries[i])
+		if net.ParseIP(entry) == nil {
+			break
+		}
+		ip = entry
+		if !t.Trusts(entry) {
+			break
+		}
+	}
+	return ip
+}
@@ -0,0 +1,111 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// Limits are amounts by currency. Payments in a currency without a limit are
+// not checked.
+type Limits map[string]money.Money
+
+// ParseLimits reads limits written as "USD=1000,EUR=900.50", in major units
+func ParseLimits(value string) (Limits, error) {
+	limits := make(Limits)
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		currency, amount, ok := strings.Cut(entry, "=")
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !ok || money.ValidateCurrency(currency) != nil {
+			return nil, fmt.Errorf("invalid limit %q", entry)
+		}
+		limit, err := money.Parse(amount, currency)
+		if err != nil || !limit.IsPositive() {
+			return nil, fmt.Errorf("invalid limit %q", entry)
+		}
+		limits[currency] = limit
+	}
+	return limits, nil
+}
+
+// exceeds reports whether amount is above the limit of its currency
+func (l Limits) exceeds(amount money.Money) (money.Money, bool) {
+	limit, ok := l[amount.Currency]
+	if !ok {
+		return money.Money{}, false
+	}
+	cmp, err := amount.Cmp(limit)
+	return limit, err == nil && cmp > 0
+}
+
+// AmountThreshold flags payments above Review and denies payments above Deny
+type AmountThreshold struct {
+	Review Limits
+	Deny   Limits
+}
+
+// Name implements domain.RiskRule
+func (AmountThreshold) Name() string {
+	return "amount_threshold"
+}
+
+// Evaluate implements domain.RiskRule
+func (t AmountThreshold) Evaluate(ctx context.Context, input domain.RiskInput) (domain.RiskVerdict, error) {
+	if limit, ok := t.Deny.exceeds(input.Amount); ok {
+		return domain.RiskVerdict{
+			Decision: domain.RiskDecisionDeny,
+			Reason:   fmt.Sprintf("amount %s is above %s", input.Amount, limit),
+		}, nil
+	}
+	if limit, ok := t.Review.exceeds(input.Amount); ok {
+		return domain.RiskVerdict{
+			Decision: domain.RiskDecisionReview,
+			Reason:   fmt.Sprintf("amount %s is above %s", input.Amount, limit),
+		}, nil
+	}
+	return domain.RiskVerdict{Decision: domain.RiskDecisionAllow}, nil
+}
+
+// NewAccountLimit caps the amount accounts younger than MinAge can pay. Above
+// the limit the payment gets Decision, deny by default. Payments of accounts
+// of unknown age are not checked.
+type NewAccountLimit struct {
+	MinAge   time.Duration
+	Limits   Limits
+	Decision string
+}
+
+// Name implements domain.RiskRule
+func (NewAccountLimit) Name() string {
+	return "new_account_limit"
+}
+
+// Evaluate implements domain.RiskRule
+func (l NewAccountLimit) Evaluate(ctx context.Context, input domain.RiskInput) (domain.RiskVerdict, error) {
+	createdAt := input.Origin.AccountCreatedAt
+	if createdAt == nil || time.Since(*createdAt) >= l.MinAge {
+		return domain.RiskVerdict{Decision: domain.RiskDecisionAllow}, nil
+	}
+
+	limit, ok := l.Limits.exceeds(input.Amount)
+	if !ok {
+		return domain.RiskVerdict{Decision: domain.RiskDecisionAllow}, nil
+	}
+
+	decision := l.Decision
+	if decision == "" {
+		decision = domain.RiskDecisionDeny
+	}
+	return domain.RiskVerdict{
+		Decision: decision,
+		Reason: fmt.Sprintf("account created %s ago may pay up to %s",
+			time.Since(*createdAt).Round(time.Minute), limit),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+)
+
+// Velocity subjects
+const (
+	VelocityByUser = "user"
+	VelocityByIP   = "ip"
+)
+
+// Velocity counts the payment attempts of a user or client IP over a sliding
+// window, kept as a Redis sorted set of attempt timestamps. Attempts beyond
+// Review are flagged and attempts beyond Deny are denied; a zero threshold is
+// not checked.
+type Velocity struct {
+	client  *redis.Client
+	prefix  string
+	subject string
+	window  time.Duration
+	review  int64
+	deny    int64
+}
+
+// NewVelocity creates a velocity rule for subject, VelocityByUser or
+// VelocityByIP
+func NewVelocity(client *redis.Client, subject string, window time.Duration, review, deny int64) *Velocity {
+	return &Velocity{
+		client:  client,
+		prefix:  "payment_risk:velocity",
+		subject: subject,
+		window:  window,
+		review:  review,
+		deny:    deny,
+	}
+}
+
+// Name implements domain.RiskRule
+func (v *Velocity) Name() string {
+	return "velocity_" + v.subject
+}
+
+// Evaluate implements domain.RiskRule. The attempt being evaluated is counted,
+// so the window holds every attempt whether it was charged or not.
+func (v *Velocity) Evaluate(ctx context.Context, input domain.RiskInput) (domain.RiskVerdict, error) {
+	id := v.subjectID(input)
+	if id == "" {
+		return domain.RiskVerdict{Decision: domain.RiskDecisionAllow}, nil
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", v.prefix, v.subject, id)
+	now := time.Now()
+	windowStart := now.Add(-v.window).UnixNano()
+
+	var count *redis.IntCmd
+	_, err := v.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(windowStart, 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixNano()), Member: uuid.New().String()})
+		count = pipe.ZCard(ctx, key)
+		pipe.Expire(ctx, key, v.window)
+		return nil
+	})
+	if err != nil {
+		return domain.RiskVerdict{}, fmt.Errorf("failed to count attempts: %w", err)
+	}
+
+	attempts := count.Val()
+	reason := fmt.Sprintf("%d payment attempts by %s %s in %s", attempts, v.subject, id, v.window)
+	switch {
+	case v.deny > 0 && attempts > v.deny:
+		return domain.RiskVerdict{Decision: domain.RiskDecisionDeny, Reason: reason}, nil
+	case v.review > 0 && attempts > v.review:
+		return domain.RiskVerdict{Decision: domain.RiskDecisionReview, Reason: reason}, nil
+	}
+	return domain.RiskVerdict{Decision: domain.RiskDecisionAllow}, nil
+}
+
+func (v *Velocity) subjectID(input domain.RiskInput) string {
+	if v.subject == VelocityByIP {
+		return input.Origin.ClientIP
+	}
+	if input.UserID == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(input.UserID), 10)
+}
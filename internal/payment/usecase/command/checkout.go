@@ -0,0 +1,380 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/logger"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// CheckoutCommand represents the command to run a checkout saga for a single product
+type CheckoutCommand struct {
+	UserID        uint
+	ProductID     uint
+	Quantity      int32
+	Amount        string // optional decimal in major units; when set it must equal the server-side price
+	Currency      string // optional; the price is converted into it from the catalog currency
+	PaymentMethod string
+	CardNumber    string
+	Origin        domain.PaymentOrigin // who is paying, for the risk check
+	// ShippingRegion is the customer's region; optional, it lets the
+	// inventory service ship from the nearest location
+	ShippingRegion string
+}
+
+// CheckoutHandler orchestrates the checkout saga:
+// reserve stock -> charge (create payment) -> confirm (commit reservation),
+// releasing the reservation as compensation when a later step fails.
+// The charge is priced from the product catalog, never from the client.
+type CheckoutHandler struct {
+	sagaRepo      domain.CheckoutSagaRepository
+	paymentRepo   domain.PaymentRepository
+	createHandler *CreatePaymentHandler
+	catalog       domain.ProductCatalog
+	pricer        domain.Pricer
+	inventory     domain.StockReservationService
+}
+
+// NewCheckoutHandler creates a new checkout saga handler
+func NewCheckoutHandler(
+	sagaRepo domain.CheckoutSagaRepository,
+	paymentRepo domain.PaymentRepository,
+	createHandler *CreatePaymentHandler,
+	catalog domain.ProductCatalog,
+	pricer domain.Pricer,
+	inventory domain.StockReservationService,
+) *CheckoutHandler {
+	return &CheckoutHandler{
+		sagaRepo:      sagaRepo,
+		paymentRepo:   paymentRepo,
+		createHandler: createHandler,
+		catalog:       catalog,
+		pricer:        pricer,
+		inventory:     inventory,
+	}
+}
+
+// Handle executes the checkout saga and returns the created payment. When the
+// charge fails the failed payment is returned along with the error.
+func (h *CheckoutHandler) Handle(ctx context.Context, cmd CheckoutCommand) (*domain.Payment, error) {
+	if cmd.UserID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	if cmd.ProductID == 0 {
+		return nil, fmt.Errorf("product_id is required")
+	}
+
+	if cmd.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than 0")
+	}
+	if cmd.Quantity > domain.MaxLineQuantity {
+		return nil, fmt.Errorf("quantity must not be greater than %d", domain.MaxLineQuantity)
+	}
+
+	product, err := h.catalog.GetProductSnapshot(ctx, cmd.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price payment: %w", err)
+	}
+	if !product.IsActive {
+		return nil, fmt.Errorf("product %d: %w", cmd.ProductID, domain.ErrProductUnavailable)
+	}
+
+	subtotal, err := product.Price.Mul(int64(cmd.Quantity))
+	if err != nil {
+		return nil, fmt.Errorf("failed to price payment: %w", err)
+	}
+	quote, err := h.pricer.Price(ctx, subtotal, cmd.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if !quote.Total.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	if cmd.Amount != "" {
+		amount, err := money.Parse(cmd.Amount, quote.Currency())
+		if err != nil || !quote.Matches(amount) {
+			return nil, fmt.Errorf("%w: sent %s, expected %s", domain.ErrAmountMismatch, cmd.Amount, quote.Total)
+		}
+	}
+
+	saga := &domain.CheckoutSaga{
+		ReservationID:  fmt.Sprintf("RSV-%s", uuid.New().String()),
+		OrderID:        fmt.Sprintf("ORD-%s", uuid.New().String()[:8]),
+		UserID:         cmd.UserID,
+		ProductID:      cmd.ProductID,
+		Quantity:       cmd.Quantity,
+		Amount:         quote.Total,
+		PaymentMethod:  cmd.PaymentMethod,
+		ShippingRegion: cmd.ShippingRegion,
+		Step:           domain.SagaStepReserveStock,
+		Status:         domain.SagaStatusRunning,
+	}
+
+	if err := h.sagaRepo.Create(saga); err != nil {
+		return nil, fmt.Errorf("failed to start checkout saga: %w", err)
+	}
+
+	// Step 1: reserve stock
+	h.recordStep(saga, domain.SagaStepReserveStock, domain.SagaOutcomeStarted, nil)
+	reserved, message, err := h.inventory.ReserveStock(ctx, saga.ProductID, saga.Quantity, saga.ReservationID, saga.ShippingRegion)
+	if err != nil {
+		h.recordStep(saga, domain.SagaStepReserveStock, domain.SagaOutcomeFailed, err)
+		// The reservation may have been applied before the transport failed
+		h.compensate(ctx, saga, err)
+		return nil, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	if !reserved {
+		stockErr := fmt.Errorf("%w: %s", domain.ErrInsufficientStock, message)
+		h.recordStep(saga, domain.SagaStepReserveStock, domain.SagaOutcomeFailed, stockErr)
+		saga.Status = domain.SagaStatusCompensated
+		saga.LastError = stockErr.Error()
+		h.saveSaga(saga)
+		return nil, stockErr
+	}
+	h.recordStep(saga, domain.SagaStepReserveStock, domain.SagaOutcomeSucceeded, nil)
+
+	// Step 2: charge (the purchase event is written to the outbox when the payment completes)
+	saga.Step = domain.SagaStepCharge
+	h.saveSaga(saga)
+	h.recordStep(saga, domain.SagaStepCharge, domain.SagaOutcomeStarted, nil)
+
+	traceHeaders := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceHeaders)
+
+	payment, err := h.createHandler.Handle(ctx, CreatePaymentCommand{
+		UserID:        saga.UserID,
+		OrderID:       saga.OrderID,
+		Amount:        saga.Amount,
+		PaymentMethod: saga.PaymentMethod,
+		CardNumber:    cmd.CardNumber,
+		TraceHeaders:  traceHeaders,
+		Quote:         quote,
+		Origin:        cmd.Origin,
+		Purchase: &PurchaseDetails{
+			ProductID:     saga.ProductID,
+			Quantity:      saga.Quantity,
+			ReservationID: saga.ReservationID,
+			UnitPrice:     product.Price,
+		},
+	})
+	if err != nil {
+		if payment != nil {
+			saga.PaymentID = &payment.ID
+		}
+		h.recordStep(saga, domain.SagaStepCharge, domain.SagaOutcomeFailed, err)
+		if errors.Is(err, domain.ErrCaptureNotRecorded) {
+			// The money was taken: keep the stock and leave the saga at the
+			// charge step for Resume to complete the payment and confirm
+			saga.LastError = err.Error()
+			h.saveSaga(saga)
+			logger.Logger.Error().
+				Err(err).
+				Uint("saga_id", saga.ID).
+				Str("reservation_id", saga.ReservationID).
+				Msg("Payment captured but not recorded, saga will be resumed")
+			return payment, err
+		}
+		h.compensate(ctx, saga, err)
+		return payment, err
+	}
+	saga.PaymentID = &payment.ID
+	h.recordStep(saga, domain.SagaStepCharge, domain.SagaOutcomeSucceeded, nil)
+
+	// Step 3: confirm
+	h.confirm(ctx, saga, payment)
+
+	return payment, nil
+}
+
+// resumeBatchSize is the number of unfinished checkouts loaded at a time by Resume
+const resumeBatchSize = 100
+
+// Resume drives every unfinished saga that has not been updated for idle to
+// a terminal state: checkouts interrupted by a crash or a failed commit are
+// either rolled forward (payment exists) or compensated (stock released).
+// It runs periodically, more often than the inventory service expires
+// reservations; idle keeps it away from checkouts still in flight.
+func (h *CheckoutHandler) Resume(ctx context.Context, idle time.Duration) error {
+	idleSince := time.Now().Add(-idle)
+	var afterID uint
+	for {
+		sagas, err := h.sagaRepo.FindIncomplete(idleSince, afterID, resumeBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load incomplete sagas: %w", err)
+		}
+
+		for i := range sagas {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			h.resume(ctx, &sagas[i])
+			afterID = sagas[i].ID
+		}
+
+		if len(sagas) < resumeBatchSize {
+			return nil
+		}
+	}
+}
+
+func (h *CheckoutHandler) resume(ctx context.Context, saga *domain.CheckoutSaga) {
+	logger.Logger.Info().
+		Uint("saga_id", saga.ID).
+		Str("reservation_id", saga.ReservationID).
+		Str("step", saga.Step).
+		Str("status", saga.Status).
+		Msg("Resuming checkout saga")
+
+	if saga.Status == domain.SagaStatusCompensating {
+		h.compensate(ctx, saga, errors.New(saga.LastError))
+		return
+	}
+
+	switch saga.Step {
+	case domain.SagaStepCharge, domain.SagaStepConfirm:
+		// The payment row is the source of truth for whether the charge happened
+		payment, err := h.paymentRepo.FindByOrderID(saga.OrderID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.compensate(ctx, saga, fmt.Errorf("checkout interrupted before charge completed"))
+			return
+		}
+		if err != nil {
+			// The charge may have happened; keep the stock until the payment can be read
+			logger.Logger.Error().
+				Err(err).
+				Uint("saga_id", saga.ID).
+				Msg("Failed to load saga payment, saga will be retried")
+			return
+		}
+		saga.PaymentID = &payment.ID
+
+		payment, err = h.createHandler.ResolvePending(ctx, payment)
+		if payment.Status == domain.StatusFailed {
+			if err == nil {
+				err = fmt.Errorf("payment %d failed", payment.ID)
+			}
+			h.compensate(ctx, saga, err)
+			return
+		}
+		if err != nil {
+			// Leave the saga running so the next run retries the charge
+			logger.Logger.Error().
+				Err(err).
+				Uint("saga_id", saga.ID).
+				Uint("payment_id", payment.ID).
+				Msg("Failed to resolve pending payment, saga will be retried")
+			return
+		}
+		h.confirm(ctx, saga, payment)
+	default:
+		h.compensate(ctx, saga, fmt.Errorf("checkout interrupted during %s", saga.Step))
+	}
+}
+
+// confirm commits the reservation and completes the saga. A failed commit
+// leaves the saga running so that Resume retries it before the reservation's
+// TTL runs out.
+func (h *CheckoutHandler) confirm(ctx context.Context, saga *domain.CheckoutSaga, payment *domain.Payment) {
+	saga.Step = domain.SagaStepConfirm
+	h.saveSaga(saga)
+	h.recordStep(saga, domain.SagaStepConfirm, domain.SagaOutcomeStarted, nil)
+
+	committed, message, err := h.inventory.CommitStock(ctx, saga.ReservationID)
+	if err == nil && !committed {
+		err = fmt.Errorf("commit rejected: %s", message)
+	}
+	if err != nil {
+		h.recordStep(saga, domain.SagaStepConfirm, domain.SagaOutcomeFailed, err)
+		saga.LastError = err.Error()
+		h.saveSaga(saga)
+		logger.Logger.Error().
+			Err(err).
+			Uint("saga_id", saga.ID).
+			Str("reservation_id", saga.ReservationID).
+			Msg("Failed to commit stock reservation, saga will be retried")
+		return
+	}
+
+	h.recordStep(saga, domain.SagaStepConfirm, domain.SagaOutcomeSucceeded, nil)
+	saga.Status = domain.SagaStatusCompleted
+	saga.LastError = ""
+	h.saveSaga(saga)
+
+	logger.Logger.Info().
+		Uint("saga_id", saga.ID).
+		Uint("payment_id", payment.ID).
+		Str("reservation_id", saga.ReservationID).
+		Msg("Checkout saga completed")
+}
+
+// compensate releases the stock reservation. When the release itself fails the
+// saga is left in the compensating state so that Resume retries it.
+func (h *CheckoutHandler) compensate(ctx context.Context, saga *domain.CheckoutSaga, cause error) {
+	saga.Step = domain.SagaStepReleaseStock
+	saga.Status = domain.SagaStatusCompensating
+	if cause != nil {
+		saga.LastError = cause.Error()
+	}
+	h.saveSaga(saga)
+	h.recordStep(saga, domain.SagaStepReleaseStock, domain.SagaOutcomeStarted, nil)
+
+	released, message, err := h.inventory.ReleaseStock(ctx, saga.ProductID, saga.Quantity, saga.ReservationID)
+	if err == nil && !released {
+		err = fmt.Errorf("release rejected: %s", message)
+	}
+	if err != nil {
+		h.recordStep(saga, domain.SagaStepReleaseStock, domain.SagaOutcomeFailed, err)
+		logger.Logger.Error().
+			Err(err).
+			Uint("saga_id", saga.ID).
+			Str("reservation_id", saga.ReservationID).
+			Msg("Failed to release stock, saga will be retried")
+		return
+	}
+
+	h.recordStep(saga, domain.SagaStepReleaseStock, domain.SagaOutcomeSucceeded, nil)
+	saga.Status = domain.SagaStatusCompensated
+	h.saveSaga(saga)
+
+	logger.Logger.Warn().
+		Uint("saga_id", saga.ID).
+		Str("reservation_id", saga.ReservationID).
+		Str("cause", saga.LastError).
+		Msg("Checkout saga compensated")
+}
+
+func (h *CheckoutHandler) saveSaga(saga *domain.CheckoutSaga) {
+	if err := h.sagaRepo.Update(saga); err != nil {
+		logger.Logger.Error().
+			Err(err).
+			Uint("saga_id", saga.ID).
+			Str("step", saga.Step).
+			Str("status", saga.Status).
+			Msg("Failed to persist checkout saga")
+	}
+}
+
+func (h *CheckoutHandler) recordStep(saga *domain.CheckoutSaga, step, outcome string, stepErr error) {
+	errMsg := ""
+	if stepErr != nil {
+		errMsg = stepErr.Error()
+	}
+	if err := h.sagaRepo.RecordStep(saga.ID, step, outcome, errMsg); err != nil {
+		logger.Logger.Error().
+			Err(err).
+			Uint("saga_id", saga.ID).
+			Str("step", step).
+			Str("outcome", outcome).
+			Msg("Failed to record checkout saga step")
+	}
+}
@@ -1,61 +1,245 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/kafka"
+	"github.com/tair/full-observability/pkg/logger"
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // CreatePaymentCommand represents the command to create a payment
 type CreatePaymentCommand struct {
 	UserID        uint
-	Amount        float64
-	Currency      string
+	OrderID       string // optional, generated when empty
+	Amount        money.Money
 	PaymentMethod string
+	CardNumber    string               // passed to the provider, only the last four digits are stored
+	TraceHeaders  map[string]string    // trace context recorded with the outbox events
+	Quote         *domain.PriceQuote   // optional; the price snapshot Amount was calculated from
+	Origin        domain.PaymentOrigin // who is paying, for the risk check
+
+	// Purchase, when set, is recorded as a product.purchased outbox event
+	// once the payment completes
+	Purchase *PurchaseDetails
+}
+
+// PurchaseDetails describes the product bought with a payment
+type PurchaseDetails struct {
+	ProductID     uint
+	Quantity      int32
+	ReservationID string
+	UnitPrice     money.Money // catalog price snapshot
 }
 
 // CreatePaymentHandler handles create payment command
 type CreatePaymentHandler struct {
-	repo domain.PaymentRepository
+	repo      domain.PaymentRepository
+	gateway   domain.PaymentGateway
+	publisher *kafka.Publisher     // optional; seals events with the registered payload schemas
+	risk      domain.RiskEvaluator // optional; without it every payment is charged
 }
 
-// NewCreatePaymentHandler creates a new create payment handler
-func NewCreatePaymentHandler(repo domain.PaymentRepository) *CreatePaymentHandler {
-	return &CreatePaymentHandler{repo: repo}
+// NewCreatePaymentHandler creates a new create payment handler. Without a
+// publisher, outbox events are written as JSON envelopes.
+func NewCreatePaymentHandler(repo domain.PaymentRepository, gateway domain.PaymentGateway, publisher *kafka.Publisher, risk domain.RiskEvaluator) *CreatePaymentHandler {
+	return &CreatePaymentHandler{repo: repo, gateway: gateway, publisher: publisher, risk: risk}
 }
 
-// Handle executes the create payment command
-func (h *CreatePaymentHandler) Handle(cmd CreatePaymentCommand) (*domain.Payment, error) {
+// Handle executes the create payment command: the payment is risk-checked and
+// stored as pending, then authorized and captured by the provider of its
+// payment method and moved to completed or failed. A declined or timed-out
+// charge returns the failed payment together with an error matching
+// domain.ErrPaymentDeclined or domain.ErrProviderTimeout; a payment denied by
+// the risk check is failed without charging and returned with
+// domain.ErrPaymentRiskDenied.
+func (h *CreatePaymentHandler) Handle(ctx context.Context, cmd CreatePaymentCommand) (*domain.Payment, error) {
 	if cmd.UserID == 0 {
 		return nil, fmt.Errorf("user_id is required")
 	}
 
-	if cmd.Amount <= 0 {
+	if !cmd.Amount.IsPositive() {
 		return nil, fmt.Errorf("amount must be greater than 0")
 	}
 
-	if cmd.Currency == "" {
-		cmd.Currency = "USD"
+	if err := money.ValidateCurrency(cmd.Amount.Currency); err != nil {
+		return nil, err
+	}
+
+	provider, err := h.gateway.ProviderFor(cmd.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	assessment := domain.NewRiskAssessment()
+	if h.risk != nil {
+		assessment, err = h.risk.Evaluate(ctx, domain.RiskInput{
+			UserID:        cmd.UserID,
+			Amount:        cmd.Amount,
+			PaymentMethod: cmd.PaymentMethod,
+			Origin:        cmd.Origin,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate payment risk: %w", err)
+		}
 	}
 
 	// Generate unique IDs
-	orderID := fmt.Sprintf("ORD-%s", uuid.New().String()[:8])
-	transactionID := fmt.Sprintf("TXN-%s", uuid.New().String()[:12])
+	orderID := cmd.OrderID
+	if orderID == "" {
+		orderID = fmt.Sprintf("ORD-%s", uuid.New().String()[:8])
+	}
 
 	payment := &domain.Payment{
 		UserID:        cmd.UserID,
 		OrderID:       orderID,
 		Amount:        cmd.Amount,
-		Currency:      cmd.Currency,
 		Status:        domain.StatusPending,
 		PaymentMethod: cmd.PaymentMethod,
-		TransactionID: transactionID,
+		Provider:      provider.Name(),
+		CardLast4:     cardLast4(cmd.CardNumber),
+	}
+
+	if cmd.Purchase != nil {
+		payment.ProductID = cmd.Purchase.ProductID
+		payment.Quantity = cmd.Purchase.Quantity
+		payment.ReservationID = cmd.Purchase.ReservationID
+		payment.UnitPrice = cmd.Purchase.UnitPrice
 	}
 
-	if err := h.repo.Create(payment); err != nil {
+	if cmd.Quote != nil {
+		payment.ApplyQuote(cmd.Quote)
+	}
+	payment.ApplyRiskAssessment(assessment)
+
+	err = h.repo.CreateWithOutbox(payment, func(p *domain.Payment) ([]domain.OutboxEvent, error) {
+		created, err := paymentOutboxEvent(h.publisher, kafka.EventTypePaymentCreated, p, "", cmd.TraceHeaders)
+		if err != nil {
+			return nil, err
+		}
+		return []domain.OutboxEvent{*created}, nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create payment: %w", err)
 	}
 
-	return payment, nil
+	switch assessment.Decision {
+	case domain.RiskDecisionDeny:
+		return h.fail(ctx, payment, fmt.Errorf("%w: %s", domain.ErrPaymentRiskDenied, payment.RiskReasons), cmd.TraceHeaders)
+	case domain.RiskDecisionReview:
+		logger.Logger.Warn().
+			Uint("payment_id", payment.ID).
+			Uint("user_id", payment.UserID).
+			Str("risk_reasons", payment.RiskReasons).
+			Msg("Payment flagged for risk review")
+	}
+
+	// Authorize: the order ID makes a retried authorization return the same hold
+	auth, err := provider.Authorize(ctx, domain.AuthorizeRequest{
+		IdempotencyKey: payment.OrderID,
+		UserID:         payment.UserID,
+		Amount:         payment.Amount,
+		PaymentMethod:  payment.PaymentMethod,
+		CardNumber:     cmd.CardNumber,
+	})
+	if err != nil {
+		return h.fail(ctx, payment, fmt.Errorf("authorization failed: %w", err), cmd.TraceHeaders)
+	}
+
+	payment.AuthorizationID = auth.Reference
+	if err := h.repo.SaveProviderReferences(payment.ID, payment.AuthorizationID, ""); err != nil {
+		// An unrecorded hold could never be captured or voided later
+		h.void(ctx, provider, payment, "Failed to void authorization that could not be recorded")
+		return h.fail(ctx, payment, fmt.Errorf("failed to record authorization: %w", err), cmd.TraceHeaders)
+	}
+
+	return h.capture(ctx, provider, payment, cmd.TraceHeaders)
+}
+
+// ResolvePending drives a payment left pending by an interrupted charge to a
+// final status: authorized payments are captured, anything earlier is failed.
+// Payments created before providers were introduced are returned unchanged.
+func (h *CreatePaymentHandler) ResolvePending(ctx context.Context, payment *domain.Payment) (*domain.Payment, error) {
+	if payment.Status != domain.StatusPending || payment.Provider == "" {
+		return payment, nil
+	}
+
+	if payment.AuthorizationID == "" {
+		return h.fail(ctx, payment, fmt.Errorf("charge interrupted before authorization"), nil)
+	}
+
+	provider, err := h.gateway.Provider(payment.Provider)
+	if err != nil {
+		return payment, err
+	}
+	return h.capture(ctx, provider, payment, nil)
+}
+
+// capture settles the authorization of a payment and completes it. The hold
+// is voided when the capture fails.
+func (h *CreatePaymentHandler) capture(ctx context.Context, provider domain.PaymentProvider, payment *domain.Payment, traceHeaders map[string]string) (*domain.Payment, error) {
+	if payment.TransactionID == "" {
+		captured, err := provider.Capture(ctx, payment.AuthorizationID, payment.Amount)
+		if err != nil {
+			h.void(ctx, provider, payment, "Failed to void authorization after failed capture")
+			return h.fail(ctx, payment, fmt.Errorf("capture failed: %w", err), traceHeaders)
+		}
+
+		payment.TransactionID = captured.Reference
+		if err := h.repo.SaveProviderReferences(payment.ID, "", payment.TransactionID); err != nil {
+			return payment, fmt.Errorf("%w: %w", domain.ErrCaptureNotRecorded, err)
+		}
+	}
+
+	change := newStatusChange(ctx, payment.ID, domain.StatusCompleted, 0,
+		fmt.Sprintf("captured by %s", payment.Provider))
+	completed, err := h.repo.UpdateStatusWithOutbox(change, func(p *domain.Payment, previous string) ([]domain.OutboxEvent, error) {
+		return paymentStatusOutboxEvents(h.publisher, p, previous, traceHeaders)
+	})
+	if err != nil {
+		return payment, fmt.Errorf("%w: failed to complete payment: %w", domain.ErrCaptureNotRecorded, err)
+	}
+	return completed, nil
+}
+
+// void releases the payment's authorization hold, logging failures with message
+func (h *CreatePaymentHandler) void(ctx context.Context, provider domain.PaymentProvider, payment *domain.Payment, message string) {
+	// The request may be cancelled already; the hold must still be released
+	if err := provider.Void(context.WithoutCancel(ctx), payment.AuthorizationID); err != nil {
+		logger.Logger.Error().
+			Err(err).
+			Uint("payment_id", payment.ID).
+			Str("authorization_id", payment.AuthorizationID).
+			Msg(message)
+	}
+}
+
+// fail moves the payment to failed, recording cause as the reason, and
+// returns cause
+func (h *CreatePaymentHandler) fail(ctx context.Context, payment *domain.Payment, cause error, traceHeaders map[string]string) (*domain.Payment, error) {
+	change := newStatusChange(ctx, payment.ID, domain.StatusFailed, 0, cause.Error())
+	failed, err := h.repo.UpdateStatusWithOutbox(change, func(p *domain.Payment, previous string) ([]domain.OutboxEvent, error) {
+		return paymentStatusOutboxEvents(h.publisher, p, previous, traceHeaders)
+	})
+	if err != nil {
+		logger.Logger.Error().
+			Err(err).
+			Uint("payment_id", payment.ID).
+			Msg("Failed to mark payment as failed")
+		return payment, fmt.Errorf("payment %d failed: %w", payment.ID, cause)
+	}
+	return failed, fmt.Errorf("payment %d failed: %w", failed.ID, cause)
+}
+
+// cardLast4 returns the last four digits of a card number
+func cardLast4(cardNumber string) string {
+	digits := strings.ReplaceAll(cardNumber, " ", "")
+	if len(digits) < 4 {
+		return ""
+	}
+	return digits[len(digits)-4:]
 }
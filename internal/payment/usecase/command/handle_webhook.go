@@ -0,0 +1,131 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/kafka"
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+// HandleWebhookCommand represents a verified provider webhook
+type HandleWebhookCommand struct {
+	Provider      string
+	EventID       string
+	EventType     string // charge.succeeded, charge.failed or charge.refunded
+	OrderID       string // the idempotency key the payment was authorized with
+	TransactionID string // capture reference, for charge.succeeded
+	Reason        string // optional
+}
+
+// HandleWebhookHandler applies provider webhooks to payments
+type HandleWebhookHandler struct {
+	repo          domain.PaymentRepository
+	webhooks      domain.WebhookEventRepository
+	refundHandler *RefundPaymentHandler
+	publisher     *kafka.Publisher // optional; seals events with the registered payload schemas
+}
+
+// NewHandleWebhookHandler creates a new handle webhook handler
+func NewHandleWebhookHandler(repo domain.PaymentRepository, webhooks domain.WebhookEventRepository, refundHandler *RefundPaymentHandler, publisher *kafka.Publisher) *HandleWebhookHandler {
+	return &HandleWebhookHandler{repo: repo, webhooks: webhooks, refundHandler: refundHandler, publisher: publisher}
+}
+
+// Handle moves the payment to the status confirmed by the webhook; a refund
+// webhook records a refund of the remaining balance. It reports false for a
+// provider event that was already handled. A webhook the payment cannot
+// accept stays recorded, since redelivering it cannot succeed; any other
+// failure forgets it so the provider's retry is handled.
+func (h *HandleWebhookHandler) Handle(ctx context.Context, cmd HandleWebhookCommand) (bool, error) {
+	status, ok := domain.WebhookStatus(cmd.EventType)
+	if !ok {
+		return false, fmt.Errorf("%w: %s", domain.ErrUnsupportedWebhookEvent, cmd.EventType)
+	}
+
+	if cmd.EventID == "" {
+		return false, fmt.Errorf("event id is required")
+	}
+
+	if cmd.OrderID == "" {
+		return false, fmt.Errorf("order id is required")
+	}
+
+	payment, err := h.repo.FindByOrderID(cmd.OrderID)
+	if err != nil {
+		return false, fmt.Errorf("payment not found: %w", err)
+	}
+	if payment.Provider != cmd.Provider {
+		return false, fmt.Errorf("payment %d is not handled by %s", payment.ID, cmd.Provider)
+	}
+
+	claimed, err := h.webhooks.Claim(&domain.WebhookEvent{
+		Provider:  cmd.Provider,
+		EventID:   cmd.EventID,
+		EventType: cmd.EventType,
+		PaymentID: payment.ID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook: %w", err)
+	}
+	if !claimed {
+		return false, nil
+	}
+
+	if err := h.apply(ctx, payment, status, cmd); err != nil {
+		if !errors.Is(err, domain.ErrInvalidStatusTransition) && !errors.Is(err, domain.ErrPaymentNotRefundable) {
+			if releaseErr := h.webhooks.Release(cmd.Provider, cmd.EventID); releaseErr != nil {
+				logger.Logger.Error().
+					Err(releaseErr).
+					Str("provider", cmd.Provider).
+					Str("event_id", cmd.EventID).
+					Msg("Failed to release webhook event")
+			}
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *HandleWebhookHandler) apply(ctx context.Context, payment *domain.Payment, status string, cmd HandleWebhookCommand) error {
+	reason := fmt.Sprintf("%s webhook %s", cmd.Provider, cmd.EventID)
+	if cmd.Reason != "" {
+		reason = fmt.Sprintf("%s: %s", reason, cmd.Reason)
+	}
+
+	if status == domain.StatusRefunded {
+		if payment.Status == domain.StatusRefunded {
+			return nil
+		}
+		providerReference := cmd.TransactionID
+		if providerReference == "" {
+			providerReference = cmd.EventID
+		}
+		_, err := h.refundHandler.Handle(ctx, RefundPaymentCommand{
+			PaymentID:         payment.ID,
+			Reason:            reason,
+			ProviderReference: providerReference,
+		})
+		return err
+	}
+
+	traceHeaders := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceHeaders)
+
+	change := newStatusChange(ctx, payment.ID, status, 0, reason)
+	if status == domain.StatusCompleted {
+		// Recorded under the row lock, so a concurrent change is not overwritten
+		change.TransactionID = cmd.TransactionID
+	}
+	_, err := h.repo.UpdateStatusWithOutbox(change, func(p *domain.Payment, previous string) ([]domain.OutboxEvent, error) {
+		return paymentStatusOutboxEvents(h.publisher, p, previous, traceHeaders)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply webhook: %w", err)
+	}
+	return nil
+}
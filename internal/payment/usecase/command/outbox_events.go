@@ -0,0 +1,162 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/kafka"
+)
+
+// newOutboxEvent seals a domain event in an envelope and builds its outbox row.
+// Without a publisher the envelope is JSON-encoded with the service as source.
+func newOutboxEvent[T any](publisher *kafka.Publisher, topic, key, eventID, eventType string, schemaVersion int, event T, traceHeaders map[string]string) (*domain.OutboxEvent, error) {
+	var envelope *kafka.Envelope
+	var err error
+	if publisher == nil {
+		envelope, err = kafka.NewEnvelope(eventID, eventType, domain.EventSource, schemaVersion, event)
+	} else {
+		envelope, err = kafka.SealEvent(publisher, eventID, eventType, schemaVersion, event)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	payload, envelopeHeaders, err := envelope.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(traceHeaders)+len(envelopeHeaders))
+	for key, value := range traceHeaders {
+		headers[key] = value
+	}
+	for key, value := range envelopeHeaders {
+		headers[key] = value
+	}
+
+	return &domain.OutboxEvent{
+		EventID:       envelope.ID,
+		EventType:     envelope.Type,
+		Topic:         topic,
+		MessageKey:    key,
+		Payload:       payload,
+		Headers:       headers,
+		Status:        domain.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// paymentOutboxEvent builds the outbox row for a payment lifecycle event. The
+// event ID is derived from the payment and status, since each status is
+// reached at most once.
+func paymentOutboxEvent(publisher *kafka.Publisher, eventType string, payment *domain.Payment, previous string, traceHeaders map[string]string) (*domain.OutboxEvent, error) {
+	event := kafka.PaymentEvent{
+		PaymentID:      payment.ID,
+		OrderID:        payment.OrderID,
+		UserID:         payment.UserID,
+		Amount:         payment.Amount,
+		PaymentMethod:  payment.PaymentMethod,
+		Status:         payment.Status,
+		PreviousStatus: previous,
+		ProductID:      payment.ProductID,
+		Quantity:       payment.Quantity,
+		ReservationID:  payment.ReservationID,
+	}
+
+	return newOutboxEvent(publisher, kafka.TopicPaymentEvents,
+		fmt.Sprintf("payment_%d", payment.ID),
+		fmt.Sprintf("evt_payment_%d_%s", payment.ID, payment.Status),
+		eventType, kafka.SchemaVersionPayment, event, traceHeaders)
+}
+
+// paymentEventTypes maps payment statuses to the event emitted on reaching them
+var paymentEventTypes = map[string]string{
+	domain.StatusPending:   kafka.EventTypePaymentCreated,
+	domain.StatusCompleted: kafka.EventTypePaymentCompleted,
+	domain.StatusFailed:    kafka.EventTypePaymentFailed,
+	domain.StatusRefunded:  kafka.EventTypePaymentRefunded,
+}
+
+// paymentStatusOutboxEvents builds the events for a payment reaching its
+// current status. Completing a payment that bought stock also records the
+// product purchase.
+func paymentStatusOutboxEvents(publisher *kafka.Publisher, payment *domain.Payment, previous string, traceHeaders map[string]string) ([]domain.OutboxEvent, error) {
+	event, err := paymentOutboxEvent(publisher, paymentEventTypes[payment.Status], payment, previous, traceHeaders)
+	if err != nil {
+		return nil, err
+	}
+	events := []domain.OutboxEvent{*event}
+
+	if payment.Status == domain.StatusCompleted && payment.ProductID != 0 {
+		purchased, err := productPurchasedOutboxEvent(publisher, payment, traceHeaders)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *purchased)
+	}
+	return events, nil
+}
+
+// productPurchasedOutboxEvent builds the outbox row for the product bought
+// with a payment, encoded as protobuf once a payload schema has been registered
+func productPurchasedOutboxEvent(publisher *kafka.Publisher, payment *domain.Payment, traceHeaders map[string]string) (*domain.OutboxEvent, error) {
+	eventID := fmt.Sprintf("evt_%s", uuid.New().String())
+	if payment.ReservationID != "" {
+		eventID = fmt.Sprintf("evt_%s", payment.ReservationID)
+	}
+
+	event := kafka.ProductPurchasedEvent{
+		EventID:       eventID,
+		EventType:     kafka.EventTypeProductPurchased,
+		PaymentID:     payment.ID,
+		ProductID:     payment.ProductID,
+		Quantity:      payment.Quantity,
+		UserID:        payment.UserID,
+		Amount:        payment.Amount,
+		PaymentMethod: payment.PaymentMethod,
+		ReservationID: payment.ReservationID,
+		Timestamp:     time.Now(),
+	}
+
+	key := fmt.Sprintf("product_%d", payment.ProductID)
+	if publisher != nil {
+		if _, ok := publisher.SchemaFor(event.EventType); ok {
+			return newOutboxEvent(publisher, kafka.TopicProductPurchased, key, event.EventID, event.EventType,
+				kafka.SchemaVersionProductPurchased, event.Proto(), traceHeaders)
+		}
+	}
+	return newOutboxEvent(publisher, kafka.TopicProductPurchased, key, event.EventID, event.EventType,
+		kafka.SchemaVersionProductPurchased, event, traceHeaders)
+}
+
+// refundOutboxEvent builds the outbox row for an issued refund. It shares the
+// payment's key so consumers see refunds in order with the payment's events.
+func refundOutboxEvent(publisher *kafka.Publisher, payment *domain.Payment, refund *domain.Refund, traceHeaders map[string]string) (*domain.OutboxEvent, error) {
+	event := kafka.RefundEvent{
+		RefundID:       refund.ID,
+		PaymentID:      payment.ID,
+		OrderID:        payment.OrderID,
+		UserID:         payment.UserID,
+		Amount:         refund.Amount,
+		Reason:         refund.Reason,
+		RefundedAmount: payment.RefundedAmount,
+		FullyRefunded:  payment.IsFullyRefunded(),
+		ProductID:      payment.ProductID,
+		Quantity:       refund.Quantity,
+		ReservationID:  payment.ReservationID,
+	}
+	for _, line := range refund.Lines {
+		event.Lines = append(event.Lines, kafka.RefundLine{
+			ProductID:     line.ProductID,
+			ReservationID: line.ReservationID,
+			Quantity:      line.Quantity,
+		})
+	}
+
+	return newOutboxEvent(publisher, kafka.TopicPaymentEvents,
+		fmt.Sprintf("payment_%d", payment.ID),
+		fmt.Sprintf("evt_refund_%d", refund.ID),
+		kafka.EventTypeRefundIssued, kafka.SchemaVersionRefund, event, traceHeaders)
+}
@@ -0,0 +1,372 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+// PlaceOrderCommand represents the command to place and check out a multi-line order
+type PlaceOrderCommand struct {
+	UserID        uint
+	Lines         []OrderLineInput
+	Currency      string // optional; the order is converted into it from the catalog currency
+	PaymentMethod string
+	CardNumber    string
+	Origin        domain.PaymentOrigin // who is paying, for the risk check
+	// ShippingRegion is the customer's region; optional, it lets the
+	// inventory service ship every line from the nearest location
+	ShippingRegion string
+}
+
+// OrderLineInput is a product and quantity requested in an order
+type OrderLineInput struct {
+	ProductID uint
+	Quantity  int32
+}
+
+// PlaceOrderHandler prices an order from the product catalog and the pricing
+// pipeline, and checks it out as a saga: reserve the stock of every line ->
+// charge the order total -> commit every reservation. When any line cannot be
+// reserved, or the charge fails, the reservations of all lines are released,
+// so an order holds the stock of all its lines or of none.
+type PlaceOrderHandler struct {
+	orderRepo     domain.OrderRepository
+	paymentRepo   domain.PaymentRepository
+	createHandler *CreatePaymentHandler
+	catalog       domain.ProductCatalog
+	pricer        domain.Pricer
+	inventory     domain.StockReservationService
+}
+
+// NewPlaceOrderHandler creates a new place order handler
+func NewPlaceOrderHandler(
+	orderRepo domain.OrderRepository,
+	paymentRepo domain.PaymentRepository,
+	createHandler *CreatePaymentHandler,
+	catalog domain.ProductCatalog,
+	pricer domain.Pricer,
+	inventory domain.StockReservationService,
+) *PlaceOrderHandler {
+	return &PlaceOrderHandler{
+		orderRepo:     orderRepo,
+		paymentRepo:   paymentRepo,
+		createHandler: createHandler,
+		catalog:       catalog,
+		pricer:        pricer,
+		inventory:     inventory,
+	}
+}
+
+// Handle places the order and runs its checkout. The order is returned with
+// the error when checkout fails after the order was stored, so the caller can
+// show its final status. When the charge fails the payment is returned too.
+func (h *PlaceOrderHandler) Handle(ctx context.Context, cmd PlaceOrderCommand) (*domain.Order, *domain.Payment, error) {
+	if cmd.UserID == 0 {
+		return nil, nil, fmt.Errorf("user_id is required")
+	}
+
+	if len(cmd.Lines) == 0 {
+		return nil, nil, fmt.Errorf("at least one order line is required")
+	}
+
+	if len(cmd.Lines) > domain.MaxOrderLines {
+		return nil, nil, fmt.Errorf("an order can have at most %d lines", domain.MaxOrderLines)
+	}
+
+	order := &domain.Order{
+		OrderNumber:    fmt.Sprintf("ORD-%s", uuid.New().String()[:8]),
+		UserID:         cmd.UserID,
+		Status:         domain.OrderStatusPending,
+		Step:           domain.SagaStepReserveStock,
+		PaymentMethod:  cmd.PaymentMethod,
+		ShippingRegion: cmd.ShippingRegion,
+	}
+
+	// Snapshot the current price of every product so later catalog changes
+	// do not alter the order
+	seen := make(map[uint]bool, len(cmd.Lines))
+	for _, input := range cmd.Lines {
+		if input.ProductID == 0 {
+			return nil, nil, fmt.Errorf("product_id is required")
+		}
+		if input.Quantity <= 0 {
+			return nil, nil, fmt.Errorf("quantity must be greater than 0")
+		}
+		if input.Quantity > domain.MaxLineQuantity {
+			return nil, nil, fmt.Errorf("quantity must not be greater than %d", domain.MaxLineQuantity)
+		}
+		if seen[input.ProductID] {
+			return nil, nil, fmt.Errorf("product %d: %w", input.ProductID, domain.ErrDuplicateOrderLine)
+		}
+		seen[input.ProductID] = true
+
+		product, err := h.catalog.GetProductSnapshot(ctx, input.ProductID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to price order: %w", err)
+		}
+		if !product.IsActive {
+			return nil, nil, fmt.Errorf("product %d: %w", input.ProductID, domain.ErrProductUnavailable)
+		}
+
+		order.Lines = append(order.Lines, domain.OrderLine{
+			ProductID:     product.ProductID,
+			ProductName:   product.Name,
+			SKU:           product.SKU,
+			UnitPrice:     product.Price,
+			Quantity:      input.Quantity,
+			ReservationID: fmt.Sprintf("RSV-%s", uuid.New().String()),
+		})
+	}
+
+	// Lines keep the catalog currency, which must be the same for every
+	// product; the pricer converts the subtotal when the client pays in
+	// another currency
+	if err := order.CalculateSubtotal(order.Lines[0].UnitPrice.Currency); err != nil {
+		return nil, nil, err
+	}
+	quote, err := h.pricer.Price(ctx, order.Subtotal, cmd.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	order.ApplyQuote(quote)
+	if !order.Total.IsPositive() {
+		return nil, nil, fmt.Errorf("order total must be greater than 0")
+	}
+
+	if err := h.orderRepo.Create(order); err != nil {
+		return nil, nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	// Step 1: reserve the stock of every line
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		reserved, message, err := h.inventory.ReserveStock(ctx, line.ProductID, line.Quantity, line.ReservationID, order.ShippingRegion)
+		if err != nil {
+			// The reservation may have been applied before the transport failed
+			err = fmt.Errorf("failed to reserve stock for product %d: %w", line.ProductID, err)
+			h.compensate(ctx, order, err)
+			return order, nil, err
+		}
+		if !reserved {
+			stockErr := fmt.Errorf("%w for product %d: %s", domain.ErrInsufficientStock, line.ProductID, message)
+			h.compensate(ctx, order, stockErr)
+			return order, nil, stockErr
+		}
+	}
+
+	// Step 2: charge the order total
+	order.Step = domain.SagaStepCharge
+	h.saveOrder(order)
+
+	traceHeaders := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceHeaders)
+
+	payment, err := h.createHandler.Handle(ctx, CreatePaymentCommand{
+		UserID:        order.UserID,
+		OrderID:       order.OrderNumber,
+		Amount:        order.Total,
+		PaymentMethod: order.PaymentMethod,
+		CardNumber:    cmd.CardNumber,
+		TraceHeaders:  traceHeaders,
+		Quote:         quote,
+		Origin:        cmd.Origin,
+	})
+	if payment != nil {
+		order.PaymentID = &payment.ID
+	}
+	if errors.Is(err, domain.ErrCaptureNotRecorded) {
+		// The money was taken: keep the stock and leave the order at the
+		// charge step for Resume to complete the payment and confirm
+		order.LastError = err.Error()
+		h.saveOrder(order)
+		logger.Logger.Error().
+			Err(err).
+			Uint("order_id", order.ID).
+			Str("order_number", order.OrderNumber).
+			Msg("Order payment captured but not recorded, checkout will be resumed")
+		return order, payment, err
+	}
+	if err != nil {
+		h.compensate(ctx, order, err)
+		return order, payment, err
+	}
+
+	// Step 3: commit every reservation
+	order.Status = domain.OrderStatusPaid
+	h.confirm(ctx, order)
+
+	return order, payment, nil
+}
+
+// Resume drives every unfinished order checkout that has not been updated
+// for idle to a terminal state. It runs periodically, like
+// CheckoutHandler.Resume.
+func (h *PlaceOrderHandler) Resume(ctx context.Context, idle time.Duration) error {
+	idleSince := time.Now().Add(-idle)
+	var afterID uint
+	for {
+		orders, err := h.orderRepo.FindIncomplete(idleSince, afterID, resumeBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load incomplete orders: %w", err)
+		}
+
+		for i := range orders {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			h.resume(ctx, &orders[i])
+			afterID = orders[i].ID
+		}
+
+		if len(orders) < resumeBatchSize {
+			return nil
+		}
+	}
+}
+
+func (h *PlaceOrderHandler) resume(ctx context.Context, order *domain.Order) {
+	logger.Logger.Info().
+		Uint("order_id", order.ID).
+		Str("order_number", order.OrderNumber).
+		Str("step", order.Step).
+		Str("status", order.Status).
+		Msg("Resuming order checkout")
+
+	switch {
+	case order.Status == domain.OrderStatusCancelling:
+		h.compensate(ctx, order, errors.New(order.LastError))
+	case order.Status == domain.OrderStatusPaid:
+		h.confirm(ctx, order)
+	case order.Step == domain.SagaStepCharge:
+		// The payment row is the source of truth for whether the charge happened
+		payment, err := h.paymentRepo.FindByOrderID(order.OrderNumber)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.compensate(ctx, order, fmt.Errorf("checkout interrupted before charge completed"))
+			return
+		}
+		if err != nil {
+			// The charge may have happened; keep the stock until the payment can be read
+			logger.Logger.Error().
+				Err(err).
+				Uint("order_id", order.ID).
+				Msg("Failed to load order payment, checkout will be retried")
+			return
+		}
+		order.PaymentID = &payment.ID
+
+		payment, err = h.createHandler.ResolvePending(ctx, payment)
+		if payment.Status == domain.StatusFailed {
+			if err == nil {
+				err = fmt.Errorf("payment %d failed", payment.ID)
+			}
+			h.compensate(ctx, order, err)
+			return
+		}
+		if err != nil {
+			// Leave the order pending so the next run retries the charge
+			logger.Logger.Error().
+				Err(err).
+				Uint("order_id", order.ID).
+				Uint("payment_id", payment.ID).
+				Msg("Failed to resolve pending order payment, checkout will be retried")
+			return
+		}
+		order.Status = domain.OrderStatusPaid
+		h.confirm(ctx, order)
+	default:
+		h.compensate(ctx, order, fmt.Errorf("checkout interrupted during %s", order.Step))
+	}
+}
+
+// confirm commits the reservation of every line and completes the order.
+// Committing is idempotent, so after a failure Resume commits all lines again
+// before the reservations' TTL runs out.
+func (h *PlaceOrderHandler) confirm(ctx context.Context, order *domain.Order) {
+	order.Step = domain.SagaStepConfirm
+	h.saveOrder(order)
+
+	for _, line := range order.Lines {
+		committed, message, err := h.inventory.CommitStock(ctx, line.ReservationID)
+		if err == nil && !committed {
+			err = fmt.Errorf("commit rejected: %s", message)
+		}
+		if err != nil {
+			order.LastError = fmt.Sprintf("product %d: %v", line.ProductID, err)
+			h.saveOrder(order)
+			logger.Logger.Error().
+				Err(err).
+				Uint("order_id", order.ID).
+				Str("reservation_id", line.ReservationID).
+				Msg("Failed to commit order line reservation, checkout will be retried")
+			return
+		}
+	}
+
+	order.Status = domain.OrderStatusCompleted
+	order.LastError = ""
+	h.saveOrder(order)
+
+	logger.Logger.Info().
+		Uint("order_id", order.ID).
+		Str("order_number", order.OrderNumber).
+		Int("lines", len(order.Lines)).
+		Str("total", order.Total.String()).
+		Msg("Order checkout completed")
+}
+
+// compensate releases the reservation of every line and cancels the order.
+// Lines that were never reserved are released as no-ops. When a release
+// fails the order stays cancelling so that Resume retries it.
+func (h *PlaceOrderHandler) compensate(ctx context.Context, order *domain.Order, cause error) {
+	order.Step = domain.SagaStepReleaseStock
+	order.Status = domain.OrderStatusCancelling
+	if cause != nil {
+		order.LastError = cause.Error()
+	}
+	h.saveOrder(order)
+
+	for _, line := range order.Lines {
+		released, message, err := h.inventory.ReleaseStock(ctx, line.ProductID, line.Quantity, line.ReservationID)
+		if err == nil && !released {
+			err = fmt.Errorf("release rejected: %s", message)
+		}
+		if err != nil {
+			logger.Logger.Error().
+				Err(err).
+				Uint("order_id", order.ID).
+				Str("reservation_id", line.ReservationID).
+				Msg("Failed to release order line stock, order will be retried")
+			return
+		}
+	}
+
+	order.Status = domain.OrderStatusCancelled
+	h.saveOrder(order)
+
+	logger.Logger.Warn().
+		Uint("order_id", order.ID).
+		Str("order_number", order.OrderNumber).
+		Str("cause", order.LastError).
+		Msg("Order checkout cancelled")
+}
+
+func (h *PlaceOrderHandler) saveOrder(order *domain.Order) {
+	if err := h.orderRepo.Update(order); err != nil {
+		logger.Logger.Error().
+			Err(err).
+			Uint("order_id", order.ID).
+			Str("step", order.Step).
+			Str("status", order.Status).
+			Msg("Failed to persist order")
+	}
+}
@@ -0,0 +1,293 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/kafka"
+	"github.com/tair/full-observability/pkg/logger"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// RefundPaymentCommand represents the command to refund a payment
+type RefundPaymentCommand struct {
+	PaymentID   uint
+	Amount      string            // decimal in major units of the payment's currency; empty or 0 refunds the remaining balance
+	Quantity    int32             // purchased items returned; defaults to all remaining items when the refund leaves nothing to refund
+	Lines       []RefundLineInput // items returned by an order payment, instead of Quantity
+	Reason      string
+	ActorUserID uint
+
+	// ProviderReference is set when the provider already returned the money,
+	// e.g. for refunds reported by webhook, and skips the provider call
+	ProviderReference string
+}
+
+// RefundLineInput is a product of an order and the number of its items returned
+type RefundLineInput struct {
+	ProductID uint
+	Quantity  int32
+}
+
+// RefundPaymentHandler handles refund payment command
+type RefundPaymentHandler struct {
+	repo      domain.PaymentRepository
+	refunds   domain.RefundRepository
+	orders    domain.OrderRepository
+	gateway   domain.PaymentGateway
+	publisher *kafka.Publisher // optional; seals events with the registered payload schemas
+}
+
+// NewRefundPaymentHandler creates a new refund payment handler
+func NewRefundPaymentHandler(repo domain.PaymentRepository, refunds domain.RefundRepository, orders domain.OrderRepository, gateway domain.PaymentGateway, publisher *kafka.Publisher) *RefundPaymentHandler {
+	return &RefundPaymentHandler{repo: repo, refunds: refunds, orders: orders, gateway: gateway, publisher: publisher}
+}
+
+// Handle refunds part or all of a completed payment. The refund is reserved
+// against the payment's balance before the provider is asked to return the
+// money, so concurrent refunds can never exceed the captured amount. A
+// refund.issued event is written to the outbox once it succeeds, together
+// with payment.refunded when nothing is left to refund. Order payments
+// return their items by order line, so each line's stock can be given back
+// to its reservation.
+func (h *RefundPaymentHandler) Handle(ctx context.Context, cmd RefundPaymentCommand) (*domain.Refund, error) {
+	if cmd.PaymentID == 0 {
+		return nil, fmt.Errorf("payment_id is required")
+	}
+
+	payment, err := h.repo.FindByID(cmd.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+
+	var amount money.Money
+	if cmd.Amount != "" {
+		if amount, err = money.Parse(cmd.Amount, payment.Amount.Currency); err != nil {
+			return nil, err
+		}
+		if amount.IsNegative() {
+			return nil, fmt.Errorf("amount must not be negative")
+		}
+	}
+	if amount.IsZero() {
+		amount = payment.RefundableAmount()
+	}
+
+	order, err := h.orderOf(payment)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := refundLines(payment, order, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if amount == payment.RefundableAmount() {
+		if order != nil {
+			if lines, err = h.fillRemainingLines(payment, order, lines); err != nil {
+				return nil, err
+			}
+		} else if err := h.fillRemainingQuantity(payment, &cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	quantity := cmd.Quantity
+	if order != nil {
+		quantity = 0
+		for _, line := range lines {
+			quantity += line.Quantity
+		}
+	}
+
+	change := newStatusChange(ctx, cmd.PaymentID, domain.StatusRefunded, cmd.ActorUserID, cmd.Reason)
+	refund := &domain.Refund{
+		PaymentID:   cmd.PaymentID,
+		Amount:      amount,
+		Quantity:    quantity,
+		Lines:       lines,
+		Reason:      cmd.Reason,
+		ActorUserID: cmd.ActorUserID,
+		TraceID:     change.TraceID,
+	}
+
+	payment, err = h.refunds.CreatePending(refund)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	providerReference := cmd.ProviderReference
+	if providerReference == "" {
+		providerReference, err = h.refundWithProvider(ctx, payment, refund)
+	}
+	if err != nil {
+		if failErr := h.refunds.Fail(refund.ID, err.Error()); failErr != nil {
+			logger.Logger.Error().
+				Err(failErr).
+				Uint("refund_id", refund.ID).
+				Msg("Failed to mark refund as failed")
+		}
+		refund.Status = domain.RefundStatusFailed
+		refund.LastError = err.Error()
+		return refund, fmt.Errorf("%w: %w", domain.ErrRefundFailed, err)
+	}
+
+	traceHeaders := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceHeaders)
+
+	refund, _, err = h.refunds.Complete(refund.ID, providerReference, change, func(p *domain.Payment, r *domain.Refund, previous string) ([]domain.OutboxEvent, error) {
+		issued, err := refundOutboxEvent(h.publisher, p, r, traceHeaders)
+		if err != nil {
+			return nil, err
+		}
+		events := []domain.OutboxEvent{*issued}
+
+		if p.Status != previous {
+			statusEvents, err := paymentStatusOutboxEvents(h.publisher, p, previous, traceHeaders)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, statusEvents...)
+		}
+		return events, nil
+	})
+	if err != nil {
+		// The provider has returned the money; the refund stays pending for reconciliation
+		logger.Logger.Error().
+			Err(err).
+			Uint("payment_id", cmd.PaymentID).
+			Str("provider_reference", providerReference).
+			Msg("Failed to record completed refund")
+		return nil, fmt.Errorf("failed to complete refund: %w", err)
+	}
+
+	return refund, nil
+}
+
+// fillRemainingQuantity sets a refund of the remaining balance up to return
+// the items left
+func (h *RefundPaymentHandler) fillRemainingQuantity(payment *domain.Payment, cmd *RefundPaymentCommand) error {
+	if cmd.Quantity == 0 && payment.Quantity > 0 {
+		refunds, err := h.refunds.FindByPaymentID(payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load refunds: %w", err)
+		}
+		cmd.Quantity = payment.Quantity
+		for _, refund := range refunds {
+			if refund.Status != domain.RefundStatusFailed {
+				cmd.Quantity -= refund.Quantity
+			}
+		}
+	}
+	return nil
+}
+
+// orderOf returns the order paid by a payment, or nil when the payment is
+// for a single product
+func (h *RefundPaymentHandler) orderOf(payment *domain.Payment) (*domain.Order, error) {
+	if payment.ProductID != 0 || payment.OrderID == "" {
+		return nil, nil
+	}
+	order, err := h.orders.FindByOrderNumber(payment.OrderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order: %w", err)
+	}
+	return order, nil
+}
+
+// refundLines maps the returned products of an order to its lines. Order
+// refunds list their items by product rather than as a quantity.
+func refundLines(payment *domain.Payment, order *domain.Order, cmd RefundPaymentCommand) ([]domain.RefundLine, error) {
+	if order == nil {
+		if len(cmd.Lines) > 0 {
+			return nil, fmt.Errorf("payment %d is not an order, refund lines are not accepted", payment.ID)
+		}
+		return nil, nil
+	}
+	if cmd.Quantity != 0 {
+		return nil, fmt.Errorf("refunds of order %s list the returned items in lines", order.OrderNumber)
+	}
+
+	lines := make([]domain.RefundLine, 0, len(cmd.Lines))
+	for _, input := range cmd.Lines {
+		orderLine := orderLineOf(order, input.ProductID)
+		if orderLine == nil {
+			return nil, fmt.Errorf("product %d is not in order %s", input.ProductID, order.OrderNumber)
+		}
+		lines = append(lines, domain.RefundLine{
+			ProductID:     orderLine.ProductID,
+			ReservationID: orderLine.ReservationID,
+			Quantity:      input.Quantity,
+		})
+	}
+	return lines, nil
+}
+
+// fillRemainingLines sets an order refund of the remaining balance up to
+// return the items left on every line, unless it lists its lines
+func (h *RefundPaymentHandler) fillRemainingLines(payment *domain.Payment, order *domain.Order, lines []domain.RefundLine) ([]domain.RefundLine, error) {
+	if len(lines) > 0 {
+		return lines, nil
+	}
+
+	refunds, err := h.refunds.FindByPaymentID(payment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load refunds: %w", err)
+	}
+	refunded := make(map[string]int32)
+	for _, refund := range refunds {
+		if refund.Status == domain.RefundStatusFailed {
+			continue
+		}
+		for _, line := range refund.Lines {
+			refunded[line.ReservationID] += line.Quantity
+		}
+	}
+
+	for _, orderLine := range order.Lines {
+		if remaining := orderLine.Quantity - refunded[orderLine.ReservationID]; remaining > 0 {
+			lines = append(lines, domain.RefundLine{
+				ProductID:     orderLine.ProductID,
+				ReservationID: orderLine.ReservationID,
+				Quantity:      remaining,
+			})
+		}
+	}
+	return lines, nil
+}
+
+// orderLineOf returns the line of an order for a product, or nil
+func orderLineOf(order *domain.Order, productID uint) *domain.OrderLine {
+	for i := range order.Lines {
+		if order.Lines[i].ProductID == productID {
+			return &order.Lines[i]
+		}
+	}
+	return nil
+}
+
+// refundWithProvider returns the money through the provider that captured
+// it. Payments made before providers were introduced are refunded locally.
+func (h *RefundPaymentHandler) refundWithProvider(ctx context.Context, payment *domain.Payment, refund *domain.Refund) (string, error) {
+	if payment.Provider == "" || payment.TransactionID == "" {
+		return "", nil
+	}
+
+	provider, err := h.gateway.Provider(payment.Provider)
+	if err != nil {
+		return "", err
+	}
+	result, err := provider.Refund(ctx, payment.TransactionID, refund.Amount)
+	if err != nil {
+		return "", err
+	}
+	return result.Reference, nil
+}
@@ -0,0 +1,159 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/kafka"
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+// Outbox relay Prometheus metrics
+var (
+	outboxBacklogSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "payment_service_outbox_backlog_size",
+			Help: "Number of outbox events waiting to be published",
+		},
+	)
+
+	outboxBacklogAge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "payment_service_outbox_oldest_pending_age_seconds",
+			Help: "Age of the oldest unpublished outbox event in seconds",
+		},
+	)
+
+	outboxPublishedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "payment_service_outbox_publish_attempts_total",
+			Help: "Total number of outbox publish attempts",
+		},
+		[]string{"event_type", "status"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(outboxBacklogSize)
+	prometheus.MustRegister(outboxBacklogAge)
+	prometheus.MustRegister(outboxPublishedTotal)
+}
+
+const (
+	outboxBatchSize  = 100
+	outboxLease      = 30 * time.Second
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+// RelayOutboxHandler publishes pending outbox events to Kafka
+type RelayOutboxHandler struct {
+	repo      domain.OutboxRepository
+	publisher *kafka.Publisher
+}
+
+// NewRelayOutboxHandler creates a new outbox relay handler
+func NewRelayOutboxHandler(repo domain.OutboxRepository, publisher *kafka.Publisher) *RelayOutboxHandler {
+	return &RelayOutboxHandler{repo: repo, publisher: publisher}
+}
+
+// Handle publishes one batch of due events and refreshes the backlog metrics.
+// Failed events are retried with exponential backoff. Once an event fails,
+// the later events with the same key wait for it, so that consumers see the
+// events of a payment in order.
+func (h *RelayOutboxHandler) Handle(ctx context.Context) (int, error) {
+	now := time.Now()
+	events, err := h.repo.ClaimDue(now, outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	published := 0
+	failedKeys := make(map[string]bool)
+	for i := range events {
+		event := &events[i]
+		orderKey := event.Topic + "/" + event.MessageKey
+		if event.MessageKey != "" && failedKeys[orderKey] {
+			// Left leased; claimed again once the earlier event is sent
+			continue
+		}
+
+		// Continue the trace of the request that wrote the event
+		eventCtx := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(event.Headers))
+
+		if err := h.publisher.Publish(eventCtx, event.Topic, event.MessageKey, event.EventType, event.EventID, event.Payload, event.Headers); err != nil {
+			outboxPublishedTotal.WithLabelValues(event.EventType, "failed").Inc()
+			failedKeys[orderKey] = true
+			next := time.Now().Add(outboxBackoff(event.Attempts + 1))
+			if markErr := h.repo.MarkFailed(event.ID, err.Error(), next); markErr != nil {
+				logger.Logger.Error().
+					Err(markErr).
+					Str("event_id", event.EventID).
+					Msg("Failed to record outbox publish failure")
+			}
+			logger.Logger.Warn().
+				Err(err).
+				Str("event_id", event.EventID).
+				Int("attempts", event.Attempts+1).
+				Time("next_attempt_at", next).
+				Msg("Outbox event publish failed, will retry")
+			continue
+		}
+
+		outboxPublishedTotal.WithLabelValues(event.EventType, "sent").Inc()
+		if err := h.repo.MarkSent(event.ID, time.Now()); err != nil {
+			// The event will be published again once the lease expires
+			logger.Logger.Error().
+				Err(err).
+				Str("event_id", event.EventID).
+				Msg("Failed to mark outbox event as sent")
+			continue
+		}
+		published++
+	}
+
+	h.updateBacklogMetrics()
+
+	return published, nil
+}
+
+// PurgeSent deletes the events sent longer than retention ago
+func (h *RelayOutboxHandler) PurgeSent(retention time.Duration) (int64, error) {
+	purged, err := h.repo.PurgeSent(time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge sent outbox events: %w", err)
+	}
+	return purged, nil
+}
+
+func (h *RelayOutboxHandler) updateBacklogMetrics() {
+	count, oldest, err := h.repo.Backlog()
+	if err != nil {
+		logger.Logger.Error().Err(err).Msg("Failed to read outbox backlog")
+		return
+	}
+
+	outboxBacklogSize.Set(float64(count))
+	if oldest == nil {
+		outboxBacklogAge.Set(0)
+		return
+	}
+	outboxBacklogAge.Set(time.Since(*oldest).Seconds())
+}
+
+// outboxBackoff returns the delay before the given attempt (1s, 2s, 4s ... capped)
+func outboxBackoff(attempt int) time.Duration {
+	if attempt > 16 {
+		return outboxMaxBackoff
+	}
+	backoff := time.Second << (attempt - 1)
+	if backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
@@ -1,48 +1,123 @@
 package command
 
 import (
+	"context"
 	"fmt"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/kafka"
 )
 
 // UpdateStatusCommand represents the command to update payment status
 type UpdateStatusCommand struct {
-	PaymentID uint
-	Status    string
+	PaymentID   uint
+	Status      string
+	ActorUserID uint   // user who requested the change, recorded in the status history
+	Reason      string // optional
 }
 
 // UpdateStatusHandler handles update status command
 type UpdateStatusHandler struct {
-	repo domain.PaymentRepository
+	repo          domain.PaymentRepository
+	gateway       domain.PaymentGateway
+	refundHandler *RefundPaymentHandler
+	publisher     *kafka.Publisher // optional; seals events with the registered payload schemas
 }
 
 // NewUpdateStatusHandler creates a new update status handler
-func NewUpdateStatusHandler(repo domain.PaymentRepository) *UpdateStatusHandler {
-	return &UpdateStatusHandler{repo: repo}
+func NewUpdateStatusHandler(repo domain.PaymentRepository, gateway domain.PaymentGateway, refundHandler *RefundPaymentHandler, publisher *kafka.Publisher) *UpdateStatusHandler {
+	return &UpdateStatusHandler{repo: repo, gateway: gateway, refundHandler: refundHandler, publisher: publisher}
 }
 
-// Handle executes the update status command
-func (h *UpdateStatusHandler) Handle(cmd UpdateStatusCommand) error {
+// Handle executes the update status command. Moving a payment to refunded
+// issues a full refund of its remaining balance, and failing an authorized
+// payment voids its hold before the status changes. Payments are completed
+// only by capturing them with their provider, never by a status update. The transition is
+// recorded in the payment's status history and the matching payment event
+// (completed, failed or refunded) is written to the outbox with the change.
+func (h *UpdateStatusHandler) Handle(ctx context.Context, cmd UpdateStatusCommand) error {
 	if cmd.PaymentID == 0 {
 		return fmt.Errorf("payment_id is required")
 	}
 
-	// Validate status
-	validStatuses := map[string]bool{
-		domain.StatusPending:   true,
-		domain.StatusCompleted: true,
-		domain.StatusFailed:    true,
-		domain.StatusRefunded:  true,
+	if !domain.IsValidStatus(cmd.Status) {
+		return fmt.Errorf("invalid status: %s", cmd.Status)
 	}
 
-	if !validStatuses[cmd.Status] {
-		return fmt.Errorf("invalid status: %s", cmd.Status)
+	payment, err := h.repo.FindByID(cmd.PaymentID)
+	if err != nil {
+		return fmt.Errorf("payment not found: %w", err)
+	}
+	if payment.Status == cmd.Status {
+		return nil
+	}
+	if !domain.CanTransition(payment.Status, cmd.Status) {
+		return &domain.StatusTransitionError{PaymentID: payment.ID, From: payment.Status, To: cmd.Status}
+	}
+	if cmd.Status == domain.StatusCompleted {
+		transitionErr := &domain.StatusTransitionError{PaymentID: payment.ID, From: payment.Status, To: cmd.Status}
+		return fmt.Errorf("%w: payments are completed by their provider capture", transitionErr)
 	}
 
-	if err := h.repo.UpdateStatus(cmd.PaymentID, cmd.Status); err != nil {
+	if cmd.Status == domain.StatusRefunded {
+		_, err := h.refundHandler.Handle(ctx, RefundPaymentCommand{
+			PaymentID:   cmd.PaymentID,
+			Reason:      cmd.Reason,
+			ActorUserID: cmd.ActorUserID,
+		})
+		return err
+	}
+
+	if err := h.voidAuthorization(ctx, payment, cmd.Status); err != nil {
+		return err
+	}
+
+	traceHeaders := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceHeaders)
+
+	change := newStatusChange(ctx, cmd.PaymentID, cmd.Status, cmd.ActorUserID, cmd.Reason)
+	_, err = h.repo.UpdateStatusWithOutbox(change, func(payment *domain.Payment, previous string) ([]domain.OutboxEvent, error) {
+		return paymentStatusOutboxEvents(h.publisher, payment, previous, traceHeaders)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update payment status: %w", err)
 	}
 
 	return nil
 }
+
+// voidAuthorization releases the provider hold of an authorized payment
+// that is being failed before capture. Payments created before providers
+// were introduced have nothing to void.
+func (h *UpdateStatusHandler) voidAuthorization(ctx context.Context, payment *domain.Payment, status string) error {
+	if status != domain.StatusFailed || payment.Provider == "" || payment.AuthorizationID == "" || payment.TransactionID != "" {
+		return nil
+	}
+
+	provider, err := h.gateway.Provider(payment.Provider)
+	if err != nil {
+		return err
+	}
+	if err := provider.Void(ctx, payment.AuthorizationID); err != nil {
+		return fmt.Errorf("failed to void authorization: %w", err)
+	}
+	return nil
+}
+
+// newStatusChange builds a status change tagged with the trace of ctx
+func newStatusChange(ctx context.Context, paymentID uint, status string, actorUserID uint, reason string) domain.StatusChange {
+	change := domain.StatusChange{
+		PaymentID:   paymentID,
+		To:          status,
+		ActorUserID: actorUserID,
+		Reason:      reason,
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		change.TraceID = spanContext.TraceID().String()
+	}
+	return change
+}
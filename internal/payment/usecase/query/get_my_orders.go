@@ -0,0 +1,46 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+)
+
+// GetMyOrdersQuery represents the query to get user's own orders
+type GetMyOrdersQuery struct {
+	UserID uint
+	Limit  int
+	Offset int
+}
+
+// GetMyOrdersHandler handles get my orders query
+type GetMyOrdersHandler struct {
+	repo domain.OrderRepository
+}
+
+// NewGetMyOrdersHandler creates a new get my orders handler
+func NewGetMyOrdersHandler(repo domain.OrderRepository) *GetMyOrdersHandler {
+	return &GetMyOrdersHandler{repo: repo}
+}
+
+// Handle executes the get my orders query
+func (h *GetMyOrdersHandler) Handle(query GetMyOrdersQuery) ([]domain.Order, error) {
+	if query.UserID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	orders, err := h.repo.FindByUserID(query.UserID, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user orders: %w", err)
+	}
+
+	return orders, nil
+}
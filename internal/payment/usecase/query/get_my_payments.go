@@ -6,11 +6,10 @@ import (
 	"github.com/tair/full-observability/internal/payment/domain"
 )
 
-// GetMyPaymentsQuery represents the query to get user's own payments
+// GetMyPaymentsQuery represents the query to search user's own payments
 type GetMyPaymentsQuery struct {
 	UserID uint
-	Limit  int
-	Offset int
+	PaymentSearchParams
 }
 
 // GetMyPaymentsHandler handles get my payments query
@@ -24,23 +23,15 @@ func NewGetMyPaymentsHandler(repo domain.PaymentRepository) *GetMyPaymentsHandle
 }
 
 // Handle executes the get my payments query
-func (h *GetMyPaymentsHandler) Handle(query GetMyPaymentsQuery) ([]domain.Payment, error) {
+func (h *GetMyPaymentsHandler) Handle(query GetMyPaymentsQuery) (*PaymentPage, error) {
 	if query.UserID == 0 {
 		return nil, fmt.Errorf("user_id is required")
 	}
 
-	if query.Limit == 0 {
-		query.Limit = 10
-	}
-
-	if query.Limit > 100 {
-		query.Limit = 100
-	}
-
-	payments, err := h.repo.FindByUserID(query.UserID, query.Limit, query.Offset)
+	page, err := searchPayments(h.repo, query.UserID, query.PaymentSearchParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user payments: %w", err)
 	}
 
-	return payments, nil
+	return page, nil
 }
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+)
+
+// GetPaymentHistoryQuery represents the query to get the status history of a payment
+type GetPaymentHistoryQuery struct {
+	PaymentID uint
+}
+
+// GetPaymentHistoryHandler handles get payment history query
+type GetPaymentHistoryHandler struct {
+	repo domain.PaymentRepository
+}
+
+// NewGetPaymentHistoryHandler creates a new get payment history handler
+func NewGetPaymentHistoryHandler(repo domain.PaymentRepository) *GetPaymentHistoryHandler {
+	return &GetPaymentHistoryHandler{repo: repo}
+}
+
+// Handle executes the get payment history query
+func (h *GetPaymentHistoryHandler) Handle(query GetPaymentHistoryQuery) ([]domain.PaymentStatusHistory, error) {
+	if query.PaymentID == 0 {
+		return nil, fmt.Errorf("payment_id is required")
+	}
+
+	if _, err := h.repo.FindByID(query.PaymentID); err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+
+	history, err := h.repo.FindStatusHistory(query.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment history: %w", err)
+	}
+
+	return history, nil
+}
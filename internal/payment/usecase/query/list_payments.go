@@ -6,10 +6,10 @@ import (
 	"github.com/tair/full-observability/internal/payment/domain"
 )
 
-// ListPaymentsQuery represents the query to list payments
+// ListPaymentsQuery represents the query to search all payments
 type ListPaymentsQuery struct {
-	Limit  int
-	Offset int
+	UserID uint // optional; zero lists the payments of every user
+	PaymentSearchParams
 }
 
 // ListPaymentsHandler handles list payments query
@@ -23,19 +23,11 @@ func NewListPaymentsHandler(repo domain.PaymentRepository) *ListPaymentsHandler
 }
 
 // Handle executes the list payments query
-func (h *ListPaymentsHandler) Handle(query ListPaymentsQuery) ([]domain.Payment, error) {
-	if query.Limit == 0 {
-		query.Limit = 10
-	}
-
-	if query.Limit > 100 {
-		query.Limit = 100
-	}
-
-	payments, err := h.repo.FindAll(query.Limit, query.Offset)
+func (h *ListPaymentsHandler) Handle(query ListPaymentsQuery) (*PaymentPage, error) {
+	page, err := searchPayments(h.repo, query.UserID, query.PaymentSearchParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list payments: %w", err)
 	}
 
-	return payments, nil
+	return page, nil
 }
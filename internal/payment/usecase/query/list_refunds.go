@@ -0,0 +1,41 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+)
+
+// ListRefundsQuery represents the query to list the refunds of a payment
+type ListRefundsQuery struct {
+	PaymentID uint
+}
+
+// ListRefundsHandler handles list refunds query
+type ListRefundsHandler struct {
+	repo    domain.PaymentRepository
+	refunds domain.RefundRepository
+}
+
+// NewListRefundsHandler creates a new list refunds handler
+func NewListRefundsHandler(repo domain.PaymentRepository, refunds domain.RefundRepository) *ListRefundsHandler {
+	return &ListRefundsHandler{repo: repo, refunds: refunds}
+}
+
+// Handle executes the list refunds query
+func (h *ListRefundsHandler) Handle(query ListRefundsQuery) ([]domain.Refund, error) {
+	if query.PaymentID == 0 {
+		return nil, fmt.Errorf("payment_id is required")
+	}
+
+	if _, err := h.repo.FindByID(query.PaymentID); err != nil {
+		return nil, fmt.Errorf("payment not found: %w", err)
+	}
+
+	refunds, err := h.refunds.FindByPaymentID(query.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	return refunds, nil
+}
@@ -0,0 +1,147 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// PaymentSearchParams are the filters, sort order and page shared by the
+// payment list queries
+type PaymentSearchParams struct {
+	Statuses       []string
+	PaymentMethods []string
+	RiskDecisions  []string
+	Currency       string
+	MinAmount      string // decimal in major units of Currency
+	MaxAmount      string // decimal in major units of Currency
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+	Sort           string // field, prefixed with "-" for descending order; newest first by default
+	Cursor         string // next_cursor of the previous page
+	Limit          int
+	Offset         int // deprecated, ignored with a cursor
+}
+
+// PaymentPage is a page of a payment search
+type PaymentPage struct {
+	Payments   []domain.Payment `json:"payments"`
+	Total      int64            `json:"total"`                 // payments matching the filters across all pages
+	NextCursor string           `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+// searchPayments validates the params and runs the search for userID, or for
+// every user when it is zero
+func searchPayments(repo domain.PaymentRepository, userID uint, params PaymentSearchParams) (*PaymentPage, error) {
+	search, err := params.build(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra payment to learn whether there is a next page
+	limit := search.Limit
+	search.Limit++
+	payments, total, err := repo.Search(search)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PaymentPage{Payments: payments, Total: total}
+	if len(payments) > limit {
+		page.Payments = payments[:limit]
+		page.NextCursor = domain.NewPaymentCursor(search.Sort, &page.Payments[limit-1]).Encode()
+	}
+	if page.Payments == nil {
+		page.Payments = []domain.Payment{}
+	}
+	return page, nil
+}
+
+func (p PaymentSearchParams) build(userID uint) (domain.PaymentSearch, error) {
+	search := domain.PaymentSearch{
+		Filter: domain.PaymentFilter{
+			UserID:         userID,
+			PaymentMethods: p.PaymentMethods,
+			CreatedFrom:    p.CreatedFrom,
+			CreatedTo:      p.CreatedTo,
+		},
+		Limit:  p.Limit,
+		Offset: p.Offset,
+	}
+
+	if search.Limit <= 0 {
+		search.Limit = 10
+	}
+	if search.Limit > 100 {
+		search.Limit = 100
+	}
+	if search.Offset < 0 {
+		return search, fmt.Errorf("%w: offset must not be negative", domain.ErrInvalidSearch)
+	}
+
+	for _, status := range p.Statuses {
+		if !domain.IsValidStatus(status) {
+			return search, fmt.Errorf("%w: unknown status %q", domain.ErrInvalidSearch, status)
+		}
+		search.Filter.Statuses = append(search.Filter.Statuses, status)
+	}
+
+	for _, decision := range p.RiskDecisions {
+		if !domain.IsValidRiskDecision(decision) {
+			return search, fmt.Errorf("%w: unknown risk decision %q", domain.ErrInvalidSearch, decision)
+		}
+		search.Filter.RiskDecisions = append(search.Filter.RiskDecisions, decision)
+	}
+
+	if p.Currency != "" {
+		if err := money.ValidateCurrency(p.Currency); err != nil {
+			return search, fmt.Errorf("%w: %v", domain.ErrInvalidSearch, err)
+		}
+		search.Filter.Currency = strings.ToUpper(p.Currency)
+	}
+
+	// Amounts only compare within a currency
+	if (p.MinAmount != "" || p.MaxAmount != "") && search.Filter.Currency == "" {
+		return search, fmt.Errorf("%w: min_amount and max_amount require currency", domain.ErrInvalidSearch)
+	}
+	if p.MinAmount != "" {
+		amount, err := money.Parse(p.MinAmount, search.Filter.Currency)
+		if err != nil {
+			return search, fmt.Errorf("%w: min_amount: %v", domain.ErrInvalidSearch, err)
+		}
+		search.Filter.MinAmount = &amount
+	}
+	if p.MaxAmount != "" {
+		amount, err := money.Parse(p.MaxAmount, search.Filter.Currency)
+		if err != nil {
+			return search, fmt.Errorf("%w: max_amount: %v", domain.ErrInvalidSearch, err)
+		}
+		search.Filter.MaxAmount = &amount
+	}
+
+	if p.CreatedFrom != nil && p.CreatedTo != nil && !p.CreatedFrom.Before(*p.CreatedTo) {
+		return search, fmt.Errorf("%w: created_from must be before created_to", domain.ErrInvalidSearch)
+	}
+
+	sort, err := domain.ParsePaymentSort(p.Sort)
+	if err != nil {
+		return search, err
+	}
+	if sort.Field == domain.PaymentSortAmount && search.Filter.Currency == "" {
+		return search, fmt.Errorf("%w: sort by amount requires currency", domain.ErrInvalidSearch)
+	}
+	search.Sort = sort
+
+	if p.Cursor != "" {
+		cursor, err := domain.DecodePaymentCursor(p.Cursor, sort)
+		if err != nil {
+			return search, err
+		}
+		search.After = cursor
+	}
+
+	return search, nil
+}
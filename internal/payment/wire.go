@@ -4,13 +4,19 @@
 package payment
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/wire"
 	"gorm.io/gorm"
 
+	eventspb "github.com/tair/full-observability/api/proto/events"
 	"github.com/tair/full-observability/internal/payment/client"
+	"github.com/tair/full-observability/internal/payment/delivery/grpc"
 	"github.com/tair/full-observability/internal/payment/domain"
 	"github.com/tair/full-observability/internal/payment/handler"
 	"github.com/tair/full-observability/internal/payment/repository"
+	"github.com/tair/full-observability/internal/payment/risk"
 	"github.com/tair/full-observability/internal/payment/usecase/command"
 	"github.com/tair/full-observability/internal/payment/usecase/query"
 	"github.com/tair/full-observability/kafka"
@@ -28,13 +34,82 @@ func ProvidePaymentRepository(db *gorm.DB) domain.PaymentRepository {
 	return repository.NewGormPaymentRepository(db)
 }
 
+// ProvideCheckoutSagaRepository provides the checkout saga repository
+func ProvideCheckoutSagaRepository(db *gorm.DB) domain.CheckoutSagaRepository {
+	return repository.NewGormCheckoutSagaRepository(db)
+}
+
+// ProvideOutboxRepository provides the outbox repository
+func ProvideOutboxRepository(db *gorm.DB) domain.OutboxRepository {
+	return repository.NewGormOutboxRepository(db)
+}
+
+// ProvideWebhookEventRepository provides the webhook event repository
+func ProvideWebhookEventRepository(db *gorm.DB) domain.WebhookEventRepository {
+	return repository.NewGormWebhookEventRepository(db)
+}
+
+// ProvideRefundRepository provides the refund repository
+func ProvideRefundRepository(db *gorm.DB) domain.RefundRepository {
+	return repository.NewGormRefundRepository(db)
+}
+
+// ProvideOrderRepository provides the order repository
+func ProvideOrderRepository(db *gorm.DB) domain.OrderRepository {
+	return repository.NewGormOrderRepository(db)
+}
+
+// ProvideIdempotencyKeyRepository provides the idempotency key repository
+func ProvideIdempotencyKeyRepository(db *gorm.DB) domain.IdempotencyKeyRepository {
+	return repository.NewGormIdempotencyKeyRepository(db)
+}
+
+// ProvideIdempotencyConfig provides the Idempotency-Key configuration
+func ProvideIdempotencyConfig(keys domain.IdempotencyKeyRepository, ttl time.Duration) handler.IdempotencyConfig {
+	return handler.IdempotencyConfig{Keys: keys, TTL: ttl}
+}
+
 // Command Handlers Providers
-func ProvideCreatePaymentHandler(repo domain.PaymentRepository) *command.CreatePaymentHandler {
-	return command.NewCreatePaymentHandler(repo)
+func ProvideCreatePaymentHandler(repo domain.PaymentRepository, gateway domain.PaymentGateway, kafkaPublisher *kafka.Publisher, riskEvaluator domain.RiskEvaluator) *command.CreatePaymentHandler {
+	return command.NewCreatePaymentHandler(repo, gateway, kafkaPublisher, riskEvaluator)
+}
+
+func ProvideUpdateStatusHandler(repo domain.PaymentRepository, gateway domain.PaymentGateway, refundHandler *command.RefundPaymentHandler, kafkaPublisher *kafka.Publisher) *command.UpdateStatusHandler {
+	return command.NewUpdateStatusHandler(repo, gateway, refundHandler, kafkaPublisher)
 }
 
-func ProvideUpdateStatusHandler(repo domain.PaymentRepository) *command.UpdateStatusHandler {
-	return command.NewUpdateStatusHandler(repo)
+func ProvideRefundPaymentHandler(repo domain.PaymentRepository, refunds domain.RefundRepository, orders domain.OrderRepository, gateway domain.PaymentGateway, kafkaPublisher *kafka.Publisher) *command.RefundPaymentHandler {
+	return command.NewRefundPaymentHandler(repo, refunds, orders, gateway, kafkaPublisher)
+}
+
+func ProvideCheckoutHandler(
+	sagaRepo domain.CheckoutSagaRepository,
+	repo domain.PaymentRepository,
+	createHandler *command.CreatePaymentHandler,
+	productClient *client.ProductServiceClient,
+	pricer domain.Pricer,
+	inventoryClient *client.InventoryServiceClient,
+) *command.CheckoutHandler {
+	return command.NewCheckoutHandler(sagaRepo, repo, createHandler, productClient, pricer, inventoryClient)
+}
+
+func ProvidePlaceOrderHandler(
+	orderRepo domain.OrderRepository,
+	repo domain.PaymentRepository,
+	createHandler *command.CreatePaymentHandler,
+	productClient *client.ProductServiceClient,
+	pricer domain.Pricer,
+	inventoryClient *client.InventoryServiceClient,
+) *command.PlaceOrderHandler {
+	return command.NewPlaceOrderHandler(orderRepo, repo, createHandler, productClient, pricer, inventoryClient)
+}
+
+func ProvideHandleWebhookHandler(repo domain.PaymentRepository, webhooks domain.WebhookEventRepository, refundHandler *command.RefundPaymentHandler, kafkaPublisher *kafka.Publisher) *command.HandleWebhookHandler {
+	return command.NewHandleWebhookHandler(repo, webhooks, refundHandler, kafkaPublisher)
+}
+
+func ProvideRelayOutboxHandler(repo domain.OutboxRepository, kafkaPublisher *kafka.Publisher) *command.RelayOutboxHandler {
+	return command.NewRelayOutboxHandler(repo, kafkaPublisher)
 }
 
 // Query Handlers Providers
@@ -50,6 +125,22 @@ func ProvideGetMyPaymentsHandler(repo domain.PaymentRepository) *query.GetMyPaym
 	return query.NewGetMyPaymentsHandler(repo)
 }
 
+func ProvideGetPaymentHistoryHandler(repo domain.PaymentRepository) *query.GetPaymentHistoryHandler {
+	return query.NewGetPaymentHistoryHandler(repo)
+}
+
+func ProvideListRefundsHandler(repo domain.PaymentRepository, refunds domain.RefundRepository) *query.ListRefundsHandler {
+	return query.NewListRefundsHandler(repo, refunds)
+}
+
+func ProvideGetOrderHandler(repo domain.OrderRepository) *query.GetOrderHandler {
+	return query.NewGetOrderHandler(repo)
+}
+
+func ProvideGetMyOrdersHandler(repo domain.OrderRepository) *query.GetMyOrdersHandler {
+	return query.NewGetMyOrdersHandler(repo)
+}
+
 // ProvideUserServiceClient provides the user service gRPC client
 func ProvideUserServiceClient(addrs ServiceAddrs) (*client.UserServiceClient, error) {
 	return client.NewUserServiceClient(addrs.UserServiceAddr)
@@ -65,25 +156,54 @@ func ProvideInventoryServiceClient(addrs ServiceAddrs) (*client.InventoryService
 	return client.NewInventoryServiceClient(addrs.InventoryServiceAddr)
 }
 
-// ProvideKafkaPublisher provides the Kafka publisher
-func ProvideKafkaPublisher(kafkaBrokers []string) (*kafka.Publisher, error) {
-	return kafka.NewPublisher(kafkaBrokers)
+// ProvideKafkaPublisher provides the Kafka publisher. With a schema registry,
+// event payloads are registered and published as protobuf.
+func ProvideKafkaPublisher(kafkaBrokers []string, schemaRegistry kafka.SchemaRegistry) (*kafka.Publisher, error) {
+	publisher, err := kafka.NewPublisher(kafkaBrokers)
+	if err != nil {
+		return nil, err
+	}
+	publisher.SetSource(domain.EventSource)
+
+	if schemaRegistry != nil {
+		publisher.UseSchemaRegistry(schemaRegistry)
+		if _, err := publisher.RegisterSchema(context.Background(), kafka.EventTypeProductPurchased, &eventspb.ProductPurchased{}); err != nil {
+			publisher.Close()
+			return nil, err
+		}
+	}
+	return publisher, nil
 }
 
 // Wire sets
 var RepositorySet = wire.NewSet(
 	ProvidePaymentRepository,
+	ProvideCheckoutSagaRepository,
+	ProvideOutboxRepository,
+	ProvideWebhookEventRepository,
+	ProvideRefundRepository,
+	ProvideOrderRepository,
+	ProvideIdempotencyKeyRepository,
 )
 
 var CommandHandlerSet = wire.NewSet(
 	ProvideCreatePaymentHandler,
 	ProvideUpdateStatusHandler,
+	ProvideRefundPaymentHandler,
+	ProvideCheckoutHandler,
+	ProvidePlaceOrderHandler,
+	ProvideRelayOutboxHandler,
+	ProvideHandleWebhookHandler,
 )
 
 var QueryHandlerSet = wire.NewSet(
 	ProvideGetPaymentHandler,
 	ProvideListPaymentsHandler,
 	ProvideGetMyPaymentsHandler,
+	ProvideGetPaymentHistoryHandler,
+	ProvideListRefundsHandler,
+	ProvideGetOrderHandler,
+	ProvideGetMyOrdersHandler,
 )
 
 var AllHandlersSet = wire.NewSet(
@@ -92,15 +212,25 @@ var AllHandlersSet = wire.NewSet(
 	QueryHandlerSet,
 )
 
-// InitializeHandler initializes payment handler with all dependencies
-func InitializeHandler(db *gorm.DB, addrs ServiceAddrs, kafkaBrokers []string) (*handler.PaymentHandler, error) {
+// Servers holds the HTTP handler and the gRPC server of the payment service.
+// They share one set of command handlers, Kafka publisher and service clients.
+type Servers struct {
+	HTTP *handler.PaymentHandler
+	GRPC *grpc.PaymentServer
+}
+
+// InitializeServers initializes the payment HTTP handler and gRPC server with all dependencies
+func InitializeServers(db *gorm.DB, addrs ServiceAddrs, kafkaBrokers []string, schemaRegistry kafka.SchemaRegistry, gateway domain.PaymentGateway, webhookVerifier domain.WebhookVerifier, pricer domain.Pricer, riskEvaluator domain.RiskEvaluator, idempotencyTTL time.Duration, trustedProxies risk.TrustedProxies) (*Servers, error) {
 	wire.Build(
 		AllHandlersSet,
 		ProvideUserServiceClient,
 		ProvideProductServiceClient,
 		ProvideInventoryServiceClient,
 		ProvideKafkaPublisher,
+		ProvideIdempotencyConfig,
 		handler.NewPaymentHandlerWithDI,
+		grpc.NewPaymentServerWithDI,
+		wire.Struct(new(Servers), "*"),
 	)
 	return nil, nil
 }
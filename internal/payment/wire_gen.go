@@ -7,31 +7,37 @@
 package payment
 
 import (
+	"context"
 	"github.com/google/wire"
+	"github.com/tair/full-observability/api/proto/events"
 	"github.com/tair/full-observability/internal/payment/client"
+	"github.com/tair/full-observability/internal/payment/delivery/grpc"
 	"github.com/tair/full-observability/internal/payment/domain"
 	"github.com/tair/full-observability/internal/payment/handler"
 	"github.com/tair/full-observability/internal/payment/repository"
+	"github.com/tair/full-observability/internal/payment/risk"
 	"github.com/tair/full-observability/internal/payment/usecase/command"
 	"github.com/tair/full-observability/internal/payment/usecase/query"
 	"github.com/tair/full-observability/kafka"
 	"gorm.io/gorm"
+	"time"
 )
 
 // Injectors from wire.go:
 
-// InitializeHandler initializes payment handler with all dependencies
-func InitializeHandler(db *gorm.DB, addrs ServiceAddrs, kafkaBrokers []string) (*handler.PaymentHandler, error) {
+// InitializeServers initializes the payment HTTP handler and gRPC server with all dependencies
+func InitializeServers(db *gorm.DB, addrs ServiceAddrs, kafkaBrokers []string, schemaRegistry kafka.SchemaRegistry, gateway domain.PaymentGateway, webhookVerifier domain.WebhookVerifier, pricer domain.Pricer, riskEvaluator domain.RiskEvaluator, idempotencyTTL time.Duration, trustedProxies risk.TrustedProxies) (*Servers, error) {
 	paymentRepository := ProvidePaymentRepository(db)
-	createPaymentHandler := ProvideCreatePaymentHandler(paymentRepository)
-	updateStatusHandler := ProvideUpdateStatusHandler(paymentRepository)
-	getPaymentHandler := ProvideGetPaymentHandler(paymentRepository)
-	listPaymentsHandler := ProvideListPaymentsHandler(paymentRepository)
-	getMyPaymentsHandler := ProvideGetMyPaymentsHandler(paymentRepository)
-	userServiceClient, err := ProvideUserServiceClient(addrs)
+	publisher, err := ProvideKafkaPublisher(kafkaBrokers, schemaRegistry)
 	if err != nil {
 		return nil, err
 	}
+	createPaymentHandler := ProvideCreatePaymentHandler(paymentRepository, gateway, publisher, riskEvaluator)
+	refundRepository := ProvideRefundRepository(db)
+	orderRepository := ProvideOrderRepository(db)
+	refundPaymentHandler := ProvideRefundPaymentHandler(paymentRepository, refundRepository, orderRepository, gateway, publisher)
+	updateStatusHandler := ProvideUpdateStatusHandler(paymentRepository, gateway, refundPaymentHandler, publisher)
+	checkoutSagaRepository := ProvideCheckoutSagaRepository(db)
 	productServiceClient, err := ProvideProductServiceClient(addrs)
 	if err != nil {
 		return nil, err
@@ -40,12 +46,32 @@ func InitializeHandler(db *gorm.DB, addrs ServiceAddrs, kafkaBrokers []string) (
 	if err != nil {
 		return nil, err
 	}
-	publisher, err := ProvideKafkaPublisher(kafkaBrokers)
+	checkoutHandler := ProvideCheckoutHandler(checkoutSagaRepository, paymentRepository, createPaymentHandler, productServiceClient, pricer, inventoryServiceClient)
+	outboxRepository := ProvideOutboxRepository(db)
+	relayOutboxHandler := ProvideRelayOutboxHandler(outboxRepository, publisher)
+	webhookEventRepository := ProvideWebhookEventRepository(db)
+	handleWebhookHandler := ProvideHandleWebhookHandler(paymentRepository, webhookEventRepository, refundPaymentHandler, publisher)
+	placeOrderHandler := ProvidePlaceOrderHandler(orderRepository, paymentRepository, createPaymentHandler, productServiceClient, pricer, inventoryServiceClient)
+	getPaymentHandler := ProvideGetPaymentHandler(paymentRepository)
+	listPaymentsHandler := ProvideListPaymentsHandler(paymentRepository)
+	getMyPaymentsHandler := ProvideGetMyPaymentsHandler(paymentRepository)
+	getPaymentHistoryHandler := ProvideGetPaymentHistoryHandler(paymentRepository)
+	listRefundsHandler := ProvideListRefundsHandler(paymentRepository, refundRepository)
+	getOrderHandler := ProvideGetOrderHandler(orderRepository)
+	getMyOrdersHandler := ProvideGetMyOrdersHandler(orderRepository)
+	idempotencyKeyRepository := ProvideIdempotencyKeyRepository(db)
+	idempotencyConfig := ProvideIdempotencyConfig(idempotencyKeyRepository, idempotencyTTL)
+	userServiceClient, err := ProvideUserServiceClient(addrs)
 	if err != nil {
 		return nil, err
 	}
-	paymentHandler := handler.NewPaymentHandlerWithDI(createPaymentHandler, updateStatusHandler, getPaymentHandler, listPaymentsHandler, getMyPaymentsHandler, paymentRepository, userServiceClient, productServiceClient, inventoryServiceClient, publisher)
-	return paymentHandler, nil
+	paymentHandler := handler.NewPaymentHandlerWithDI(createPaymentHandler, updateStatusHandler, checkoutHandler, relayOutboxHandler, handleWebhookHandler, refundPaymentHandler, placeOrderHandler, getPaymentHandler, listPaymentsHandler, getMyPaymentsHandler, getPaymentHistoryHandler, listRefundsHandler, getOrderHandler, getMyOrdersHandler, paymentRepository, webhookVerifier, idempotencyConfig, trustedProxies, userServiceClient, productServiceClient, inventoryServiceClient, publisher)
+	paymentServer := grpc.NewPaymentServerWithDI(checkoutHandler, updateStatusHandler, refundPaymentHandler, getPaymentHandler, listPaymentsHandler, trustedProxies, userServiceClient)
+	servers := &Servers{
+		HTTP: paymentHandler,
+		GRPC: paymentServer,
+	}
+	return servers, nil
 }
 
 // wire.go:
@@ -62,13 +88,82 @@ func ProvidePaymentRepository(db *gorm.DB) domain.PaymentRepository {
 	return repository.NewGormPaymentRepository(db)
 }
 
+// ProvideCheckoutSagaRepository provides the checkout saga repository
+func ProvideCheckoutSagaRepository(db *gorm.DB) domain.CheckoutSagaRepository {
+	return repository.NewGormCheckoutSagaRepository(db)
+}
+
+// ProvideOutboxRepository provides the outbox repository
+func ProvideOutboxRepository(db *gorm.DB) domain.OutboxRepository {
+	return repository.NewGormOutboxRepository(db)
+}
+
+// ProvideWebhookEventRepository provides the webhook event repository
+func ProvideWebhookEventRepository(db *gorm.DB) domain.WebhookEventRepository {
+	return repository.NewGormWebhookEventRepository(db)
+}
+
+// ProvideRefundRepository provides the refund repository
+func ProvideRefundRepository(db *gorm.DB) domain.RefundRepository {
+	return repository.NewGormRefundRepository(db)
+}
+
+// ProvideOrderRepository provides the order repository
+func ProvideOrderRepository(db *gorm.DB) domain.OrderRepository {
+	return repository.NewGormOrderRepository(db)
+}
+
+// ProvideIdempotencyKeyRepository provides the idempotency key repository
+func ProvideIdempotencyKeyRepository(db *gorm.DB) domain.IdempotencyKeyRepository {
+	return repository.NewGormIdempotencyKeyRepository(db)
+}
+
+// ProvideIdempotencyConfig provides the Idempotency-Key configuration
+func ProvideIdempotencyConfig(keys domain.IdempotencyKeyRepository, ttl time.Duration) handler.IdempotencyConfig {
+	return handler.IdempotencyConfig{Keys: keys, TTL: ttl}
+}
+
 // Command Handlers Providers
-func ProvideCreatePaymentHandler(repo domain.PaymentRepository) *command.CreatePaymentHandler {
-	return command.NewCreatePaymentHandler(repo)
+func ProvideCreatePaymentHandler(repo domain.PaymentRepository, gateway domain.PaymentGateway, kafkaPublisher *kafka.Publisher, riskEvaluator domain.RiskEvaluator) *command.CreatePaymentHandler {
+	return command.NewCreatePaymentHandler(repo, gateway, kafkaPublisher, riskEvaluator)
+}
+
+func ProvideUpdateStatusHandler(repo domain.PaymentRepository, gateway domain.PaymentGateway, refundHandler *command.RefundPaymentHandler, kafkaPublisher *kafka.Publisher) *command.UpdateStatusHandler {
+	return command.NewUpdateStatusHandler(repo, gateway, refundHandler, kafkaPublisher)
+}
+
+func ProvideRefundPaymentHandler(repo domain.PaymentRepository, refunds domain.RefundRepository, orders domain.OrderRepository, gateway domain.PaymentGateway, kafkaPublisher *kafka.Publisher) *command.RefundPaymentHandler {
+	return command.NewRefundPaymentHandler(repo, refunds, orders, gateway, kafkaPublisher)
+}
+
+func ProvideCheckoutHandler(
+	sagaRepo domain.CheckoutSagaRepository,
+	repo domain.PaymentRepository,
+	createHandler *command.CreatePaymentHandler,
+	productClient *client.ProductServiceClient,
+	pricer domain.Pricer,
+	inventoryClient *client.InventoryServiceClient,
+) *command.CheckoutHandler {
+	return command.NewCheckoutHandler(sagaRepo, repo, createHandler, productClient, pricer, inventoryClient)
+}
+
+func ProvidePlaceOrderHandler(
+	orderRepo domain.OrderRepository,
+	repo domain.PaymentRepository,
+	createHandler *command.CreatePaymentHandler,
+	productClient *client.ProductServiceClient,
+	pricer domain.Pricer,
+	inventoryClient *client.InventoryServiceClient,
+) *command.PlaceOrderHandler {
+	return command.NewPlaceOrderHandler(orderRepo, repo, createHandler, productClient, pricer, inventoryClient)
+}
+
+func ProvideHandleWebhookHandler(repo domain.PaymentRepository, webhooks domain.WebhookEventRepository, refundHandler *command.RefundPaymentHandler, kafkaPublisher *kafka.Publisher) *command.HandleWebhookHandler {
+	return command.NewHandleWebhookHandler(repo, webhooks, refundHandler, kafkaPublisher)
 }
 
-func ProvideUpdateStatusHandler(repo domain.PaymentRepository) *command.UpdateStatusHandler {
-	return command.NewUpdateStatusHandler(repo)
+func ProvideRelayOutboxHandler(repo domain.OutboxRepository, kafkaPublisher *kafka.Publisher) *command.RelayOutboxHandler {
+	return command.NewRelayOutboxHandler(repo, kafkaPublisher)
 }
 
 // Query Handlers Providers
@@ -84,6 +179,22 @@ func ProvideGetMyPaymentsHandler(repo domain.PaymentRepository) *query.GetMyPaym
 	return query.NewGetMyPaymentsHandler(repo)
 }
 
+func ProvideGetPaymentHistoryHandler(repo domain.PaymentRepository) *query.GetPaymentHistoryHandler {
+	return query.NewGetPaymentHistoryHandler(repo)
+}
+
+func ProvideListRefundsHandler(repo domain.PaymentRepository, refunds domain.RefundRepository) *query.ListRefundsHandler {
+	return query.NewListRefundsHandler(repo, refunds)
+}
+
+func ProvideGetOrderHandler(repo domain.OrderRepository) *query.GetOrderHandler {
+	return query.NewGetOrderHandler(repo)
+}
+
+func ProvideGetMyOrdersHandler(repo domain.OrderRepository) *query.GetMyOrdersHandler {
+	return query.NewGetMyOrdersHandler(repo)
+}
+
 // ProvideUserServiceClient provides the user service gRPC client
 func ProvideUserServiceClient(addrs ServiceAddrs) (*client.UserServiceClient, error) {
 	return client.NewUserServiceClient(addrs.UserServiceAddr)
@@ -99,25 +210,54 @@ func ProvideInventoryServiceClient(addrs ServiceAddrs) (*client.InventoryService
 	return client.NewInventoryServiceClient(addrs.InventoryServiceAddr)
 }
 
-// ProvideKafkaPublisher provides the Kafka publisher
-func ProvideKafkaPublisher(kafkaBrokers []string) (*kafka.Publisher, error) {
-	return kafka.NewPublisher(kafkaBrokers)
+// ProvideKafkaPublisher provides the Kafka publisher. With a schema registry,
+// event payloads are registered and published as protobuf.
+func ProvideKafkaPublisher(kafkaBrokers []string, schemaRegistry kafka.SchemaRegistry) (*kafka.Publisher, error) {
+	publisher, err := kafka.NewPublisher(kafkaBrokers)
+	if err != nil {
+		return nil, err
+	}
+	publisher.SetSource(domain.EventSource)
+
+	if schemaRegistry != nil {
+		publisher.UseSchemaRegistry(schemaRegistry)
+		if _, err := publisher.RegisterSchema(context.Background(), kafka.EventTypeProductPurchased, &eventspb.ProductPurchased{}); err != nil {
+			publisher.Close()
+			return nil, err
+		}
+	}
+	return publisher, nil
 }
 
 // Wire sets
 var RepositorySet = wire.NewSet(
 	ProvidePaymentRepository,
+	ProvideCheckoutSagaRepository,
+	ProvideOutboxRepository,
+	ProvideWebhookEventRepository,
+	ProvideRefundRepository,
+	ProvideOrderRepository,
+	ProvideIdempotencyKeyRepository,
 )
 
 var CommandHandlerSet = wire.NewSet(
 	ProvideCreatePaymentHandler,
 	ProvideUpdateStatusHandler,
+	ProvideRefundPaymentHandler,
+	ProvideCheckoutHandler,
+	ProvidePlaceOrderHandler,
+	ProvideRelayOutboxHandler,
+	ProvideHandleWebhookHandler,
 )
 
 var QueryHandlerSet = wire.NewSet(
 	ProvideGetPaymentHandler,
 	ProvideListPaymentsHandler,
 	ProvideGetMyPaymentsHandler,
+	ProvideGetPaymentHistoryHandler,
+	ProvideListRefundsHandler,
+	ProvideGetOrderHandler,
+	ProvideGetMyOrdersHandler,
 )
 
 var AllHandlersSet = wire.NewSet(
@@ -125,3 +265,10 @@ var AllHandlersSet = wire.NewSet(
 	CommandHandlerSet,
 	QueryHandlerSet,
 )
+
+// Servers holds the HTTP handler and the gRPC server of the payment service.
+// They share one set of command handlers, Kafka publisher and service clients.
+type Servers struct {
+	HTTP *handler.PaymentHandler
+	GRPC *grpc.PaymentServer
+}
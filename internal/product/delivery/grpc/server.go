@@ -8,10 +8,12 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	moneypb "github.com/tair/full-observability/api/proto/money"
 	pb "github.com/tair/full-observability/api/proto/product"
 	"github.com/tair/full-observability/internal/product/domain"
 	"github.com/tair/full-observability/internal/product/usecase/command"
 	"github.com/tair/full-observability/internal/product/usecase/query"
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // ProductServer implements the gRPC ProductService
@@ -76,7 +78,7 @@ func (s *ProductServer) CreateProduct(ctx context.Context, req *pb.CreateProduct
 	cmd := command.CreateProductCommand{
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		Price:       priceFromProto(req.GetPriceMoney(), req.GetPrice()),
 		Stock:       int(req.Stock),
 		Category:    req.Category,
 		SKU:         req.Sku,
@@ -109,11 +111,12 @@ func (s *ProductServer) GetProduct(ctx context.Context, req *pb.GetProductReques
 
 // UpdateProduct updates product information
 func (s *ProductServer) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.ProductResponse, error) {
+	price := priceFromProto(req.GetPriceMoney(), req.GetPrice())
 	cmd := command.UpdateProductCommand{
 		ID:          uint(req.Id),
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		Price:       &price,
 		Stock:       int(req.Stock),
 		Category:    req.Category,
 		SKU:         req.Sku,
@@ -234,7 +237,8 @@ func domainProductToProto(product *domain.Product) *pb.Product {
 		Id:          uint32(product.ID),
 		Name:        product.Name,
 		Description: product.Description,
-		Price:       product.Price,
+		Price:       product.Price.Major(),
+		PriceMoney:  &moneypb.Money{Minor: product.Price.Minor, Currency: product.Price.Currency},
 		Stock:       int32(product.Stock),
 		Category:    product.Category,
 		Sku:         product.SKU,
@@ -243,3 +247,12 @@ func domainProductToProto(product *domain.Product) *pb.Product {
 		UpdatedAt:   timestamppb.New(product.UpdatedAt),
 	}
 }
+
+// priceFromProto prefers the exact price and falls back to the major-unit
+// price sent by older clients
+func priceFromProto(price *moneypb.Money, major float64) money.Money {
+	if price != nil {
+		return money.New(price.GetMinor(), price.GetCurrency())
+	}
+	return money.FromMajor(major, money.DefaultCurrency)
+}
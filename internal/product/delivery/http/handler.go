@@ -14,6 +14,7 @@ import (
 	"github.com/tair/full-observability/internal/product/usecase/command"
 	"github.com/tair/full-observability/internal/product/usecase/query"
 	"github.com/tair/full-observability/pkg/logger"
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // ProductHandler handles HTTP requests for products using CQRS pattern
@@ -264,13 +265,13 @@ func (h *ProductHandler) RegisterRoutes(router *mux.Router) {
 // CreateProduct handles POST /api/products
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price"`
-		Stock       int     `json:"stock"`
-		Category    string  `json:"category"`
-		SKU         string  `json:"sku"`
-		IsActive    bool    `json:"is_active"`
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		Price       money.Money `json:"price"`
+		Stock       int         `json:"stock"`
+		Category    string      `json:"category"`
+		SKU         string      `json:"sku"`
+		IsActive    bool        `json:"is_active"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -387,13 +388,13 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price"`
-		Stock       int     `json:"stock"`
-		Category    string  `json:"category"`
-		SKU         string  `json:"sku"`
-		IsActive    bool    `json:"is_active"`
+		Name        string       `json:"name"`
+		Description string       `json:"description"`
+		Price       *money.Money `json:"price"` // omitted keeps the current price
+		Stock       int          `json:"stock"`
+		Category    string       `json:"category"`
+		SKU         string       `json:"sku"`
+		IsActive    bool         `json:"is_active"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
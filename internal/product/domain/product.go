@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // Product represents the product entity
@@ -11,7 +13,7 @@ type Product struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	Name        string         `json:"name" gorm:"not null"`
 	Description string         `json:"description"`
-	Price       float64        `json:"price" gorm:"not null"`
+	Price       money.Money    `json:"price" gorm:"embedded;embeddedPrefix:price_"`
 	Stock       int            `json:"stock" gorm:"not null;default:0"`
 	Category    string         `json:"category"`
 	SKU         string         `json:"sku" gorm:"uniqueIndex"`
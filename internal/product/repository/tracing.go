@@ -34,7 +34,8 @@ func (r *GormProductRepositoryWithTracing) CreateWithContext(ctx context.Context
 			attribute.String("product.name", product.Name),
 			attribute.String("product.sku", product.SKU),
 			attribute.String("product.category", product.Category),
-			attribute.Float64("product.price", product.Price),
+			attribute.Int64("product.price_minor", product.Price.Minor),
+			attribute.String("product.currency", product.Price.Currency),
 			attribute.Int("product.stock", product.Stock),
 		),
 	)
@@ -148,7 +149,8 @@ func (r *GormProductRepositoryWithTracing) UpdateWithContext(ctx context.Context
 			attribute.Int("product.id", int(product.ID)),
 			attribute.String("product.name", product.Name),
 			attribute.String("product.sku", product.SKU),
-			attribute.Float64("product.price", product.Price),
+			attribute.Int64("product.price_minor", product.Price.Minor),
+			attribute.String("product.currency", product.Price.Currency),
 		),
 	)
 	defer span.End()
@@ -5,13 +5,14 @@ import (
 	"time"
 
 	"github.com/tair/full-observability/internal/product/domain"
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // CreateProductCommand represents the command to create a new product
 type CreateProductCommand struct {
 	Name        string
 	Description string
-	Price       float64
+	Price       money.Money
 	Stock       int
 	Category    string
 	SKU         string
@@ -34,9 +35,15 @@ func (h *CreateProductHandler) Handle(cmd CreateProductCommand) (*domain.Product
 	if cmd.Name == "" {
 		return nil, fmt.Errorf("product name is required")
 	}
-	if cmd.Price < 0 {
+	if cmd.Price.Currency == "" {
+		cmd.Price.Currency = money.DefaultCurrency
+	}
+	if cmd.Price.IsNegative() {
 		return nil, fmt.Errorf("price cannot be negative")
 	}
+	if err := money.ValidateCurrency(cmd.Price.Currency); err != nil {
+		return nil, err
+	}
 	if cmd.Stock < 0 {
 		return nil, fmt.Errorf("stock cannot be negative")
 	}
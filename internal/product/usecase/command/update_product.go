@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/tair/full-observability/internal/product/domain"
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // UpdateProductCommand represents the command to update a product
@@ -12,7 +13,7 @@ type UpdateProductCommand struct {
 	ID          uint
 	Name        string
 	Description string
-	Price       float64
+	Price       *money.Money // nil keeps the current price
 	Stock       int
 	Category    string
 	SKU         string
@@ -51,8 +52,17 @@ func (h *UpdateProductHandler) Handle(cmd UpdateProductCommand) (*domain.Product
 		product.Description = cmd.Description
 	}
 
-	if cmd.Price >= 0 {
-		product.Price = cmd.Price
+	if cmd.Price != nil {
+		if cmd.Price.Currency == "" {
+			cmd.Price.Currency = money.DefaultCurrency
+		}
+		if cmd.Price.IsNegative() {
+			return nil, fmt.Errorf("price cannot be negative")
+		}
+		if err := money.ValidateCurrency(cmd.Price.Currency); err != nil {
+			return nil, err
+		}
+		product.Price = *cmd.Price
 	}
 
 	if cmd.Stock >= 0 {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/tair/full-observability/internal/product/domain"
+	"github.com/tair/full-observability/pkg/money"
 )
 
 // GetStatsQuery represents the query to get product statistics
@@ -11,14 +12,14 @@ type GetStatsQuery struct{}
 
 // ProductStats represents product statistics
 type ProductStats struct {
-	TotalProducts      int64            `json:"total_products"`
-	ActiveProducts     int64            `json:"active_products"`
-	OutOfStock         int64            `json:"out_of_stock"`
-	LowStock           int64            `json:"low_stock"`
-	TotalStock         int64            `json:"total_stock"`
-	AveragePrice       float64          `json:"average_price"`
-	TotalCategories    int64            `json:"total_categories"`
-	ProductsByCategory map[string]int64 `json:"products_by_category"`
+	TotalProducts      int64                  `json:"total_products"`
+	ActiveProducts     int64                  `json:"active_products"`
+	OutOfStock         int64                  `json:"out_of_stock"`
+	LowStock           int64                  `json:"low_stock"`
+	TotalStock         int64                  `json:"total_stock"`
+	AveragePrice       map[string]money.Money `json:"average_price"` // by currency
+	TotalCategories    int64                  `json:"total_categories"`
+	ProductsByCategory map[string]int64       `json:"products_by_category"`
 }
 
 // GetStatsHandler handles get stats query
@@ -48,7 +49,8 @@ func (h *GetStatsHandler) Handle(query GetStatsQuery) (*ProductStats, error) {
 	var outOfStock int64
 	var lowStock int64
 	var totalStock int64
-	var totalPrice float64
+	totalPrice := make(map[string]int64)
+	pricedProducts := make(map[string]int64)
 	categories := make(map[string]bool)
 	productsByCategory := make(map[string]int64)
 
@@ -65,16 +67,23 @@ func (h *GetStatsHandler) Handle(query GetStatsQuery) (*ProductStats, error) {
 			lowStock++
 		}
 		totalStock += int64(product.Stock)
-		totalPrice += product.Price
+		totalPrice[product.Price.Currency] += product.Price.Minor
+		pricedProducts[product.Price.Currency]++
 		if product.Category != "" {
 			categories[product.Category] = true
 			productsByCategory[product.Category]++
 		}
 	}
 
-	averagePrice := 0.0
-	if totalProducts > 0 {
-		averagePrice = totalPrice / float64(totalProducts)
+	// Averages are taken in minor units, per currency, rounded half up
+	averagePrice := make(map[string]money.Money, len(totalPrice))
+	for currency, total := range totalPrice {
+		count := pricedProducts[currency]
+		average := total / count
+		if 2*(total%count) >= count {
+			average++
+		}
+		averagePrice[currency] = money.New(average, currency)
 	}
 
 	stats := &ProductStats{
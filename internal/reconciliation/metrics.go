@@ -0,0 +1,62 @@
+package reconciliation
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// PushJob is the Pushgateway job the reconciliation metrics are grouped under
+const PushJob = "payment_reconciliation"
+
+// PushMetrics exports the report to a Prometheus Pushgateway, replacing the
+// metrics of the previous run: the number of mismatches by kind, the number
+// of records checked and the time of the run
+func PushMetrics(url string, report *Report) error {
+	mismatches := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "payment_reconciliation_mismatches",
+			Help: "Mismatches between payments, outbox events and inventory reservations found by the last reconciliation",
+		},
+		[]string{"kind"},
+	)
+	checked := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "payment_reconciliation_checked",
+			Help: "Records checked by the last reconciliation",
+		},
+		[]string{"record"},
+	)
+	lastRun := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "payment_reconciliation_last_run_timestamp_seconds",
+			Help: "Unix time of the last reconciliation",
+		},
+	)
+	window := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "payment_reconciliation_window_seconds",
+			Help: "Length of the time range covered by the last reconciliation",
+		},
+	)
+
+	for kind, count := range report.Counts {
+		mismatches.WithLabelValues(kind).Set(float64(count))
+	}
+	checked.WithLabelValues("payments").Set(float64(report.PaymentsChecked))
+	checked.WithLabelValues("reservations").Set(float64(report.ReservationsChecked))
+	lastRun.Set(float64(report.GeneratedAt.Unix()))
+	window.Set(report.To.Sub(report.From).Seconds())
+
+	err := push.New(url, PushJob).
+		Collector(mismatches).
+		Collector(checked).
+		Collector(lastRun).
+		Collector(window).
+		Push()
+	if err != nil {
+		return fmt.Errorf("failed to push reconciliation metrics: %w", err)
+	}
+	return nil
+}
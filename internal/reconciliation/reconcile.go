@@ -0,0 +1,400 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	inventorydomain "github.com/tair/full-observability/internal/inventory/domain"
+	paymentdomain "github.com/tair/full-observability/internal/payment/domain"
+	"github.com/tair/full-observability/kafka"
+)
+
+// Mismatch kinds
+const (
+	// A completed payment has no outbox row for an event it must have emitted
+	MismatchEventMissing = "event_missing"
+	// The outbox row exists but the relay has not published it yet
+	MismatchEventUnpublished = "event_unpublished"
+	// A completed payment's reservation is unknown to the inventory service
+	MismatchReservationMissing = "reservation_missing"
+	// A completed payment's reservation was not committed, so the stock was
+	// never decremented or was returned
+	MismatchReservationState = "reservation_state"
+	// The reservation holds another product or quantity than was paid for
+	MismatchReservationQuantity = "reservation_quantity"
+	// Stock was decremented by a committed reservation that no completed
+	// payment accounts for
+	MismatchUnpaidReservation = "unpaid_reservation"
+)
+
+// MismatchKinds lists every mismatch kind, so reports can show zero counts
+var MismatchKinds = []string{
+	MismatchEventMissing,
+	MismatchEventUnpublished,
+	MismatchReservationMissing,
+	MismatchReservationState,
+	MismatchReservationQuantity,
+	MismatchUnpaidReservation,
+}
+
+// batchSize bounds the number of IDs in a single IN query
+const batchSize = 500
+
+// Mismatch is a disagreement between a payment, its events and the inventory
+type Mismatch struct {
+	Kind          string `json:"kind"`
+	PaymentID     uint   `json:"payment_id,omitempty"`
+	OrderID       string `json:"order_id,omitempty"`
+	ReservationID string `json:"reservation_id,omitempty"`
+	EventID       string `json:"event_id,omitempty"`
+	ProductID     uint   `json:"product_id,omitempty"`
+	Expected      string `json:"expected,omitempty"`
+	Actual        string `json:"actual,omitempty"`
+}
+
+// Report is the result of a reconciliation run
+type Report struct {
+	From                time.Time      `json:"from"`
+	To                  time.Time      `json:"to"`
+	GeneratedAt         time.Time      `json:"generated_at"`
+	PaymentsChecked     int            `json:"payments_checked"`
+	ReservationsChecked int            `json:"reservations_checked"`
+	Counts              map[string]int `json:"counts"`
+	Mismatches          []Mismatch     `json:"mismatches"`
+}
+
+func (r *Report) add(m Mismatch) {
+	r.Mismatches = append(r.Mismatches, m)
+	r.Counts[m.Kind]++
+}
+
+// expectedReservation is a stock decrement a completed payment paid for
+type expectedReservation struct {
+	payment   *paymentdomain.Payment
+	id        string
+	productID uint
+	quantity  int
+}
+
+// Reconciler compares completed payments with the outbox of the payment
+// service and the reservations of the inventory service. Both databases are
+// only read.
+type Reconciler struct {
+	payments  *gorm.DB
+	inventory *gorm.DB
+}
+
+// NewReconciler creates a reconciler over the payment and inventory databases
+func NewReconciler(payments, inventory *gorm.DB) *Reconciler {
+	return &Reconciler{payments: payments, inventory: inventory}
+}
+
+// Run reconciles the payments created and the reservations committed in
+// [from, to). Payments are checked for their payment.completed and
+// product.purchased events and for a committed reservation of every item
+// they paid for; committed reservations are checked for a completed payment.
+func (r *Reconciler) Run(ctx context.Context, from, to time.Time) (*Report, error) {
+	report := &Report{
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+		Counts:      make(map[string]int, len(MismatchKinds)),
+		Mismatches:  []Mismatch{},
+	}
+	for _, kind := range MismatchKinds {
+		report.Counts[kind] = 0
+	}
+
+	var payments []paymentdomain.Payment
+	err := r.payments.WithContext(ctx).
+		Where("status IN ? AND created_at >= ? AND created_at < ?", paidStatuses(), from, to).
+		Order("id ASC").
+		Find(&payments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payments: %w", err)
+	}
+	report.PaymentsChecked = len(payments)
+
+	if err := r.checkEvents(ctx, report, payments); err != nil {
+		return nil, err
+	}
+
+	expected, err := r.expectedReservations(ctx, payments)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkReservations(ctx, report, expected); err != nil {
+		return nil, err
+	}
+	if err := r.checkCommitted(ctx, report, from, to); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Mismatches, func(i, j int) bool {
+		return report.Mismatches[i].PaymentID < report.Mismatches[j].PaymentID
+	})
+	return report, nil
+}
+
+// paidStatuses are the payment statuses that took the customer's money.
+// Refunds return stock by product and quantity, so the reservation of a
+// refunded payment stays committed.
+func paidStatuses() []string {
+	return []string{paymentdomain.StatusCompleted, paymentdomain.StatusRefunded}
+}
+
+// checkEvents verifies that every payment recorded its payment.completed
+// event, and its product.purchased event when it bought stock through a
+// reservation, and that the relay published them
+func (r *Reconciler) checkEvents(ctx context.Context, report *Report, payments []paymentdomain.Payment) error {
+	type wanted struct {
+		payment   *paymentdomain.Payment
+		eventType string
+	}
+	want := make(map[string]wanted, len(payments))
+	for i := range payments {
+		payment := &payments[i]
+		want[fmt.Sprintf("evt_payment_%d_%s", payment.ID, paymentdomain.StatusCompleted)] = wanted{payment, kafka.EventTypePaymentCompleted}
+		// Purchases without a reservation get a random event ID and cannot be matched
+		if payment.ProductID != 0 && payment.ReservationID != "" {
+			want[fmt.Sprintf("evt_%s", payment.ReservationID)] = wanted{payment, kafka.EventTypeProductPurchased}
+		}
+	}
+
+	ids := make([]string, 0, len(want))
+	for id := range want {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	found := make(map[string]paymentdomain.OutboxEvent, len(ids))
+	for _, batch := range batches(ids) {
+		var events []paymentdomain.OutboxEvent
+		err := r.payments.WithContext(ctx).
+			Select("event_id", "event_type", "status", "attempts", "last_error").
+			Where("event_id IN ?", batch).
+			Find(&events).Error
+		if err != nil {
+			return fmt.Errorf("failed to load outbox events: %w", err)
+		}
+		for _, event := range events {
+			found[event.EventID] = event
+		}
+	}
+
+	for _, id := range ids {
+		w := want[id]
+		event, ok := found[id]
+		switch {
+		case !ok:
+			report.add(Mismatch{
+				Kind:          MismatchEventMissing,
+				PaymentID:     w.payment.ID,
+				OrderID:       w.payment.OrderID,
+				ReservationID: w.payment.ReservationID,
+				EventID:       id,
+				ProductID:     w.payment.ProductID,
+				Expected:      w.eventType,
+			})
+		case event.Status != paymentdomain.OutboxStatusSent:
+			actual := fmt.Sprintf("%s after %d attempts", event.Status, event.Attempts)
+			if event.LastError != "" {
+				actual += ": " + event.LastError
+			}
+			report.add(Mismatch{
+				Kind:          MismatchEventUnpublished,
+				PaymentID:     w.payment.ID,
+				OrderID:       w.payment.OrderID,
+				ReservationID: w.payment.ReservationID,
+				EventID:       id,
+				ProductID:     w.payment.ProductID,
+				Expected:      paymentdomain.OutboxStatusSent,
+				Actual:        actual,
+			})
+		}
+	}
+	return nil
+}
+
+// expectedReservations lists the stock each payment paid for: the
+// reservation of a single product checkout, or the line reservations of an
+// order
+func (r *Reconciler) expectedReservations(ctx context.Context, payments []paymentdomain.Payment) ([]expectedReservation, error) {
+	var expected []expectedReservation
+	byOrder := make(map[string]*paymentdomain.Payment)
+	for i := range payments {
+		payment := &payments[i]
+		switch {
+		case payment.ReservationID != "":
+			expected = append(expected, expectedReservation{
+				payment:   payment,
+				id:        payment.ReservationID,
+				productID: payment.ProductID,
+				quantity:  int(payment.Quantity),
+			})
+		case payment.ProductID == 0:
+			byOrder[payment.OrderID] = payment
+		}
+	}
+
+	orderNumbers := make([]string, 0, len(byOrder))
+	for number := range byOrder {
+		orderNumbers = append(orderNumbers, number)
+	}
+	sort.Strings(orderNumbers)
+
+	for _, batch := range batches(orderNumbers) {
+		var orders []paymentdomain.Order
+		err := r.payments.WithContext(ctx).
+			Preload("Lines", func(db *gorm.DB) *gorm.DB {
+				return db.Order("id ASC")
+			}).
+			Where("order_number IN ?", batch).
+			Order("id ASC").
+			Find(&orders).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to load orders: %w", err)
+		}
+		for _, order := range orders {
+			for _, line := range order.Lines {
+				expected = append(expected, expectedReservation{
+					payment:   byOrder[order.OrderNumber],
+					id:        line.ReservationID,
+					productID: line.ProductID,
+					quantity:  int(line.Quantity),
+				})
+			}
+		}
+	}
+	return expected, nil
+}
+
+// checkReservations verifies that the inventory committed every reservation
+// a payment paid for, with the paid product and quantity
+func (r *Reconciler) checkReservations(ctx context.Context, report *Report, expected []expectedReservation) error {
+	ids := make([]string, 0, len(expected))
+	for _, e := range expected {
+		ids = append(ids, e.id)
+	}
+
+	reservations, err := r.loadReservations(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range expected {
+		mismatch := Mismatch{
+			PaymentID:     e.payment.ID,
+			OrderID:       e.payment.OrderID,
+			ReservationID: e.id,
+			ProductID:     e.productID,
+		}
+
+		reservation, ok := reservations[e.id]
+		switch {
+		case !ok:
+			mismatch.Kind = MismatchReservationMissing
+			mismatch.Expected = inventorydomain.ReservationCommitted
+		case reservation.State != inventorydomain.ReservationCommitted:
+			mismatch.Kind = MismatchReservationState
+			mismatch.Expected = inventorydomain.ReservationCommitted
+			mismatch.Actual = reservation.State
+		case reservation.ProductID != e.productID || reservation.Quantity != e.quantity:
+			mismatch.Kind = MismatchReservationQuantity
+			mismatch.Expected = fmt.Sprintf("product %d x %d", e.productID, e.quantity)
+			mismatch.Actual = fmt.Sprintf("product %d x %d", reservation.ProductID, reservation.Quantity)
+		default:
+			continue
+		}
+		report.add(mismatch)
+	}
+	return nil
+}
+
+// checkCommitted verifies that every reservation committed in [from, to)
+// belongs to a completed payment, whenever that payment was created
+func (r *Reconciler) checkCommitted(ctx context.Context, report *Report, from, to time.Time) error {
+	var committed []inventorydomain.Reservation
+	err := r.inventory.WithContext(ctx).
+		Where("state = ? AND updated_at >= ? AND updated_at < ?", inventorydomain.ReservationCommitted, from, to).
+		Order("updated_at ASC").
+		Find(&committed).Error
+	if err != nil {
+		return fmt.Errorf("failed to load committed reservations: %w", err)
+	}
+	report.ReservationsChecked = len(committed)
+
+	ids := make([]string, 0, len(committed))
+	for _, reservation := range committed {
+		ids = append(ids, reservation.ID)
+	}
+
+	paid := make(map[string]bool, len(ids))
+	for _, batch := range batches(ids) {
+		var direct []string
+		err := r.payments.WithContext(ctx).
+			Model(&paymentdomain.Payment{}).
+			Where("reservation_id IN ? AND status IN ?", batch, paidStatuses()).
+			Pluck("reservation_id", &direct).Error
+		if err != nil {
+			return fmt.Errorf("failed to match reservations to payments: %w", err)
+		}
+
+		var lines []string
+		err = r.payments.WithContext(ctx).
+			Model(&paymentdomain.OrderLine{}).
+			Joins("JOIN orders ON orders.id = order_lines.order_id").
+			Joins("JOIN payments ON payments.order_id = orders.order_number AND payments.deleted_at IS NULL").
+			Where("order_lines.reservation_id IN ? AND payments.status IN ?", batch, paidStatuses()).
+			Pluck("order_lines.reservation_id", &lines).Error
+		if err != nil {
+			return fmt.Errorf("failed to match reservations to orders: %w", err)
+		}
+
+		for _, id := range append(direct, lines...) {
+			paid[id] = true
+		}
+	}
+
+	for _, reservation := range committed {
+		if paid[reservation.ID] {
+			continue
+		}
+		report.add(Mismatch{
+			Kind:          MismatchUnpaidReservation,
+			ReservationID: reservation.ID,
+			ProductID:     reservation.ProductID,
+			Expected:      "completed payment",
+			Actual:        fmt.Sprintf("%d units committed", reservation.Quantity),
+		})
+	}
+	return nil
+}
+
+func (r *Reconciler) loadReservations(ctx context.Context, ids []string) (map[string]inventorydomain.Reservation, error) {
+	result := make(map[string]inventorydomain.Reservation, len(ids))
+	for _, batch := range batches(ids) {
+		var reservations []inventorydomain.Reservation
+		if err := r.inventory.WithContext(ctx).Where("id IN ?", batch).Find(&reservations).Error; err != nil {
+			return nil, fmt.Errorf("failed to load reservations: %w", err)
+		}
+		for _, reservation := range reservations {
+			result[reservation.ID] = reservation
+		}
+	}
+	return result, nil
+}
+
+// batches splits ids into slices of at most batchSize
+func batches(ids []string) [][]string {
+	var result [][]string
+	for start := 0; start < len(ids); start += batchSize {
+		end := min(start+batchSize, len(ids))
+		result = append(result, ids[start:end])
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Report formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Write encodes the report in format. CSV has one row per mismatch and no
+// summary; JSON carries the counts as well.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// WriteJSON encodes the whole report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV encodes the mismatches as CSV with a header row
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"kind", "payment_id", "order_id", "reservation_id", "event_id", "product_id", "expected", "actual"}); err != nil {
+		return err
+	}
+	for _, m := range r.Mismatches {
+		record := []string{
+			m.Kind,
+			formatID(m.PaymentID),
+			m.OrderID,
+			m.ReservationID,
+			m.EventID,
+			formatID(m.ProductID),
+			m.Expected,
+			m.Actual,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatID(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -2,9 +2,9 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
 	"go.opentelemetry.io/otel"
@@ -22,12 +22,19 @@ type Consumer struct {
 	brokers       []string
 	groupID       string
 	topics        []string
-	handlers      map[string]EventHandler
+	handlers      map[string]envelopeHandler
 	handlersMutex sync.RWMutex
+	dedup         ProcessedEventStore // optional; nil disables deduplication
+	retry         *RetryPolicy        // optional; nil drops events whose handler fails
+	retrySources  []string            // topics whose retry and dead-letter topics are used
+	publisher     *Publisher          // writes retry and dead-letter topics
 }
 
-// EventHandler is a function that handles events
-type EventHandler func(ctx context.Context, event ProductPurchasedEvent) error
+// EventHandler is a function that handles a decoded domain event
+type EventHandler[T any] func(ctx context.Context, event T) error
+
+// envelopeHandler is the type-erased form of an EventHandler kept in the registry
+type envelopeHandler func(ctx context.Context, envelope *Envelope) error
 
 // NewConsumer creates a new Kafka consumer
 func NewConsumer(brokers []string, groupID string, topics []string) (*Consumer, error) {
@@ -53,22 +60,48 @@ func NewConsumer(brokers []string, groupID string, topics []string) (*Consumer,
 		brokers:  brokers,
 		groupID:  groupID,
 		topics:   topics,
-		handlers: make(map[string]EventHandler),
+		handlers: make(map[string]envelopeHandler),
 	}, nil
 }
 
-// RegisterHandler registers an event handler for a specific event type
-func (c *Consumer) RegisterHandler(eventType string, handler EventHandler) {
+// Register registers a typed event handler for a specific event type. The
+// envelope payload is decoded into T before the handler is called.
+func Register[T any](c *Consumer, eventType string, handler EventHandler[T]) {
 	c.handlersMutex.Lock()
 	defer c.handlersMutex.Unlock()
-	c.handlers[eventType] = handler
+	c.handlers[eventType] = func(ctx context.Context, envelope *Envelope) error {
+		event, err := DecodePayload[T](envelope)
+		if err != nil {
+			return &permanentError{err: err}
+		}
+		return handler(ctx, event)
+	}
 	logger.Logger.Info().
 		Str("event_type", eventType).
 		Msg("Event handler registered")
 }
 
+// UseProcessedEventStore enables deduplication by event_id header, so that
+// registered handlers succeed once per event across redeliveries. How a crash
+// while a handler runs is dealt with depends on the store: the Postgres store
+// commits its claim only after the handler succeeds, so the event is
+// redelivered and handled again (at least once), while the Redis store claims
+// the event before the handler runs and the event is lost (at most once).
+func (c *Consumer) UseProcessedEventStore(store ProcessedEventStore) {
+	c.dedup = store
+	logger.Logger.Info().
+		Str("group_id", c.groupID).
+		Msg("Event deduplication enabled")
+}
+
 // Start starts consuming messages
 func (c *Consumer) Start(ctx context.Context) error {
+	if c.retry != nil {
+		if err := c.ensureRetryTopics(); err != nil {
+			return err
+		}
+	}
+
 	handler := &consumerGroupHandler{
 		consumer: c,
 	}
@@ -127,15 +160,65 @@ func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
 	return nil
 }
 
+// ConsumeClaim handles the messages of a partition in order. A message on a
+// retry topic that is not due yet is held together with the messages behind
+// it while the partition is paused, and handled once due, so the claim never
+// blocks the session while it waits.
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for message := range claim.Messages() {
-		h.handleMessage(session.Context(), message)
-		session.MarkMessage(message, "")
+	partition := map[string][]int32{claim.Topic(): {claim.Partition()}}
+	var held []*sarama.ConsumerMessage
+	var due <-chan time.Time
+	defer func() {
+		if held != nil {
+			h.consumer.consumer.Resume(partition)
+		}
+	}()
+
+	messages := claim.Messages()
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			if len(held) > 0 || !isDue(message) {
+				if len(held) == 0 {
+					h.consumer.consumer.Pause(partition)
+					due = time.After(retryDelay(message))
+				}
+				held = append(held, message)
+				continue
+			}
+			if !h.process(session.Context(), message) {
+				return nil
+			}
+			session.MarkMessage(message, "")
+
+		case <-due:
+			for len(held) > 0 && isDue(held[0]) {
+				if !h.process(session.Context(), held[0]) {
+					return nil
+				}
+				session.MarkMessage(held[0], "")
+				held = held[1:]
+			}
+			if len(held) > 0 {
+				due = time.After(retryDelay(held[0]))
+				continue
+			}
+			held, due = nil, nil
+			h.consumer.consumer.Resume(partition)
+
+		case <-session.Context().Done():
+			return nil
+		}
 	}
-	return nil
 }
 
-func (h *consumerGroupHandler) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) {
+// handleMessage dispatches a message to its registered handler. It returns an
+// error only when the event could not be handled; messages that no handler is
+// registered for are logged and skipped.
+func (h *consumerGroupHandler) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
 	// Extract trace context from Kafka headers
 	carrier := propagation.MapCarrier{}
 	for _, header := range message.Headers {
@@ -151,7 +234,7 @@ func (h *consumerGroupHandler) handleMessage(ctx context.Context, message *saram
 
 	// Start consumer span
 	tracer := otel.Tracer("kafka-consumer")
-	ctx, span := tracer.Start(ctx, "kafka.consume.product_purchased",
+	ctx, span := tracer.Start(ctx, "kafka.consume",
 		trace.WithSpanKind(trace.SpanKindConsumer),
 		trace.WithAttributes(
 			attribute.String("messaging.system", "kafka"),
@@ -185,7 +268,7 @@ func (h *consumerGroupHandler) handleMessage(ctx context.Context, message *saram
 	if eventType == "" {
 		span.SetStatus(codes.Error, "Message without event_type header")
 		logger.Logger.Warn().Msg("Message without event_type header")
-		return
+		return nil
 	}
 
 	span.SetAttributes(
@@ -203,55 +286,75 @@ func (h *consumerGroupHandler) handleMessage(ctx context.Context, message *saram
 		logger.Logger.Warn().
 			Str("event_type", eventType).
 			Msg("No handler registered for event type")
-		return
+		return nil
 	}
 
-	// Parse event based on type
-	switch eventType {
-	case EventTypeProductPurchased:
-		var event ProductPurchasedEvent
-		if err := json.Unmarshal(message.Value, &event); err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Failed to unmarshal event")
-			logger.Logger.Error().
-				Err(err).
-				Str("event_type", eventType).
-				Msg("Failed to unmarshal event")
-			return
-		}
-
-		span.SetAttributes(
-			attribute.Int64("product.id", int64(event.ProductID)),
-			attribute.Int("product.quantity", int(event.Quantity)),
-			attribute.Int64("payment.id", int64(event.PaymentID)),
-		)
+	envelope, err := decodeEnvelope(message, eventType, eventID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to decode event")
+		logger.Logger.Error().
+			Err(err).
+			Str("event_type", eventType).
+			Msg("Failed to decode event")
+		return &permanentError{err: err}
+	}
+	if eventID == "" {
+		eventID = envelope.ID
+	}
 
-		// Handle event
-		if err := handler(ctx, event); err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Failed to handle event")
-			logger.Logger.Error().
-				Err(err).
-				Str("event_type", eventType).
-				Str("event_id", event.EventID).
-				Str("trace_id", span.SpanContext().TraceID().String()).
-				Msg("Failed to handle event")
-			return
-		}
+	span.SetAttributes(
+		attribute.Int("event.schema_version", envelope.SchemaVersion),
+		attribute.String("event.source", envelope.Source),
+		attribute.String("event.content_type", envelope.ContentType),
+	)
+	if envelope.SchemaID != 0 {
+		span.SetAttributes(attribute.Int("event.schema_id", envelope.SchemaID))
+	}
 
-		span.SetStatus(codes.Ok, "Event handled successfully")
-		logger.Logger.Info().
+	// Handle the event unless this group already has
+	handled, err := h.processOnce(ctx, eventID, func(ctx context.Context) error {
+		return handler(contextWithEnvelope(ctx, envelope), envelope)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to handle event")
+		logger.Logger.Error().
+			Err(err).
 			Str("event_type", eventType).
-			Str("event_id", event.EventID).
-			Uint("product_id", event.ProductID).
-			Int32("quantity", event.Quantity).
+			Str("event_id", eventID).
 			Str("trace_id", span.SpanContext().TraceID().String()).
-			Msg("Event handled successfully")
-
-	default:
-		span.SetStatus(codes.Error, "Unknown event type")
-		logger.Logger.Warn().
+			Msg("Failed to handle event")
+		return err
+	}
+	if !handled {
+		span.SetAttributes(attribute.Bool("event.duplicate", true))
+		logger.Logger.Info().
 			Str("event_type", eventType).
-			Msg("Unknown event type")
+			Str("event_id", eventID).
+			Msg("Duplicate event skipped")
+		return nil
+	}
+
+	span.SetStatus(codes.Ok, "Event handled successfully")
+	logger.Logger.Info().
+		Str("event_type", eventType).
+		Str("event_id", eventID).
+		Str("trace_id", span.SpanContext().TraceID().String()).
+		Msg("Event handled successfully")
+	return nil
+}
+
+// processOnce runs handle through the processed-events store when
+// deduplication is enabled. Messages without an event_id header cannot be
+// deduplicated and are always handled.
+func (h *consumerGroupHandler) processOnce(ctx context.Context, eventID string, handle func(ctx context.Context) error) (bool, error) {
+	if h.consumer.dedup == nil {
+		return true, handle(ctx)
+	}
+	if eventID == "" {
+		logger.Logger.Warn().Msg("Message without event_id header, deduplication skipped")
+		return true, handle(ctx)
 	}
+	return h.consumer.dedup.Process(ctx, h.consumer.groupID, eventID, handle)
 }
@@ -0,0 +1,133 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProcessedEventStore records which event IDs a consumer group has handled so
+// that redelivered messages are not passed to handlers a second time
+type ProcessedEventStore interface {
+	// Process runs handle unless the group already processed eventID, and
+	// records the event as processed once handle succeeds. It returns false
+	// for an event that was processed before, without running handle. When
+	// handle fails the event is not recorded, so a redelivery runs it again.
+	Process(ctx context.Context, groupID, eventID string, handle func(ctx context.Context) error) (bool, error)
+}
+
+// ProcessedEvent is a row of the Postgres processed-events store
+type ProcessedEvent struct {
+	ConsumerGroup string    `gorm:"primaryKey;size:255"`
+	EventID       string    `gorm:"primaryKey;size:255"`
+	ProcessedAt   time.Time `gorm:"not null;index"`
+}
+
+// TableName specifies the table name
+func (ProcessedEvent) TableName() string {
+	return "processed_events"
+}
+
+// PostgresProcessedEventStore keeps processed event IDs in a Postgres table
+type PostgresProcessedEventStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresProcessedEventStore creates a Postgres-backed processed-events store
+func NewPostgresProcessedEventStore(db *gorm.DB) *PostgresProcessedEventStore {
+	return &PostgresProcessedEventStore{db: db}
+}
+
+// AutoMigrate creates the processed_events table
+func (s *PostgresProcessedEventStore) AutoMigrate() error {
+	return s.db.AutoMigrate(&ProcessedEvent{})
+}
+
+// Process inserts the event ID in a transaction that stays open while handle
+// runs and commits only once it succeeds, relying on the primary key to reject
+// duplicates. A concurrent delivery of the same event waits on the insert
+// until the first one commits or rolls back. A crash while handle runs rolls
+// the claim back, so the event is handled again when it is redelivered.
+func (s *PostgresProcessedEventStore) Process(ctx context.Context, groupID, eventID string, handle func(ctx context.Context) error) (bool, error) {
+	handled := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&ProcessedEvent{
+				ConsumerGroup: groupID,
+				EventID:       eventID,
+				ProcessedAt:   time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to claim event %s: %w", eventID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		handled = true
+		return handle(ctx)
+	})
+	if err != nil {
+		return false, err
+	}
+	return handled, nil
+}
+
+// PurgeBefore removes processed event IDs recorded before cutoff. Redeliveries
+// only happen within the topic retention, so older rows can be dropped.
+func (s *PostgresProcessedEventStore) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("processed_at < ?", cutoff).
+		Delete(&ProcessedEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge processed events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// RedisProcessedEventStore keeps processed event IDs as Redis keys that expire
+// after ttl, which should cover the topic retention
+type RedisProcessedEventStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisProcessedEventStore creates a Redis-backed processed-events store
+func NewRedisProcessedEventStore(client *redis.Client, ttl time.Duration) *RedisProcessedEventStore {
+	return &RedisProcessedEventStore{
+		client: client,
+		prefix: "processed_events",
+		ttl:    ttl,
+	}
+}
+
+// Process sets the event key only if it does not exist yet, then runs handle
+// and deletes the key again when it fails. The key is set before handle runs,
+// so an event whose handler is interrupted by a crash is not handled again:
+// delivery through this store is at most once.
+func (s *RedisProcessedEventStore) Process(ctx context.Context, groupID, eventID string, handle func(ctx context.Context) error) (bool, error) {
+	key := s.key(groupID, eventID)
+	claimed, err := s.client.SetNX(ctx, key, time.Now().Unix(), s.ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event %s: %w", eventID, err)
+	}
+	if !claimed {
+		return false, nil
+	}
+
+	if err := handle(ctx); err != nil {
+		if releaseErr := s.client.Del(context.WithoutCancel(ctx), key).Err(); releaseErr != nil {
+			return false, fmt.Errorf("%w (failed to release event %s: %w)", err, eventID, releaseErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *RedisProcessedEventStore) key(groupID, eventID string) string {
+	return fmt.Sprintf("%s:%s:%s", s.prefix, groupID, eventID)
+}
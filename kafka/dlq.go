@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// DeadLetter is a message read back from a dead-letter topic
+type DeadLetter struct {
+	Topic         string
+	Partition     int32
+	Offset        int64
+	Key           []byte
+	Value         []byte
+	Headers       []*sarama.RecordHeader
+	EventType     string
+	EventID       string
+	OriginalTopic string
+	Error         string
+	Attempts      int64
+	FailedAt      string
+}
+
+// ReadDeadLetters reads every message currently on the dead-letter topic of a
+// source topic, oldest first per partition
+func ReadDeadLetters(brokers []string, topic string) ([]DeadLetter, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	defer client.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	dlqTopic := DeadLetterTopic(topic)
+	partitions, err := client.Partitions(dlqTopic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", dlqTopic, err)
+	}
+
+	var letters []DeadLetter
+	for _, partition := range partitions {
+		oldest, err := client.GetOffset(dlqTopic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get oldest offset of %s/%d: %w", dlqTopic, partition, err)
+		}
+		newest, err := client.GetOffset(dlqTopic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get newest offset of %s/%d: %w", dlqTopic, partition, err)
+		}
+		if oldest >= newest {
+			continue
+		}
+
+		partitionLetters, err := readPartition(consumer, dlqTopic, partition, oldest, newest)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, partitionLetters...)
+	}
+
+	return letters, nil
+}
+
+func readPartition(consumer sarama.Consumer, topic string, partition int32, from, until int64) ([]DeadLetter, error) {
+	pc, err := consumer.ConsumePartition(topic, partition, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume %s/%d: %w", topic, partition, err)
+	}
+	defer pc.Close()
+
+	var letters []DeadLetter
+	for {
+		select {
+		case message := <-pc.Messages():
+			letters = append(letters, newDeadLetter(message))
+			if message.Offset >= until-1 {
+				return letters, nil
+			}
+		case err := <-pc.Errors():
+			return nil, fmt.Errorf("failed to read %s/%d: %w", topic, partition, err)
+		case <-time.After(10 * time.Second):
+			return nil, fmt.Errorf("timed out reading %s/%d", topic, partition)
+		}
+	}
+}
+
+func newDeadLetter(message *sarama.ConsumerMessage) DeadLetter {
+	attempts, _ := headerInt(message, HeaderAttempts)
+	return DeadLetter{
+		Topic:         message.Topic,
+		Partition:     message.Partition,
+		Offset:        message.Offset,
+		Key:           message.Key,
+		Value:         message.Value,
+		Headers:       message.Headers,
+		EventType:     headerValue(message, "event_type"),
+		EventID:       headerValue(message, "event_id"),
+		OriginalTopic: headerValue(message, HeaderOriginalTopic),
+		Error:         headerValue(message, HeaderError),
+		Attempts:      attempts,
+		FailedAt:      headerValue(message, HeaderFailedAt),
+	}
+}
+
+// ReplayDeadLetter publishes a dead-lettered message back onto its source
+// topic with the retry headers removed, so it starts again with fresh attempts
+func (p *Publisher) ReplayDeadLetter(letter DeadLetter) error {
+	if letter.OriginalTopic == "" {
+		return fmt.Errorf("dead letter %s/%d has no %s header", letter.Topic, letter.Offset, HeaderOriginalTopic)
+	}
+	return p.forward(letter.OriginalTopic, letter.Key, letter.Value, withoutRetryHeaders(letter.Headers))
+}
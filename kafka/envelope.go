@@ -0,0 +1,226 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"github.com/tair/full-observability/pkg/money"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Payload content types
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Headers describing the envelope of a protobuf-encoded message, whose value
+// is the bare payload
+const (
+	HeaderContentType   = "content_type"
+	HeaderSchemaID      = "schema_id"
+	HeaderSchemaVersion = "schema_version"
+	HeaderSource        = "source"
+	HeaderOccurredAt    = "occurred_at" // RFC 3339
+)
+
+// Envelope wraps every event published to Kafka with the metadata consumers
+// need to route and version it. JSON envelopes are sent as the message value
+// with Payload holding the JSON-encoded domain event; for protobuf envelopes
+// the value is the binary Payload and the metadata travels in headers.
+type Envelope struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"` // 0 for messages published before envelopes
+	Source        string          `json:"source"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+	ContentType   string          `json:"-"`
+	SchemaID      int             `json:"-"` // registry ID of the protobuf schema
+}
+
+// NewEnvelope wraps a domain event in an envelope. Generated protobuf messages
+// are encoded as protobuf, anything else as JSON. An event ID is generated
+// when id is empty.
+func NewEnvelope[T any](id, eventType, source string, schemaVersion int, event T) (*Envelope, error) {
+	contentType := ContentTypeJSON
+	var payload []byte
+	var err error
+	if msg, ok := any(event).(proto.Message); ok {
+		contentType = ContentTypeProtobuf
+		payload, err = proto.Marshal(msg)
+	} else {
+		payload, err = json.Marshal(event)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+
+	if id == "" {
+		id = fmt.Sprintf("evt_%s", uuid.New().String())
+	}
+
+	return &Envelope{
+		ID:            id,
+		Type:          eventType,
+		SchemaVersion: schemaVersion,
+		Source:        source,
+		OccurredAt:    time.Now().UTC(),
+		Payload:       payload,
+		ContentType:   contentType,
+	}, nil
+}
+
+// Encode returns the Kafka message value for the envelope and the headers
+// that describe it
+func (e *Envelope) Encode() ([]byte, map[string]string, error) {
+	if e.ContentType == ContentTypeProtobuf {
+		return e.Payload, map[string]string{
+			HeaderContentType:   ContentTypeProtobuf,
+			HeaderSchemaID:      strconv.Itoa(e.SchemaID),
+			HeaderSchemaVersion: strconv.Itoa(e.SchemaVersion),
+			HeaderSource:        e.Source,
+			HeaderOccurredAt:    e.OccurredAt.Format(time.RFC3339Nano),
+		}, nil
+	}
+
+	value, err := json.Marshal(e)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return value, map[string]string{HeaderContentType: ContentTypeJSON}, nil
+}
+
+// DecodePayload unmarshals the payload into a domain event. Generated protobuf
+// types accept both protobuf and JSON payloads, so consumers can move to them
+// before producers switch encodings.
+func DecodePayload[T any](envelope *Envelope) (T, error) {
+	var event T
+	if msg, ok := any(event).(proto.Message); ok {
+		msg = msg.ProtoReflect().Type().New().Interface()
+		var err error
+		if envelope.ContentType == ContentTypeProtobuf {
+			err = proto.Unmarshal(envelope.Payload, msg)
+		} else {
+			err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(envelope.Payload, msg)
+		}
+		if err != nil {
+			return event, fmt.Errorf("failed to unmarshal %s payload: %w", envelope.Type, err)
+		}
+		return msg.(T), nil
+	}
+
+	if envelope.ContentType == ContentTypeProtobuf {
+		return event, fmt.Errorf("%s payload is protobuf-encoded, register a generated message type to consume it", envelope.Type)
+	}
+	payload, err := upcastPayload(envelope)
+	if err != nil {
+		return event, fmt.Errorf("failed to upcast %s payload: %w", envelope.Type, err)
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return event, fmt.Errorf("failed to unmarshal %s payload: %w", envelope.Type, err)
+	}
+	return event, nil
+}
+
+// v1AmountFields are the payload fields that version 1 events carried as
+// decimals in major units of the payload's currency field
+var v1AmountFields = []string{"amount", "refunded_amount"}
+
+// upcastPayload returns the JSON payload of the envelope in the current
+// schema. Payloads older than version 2 have their amounts rewritten as
+// money.Money in the currency they were published with.
+func upcastPayload(envelope *Envelope) (json.RawMessage, error) {
+	if envelope.SchemaVersion >= 2 {
+		return envelope.Payload, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(envelope.Payload, &fields); err != nil {
+		// Not an object; unmarshalling the payload reports it
+		return envelope.Payload, nil
+	}
+	var currency string
+	if raw, ok := fields["currency"]; ok {
+		if err := json.Unmarshal(raw, &currency); err != nil {
+			return nil, fmt.Errorf("invalid currency: %w", err)
+		}
+	}
+
+	for _, name := range v1AmountFields {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		var major float64
+		if err := json.Unmarshal(raw, &major); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		amount, err := json.Marshal(money.FromMajor(major, currency))
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = amount
+	}
+	return json.Marshal(fields)
+}
+
+// decodeEnvelope reads an envelope from a message. Messages published before
+// envelopes carry the bare JSON event, which becomes the payload of an
+// envelope built from the event_type and event_id headers.
+func decodeEnvelope(message *sarama.ConsumerMessage, eventType, eventID string) (*Envelope, error) {
+	if headerValue(message, HeaderContentType) == ContentTypeProtobuf {
+		schemaID, _ := headerInt(message, HeaderSchemaID)
+		schemaVersion, _ := headerInt(message, HeaderSchemaVersion)
+		occurredAt, _ := time.Parse(time.RFC3339Nano, headerValue(message, HeaderOccurredAt))
+		return &Envelope{
+			ID:            eventID,
+			Type:          eventType,
+			SchemaVersion: int(schemaVersion),
+			Source:        headerValue(message, HeaderSource),
+			OccurredAt:    occurredAt,
+			Payload:       message.Value,
+			ContentType:   ContentTypeProtobuf,
+			SchemaID:      int(schemaID),
+		}, nil
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(message.Value, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	envelope.ContentType = ContentTypeJSON
+
+	if envelope.Type == "" || len(envelope.Payload) == 0 {
+		return &Envelope{
+			ID:          eventID,
+			Type:        eventType,
+			Payload:     json.RawMessage(message.Value),
+			ContentType: ContentTypeJSON,
+		}, nil
+	}
+
+	if envelope.Type != eventType {
+		return nil, fmt.Errorf("envelope type %q does not match event_type header %q", envelope.Type, eventType)
+	}
+	return &envelope, nil
+}
+
+type envelopeContextKey struct{}
+
+// EnvelopeFromContext returns the envelope of the event being handled, for
+// handlers that need its metadata
+func EnvelopeFromContext(ctx context.Context) (*Envelope, bool) {
+	envelope, ok := ctx.Value(envelopeContextKey{}).(*Envelope)
+	return envelope, ok
+}
+
+func contextWithEnvelope(ctx context.Context, envelope *Envelope) context.Context {
+	return context.WithValue(ctx, envelopeContextKey{}, envelope)
+}
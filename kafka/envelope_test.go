@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+func TestDecodePayloadUpcastsVersion1Amounts(t *testing.T) {
+	tests := []struct {
+		name          string
+		schemaVersion int
+		payload       string
+		want          money.Money
+		wantRefunded  money.Money
+	}{
+		{
+			name:          "version 1 in its currency",
+			schemaVersion: 1,
+			payload:       `{"refund_id":1,"amount":1230,"currency":"JPY","refunded_amount":2000}`,
+			want:          money.New(1230, "JPY"),
+			wantRefunded:  money.New(2000, "JPY"),
+		},
+		{
+			name:          "version 1 with three decimals",
+			schemaVersion: 1,
+			payload:       `{"refund_id":1,"amount":1.5,"currency":"KWD","refunded_amount":1.5}`,
+			want:          money.New(1500, "KWD"),
+			wantRefunded:  money.New(1500, "KWD"),
+		},
+		{
+			name:          "before envelopes without a currency",
+			schemaVersion: 0,
+			payload:       `{"refund_id":1,"amount":12.3,"refunded_amount":12.3}`,
+			want:          money.New(1230, money.DefaultCurrency),
+			wantRefunded:  money.New(1230, money.DefaultCurrency),
+		},
+		{
+			name:          "version 2 is left alone",
+			schemaVersion: 2,
+			payload:       `{"refund_id":1,"amount":{"minor":1230,"currency":"EUR"},"refunded_amount":{"minor":1230,"currency":"EUR"}}`,
+			want:          money.New(1230, "EUR"),
+			wantRefunded:  money.New(1230, "EUR"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope := &Envelope{
+				Type:          EventTypeRefundIssued,
+				SchemaVersion: tt.schemaVersion,
+				Payload:       []byte(tt.payload),
+				ContentType:   ContentTypeJSON,
+			}
+			event, err := DecodePayload[RefundEvent](envelope)
+			if err != nil {
+				t.Fatalf("DecodePayload error = %v", err)
+			}
+			if event.Amount != tt.want {
+				t.Errorf("Amount = %v, want %v", event.Amount, tt.want)
+			}
+			if event.RefundedAmount != tt.wantRefunded {
+				t.Errorf("RefundedAmount = %v, want %v", event.RefundedAmount, tt.wantRefunded)
+			}
+			if string(envelope.Payload) != tt.payload {
+				t.Errorf("envelope payload was modified to %s", envelope.Payload)
+			}
+		})
+	}
+}
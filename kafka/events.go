@@ -1,27 +1,103 @@
 package kafka
 
-import "time"
+import (
+	"time"
+
+	eventspb "github.com/tair/full-observability/api/proto/events"
+	moneypb "github.com/tair/full-observability/api/proto/money"
+	"github.com/tair/full-observability/pkg/money"
+)
 
 // ProductPurchasedEvent represents a product purchase event
 type ProductPurchasedEvent struct {
-	EventID       string    `json:"event_id"`
-	EventType     string    `json:"event_type"`
-	PaymentID     uint      `json:"payment_id"`
-	ProductID     uint      `json:"product_id"`
-	Quantity      int32     `json:"quantity"`
-	UserID        uint      `json:"user_id"`
-	Amount        float64   `json:"amount"`
-	Currency      string    `json:"currency"`
-	PaymentMethod string    `json:"payment_method"`
-	Timestamp     time.Time `json:"timestamp"`
+	EventID       string      `json:"event_id"`
+	EventType     string      `json:"event_type"`
+	PaymentID     uint        `json:"payment_id"`
+	ProductID     uint        `json:"product_id"`
+	Quantity      int32       `json:"quantity"`
+	UserID        uint        `json:"user_id"`
+	Amount        money.Money `json:"amount"`
+	PaymentMethod string      `json:"payment_method"`
+	ReservationID string      `json:"reservation_id,omitempty"` // set when stock was already taken by a reservation
+	Timestamp     time.Time   `json:"timestamp"`
+}
+
+// Proto converts the event to its protobuf payload
+func (e ProductPurchasedEvent) Proto() *eventspb.ProductPurchased {
+	return &eventspb.ProductPurchased{
+		PaymentId:     uint32(e.PaymentID),
+		ProductId:     uint32(e.ProductID),
+		Quantity:      e.Quantity,
+		UserId:        uint32(e.UserID),
+		Amount:        e.Amount.Major(),
+		Currency:      e.Amount.Currency,
+		PaymentMethod: e.PaymentMethod,
+		ReservationId: e.ReservationID,
+		AmountMoney:   &moneypb.Money{Minor: e.Amount.Minor, Currency: e.Amount.Currency},
+	}
+}
+
+// PaymentEvent represents a payment lifecycle change (created, completed,
+// failed or refunded). Product fields are set for payments that bought stock.
+type PaymentEvent struct {
+	PaymentID      uint        `json:"payment_id"`
+	OrderID        string      `json:"order_id"`
+	UserID         uint        `json:"user_id"`
+	Amount         money.Money `json:"amount"`
+	PaymentMethod  string      `json:"payment_method"`
+	Status         string      `json:"status"`
+	PreviousStatus string      `json:"previous_status,omitempty"`
+	ProductID      uint        `json:"product_id,omitempty"`
+	Quantity       int32       `json:"quantity,omitempty"`
+	ReservationID  string      `json:"reservation_id,omitempty"`
+}
+
+// RefundEvent represents a refund issued for a payment. Quantity is the number
+// of purchased items returned with the refund, 0 for refunds of money only.
+type RefundEvent struct {
+	RefundID       uint         `json:"refund_id"`
+	PaymentID      uint         `json:"payment_id"`
+	OrderID        string       `json:"order_id"`
+	UserID         uint         `json:"user_id"`
+	Amount         money.Money  `json:"amount"`
+	Reason         string       `json:"reason,omitempty"`
+	RefundedAmount money.Money  `json:"refunded_amount"` // total refunded for the payment so far
+	FullyRefunded  bool         `json:"fully_refunded"`
+	ProductID      uint         `json:"product_id,omitempty"`
+	Quantity       int32        `json:"quantity,omitempty"`
+	ReservationID  string       `json:"reservation_id,omitempty"` // the reservation the returned items were taken by
+	Lines          []RefundLine `json:"lines,omitempty"`          // the returned items of an order, instead of ProductID and Quantity
+}
+
+// RefundLine is the part of an order refund returning the items of one order
+// line, taken by the line's reservation
+type RefundLine struct {
+	ProductID     uint   `json:"product_id"`
+	ReservationID string `json:"reservation_id"`
+	Quantity      int32  `json:"quantity"`
 }
 
 // Event types
 const (
 	EventTypeProductPurchased = "product.purchased"
+	EventTypePaymentCreated   = "payment.created"
+	EventTypePaymentCompleted = "payment.completed"
+	EventTypePaymentFailed    = "payment.failed"
+	EventTypePaymentRefunded  = "payment.refunded"
+	EventTypeRefundIssued     = "refund.issued"
+)
+
+// Schema versions of event payloads, bumped on incompatible changes.
+// Version 2 carries amounts as money.Money; version 1 amounts were decimals
+// in major units next to a separate currency, which DecodePayload upcasts.
+const (
+	SchemaVersionProductPurchased = 2
+	SchemaVersionPayment          = 2
+	SchemaVersionRefund           = 2
 )
 
 // Kafka topics
 const (
 	TopicProductPurchased = "product-purchased"
+	TopicPaymentEvents    = "payment-events"
 )
@@ -2,8 +2,8 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -12,14 +12,19 @@ import (
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/tair/full-observability/pkg/logger"
 )
 
 // Publisher wraps Kafka producer
 type Publisher struct {
-	producer sarama.SyncProducer
-	brokers  []string
+	producer     sarama.SyncProducer
+	brokers      []string
+	source       string         // service name recorded in published envelopes
+	registry     SchemaRegistry // optional; required for protobuf payloads
+	schemas      map[string]*Schema
+	schemasMutex sync.RWMutex
 }
 
 // NewPublisher creates a new Kafka publisher
@@ -43,44 +48,122 @@ func NewPublisher(brokers []string) (*Publisher, error) {
 	return &Publisher{
 		producer: producer,
 		brokers:  brokers,
+		schemas:  make(map[string]*Schema),
 	}, nil
 }
 
 // PublishProductPurchased publishes a product purchased event with tracing
 func (p *Publisher) PublishProductPurchased(ctx context.Context, event ProductPurchasedEvent) error {
-	// Start tracing span
-	tracer := otel.Tracer("kafka-publisher")
-	ctx, span := tracer.Start(ctx, "kafka.publish.product_purchased",
-		trace.WithSpanKind(trace.SpanKindProducer),
-		trace.WithAttributes(
-			attribute.String("messaging.system", "kafka"),
-			attribute.String("messaging.destination", TopicProductPurchased),
-			attribute.String("messaging.destination_kind", "topic"),
-			attribute.String("event.type", EventTypeProductPurchased),
-			attribute.Int64("product.id", int64(event.ProductID)),
-			attribute.Int("product.quantity", int(event.Quantity)),
-			attribute.Int64("payment.id", int64(event.PaymentID)),
-		),
-	)
-	defer span.End()
-
-	// Set event metadata
 	if event.EventID == "" {
 		event.EventID = fmt.Sprintf("evt_%d", time.Now().UnixNano())
 	}
 	event.EventType = EventTypeProductPurchased
 	event.Timestamp = time.Now()
 
-	span.SetAttributes(attribute.String("event.id", event.EventID))
+	return PublishEvent(ctx, p, TopicProductPurchased, fmt.Sprintf("product_%d", event.ProductID),
+		event.EventID, EventTypeProductPurchased, SchemaVersionProductPurchased, event)
+}
 
-	// Marshal event to JSON
-	eventBytes, err := json.Marshal(event)
+// PublishEvent wraps a domain event in an envelope and publishes it to a topic.
+// An event ID is generated when eventID is empty.
+func PublishEvent[T any](ctx context.Context, p *Publisher, topic, key, eventID, eventType string, schemaVersion int, event T) error {
+	envelope, err := SealEvent(p, eventID, eventType, schemaVersion, event)
 	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Failed to marshal event")
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return err
+	}
+
+	payload, headers, err := envelope.Encode()
+	if err != nil {
+		return err
+	}
+
+	return p.Publish(ctx, topic, key, envelope.Type, envelope.ID, payload, headers)
+}
+
+// SealEvent wraps a domain event in an envelope sourced from this publisher.
+// Protobuf payloads need a schema registered for the event type with
+// RegisterSchema; the registered version replaces schemaVersion.
+func SealEvent[T any](p *Publisher, eventID, eventType string, schemaVersion int, event T) (*Envelope, error) {
+	envelope, err := NewEnvelope(eventID, eventType, p.source, schemaVersion, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.ContentType == ContentTypeProtobuf {
+		schema, ok := p.SchemaFor(eventType)
+		if !ok {
+			return nil, fmt.Errorf("no schema registered for %s", eventType)
+		}
+		envelope.SchemaID = schema.ID
+		envelope.SchemaVersion = schema.Version
+	}
+	return envelope, nil
+}
+
+// UseSchemaRegistry sets the registry that protobuf payload schemas are
+// registered with
+func (p *Publisher) UseSchemaRegistry(registry SchemaRegistry) {
+	p.registry = registry
+}
+
+// RegisterSchema registers msg as the protobuf payload schema of an event type.
+// It fails with ErrSchemaIncompatible when msg cannot read events written with
+// the latest registered version, so call it at startup to stop an incompatible
+// producer from rolling out.
+func (p *Publisher) RegisterSchema(ctx context.Context, eventType string, msg proto.Message) (*Schema, error) {
+	if p.registry == nil {
+		return nil, fmt.Errorf("no schema registry configured")
+	}
+
+	schema, err := p.registry.Register(ctx, eventType, msg)
+	if err != nil {
+		return nil, err
 	}
 
+	p.schemasMutex.Lock()
+	p.schemas[eventType] = schema
+	p.schemasMutex.Unlock()
+
+	logger.Logger.Info().
+		Str("event_type", eventType).
+		Str("message", schema.MessageName).
+		Int("schema_id", schema.ID).
+		Int("schema_version", schema.Version).
+		Msg("Event schema registered")
+
+	return schema, nil
+}
+
+// SchemaFor returns the schema registered for an event type, if any
+func (p *Publisher) SchemaFor(eventType string) (*Schema, bool) {
+	p.schemasMutex.RLock()
+	defer p.schemasMutex.RUnlock()
+	schema, ok := p.schemas[eventType]
+	return schema, ok
+}
+
+// SetSource sets the service name recorded as the source of published events
+func (p *Publisher) SetSource(source string) {
+	p.source = source
+}
+
+// Publish sends an already-encoded event payload to a topic with tracing.
+// Extra headers, such as those returned by Envelope.Encode, are sent along.
+// It is used by the outbox relay, which stores events before they are sent.
+func (p *Publisher) Publish(ctx context.Context, topic, key, eventType, eventID string, payload []byte, extra map[string]string) error {
+	tracer := otel.Tracer("kafka-publisher")
+	ctx, span := tracer.Start(ctx, "kafka.publish."+eventType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination", topic),
+			attribute.String("messaging.destination_kind", "topic"),
+			attribute.String("event.type", eventType),
+			attribute.String("event.id", eventID),
+		),
+	)
+	defer span.End()
+
 	// Inject trace context into Kafka headers
 	carrier := propagation.MapCarrier{}
 	otel.GetTextMapPropagator().Inject(ctx, carrier)
@@ -88,15 +171,24 @@ func (p *Publisher) PublishProductPurchased(ctx context.Context, event ProductPu
 	headers := []sarama.RecordHeader{
 		{
 			Key:   []byte("event_type"),
-			Value: []byte(EventTypeProductPurchased),
+			Value: []byte(eventType),
 		},
 		{
 			Key:   []byte("event_id"),
-			Value: []byte(event.EventID),
+			Value: []byte(eventID),
 		},
 	}
 
-	// Add trace context to headers
+	for key, value := range extra {
+		if _, traced := carrier[key]; traced || key == "event_type" || key == "event_id" {
+			continue
+		}
+		headers = append(headers, sarama.RecordHeader{
+			Key:   []byte(key),
+			Value: []byte(value),
+		})
+	}
+
 	for key, value := range carrier {
 		headers = append(headers, sarama.RecordHeader{
 			Key:   []byte(key),
@@ -104,23 +196,23 @@ func (p *Publisher) PublishProductPurchased(ctx context.Context, event ProductPu
 		})
 	}
 
-	// Create Kafka message
 	msg := &sarama.ProducerMessage{
-		Topic:   TopicProductPurchased,
-		Key:     sarama.StringEncoder(fmt.Sprintf("product_%d", event.ProductID)),
-		Value:   sarama.ByteEncoder(eventBytes),
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(payload),
 		Headers: headers,
 	}
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
 
-	// Send message
 	partition, offset, err := p.producer.SendMessage(msg)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Failed to send message")
 		logger.Logger.Error().
 			Err(err).
-			Str("topic", TopicProductPurchased).
-			Uint("product_id", event.ProductID).
+			Str("topic", topic).
+			Str("event_id", eventID).
 			Str("trace_id", span.SpanContext().TraceID().String()).
 			Msg("Failed to publish event")
 		return fmt.Errorf("failed to send message to Kafka: %w", err)
@@ -133,19 +225,35 @@ func (p *Publisher) PublishProductPurchased(ctx context.Context, event ProductPu
 	span.SetStatus(codes.Ok, "Event published successfully")
 
 	logger.Logger.Info().
-		Str("event_id", event.EventID).
-		Str("event_type", event.EventType).
-		Str("topic", TopicProductPurchased).
+		Str("event_id", eventID).
+		Str("event_type", eventType).
+		Str("topic", topic).
 		Int32("partition", partition).
 		Int64("offset", offset).
-		Uint("product_id", event.ProductID).
-		Int32("quantity", event.Quantity).
 		Str("trace_id", span.SpanContext().TraceID().String()).
-		Msg("Product purchased event published")
+		Msg("Event published")
 
 	return nil
 }
 
+// forward sends a consumed message to another topic keeping its key, payload
+// and headers. It is used to move failed messages to retry and dead-letter topics.
+func (p *Publisher) forward(topic string, key, value []byte, headers []sarama.RecordHeader) error {
+	msg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(value),
+		Headers: headers,
+	}
+	if len(key) > 0 {
+		msg.Key = sarama.ByteEncoder(key)
+	}
+
+	if _, _, err := p.producer.SendMessage(msg); err != nil {
+		return fmt.Errorf("failed to send message to %s: %w", topic, err)
+	}
+	return nil
+}
+
 // Close closes the Kafka producer
 func (p *Publisher) Close() error {
 	if p.producer != nil {
@@ -0,0 +1,319 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/tair/full-observability/pkg/logger"
+)
+
+// Headers added to messages moved to retry and dead-letter topics
+const (
+	HeaderOriginalTopic     = "original_topic"
+	HeaderOriginalPartition = "original_partition"
+	HeaderOriginalOffset    = "original_offset"
+	HeaderRetryLevel        = "retry_level"
+	HeaderRetryNotBefore    = "retry_not_before" // unix milliseconds
+	HeaderAttempts          = "attempts"
+	HeaderError             = "error"
+	HeaderFailedAt          = "failed_at" // RFC 3339
+)
+
+// RetryPolicy controls what happens when a registered handler returns an error.
+// A failed message is first retried in-process, then parked on up to
+// RetryTopics delayed retry topics and finally sent to the dead-letter topic.
+// A message that cannot be forwarded is not marked, so it is redelivered;
+// exhausted messages are only dropped when DeadLetter is off and Drop is set.
+type RetryPolicy struct {
+	Attempts    int           // in-process attempts per delivery, including the first
+	Backoff     time.Duration // delay before the second in-process attempt, doubled after each one
+	RetryTopics int           // number of <topic>.retry.N hops before dead-lettering
+	RetryDelay  time.Duration // delay before a message on <topic>.retry.N is handled, multiplied by N
+	DeadLetter  bool          // send exhausted messages to <topic>.dlq
+	Drop        bool          // drop exhausted messages when DeadLetter is off instead of redelivering them
+}
+
+// RetryTopic returns the name of the N-th retry topic for a source topic
+func RetryTopic(topic string, level int) string {
+	return fmt.Sprintf("%s.retry.%d", topic, level)
+}
+
+// DeadLetterTopic returns the name of the dead-letter topic for a source topic
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// permanentError marks failures that retrying cannot fix, such as a payload
+// that does not decode; these go straight to the dead-letter topic
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func isPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// UseRetryPolicy enables retries and dead-lettering for failed handlers. The
+// publisher is used to write to the retry and dead-letter topics. It must be
+// called before Start, since it subscribes the consumer to the retry topics;
+// Start creates the ones that do not exist yet.
+func (c *Consumer) UseRetryPolicy(policy RetryPolicy, publisher *Publisher) {
+	if policy.Attempts < 1 {
+		policy.Attempts = 1
+	}
+	c.retry = &policy
+	c.publisher = publisher
+
+	sources := c.topics
+	c.retrySources = sources
+	for _, topic := range sources {
+		for level := 1; level <= policy.RetryTopics; level++ {
+			c.topics = append(c.topics, RetryTopic(topic, level))
+		}
+	}
+
+	logger.Logger.Info().
+		Int("attempts", policy.Attempts).
+		Dur("backoff", policy.Backoff).
+		Int("retry_topics", policy.RetryTopics).
+		Dur("retry_delay", policy.RetryDelay).
+		Bool("dead_letter", policy.DeadLetter).
+		Bool("drop", policy.Drop).
+		Msg("Kafka retry policy enabled")
+}
+
+// ensureRetryTopics creates the retry and dead-letter topics that do not
+// exist yet, with the partitions and replication of their source topic, so
+// that failed events are never escalated to a topic left to auto-creation
+func (c *Consumer) ensureRetryTopics() error {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	admin, err := sarama.NewClusterAdmin(c.brokers, config)
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka admin: %w", err)
+	}
+	defer admin.Close()
+
+	existing, err := admin.ListTopics()
+	if err != nil {
+		return fmt.Errorf("failed to list Kafka topics: %w", err)
+	}
+
+	for _, source := range c.retrySources {
+		detail := sarama.TopicDetail{NumPartitions: 1, ReplicationFactor: 1}
+		if sourceDetail, ok := existing[source]; ok {
+			detail.NumPartitions = sourceDetail.NumPartitions
+			detail.ReplicationFactor = sourceDetail.ReplicationFactor
+		}
+
+		var targets []string
+		for level := 1; level <= c.retry.RetryTopics; level++ {
+			targets = append(targets, RetryTopic(source, level))
+		}
+		if c.retry.DeadLetter {
+			targets = append(targets, DeadLetterTopic(source))
+		}
+
+		for _, target := range targets {
+			if _, ok := existing[target]; ok {
+				continue
+			}
+			if err := admin.CreateTopic(target, &detail, false); err != nil && !errors.Is(err, sarama.ErrTopicAlreadyExists) {
+				return fmt.Errorf("failed to create topic %s: %w", target, err)
+			}
+			logger.Logger.Info().
+				Str("topic", target).
+				Int32("partitions", detail.NumPartitions).
+				Msg("Kafka topic created")
+		}
+	}
+	return nil
+}
+
+// isDue reports whether a message may be handled now. Messages on a retry
+// topic are held until their retry_not_before time.
+func isDue(message *sarama.ConsumerMessage) bool {
+	notBefore, ok := headerInt(message, HeaderRetryNotBefore)
+	return !ok || time.Now().UnixMilli() >= notBefore
+}
+
+// retryDelay returns how long a message must still be held
+func retryDelay(message *sarama.ConsumerMessage) time.Duration {
+	notBefore, _ := headerInt(message, HeaderRetryNotBefore)
+	return time.Until(time.UnixMilli(notBefore))
+}
+
+// process handles a message with in-process retries and escalates it to the
+// next retry topic or the dead-letter topic when all attempts fail. It returns
+// false if the session ended before the message was dealt with or the message
+// could not be escalated, in which case the message must not be marked so it
+// is redelivered.
+func (h *consumerGroupHandler) process(ctx context.Context, message *sarama.ConsumerMessage) bool {
+	policy := h.consumer.retry
+	if policy == nil {
+		_ = h.handleMessage(ctx, message)
+		return true
+	}
+
+	err := h.handleMessage(ctx, message)
+	attempts := 1
+	backoff := policy.Backoff
+	for err != nil && !isPermanent(err) && attempts < policy.Attempts {
+		if !sleepContext(ctx, backoff) {
+			return false
+		}
+		backoff *= 2
+		err = h.handleMessage(ctx, message)
+		attempts++
+	}
+
+	if err != nil {
+		if escalateErr := h.escalate(message, err, attempts); escalateErr != nil {
+			logger.Logger.Error().
+				Err(escalateErr).
+				Str("topic", message.Topic).
+				Str("event_id", headerValue(message, "event_id")).
+				Msg("Failed to escalate failed event, it will be redelivered")
+			// Ending the claim restarts the session from the last marked
+			// offset; wait first so a broken retry topic is not hammered
+			sleepContext(ctx, policy.Backoff)
+			return false
+		}
+	}
+	return true
+}
+
+// escalate moves a failed message to the next retry topic, or to the
+// dead-letter topic once the retry topics are exhausted. It returns an error
+// when the message was neither forwarded nor dropped by the policy.
+func (h *consumerGroupHandler) escalate(message *sarama.ConsumerMessage, cause error, attempts int) error {
+	policy := h.consumer.retry
+
+	originalTopic := headerValue(message, HeaderOriginalTopic)
+	if originalTopic == "" {
+		originalTopic = message.Topic
+	}
+	level, _ := headerInt(message, HeaderRetryLevel)
+	previousAttempts, _ := headerInt(message, HeaderAttempts)
+	totalAttempts := int(previousAttempts) + attempts
+
+	headers := map[string]string{
+		HeaderOriginalTopic: originalTopic,
+		HeaderAttempts:      strconv.Itoa(totalAttempts),
+		HeaderError:         cause.Error(),
+	}
+	if originalTopic == message.Topic {
+		headers[HeaderOriginalPartition] = strconv.Itoa(int(message.Partition))
+		headers[HeaderOriginalOffset] = strconv.FormatInt(message.Offset, 10)
+	}
+
+	var target string
+	switch {
+	case !isPermanent(cause) && int(level) < policy.RetryTopics:
+		next := int(level) + 1
+		target = RetryTopic(originalTopic, next)
+		headers[HeaderRetryLevel] = strconv.Itoa(next)
+		headers[HeaderRetryNotBefore] = strconv.FormatInt(time.Now().Add(time.Duration(next)*policy.RetryDelay).UnixMilli(), 10)
+	case policy.DeadLetter:
+		target = DeadLetterTopic(originalTopic)
+		headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+	case policy.Drop:
+		logger.Logger.Error().
+			Err(cause).
+			Str("topic", originalTopic).
+			Str("event_id", headerValue(message, "event_id")).
+			Int("attempts", totalAttempts).
+			Msg("Retries exhausted, event dropped")
+		return nil
+	default:
+		return fmt.Errorf("retries exhausted after %d attempts and dead-lettering is disabled: %w", totalAttempts, cause)
+	}
+
+	if err := h.consumer.publisher.forward(target, message.Key, message.Value, withHeaders(message.Headers, headers)); err != nil {
+		return fmt.Errorf("failed to forward event to %s: %w", target, err)
+	}
+
+	logger.Logger.Warn().
+		Err(cause).
+		Str("from_topic", message.Topic).
+		Str("to_topic", target).
+		Str("event_id", headerValue(message, "event_id")).
+		Int("attempts", totalAttempts).
+		Msg("Failed event forwarded")
+	return nil
+}
+
+// withHeaders copies the record headers, replacing any whose key is in overrides
+func withHeaders(original []*sarama.RecordHeader, overrides map[string]string) []sarama.RecordHeader {
+	headers := make([]sarama.RecordHeader, 0, len(original)+len(overrides))
+	for _, header := range original {
+		if _, replaced := overrides[string(header.Key)]; replaced {
+			continue
+		}
+		headers = append(headers, *header)
+	}
+	for key, value := range overrides {
+		headers = append(headers, sarama.RecordHeader{
+			Key:   []byte(key),
+			Value: []byte(value),
+		})
+	}
+	return headers
+}
+
+// withoutRetryHeaders copies the record headers, dropping the ones added by
+// the retry and dead-letter path
+func withoutRetryHeaders(original []*sarama.RecordHeader) []sarama.RecordHeader {
+	headers := make([]sarama.RecordHeader, 0, len(original))
+	for _, header := range original {
+		switch string(header.Key) {
+		case HeaderOriginalTopic, HeaderOriginalPartition, HeaderOriginalOffset,
+			HeaderRetryLevel, HeaderRetryNotBefore, HeaderAttempts, HeaderError, HeaderFailedAt:
+			continue
+		}
+		headers = append(headers, *header)
+	}
+	return headers
+}
+
+func headerValue(message *sarama.ConsumerMessage, key string) string {
+	for _, header := range message.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func headerInt(message *sarama.ConsumerMessage, key string) (int64, bool) {
+	value, err := strconv.ParseInt(strings.TrimSpace(headerValue(message, key)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// sleepContext waits for d and reports whether it finished before ctx ended
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
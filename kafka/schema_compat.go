@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CheckBackwardCompatible reports whether messages written with the old
+// descriptor can be read with the new one. Fields may be added, renamed or
+// removed as long as the removed numbers are reserved; an existing field
+// number must keep a wire-compatible type and cardinality.
+func CheckBackwardCompatible(oldDesc, newDesc protoreflect.MessageDescriptor) error {
+	var problems []string
+	checkMessage(oldDesc, newDesc, make(map[protoreflect.FullName]bool), &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func checkMessage(oldDesc, newDesc protoreflect.MessageDescriptor, visited map[protoreflect.FullName]bool, problems *[]string) {
+	if visited[oldDesc.FullName()] {
+		return
+	}
+	visited[oldDesc.FullName()] = true
+
+	oldFields := oldDesc.Fields()
+	for i := 0; i < oldFields.Len(); i++ {
+		oldField := oldFields.Get(i)
+		newField := newDesc.Fields().ByNumber(oldField.Number())
+
+		if newField == nil {
+			if !newDesc.ReservedRanges().Has(oldField.Number()) {
+				*problems = append(*problems, fmt.Sprintf("%s: field %d (%s) removed without reserving its number",
+					newDesc.FullName(), oldField.Number(), oldField.Name()))
+			}
+			continue
+		}
+
+		where := fmt.Sprintf("%s: field %d (%s)", newDesc.FullName(), oldField.Number(), newField.Name())
+		if oldField.IsMap() != newField.IsMap() || oldField.IsList() != newField.IsList() {
+			*problems = append(*problems, where+" changed cardinality")
+			continue
+		}
+		if wireClass(oldField.Kind()) != wireClass(newField.Kind()) {
+			*problems = append(*problems, fmt.Sprintf("%s changed type from %s to %s", where, oldField.Kind(), newField.Kind()))
+			continue
+		}
+
+		if oldField.IsMap() {
+			if wireClass(oldField.MapKey().Kind()) != wireClass(newField.MapKey().Kind()) {
+				*problems = append(*problems, where+" changed map key type")
+			}
+			oldField, newField = oldField.MapValue(), newField.MapValue()
+			if wireClass(oldField.Kind()) != wireClass(newField.Kind()) {
+				*problems = append(*problems, where+" changed map value type")
+				continue
+			}
+		}
+
+		if oldField.Message() != nil && newField.Message() != nil {
+			checkMessage(oldField.Message(), newField.Message(), visited, problems)
+		}
+	}
+}
+
+// wireClass groups field kinds whose encodings can be read as one another
+func wireClass(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Uint32Kind, protoreflect.Int64Kind,
+		protoreflect.Uint64Kind, protoreflect.BoolKind, protoreflect.EnumKind:
+		return "varint"
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return "zigzag"
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return "fixed32"
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return "fixed64"
+	case protoreflect.FloatKind:
+		return "float"
+	case protoreflect.DoubleKind:
+		return "double"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "bytes"
+	case protoreflect.MessageKind:
+		return "message"
+	default:
+		return kind.String()
+	}
+}
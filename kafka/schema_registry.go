@@ -0,0 +1,304 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Schema registry errors
+var (
+	ErrSchemaNotFound     = errors.New("schema not found")
+	ErrSchemaIncompatible = errors.New("schema is not backward compatible")
+)
+
+// Schema is a registered version of the protobuf message used as the payload
+// of an event type (the subject)
+type Schema struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	Subject     string    `json:"subject" gorm:"not null;uniqueIndex:idx_event_schemas_subject_version,priority:1"`
+	Version     int       `json:"version" gorm:"not null;uniqueIndex:idx_event_schemas_subject_version,priority:2"`
+	MessageName string    `json:"message_name" gorm:"not null"`
+	Descriptor  []byte    `json:"descriptor" gorm:"not null"` // serialized FileDescriptorSet
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (Schema) TableName() string {
+	return "event_schemas"
+}
+
+// SchemaRegistry stores payload schemas per subject. Register rejects a new
+// version that cannot read data written with the latest registered one.
+type SchemaRegistry interface {
+	// Register returns the schema's ID, reusing the latest version when the
+	// descriptor is unchanged
+	Register(ctx context.Context, subject string, msg proto.Message) (*Schema, error)
+	Get(ctx context.Context, id int) (*Schema, error)
+}
+
+// NewProtoSchema builds an unregistered schema for a generated protobuf message,
+// embedding the descriptors of its file and all of its imports
+func NewProtoSchema(subject string, msg proto.Message) (*Schema, error) {
+	desc := msg.ProtoReflect().Descriptor()
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	var addFile func(fd protoreflect.FileDescriptor)
+	addFile = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			addFile(imports.Get(i).FileDescriptor)
+		}
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	addFile(desc.ParentFile())
+
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal descriptor of %s: %w", desc.FullName(), err)
+	}
+
+	return &Schema{
+		Subject:     subject,
+		MessageName: string(desc.FullName()),
+		Descriptor:  raw,
+	}, nil
+}
+
+// MessageDescriptor resolves the payload message descriptor of the schema
+func (s *Schema) MessageDescriptor() (protoreflect.MessageDescriptor, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(s.Descriptor, set); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal descriptor of schema %d: %w", s.ID, err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load descriptor of schema %d: %w", s.ID, err)
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(s.MessageName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s in schema %d: %w", s.MessageName, s.ID, err)
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s in schema %d is not a message", s.MessageName, s.ID)
+	}
+	return msgDesc, nil
+}
+
+// nextVersion decides how a candidate schema is registered after latest: it
+// returns latest when nothing changed, or the candidate with its version set
+func nextVersion(latest, candidate *Schema) (*Schema, error) {
+	if latest == nil {
+		candidate.Version = 1
+		return candidate, nil
+	}
+	if latest.MessageName == candidate.MessageName && bytes.Equal(latest.Descriptor, candidate.Descriptor) {
+		return latest, nil
+	}
+
+	oldDesc, err := latest.MessageDescriptor()
+	if err != nil {
+		return nil, err
+	}
+	newDesc, err := candidate.MessageDescriptor()
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckBackwardCompatible(oldDesc, newDesc); err != nil {
+		return nil, fmt.Errorf("%w: %s version %d: %v", ErrSchemaIncompatible, candidate.Subject, latest.Version+1, err)
+	}
+
+	candidate.Version = latest.Version + 1
+	return candidate, nil
+}
+
+// PostgresSchemaRegistry keeps schemas in a Postgres table
+type PostgresSchemaRegistry struct {
+	db *gorm.DB
+}
+
+// NewPostgresSchemaRegistry creates a Postgres-backed schema registry
+func NewPostgresSchemaRegistry(db *gorm.DB) *PostgresSchemaRegistry {
+	return &PostgresSchemaRegistry{db: db}
+}
+
+// AutoMigrate creates the event_schemas table
+func (r *PostgresSchemaRegistry) AutoMigrate() error {
+	return r.db.AutoMigrate(&Schema{})
+}
+
+// Register checks the message against the latest version of the subject and
+// stores it as a new version if it changed
+func (r *PostgresSchemaRegistry) Register(ctx context.Context, subject string, msg proto.Message) (*Schema, error) {
+	candidate, err := NewProtoSchema(subject, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *Schema
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest Schema
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("subject = ?", subject).
+			Order("version DESC").
+			First(&latest).Error
+		var latestPtr *Schema
+		switch {
+		case err == nil:
+			latestPtr = &latest
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		next, err := nextVersion(latestPtr, candidate)
+		if err != nil {
+			return err
+		}
+		if next != candidate {
+			result = next
+			return nil
+		}
+		if err := tx.Create(candidate).Error; err != nil {
+			return err
+		}
+		result = candidate
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schema for %s: %w", subject, err)
+	}
+	return result, nil
+}
+
+// Get returns the schema with the given ID
+func (r *PostgresSchemaRegistry) Get(ctx context.Context, id int) (*Schema, error) {
+	var schema Schema
+	if err := r.db.WithContext(ctx).First(&schema, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSchemaNotFound
+		}
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// FileSchemaRegistry keeps schemas in a JSON file, for local development and
+// single-instance deployments
+type FileSchemaRegistry struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSchemaRegistry creates a schema registry stored at path. The file is
+// created on the first registration.
+func NewFileSchemaRegistry(path string) *FileSchemaRegistry {
+	return &FileSchemaRegistry{path: path}
+}
+
+// Register checks the message against the latest version of the subject and
+// stores it as a new version if it changed
+func (r *FileSchemaRegistry) Register(ctx context.Context, subject string, msg proto.Message) (*Schema, error) {
+	candidate, err := NewProtoSchema(subject, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schemas, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Schema
+	for i := range schemas {
+		if schemas[i].Subject == subject && (latest == nil || schemas[i].Version > latest.Version) {
+			latest = &schemas[i]
+		}
+	}
+
+	next, err := nextVersion(latest, candidate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schema for %s: %w", subject, err)
+	}
+	if next != candidate {
+		return next, nil
+	}
+
+	candidate.ID = len(schemas) + 1
+	candidate.CreatedAt = time.Now().UTC()
+	schemas = append(schemas, *candidate)
+	if err := r.save(schemas); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// Get returns the schema with the given ID
+func (r *FileSchemaRegistry) Get(ctx context.Context, id int) (*Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schemas, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range schemas {
+		if schemas[i].ID == id {
+			return &schemas[i], nil
+		}
+	}
+	return nil, ErrSchemaNotFound
+}
+
+func (r *FileSchemaRegistry) load() ([]Schema, error) {
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema registry: %w", err)
+	}
+
+	var schemas []Schema
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return nil, fmt.Errorf("failed to parse schema registry %s: %w", r.path, err)
+	}
+	return schemas, nil
+}
+
+func (r *FileSchemaRegistry) save(schemas []Schema) error {
+	data, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema registry: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated registry
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	return nil
+}
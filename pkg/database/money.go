@@ -0,0 +1,91 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/tair/full-observability/pkg/money"
+)
+
+// MinorUnitsColumn maps a legacy decimal amount column onto the column pair
+// of an embedded money.Money
+type MinorUnitsColumn struct {
+	From     string // legacy column with the amount in major units
+	To       string // embeddedPrefix of the money.Money field, e.g. "amount_"
+	Currency string // SQL expression for the legacy currency, e.g. a column; empty for money.DefaultCurrency
+}
+
+// MigrateToMinorUnits converts legacy decimal amount columns of a table to
+// money columns and drops them, together with the legacy columns listed in
+// drop. Amounts are scaled by their currency's exponent and rounded half away
+// from zero. Columns that no longer exist are skipped, so it is safe to call
+// after AutoMigrate on every start. Migrate tables whose currency comes from a
+// parent row before the parent drops its currency column.
+func MigrateToMinorUnits(db *gorm.DB, table string, columns []MinorUnitsColumn, drop ...string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		migrated := 0
+
+		for _, column := range columns {
+			if !migrator.HasColumn(table, column.From) {
+				continue
+			}
+
+			currency := fmt.Sprintf("'%s'", money.DefaultCurrency)
+			if column.Currency != "" {
+				currency = fmt.Sprintf("COALESCE(NULLIF(UPPER(%s), ''), '%s')", column.Currency, money.DefaultCurrency)
+			}
+
+			query := fmt.Sprintf(
+				"UPDATE %s SET %sminor = ROUND(CAST(%s AS NUMERIC) * POWER(10::NUMERIC, %s)), %scurrency = %s WHERE %s IS NOT NULL",
+				table, column.To, column.From, exponentExpr(currency), column.To, currency, column.From,
+			)
+			if err := tx.Exec(query).Error; err != nil {
+				return fmt.Errorf("failed to migrate %s.%s to minor units: %w", table, column.From, err)
+			}
+			migrated++
+		}
+
+		legacy := drop
+		for _, column := range columns {
+			legacy = append(legacy, column.From)
+		}
+		for _, name := range legacy {
+			if !migrator.HasColumn(table, name) {
+				continue
+			}
+			if err := migrator.DropColumn(table, name); err != nil {
+				return fmt.Errorf("failed to drop legacy column %s.%s: %w", table, name, err)
+			}
+		}
+
+		if migrated > 0 {
+			log.Printf("Migrated %d amount columns of %s to minor units", migrated, table)
+		}
+		return nil
+	})
+}
+
+// exponentExpr returns a SQL expression for the minor unit exponent of the
+// currency held by currencyExpr
+func exponentExpr(currencyExpr string) string {
+	exponents := money.Exponents()
+	currencies := make([]string, 0, len(exponents))
+	for currency := range exponents {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	var b strings.Builder
+	b.WriteString("CASE ")
+	b.WriteString(currencyExpr)
+	for _, currency := range currencies {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", currency, exponents[currency])
+	}
+	b.WriteString(" ELSE 2 END")
+	return b.String()
+}
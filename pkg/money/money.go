@@ -0,0 +1,342 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is assumed for amounts recorded without a currency
+const DefaultCurrency = "USD"
+
+// Money errors
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	ErrOverflow         = errors.New("amount out of range")
+)
+
+// Exponent returns the number of decimal places of a currency's minor unit.
+// Unknown currencies are assumed to have two.
+func Exponent(currency string) int {
+	if exponent, ok := currencies[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Exponents returns the currencies whose exponent differs from the default of 2
+func Exponents() map[string]int {
+	result := make(map[string]int)
+	for currency, exponent := range currencies {
+		if exponent != 2 {
+			result[currency] = exponent
+		}
+	}
+	return result
+}
+
+// Money is an amount in the minor unit of an ISO 4217 currency, e.g. cents
+// for USD. Arithmetic is exact; amounts in different currencies never mix.
+// Stored with gorm it maps to a <prefix>minor and a <prefix>currency column
+// when embedded with an embeddedPrefix.
+type Money struct {
+	Minor    int64  `gorm:"column:minor;not null;default:0"`
+	Currency string `gorm:"column:currency;size:3;not null;default:'USD'"`
+}
+
+// New creates an amount from minor units
+func New(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: normalize(currency)}
+}
+
+// Zero returns a zero amount in a currency
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// FromMajor converts a decimal amount in major units, rounding half away from
+// zero to the currency's minor unit. Use it only at boundaries that receive
+// floating point amounts.
+func FromMajor(amount float64, currency string) Money {
+	return New(int64(math.Round(amount*math.Pow10(Exponent(currency)))), currency)
+}
+
+// Parse converts a decimal string in major units such as "12.34". It fails
+// when the string has more decimal places than the currency's minor unit.
+func Parse(amount, currency string) (Money, error) {
+	currency = normalize(currency)
+	exponent := Exponent(currency)
+
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)))
+	if !value.IsInt() || !value.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places for %s", ErrInvalidAmount, amount, exponent, currency)
+	}
+	return New(value.Num().Int64(), currency), nil
+}
+
+// ValidateCurrency checks that a currency is an active ISO 4217 code
+func ValidateCurrency(currency string) error {
+	if !IsCurrency(currency) {
+		return fmt.Errorf("%w: %q is not an ISO 4217 currency code", ErrInvalidCurrency, currency)
+	}
+	return nil
+}
+
+// IsCurrency reports whether currency is an active ISO 4217 code, in any case
+func IsCurrency(currency string) bool {
+	_, ok := currencies[strings.ToUpper(currency)]
+	return ok
+}
+
+// Major returns the amount in major units. The result is for display and
+// metrics only; never compute with it.
+func (m Money) Major() float64 {
+	return float64(m.Minor) / math.Pow10(Exponent(m.Currency))
+}
+
+// Decimal formats the amount in major units with the currency's decimal
+// places, e.g. "12.30" for USD and "1230" for JPY
+func (m Money) Decimal() string {
+	exponent := Exponent(m.Currency)
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint(minor), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// String formats the amount with its currency, e.g. "12.30 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.Minor < 0
+}
+
+// SameCurrency reports whether both amounts are in the same currency
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == other.Currency
+}
+
+// Add returns m + other
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	sum := m.Minor + other.Minor
+	if (other.Minor > 0 && sum < m.Minor) || (other.Minor < 0 && sum > m.Minor) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, other)
+	}
+	return Money{Minor: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - other
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	difference := m.Minor - other.Minor
+	if (other.Minor > 0 && difference > m.Minor) || (other.Minor < 0 && difference < m.Minor) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, other)
+	}
+	return Money{Minor: difference, Currency: m.Currency}, nil
+}
+
+// Cmp compares two amounts, returning -1, 0 or +1
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Minor < other.Minor:
+		return -1, nil
+	case m.Minor > other.Minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Mul returns the amount multiplied by a whole quantity
+func (m Money) Mul(quantity int64) (Money, error) {
+	product := m.Minor * quantity
+	if m.Minor != 0 && (product/m.Minor != quantity || (m.Minor == -1 && quantity == math.MinInt64)) {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, quantity)
+	}
+	return Money{Minor: product, Currency: m.Currency}, nil
+}
+
+// MulRate returns the amount multiplied by a rate such as 0.2 for 20%,
+// rounded half away from zero to the minor unit
+func (m Money) MulRate(rate float64) (Money, error) {
+	minor, ok := roundMinor(float64(m.Minor) * rate)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s * %v", ErrOverflow, m, rate)
+	}
+	return Money{Minor: minor, Currency: m.Currency}, nil
+}
+
+// Convert converts the amount into another currency at rate, the major units
+// of currency paid for one major unit of the amount's currency, rounded half
+// away from zero to the minor unit of currency
+func (m Money) Convert(currency string, rate float64) (Money, error) {
+	currency = normalize(currency)
+	scale := math.Pow10(Exponent(currency) - Exponent(m.Currency))
+	minor, ok := roundMinor(float64(m.Minor) * rate * scale)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s in %s at %v", ErrOverflow, m, currency, rate)
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// roundMinor rounds a computed amount half away from zero, reporting whether
+// it fits in minor units
+func roundMinor(amount float64) (int64, bool) {
+	rounded := math.Round(amount)
+	if math.IsNaN(rounded) || rounded >= math.MaxInt64 || rounded < math.MinInt64 {
+		return 0, false
+	}
+	return int64(rounded), true
+}
+
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Allocate splits the amount into n parts that differ by at most one minor
+// unit and add up to the amount exactly
+func (m Money) Allocate(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	parts := make([]Money, n)
+	share, remainder := m.Minor/int64(n), m.Minor%int64(n)
+	for i := range parts {
+		parts[i] = Money{Minor: share, Currency: m.Currency}
+		if int64(i) < absInt(remainder) {
+			if remainder > 0 {
+				parts[i].Minor++
+			} else {
+				parts[i].Minor--
+			}
+		}
+	}
+	return parts
+}
+
+// Sum adds amounts of one currency; an empty list sums to zero in currency
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+// jsonMoney is the wire format of Money. Amount repeats the value in major
+// units as a decimal string for display.
+type jsonMoney struct {
+	Minor    int64  `json:"minor"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+// MarshalJSON encodes the amount as {"minor":1230,"currency":"USD","amount":"12.30"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Minor: m.Minor, Currency: m.Currency, Amount: m.Decimal()})
+}
+
+// UnmarshalJSON decodes the object written by MarshalJSON. The minor units
+// win over the decimal amount when both are set. A bare number is rejected,
+// since it does not say which currency it is in.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "null" {
+		return nil
+	}
+	if !strings.HasPrefix(trimmed, "{") {
+		return fmt.Errorf("%w: %s is not an object with a currency", ErrInvalidAmount, trimmed)
+	}
+
+	var raw struct {
+		Minor    *int64 `json:"minor"`
+		Currency string `json:"currency"`
+		Amount   string `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Minor != nil {
+		*m = New(*raw.Minor, raw.Currency)
+		return nil
+	}
+	parsed, err := Parse(raw.Amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func normalize(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+func absInt(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func absUint(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
@@ -0,0 +1,325 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestExponent(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     int
+	}{
+		{"USD", 2},
+		{"usd", 2},
+		{"JPY", 0},
+		{"KRW", 0},
+		{"KWD", 3},
+		{"BHD", 3},
+		{"CLF", 4},
+		{"XXX-unknown", 2},
+	}
+	for _, tt := range tests {
+		if got := Exponent(tt.currency); got != tt.want {
+			t.Errorf("Exponent(%q) = %d, want %d", tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestFromMajor(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		want     Money
+	}{
+		{"cents", 12.34, "USD", New(1234, "USD")},
+		{"float error", 0.1 + 0.2, "USD", New(30, "USD")},
+		{"half rounds up", 0.125, "USD", New(13, "USD")},
+		{"half rounds away from zero", -0.125, "USD", New(-13, "USD")},
+		{"no minor unit", 1230.5, "JPY", New(1231, "JPY")},
+		{"three decimals", 1.2345, "KWD", New(1235, "KWD")},
+		{"default currency", 1, "", New(100, "USD")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromMajor(tt.amount, tt.currency); got != tt.want {
+				t.Errorf("FromMajor(%v, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{"cents", "12.34", "USD", New(1234, "USD"), false},
+		{"whole", "12", "USD", New(1200, "USD"), false},
+		{"negative", "-0.05", "USD", New(-5, "USD"), false},
+		{"padded", " 7.5 ", "eur", New(750, "EUR"), false},
+		{"no minor unit", "1230", "JPY", New(1230, "JPY"), false},
+		{"three decimals", "1.234", "KWD", New(1234, "KWD"), false},
+		{"too precise", "12.345", "USD", Money{}, true},
+		{"fraction of yen", "1.5", "JPY", Money{}, true},
+		{"not a number", "twelve", "USD", Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.amount, tt.currency)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAmount) {
+					t.Fatalf("Parse(%q, %q) error = %v, want ErrInvalidAmount", tt.amount, tt.currency, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q, %q) error = %v", tt.amount, tt.currency, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		amount Money
+		want   string
+	}{
+		{New(1230, "USD"), "12.30"},
+		{New(5, "USD"), "0.05"},
+		{New(-5, "USD"), "-0.05"},
+		{New(0, "USD"), "0.00"},
+		{New(1230, "JPY"), "1230"},
+		{New(1, "KWD"), "0.001"},
+		{New(12345, "CLF"), "1.2345"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.Decimal(); got != tt.want {
+			t.Errorf("%d %s Decimal() = %q, want %q", tt.amount.Minor, tt.amount.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestMulRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		rate   float64
+		want   Money
+	}{
+		{"exact", New(1000, "USD"), 0.2, New(200, "USD")},
+		{"half rounds up", New(5, "USD"), 0.5, New(3, "USD")},
+		{"half rounds away from zero", New(-5, "USD"), 0.5, New(-3, "USD")},
+		{"below half rounds down", New(1999, "USD"), 0.1, New(200, "USD")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.amount.MulRate(tt.rate)
+			if err != nil {
+				t.Fatalf("MulRate(%v) error = %v", tt.rate, err)
+			}
+			if got != tt.want {
+				t.Errorf("MulRate(%v) = %v, want %v", tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		currency string
+		rate     float64
+		want     Money
+	}{
+		{"same exponent", New(1000, "USD"), "EUR", 0.9, New(900, "EUR")},
+		{"to no minor unit", New(1000, "USD"), "JPY", 150.25, New(1503, "JPY")},
+		{"from no minor unit", New(1000, "JPY"), "USD", 0.0067, New(670, "USD")},
+		{"to three decimals", New(1000, "USD"), "KWD", 0.3075, New(3075, "KWD")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.amount.Convert(tt.currency, tt.rate)
+			if err != nil {
+				t.Fatalf("Convert(%q, %v) error = %v", tt.currency, tt.rate, err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert(%q, %v) = %v, want %v", tt.currency, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		n      int
+		want   []int64
+	}{
+		{"even", New(900, "USD"), 3, []int64{300, 300, 300}},
+		{"remainder goes first", New(1000, "USD"), 3, []int64{334, 333, 333}},
+		{"negative", New(-1000, "USD"), 3, []int64{-334, -333, -333}},
+		{"more parts than units", New(2, "JPY"), 3, []int64{1, 1, 0}},
+		{"no parts", New(100, "USD"), 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := tt.amount.Allocate(tt.n)
+			if len(parts) != len(tt.want) {
+				t.Fatalf("Allocate(%d) returned %d parts, want %d", tt.n, len(parts), len(tt.want))
+			}
+			total := Zero(tt.amount.Currency)
+			for i, part := range parts {
+				if part.Minor != tt.want[i] || part.Currency != tt.amount.Currency {
+					t.Errorf("part %d = %v, want %d %s", i, part, tt.want[i], tt.amount.Currency)
+				}
+				total, _ = total.Add(part)
+			}
+			if len(parts) > 0 && total != tt.amount {
+				t.Errorf("parts add up to %v, want %v", total, tt.amount)
+			}
+		})
+	}
+}
+
+func TestCurrencyMismatch(t *testing.T) {
+	usd, eur := New(100, "USD"), New(100, "EUR")
+	if _, err := usd.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd.Sub(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd.Cmp(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		quantity int64
+		want     Money
+		wantErr  bool
+	}{
+		{"items", New(1999, "USD"), 3, New(5997, "USD"), false},
+		{"zero amount", New(0, "USD"), math.MaxInt64, New(0, "USD"), false},
+		{"negative", New(-5, "USD"), 3, New(-15, "USD"), false},
+		{"largest", New(math.MaxInt64, "USD"), 1, New(math.MaxInt64, "USD"), false},
+		{"overflow", New(math.MaxInt64/2+1, "USD"), 2, Money{}, true},
+		{"negative overflow", New(math.MinInt64/2-1, "USD"), 2, Money{}, true},
+		{"minus one by the smallest", New(-1, "USD"), math.MinInt64, Money{}, true},
+		{"smallest by minus one", New(math.MinInt64, "USD"), -1, Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.amount.Mul(tt.quantity)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOverflow) {
+					t.Fatalf("Mul(%d) = %v, %v, want ErrOverflow", tt.quantity, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Mul(%d) error = %v", tt.quantity, err)
+			}
+			if got != tt.want {
+				t.Errorf("Mul(%d) = %v, want %v", tt.quantity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	largest, smallest := New(math.MaxInt64, "USD"), New(math.MinInt64, "USD")
+	one := New(1, "USD")
+	if _, err := largest.Add(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Add error = %v, want ErrOverflow", err)
+	}
+	if _, err := smallest.Add(one.Neg()); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Add of a negative error = %v, want ErrOverflow", err)
+	}
+	if _, err := smallest.Sub(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Sub error = %v, want ErrOverflow", err)
+	}
+	if _, err := largest.Sub(one.Neg()); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Sub of a negative error = %v, want ErrOverflow", err)
+	}
+	if _, err := largest.MulRate(1.5); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MulRate error = %v, want ErrOverflow", err)
+	}
+	if _, err := one.MulRate(math.NaN()); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MulRate(NaN) error = %v, want ErrOverflow", err)
+	}
+	if _, err := largest.Convert("JPY", 150); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Convert error = %v, want ErrOverflow", err)
+	}
+
+	if got, err := largest.Sub(one); err != nil || got.Minor != math.MaxInt64-1 {
+		t.Errorf("Sub = %v, %v, want %d", got, err, int64(math.MaxInt64-1))
+	}
+	if got, err := smallest.Add(one); err != nil || got.Minor != math.MinInt64+1 {
+		t.Errorf("Add = %v, %v, want %d", got, err, int64(math.MinInt64+1))
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{"minor units", `{"minor":1230,"currency":"JPY"}`, New(1230, "JPY"), false},
+		{"minor units win", `{"minor":1230,"currency":"USD","amount":"99.00"}`, New(1230, "USD"), false},
+		{"decimal amount", `{"amount":"1.234","currency":"KWD"}`, New(1234, "KWD"), false},
+		{"too precise", `{"amount":"1.234","currency":"USD"}`, Money{}, true},
+		{"bare number", `12.30`, Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %v, want an error", tt.data, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.data, err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, amount := range []Money{New(1230, "USD"), New(-5, "JPY"), New(1, "KWD")} {
+		data, err := json.Marshal(amount)
+		if err != nil {
+			t.Fatalf("Marshal(%v) error = %v", amount, err)
+		}
+		var got Money
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", data, err)
+		}
+		if got != amount {
+			t.Errorf("round trip of %v = %v", amount, got)
+		}
+	}
+}
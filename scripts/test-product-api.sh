@@ -64,7 +64,7 @@ PRODUCT_ID=$(curl -s -X POST $BASE_URL/api/products \
   -d '{
     "name": "MacBook Pro M3",
     "description": "Latest MacBook Pro with M3 chip",
-    "price": 2499.99,
+    "price": {"amount": "2499.99", "currency": "USD"},
     "stock": 50,
     "category": "Electronics",
     "sku": "MBP-M3-001",
@@ -81,7 +81,7 @@ curl -s -X POST $BASE_URL/api/products \
   -d '{
     "name": "iPhone 15 Pro",
     "description": "Latest iPhone with A17 Pro chip",
-    "price": 1199.99,
+    "price": {"amount": "1199.99", "currency": "USD"},
     "stock": 100,
     "category": "Electronics",
     "sku": "IPH15-PRO-001",
@@ -113,7 +113,7 @@ if [ ! -z "$PRODUCT_ID" ] && [ "$PRODUCT_ID" != "null" ]; then
     -d '{
       "name": "MacBook Pro M3 (Updated)",
       "description": "Updated description",
-      "price": 2399.99,
+      "price": {"amount": "2399.99", "currency": "USD"},
       "stock": 50,
       "category": "Electronics",
       "sku": "MBP-M3-001",